@@ -1,17 +1,38 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	pan "github.com/baowuhe/go-bdfs/pan"
 
 	"github.com/pelletier/go-toml/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
 )
 
@@ -19,24 +40,484 @@ const VERSION = "v0.1.2"
 
 // Config represents the configuration structure
 type Config struct {
+	ClientID       string `toml:"client_id"`
+	ClientSecret   string `toml:"client_secret"`
+	TokenPath      string `toml:"token_path"`       // Optional; defaults to token.json in the configuration directory
+	IndexPath      string `toml:"index_path"`       // Optional; defaults to index.db in the cache directory
+	HashCachePath  string `toml:"hash_cache_path"`  // Optional; defaults to hashcache.db in the cache directory
+	CryptKeyFile   string `toml:"crypt_key_file"`   // Optional; key for --crypt, 32 raw bytes or 64 hex characters
+	CryptPassword  string `toml:"crypt_password"`   // Optional; password the --crypt key is derived from
+	SnapshotDir    string `toml:"snapshot_dir"`     // Optional; defaults to snapshots/ in the state directory
+	QueuePath      string `toml:"queue_path"`       // Optional; defaults to queue.db in the state directory
+	HistoryPath    string `toml:"history_path"`     // Optional; defaults to history.jsonl in the state directory
+	PhotoIndexPath string `toml:"photo_index_path"` // Optional; defaults to photos.db in the state directory
+	ResumePath     string `toml:"resume_path"`      // Optional; defaults to resume.db in the state directory
+	NotifyDesktop  bool   `toml:"notify_desktop"`   // Optional; show a desktop notification when a long transfer ends
+	NotifyAfter    string `toml:"notify_after"`     // Optional; shortest transfer that notifies, e.g. "5m" (default: 1m)
+	Language       string `toml:"language"`         // Optional; "en" or "zh" (default: the system locale); BDFS_LANG overrides it
+	MaxRequests    *int   `toml:"max_requests"`     // Optional; API requests in flight at once, 0 for unlimited (default: 16)
+	ReadOnly       bool   `toml:"read_only"`        // Optional; refuse every command that changes files in Baidu Pan
+	AssumeYes      bool   `toml:"assume_yes"`       // Optional; answer yes to every confirmation, as --yes does
+	StaticToken    bool   `toml:"static_token"`     // Optional; never refresh the access token or rewrite the token file
+
+	Profiles  map[string]Profile `toml:"profiles"`  // Optional; additional accounts, addressed as "name:/path"
+	Jobs      []JobConfig        `toml:"jobs"`      // Optional; jobs run by the daemon command
+	Webhook   WebhookConfig      `toml:"webhook"`   // Optional; where job and transfer reports are posted
+	Transport TransportConfig    `toml:"transport"` // Optional; HTTP connection tuning
+	Endpoints EndpointsConfig    `toml:"endpoints"` // Optional; base URLs replacing Baidu's hosts
+}
+
+// EndpointsConfig points the client at other hosts than Baidu's, such as a mirror or a
+// mock server. Each value is a base URL like "https://pan.example.com"; empty values keep
+// Baidu's host.
+type EndpointsConfig struct {
+	OpenAPI string `toml:"openapi"` // Authorization and token refresh (default: https://openapi.baidu.com)
+	Pan     string `toml:"pan"`     // File, share, account and task APIs (default: https://pan.baidu.com)
+	PCS     string `toml:"pcs"`     // Changes since a cursor, for snapshots (default: https://pcs.baidu.com)
+	DPCS    string `toml:"d_pcs"`   // Slice uploads (default: https://d.pcs.baidu.com)
+}
+
+// TransportConfig tunes the HTTP connections to Baidu Pan. Durations are written like
+// "90s"; empty values keep the defaults.
+type TransportConfig struct {
+	MaxIdleConnsPerHost   int    `toml:"max_idle_conns_per_host"` // Idle connections kept per host for reuse (default: 16)
+	IdleConnTimeout       string `toml:"idle_conn_timeout"`       // How long an idle connection is kept (default: 90s)
+	KeepAlive             string `toml:"keep_alive"`              // Interval of TCP keep-alive probes, "-1s" to disable (default: 30s)
+	DisableKeepAlives     bool   `toml:"disable_keep_alives"`     // Use a new connection for every request
+	TLSHandshakeTimeout   string `toml:"tls_handshake_timeout"`   // Longest TLS handshake (default: 10s)
+	ResponseHeaderTimeout string `toml:"response_header_timeout"` // Longest wait for response headers (default: none)
+
+	IPFamily  string              `toml:"ip_family"`  // "4" or "6" to connect only over IPv4 or IPv6 (default: either)
+	DNSServer string              `toml:"dns_server"` // DNS server to resolve host names with, e.g. "223.5.5.5" (default: the system's)
+	Hosts     map[string][]string `toml:"hosts"`      // IPs to connect to for a host name, e.g. a fast CDN node for d.pcs.baidu.com
+
+	CAFile         string `toml:"ca_file"`          // PEM bundle of extra root CAs, e.g. of a TLS-intercepting proxy
+	ClientCertFile string `toml:"client_cert_file"` // PEM client certificate to present
+	ClientKeyFile  string `toml:"client_key_file"`  // PEM private key of client_cert_file
+	TLSMinVersion  string `toml:"tls_min_version"`  // "1.2" (default) or "1.3"
+}
+
+// WebhookConfig describes the webhook notified when daemon jobs, syncs and large
+// transfers finish
+type WebhookConfig struct {
+	URL          string `toml:"url"`
+	Format       string `toml:"format"`        // generic (default), slack, dingtalk or feishu
+	OnlyFailures bool   `toml:"only_failures"` // Notify only about failures
+	MinSize      string `toml:"min_size"`      // Smallest successful ul or dl to report, e.g. "1G" (default: 1G)
+}
+
+// JobConfig describes a go-bdfs command the daemon runs on a cron schedule
+type JobConfig struct {
+	Name       string   `toml:"name"`
+	Schedule   string   `toml:"schedule"`    // Cron expression, e.g. "0 3 * * *"
+	Command    string   `toml:"command"`     // go-bdfs command to run, e.g. "sync"
+	Args       []string `toml:"args"`        // Arguments to the command
+	Retries    int      `toml:"retries"`     // Extra attempts after a failed run
+	RetryDelay string   `toml:"retry_delay"` // Wait between attempts, e.g. "5m" (default: 1m)
+}
+
+// Profile holds the credentials of an additional Baidu Pan account. ClientID and
+// ClientSecret default to the top-level values; each profile needs its own TokenPath.
+type Profile struct {
 	ClientID     string `toml:"client_id"`
 	ClientSecret string `toml:"client_secret"`
 	TokenPath    string `toml:"token_path"`
 }
 
+// GlobalOptions holds flags that apply to every command
+type GlobalOptions struct {
+	Debug       bool    // Log HTTP requests and responses to stderr
+	DumpRaw     string  // File raw API responses are appended to, "-" for stderr, empty to disable
+	PrintCurl   bool    // Print an equivalent curl command for each request to stderr
+	Quiet       bool    // Only print results, warnings and errors
+	Verbose     bool    // Also print a line per API request and other details
+	MetricsAddr string  // Address to serve Prometheus metrics on, empty to disable
+	StatusPort  int     // Local port serving the live status, 0 to disable
+	MaxQPS      float64 // Maximum API requests per second per endpoint, 0 for unlimited
+	DryRun      bool    // Print modifying operations instead of executing them
+	NoHashCache bool    // Hash local files from scratch instead of reusing cached MD5s
+	Notify      bool    // Show a desktop notification when a long transfer ends
+	ReadOnly    bool    // Refuse commands and requests that change files in Baidu Pan
+	Yes         bool    // Answer yes to every confirmation instead of asking
+	StaticToken bool    // Use the access token as it is, never refreshing it or writing the token file
+
+	Stats         bool          // Print the client's stats when the command completes
+	StatsInterval time.Duration // Print a one-line stats summary this often, 0 to disable
+
+	SliceRetries int // Retries of a failed upload slice, negative for the client's default
+	MaxRequests  int // API requests in flight at once, 0 for unlimited, negative for the default
+
+	IPFamily pan.IPFamily // Connect only over IPv4 or IPv6, overriding ip_family
+}
+
+// globals holds the global flags parsed from the command line
+var globals GlobalOptions
+
+// transferCtx is cancelled by the first SIGINT or SIGTERM during ul, dl and sync
+var transferCtx = context.Background()
+
+// parseGlobalFlags extracts global flags from args and returns the remaining arguments.
+// Flags taking a value accept both "--flag value" and "--flag=value".
+func parseGlobalFlags(args []string) (GlobalOptions, []string) {
+	opts := GlobalOptions{SliceRetries: -1, MaxRequests: -1}
+	remaining := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+
+		// takeValue returns the flag's value, consuming the next argument if needed
+		takeValue := func() string {
+			if hasValue {
+				return value
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+
+		switch {
+		case args[i] == "--debug":
+			opts.Debug = true
+		case args[i] == "--print-curl":
+			opts.PrintCurl = true
+		case args[i] == "--dump-raw":
+			opts.DumpRaw = "-"
+		case name == "--dump-raw" && hasValue:
+			if value == "" {
+				exitUsage(pan.T("Invalid value for --dump-raw: expected --dump-raw=<file>"))
+			}
+			opts.DumpRaw = value
+		case args[i] == "-q" || args[i] == "--quiet":
+			opts.Quiet = true
+		case args[i] == "-v" || args[i] == "--verbose":
+			opts.Verbose = true
+		case args[i] == "--dry-run":
+			opts.DryRun = true
+		case args[i] == "--no-hash-cache":
+			opts.NoHashCache = true
+		case args[i] == "--notify":
+			opts.Notify = true
+		case args[i] == "--read-only":
+			opts.ReadOnly = true
+		case args[i] == "--yes":
+			opts.Yes = true
+		case args[i] == "--static-token":
+			opts.StaticToken = true
+		case args[i] == "--ipv4":
+			opts.IPFamily = pan.IPv4
+		case args[i] == "--ipv6":
+			opts.IPFamily = pan.IPv6
+		case args[i] == "--stats":
+			opts.Stats = true
+		case name == "--stats-interval":
+			raw := takeValue()
+			interval, err := time.ParseDuration(raw)
+			if err != nil || interval <= 0 {
+				exitUsage(pan.Tf("Invalid value for --stats-interval: %q", raw))
+			}
+			opts.StatsInterval = interval
+		case name == "--metrics-addr":
+			opts.MetricsAddr = takeValue()
+		case name == "--status-port":
+			raw := takeValue()
+			port, err := strconv.Atoi(raw)
+			if err != nil || port <= 0 || port > 65535 {
+				exitUsage(pan.Tf("Invalid value for --status-port: %q", raw))
+			}
+			opts.StatusPort = port
+		case name == "--max-qps":
+			raw := takeValue()
+			qps, err := strconv.ParseFloat(raw, 64)
+			if err != nil || qps < 0 {
+				exitUsage(pan.Tf("Invalid value for --max-qps: %q", raw))
+			}
+			opts.MaxQPS = qps
+		case name == "--slice-retries":
+			raw := takeValue()
+			retries, err := strconv.Atoi(raw)
+			if err != nil || retries < 0 {
+				exitUsage(pan.Tf("Invalid value for --slice-retries: %q", raw))
+			}
+			opts.SliceRetries = retries
+		case name == "--max-requests":
+			raw := takeValue()
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				exitUsage(pan.Tf("Invalid value for --max-requests: %q", raw))
+			}
+			opts.MaxRequests = n
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+	if opts.Quiet && opts.Verbose {
+		exitUsage(pan.T("Error: --quiet and --verbose cannot be combined."))
+	}
+
+	return opts, remaining
+}
+
+// args converts global flags back into command-line arguments, for commands the daemon
+// runs. The metrics address and status port are not passed on, as only one process can
+// listen on them.
+func (g GlobalOptions) args() []string {
+	var args []string
+	if g.Debug {
+		args = append(args, "--debug")
+	}
+	if g.PrintCurl {
+		args = append(args, "--print-curl")
+	}
+	if g.DumpRaw == "-" {
+		args = append(args, "--dump-raw")
+	} else if g.DumpRaw != "" {
+		args = append(args, "--dump-raw="+g.DumpRaw)
+	}
+	if g.Quiet {
+		args = append(args, "--quiet")
+	}
+	if g.Verbose {
+		args = append(args, "--verbose")
+	}
+	if g.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if g.NoHashCache {
+		args = append(args, "--no-hash-cache")
+	}
+	if g.ReadOnly {
+		args = append(args, "--read-only")
+	}
+	if g.Yes {
+		args = append(args, "--yes")
+	}
+	if g.StaticToken {
+		args = append(args, "--static-token")
+	}
+	if g.MaxQPS > 0 {
+		args = append(args, "--max-qps", strconv.FormatFloat(g.MaxQPS, 'f', -1, 64))
+	}
+	if g.SliceRetries >= 0 {
+		args = append(args, "--slice-retries", strconv.Itoa(g.SliceRetries))
+	}
+	if g.MaxRequests >= 0 {
+		args = append(args, "--max-requests", strconv.Itoa(g.MaxRequests))
+	}
+	switch g.IPFamily {
+	case pan.IPv4:
+		args = append(args, "--ipv4")
+	case pan.IPv6:
+		args = append(args, "--ipv6")
+	}
+	return args
+}
+
+// logLevel returns the lowest level of messages printed: warnings with --quiet, debug
+// details with --verbose
+func (g GlobalOptions) logLevel() slog.Level {
+	switch {
+	case g.Quiet:
+		return slog.LevelWarn
+	case g.Verbose:
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// clientOptions converts global flags into pan client options
+func (g GlobalOptions) clientOptions() []pan.Option {
+	opts := []pan.Option{
+		pan.WithLogger(slog.New(pan.NewConsoleHandler(os.Stdout, g.logLevel()))),
+	}
+	if !g.Quiet {
+		opts = append(opts, pan.WithProgressOutput(os.Stdout))
+	}
+	if g.Debug {
+		opts = append(opts, pan.WithDebug(os.Stderr))
+	}
+	if g.PrintCurl {
+		opts = append(opts, pan.WithCurlOutput(os.Stderr))
+	}
+	if g.DumpRaw == "-" {
+		opts = append(opts, pan.WithRawDump(os.Stderr))
+	} else if g.DumpRaw != "" {
+		// Appended, so the responses of several commands collect in one file
+		file, err := os.OpenFile(g.DumpRaw, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Failed to open raw dump file: %v", err), err)
+		}
+		opts = append(opts, pan.WithRawDump(file))
+	}
+	if g.MetricsAddr != "" {
+		opts = append(opts, pan.WithMetrics(startMetricsServer(g.MetricsAddr)))
+	}
+	if g.DryRun {
+		opts = append(opts, pan.WithDryRun(os.Stdout))
+	}
+	if g.ReadOnly {
+		opts = append(opts, pan.WithReadOnly())
+	}
+	if g.StaticToken {
+		opts = append(opts, pan.WithStaticToken())
+	}
+	if g.MaxQPS > 0 {
+		opts = append(opts, pan.WithRateLimit(g.MaxQPS, int(math.Ceil(g.MaxQPS))))
+	}
+	if g.SliceRetries >= 0 {
+		opts = append(opts, pan.WithSliceRetry(g.SliceRetries, pan.DefaultSliceRetryDelay))
+	}
+	maxRequests := g.MaxRequests
+	if maxRequests < 0 {
+		maxRequests = pan.DefaultMaxRequests
+	}
+	opts = append(opts, pan.WithMaxRequests(maxRequests))
+	return opts
+}
+
+// startMetricsServer serves Prometheus metrics on addr in the background and returns the client collectors
+func startMetricsServer(addr string) *pan.Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	metrics := pan.NewMetrics(registry)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			pan.PrintError(pan.Tf("Metrics server stopped: %v", err))
+		}
+	}()
+
+	return metrics
+}
+
+// startStatusReporting prints the client's status to stderr on SIGUSR1 and, when port is
+// set, serves it on http://127.0.0.1:<port>/status (as JSON with ?format=json)
+func startStatusReporting(client pan.PanClient, port int) {
+	if len(statusSignals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, statusSignals...)
+		go func() {
+			for range signals {
+				fmt.Fprint(os.Stderr, "\n"+pan.FormatStatus(client.Status()))
+			}
+		}()
+	}
+	if port == 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status := client.Status()
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, pan.FormatStatus(status))
+	})
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			pan.PrintError(pan.Tf("Status server stopped: %v", err))
+		}
+	}()
+}
+
+// configFilePath returns the path of the TOML configuration file
+func configFilePath() (string, error) {
+	// First, try to get config file path from environment variable
+	if path := os.Getenv("BDFS_CONFIG_FILE_PATH"); path != "" {
+		return path, nil
+	}
+
+	// If BDFS_CONFIG_FILE_PATH is not set, use the configuration directory
+	dirs, err := pan.DefaultDataDirs()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dirs.Config, "config.toml")
+
+	// Until LoadConfig moves it, the file of an earlier version is read where it is
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if legacyDir, err := pan.LegacyDataDir(); err == nil {
+			if _, err := os.Stat(filepath.Join(legacyDir, "config.toml")); err == nil {
+				return filepath.Join(legacyDir, "config.toml"), nil
+			}
+		}
+	}
+	return path, nil
+}
+
+// injectedTokens returns the tokens in BDFS_ACCESS_TOKEN and BDFS_REFRESH_TOKEN, which
+// replace the token file when either is set
+func injectedTokens() (accessToken, refreshToken string, ok bool) {
+	accessToken, refreshToken = os.Getenv("BDFS_ACCESS_TOKEN"), os.Getenv("BDFS_REFRESH_TOKEN")
+	return accessToken, refreshToken, accessToken != "" || refreshToken != ""
+}
+
+// defaultDataPath returns the default path of name in dir, one of the data directories,
+// first moving it there from the directory earlier versions kept every file in. A file
+// that cannot be moved is used where it is.
+func defaultDataPath(dir, name string) string {
+	newPath := filepath.Join(dir, name)
+	moved, err := pan.MigrateLegacyFile(name, newPath)
+	if err != nil {
+		pan.PrintError(pan.Tf("Could not move %s to the new data directories, using it where it is: %v", name, err))
+		legacyDir, _ := pan.LegacyDataDir()
+		return filepath.Join(legacyDir, name)
+	}
+	if moved {
+		pan.PrintSuccess(pan.Tf("Moved %s to %s", name, newPath))
+	}
+	return newPath
+}
+
+// setupLanguage selects the language of messages from BDFS_LANG, then the language in the
+// configuration file, then the system locale. It runs before the configuration is
+// loaded, so that help and configuration errors are translated too.
+func setupLanguage() {
+	value := os.Getenv("BDFS_LANG")
+	if value == "" {
+		if path, err := configFilePath(); err == nil {
+			var config struct {
+				Language string `toml:"language"`
+			}
+			if data, err := os.ReadFile(path); err == nil && toml.Unmarshal(data, &config) == nil {
+				value = config.Language
+			}
+		}
+	}
+	if value == "" {
+		pan.SetLanguage(pan.LanguageFromLocale())
+		return
+	}
+
+	lang, err := pan.ParseLanguage(value)
+	if err != nil {
+		pan.PrintError(fmt.Sprintf("Ignoring the language setting: %v", err))
+		return
+	}
+	pan.SetLanguage(lang)
+}
+
 // LoadConfig loads configuration from environment variables or TOML file
 func LoadConfig() (*Config, error) {
 	config := &Config{}
 
-	// First, try to get config file path from environment variable
+	dirs, err := pan.DefaultDataDirs()
+	if err != nil {
+		return nil, err
+	}
 	configFilePath := os.Getenv("BDFS_CONFIG_FILE_PATH")
 	if configFilePath == "" {
-		// If BDFS_CONFIG_FILE_PATH is not set, use default path
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configFilePath = filepath.Join(homeDir, ".local", "app", "bdfs", "config.toml")
+		configFilePath = defaultDataPath(dirs.Config, "config.toml")
 	}
 
 	// Try to load from config file first
@@ -56,39 +537,146 @@ func LoadConfig() (*Config, error) {
 		config.ClientID = os.Getenv("BDFS_CLIENT_ID")
 		config.ClientSecret = os.Getenv("BDFS_CLIENT_SECRET")
 		config.TokenPath = os.Getenv("BDFS_TOKEN_PATH")
+		config.IndexPath = os.Getenv("BDFS_INDEX_PATH")
+		config.HashCachePath = os.Getenv("BDFS_HASH_CACHE_PATH")
+		config.CryptKeyFile = os.Getenv("BDFS_CRYPT_KEY_FILE")
+		config.CryptPassword = os.Getenv("BDFS_CRYPT_PASSWORD")
+		config.SnapshotDir = os.Getenv("BDFS_SNAPSHOT_DIR")
+		config.QueuePath = os.Getenv("BDFS_QUEUE_PATH")
+		config.HistoryPath = os.Getenv("BDFS_HISTORY_PATH")
+		config.PhotoIndexPath = os.Getenv("BDFS_PHOTO_INDEX_PATH")
+		config.ResumePath = os.Getenv("BDFS_RESUME_PATH")
+		config.Webhook.URL = os.Getenv("BDFS_WEBHOOK_URL")
+		config.Webhook.Format = os.Getenv("BDFS_WEBHOOK_FORMAT")
+		config.NotifyDesktop, _ = strconv.ParseBool(os.Getenv("BDFS_NOTIFY_DESKTOP"))
+		config.NotifyAfter = os.Getenv("BDFS_NOTIFY_AFTER")
+		if raw := os.Getenv("BDFS_MAX_REQUESTS"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid BDFS_MAX_REQUESTS %q", raw)
+			}
+			config.MaxRequests = &n
+		}
+	}
+
+	if config.MaxRequests != nil && *config.MaxRequests < 0 {
+		return nil, fmt.Errorf("invalid max_requests %d: must be 0 or more", *config.MaxRequests)
 	}
 
-	// Validate that all required parameters are provided
-	if config.ClientID == "" || config.ClientSecret == "" || config.TokenPath == "" {
+	// Validate that all required parameters are provided; an injected access token needs
+	// no client credentials until it has to be refreshed
+	if accessToken, _, _ := injectedTokens(); (config.ClientID == "" || config.ClientSecret == "") && accessToken == "" {
 		return nil, fmt.Errorf("missing required configuration parameters. Please set either:\n" +
-			"  1. BDFS_CONFIG_FILE_PATH environment variable pointing to a TOML file with client_id, client_secret, and token_path, or\n" +
-			"  2. BDFS_CLIENT_ID, BDFS_CLIENT_SECRET, and BDFS_TOKEN_PATH environment variables")
+			"  1. BDFS_CONFIG_FILE_PATH environment variable pointing to a TOML file with client_id and client_secret, or\n" +
+			"  2. BDFS_CLIENT_ID and BDFS_CLIENT_SECRET environment variables")
 	}
 
+	config.setDefaultPaths(dirs)
 	return config, nil
 }
 
+// setDefaultPaths gives the files without a configured path their default locations in
+// dirs, moving them from where earlier versions kept them
+func (config *Config) setDefaultPaths(dirs pan.DataDirs) {
+	defaults := []struct {
+		path      *string
+		dir, name string
+	}{
+		{&config.TokenPath, dirs.Config, "token.json"},
+		{&config.IndexPath, dirs.Cache, "index.db"},
+		{&config.HashCachePath, dirs.Cache, "hashcache.db"},
+		{&config.SnapshotDir, dirs.State, "snapshots"},
+		{&config.QueuePath, dirs.State, "queue.db"},
+		{&config.HistoryPath, dirs.State, "history.jsonl"},
+		{&config.PhotoIndexPath, dirs.State, "photos.db"},
+		{&config.ResumePath, dirs.State, "resume.db"},
+	}
+	for _, d := range defaults {
+		if *d.path == "" {
+			*d.path = defaultDataPath(d.dir, d.name)
+		}
+	}
+}
+
 func main() {
+	setupLanguage()
+
+	// Strip global flags so each command only sees its own arguments
+	var args []string
+	globals, args = parseGlobalFlags(os.Args[1:])
+	// "stats <command>" runs the command and prints the client's stats when it completes
+	if len(args) > 0 && strings.ToLower(args[0]) == "stats" {
+		globals.Stats = true
+		args = args[1:]
+	}
+	os.Args = append(os.Args[:1], args...)
+	pan.SetConsoleLogger(slog.New(pan.NewConsoleHandler(os.Stdout, globals.logLevel())))
+
 	if len(os.Args) < 2 {
-		fmt.Println("go-bdfs: Baidu Pan client")
-		fmt.Println("Usage: go-bdfs <command> [arguments]")
+		fmt.Println(pan.T("go-bdfs: Baidu Pan client"))
+		fmt.Println(pan.T("Usage: go-bdfs <command> [arguments]"))
 		fmt.Println("")
-		fmt.Println("Commands:")
-		fmt.Println("  ls          List files in a directory")
-		fmt.Println("  dl          Download a file from Baidu Pan")
-		fmt.Println("  ul          Upload a file to Baidu Pan")
-		fmt.Println("  rm          Remove a file or directory from Baidu Pan")
-		fmt.Println("  mv          Move a file or directory to another directory in Baidu Pan")
-		fmt.Println("  rn          Rename a file or directory in Baidu Pan")
-		fmt.Println("  md          Create a directory in Baidu Pan")
-		fmt.Println("  cp          Copy a file or directory in Baidu Pan")
-		fmt.Println("  if          Get information about a file in Baidu Pan")
-		fmt.Println("  di          Get disk information (storage usage) from Baidu Pan")
-		fmt.Println("  ar          Refresh the access token using the refresh token")
-		fmt.Println("  version     Show the version information")
+		fmt.Println(pan.T("Commands:"))
+		fmt.Println(pan.T("  ls          List files in a directory"))
+		fmt.Println(pan.T("  dl          Download a file or, with -r, a directory from Baidu Pan"))
+		fmt.Println(pan.T("  ul          Upload a file, or a directory, glob or file list in parallel"))
+		fmt.Println(pan.T("  rm          Remove a file or directory from Baidu Pan"))
+		fmt.Println(pan.T("  mv          Move a file or directory to another directory in Baidu Pan"))
+		fmt.Println(pan.T("  rn          Rename a file or directory in Baidu Pan"))
+		fmt.Println(pan.T("  md          Create a directory in Baidu Pan"))
+		fmt.Println(pan.T("  cp          Copy a file or directory in Baidu Pan"))
+		fmt.Println(pan.T("  task        Check on an asynchronous copy, move or delete task (query, wait)"))
+		fmt.Println(pan.T("  if          Get information about a file in Baidu Pan"))
+		fmt.Println(pan.T("  preview     Get a browser link to preview an office document, PDF or text file"))
+		fmt.Println(pan.T("  share       Create a share link with an extraction code and expiry"))
+		fmt.Println(pan.T("  shares      List shares received from other users, with their files' fs_ids"))
+		fmt.Println(pan.T("  di          Get disk information (storage usage) from Baidu Pan"))
+		fmt.Println(pan.T("  ar          Refresh the access token using the refresh token"))
+		fmt.Println(pan.T("  index       Manage the local metadata index (rebuild, prune)"))
+		fmt.Println(pan.T("  sync        Synchronize a local directory with a Baidu Pan directory"))
+		fmt.Println(pan.T("  xcopy       Copy a file or directory between two Baidu Pan accounts"))
+		fmt.Println(pan.T("  prune-empty Remove directories that contain no files"))
+		fmt.Println(pan.T("  report      Summarize storage use by extension, folder and age"))
+		fmt.Println(pan.T("  speedtest   Measure upload and download throughput with 1, 4 and 8 connections"))
+		fmt.Println(pan.T("  export      Export a manifest of every file beneath a directory"))
+		fmt.Println(pan.T("  diff        Compare an exported manifest with the current remote state"))
+		fmt.Println(pan.T("  snapshot    Manage named snapshots of the remote tree (create, list, diff, delete)"))
+		fmt.Println(pan.T("  queue       Manage the persistent transfer queue (add, ls, rm, run)"))
+		fmt.Println(pan.T("  photos      Back up photos and videos into dated folders, skipping duplicates"))
+		fmt.Println(pan.T("  organize    Move remote photos into dated folders by their EXIF capture dates"))
+		fmt.Println(pan.T("  shell       Browse Baidu Pan interactively with cd, ls, get and put"))
+		fmt.Println(pan.T("  history     Show past transfers, with filters and summaries"))
+		fmt.Println(pan.T("  stats       Run a command and print its API calls, bytes, retries and cache hits"))
+		fmt.Println(pan.T("  hash-cache  Manage the local file hash cache (clear)"))
+		fmt.Println(pan.T("  daemon      Run the scheduled jobs from the configuration file"))
+		fmt.Println(pan.T("  doctor      Check the configuration, token, endpoints and clock, suggesting fixes"))
+		fmt.Println(pan.T("  paths       Show where the configuration, token, caches and state are kept"))
+		fmt.Println(pan.T("  selfupdate  Download, verify and install the latest release"))
+		fmt.Println(pan.T("  version     Show the version information (--check for a newer release)"))
 		fmt.Println("")
-		fmt.Println("Use 'go-bdfs <command> -h' for more information about a command.")
-		os.Exit(1)
+		fmt.Println(pan.T("Global flags:"))
+		fmt.Println(pan.T("  --debug     Log HTTP requests and responses to stderr"))
+		fmt.Println(pan.T("  --dump-raw[=<file>]    Write each raw API response as JSON to stderr or <file>"))
+		fmt.Println(pan.T("  --print-curl           Print an equivalent curl command for each API request to stderr"))
+		fmt.Println(pan.T("  -q, --quiet            Only print results, warnings and errors"))
+		fmt.Println(pan.T("  -v, --verbose          Also print a line per API request and other details"))
+		fmt.Println(pan.T("  --metrics-addr <addr>  Serve Prometheus metrics on <addr>/metrics"))
+		fmt.Println(pan.T("  --status-port <port>   Serve the live status on http://127.0.0.1:<port>/status (also printed on SIGUSR1)"))
+		fmt.Println(pan.T("  --max-qps <n>          Limit API requests per second per endpoint"))
+		fmt.Println(pan.T("  --max-requests <n>     Limit API requests in flight at once (default: 16, 0 for unlimited)"))
+		fmt.Println(pan.T("  --ipv4, --ipv6         Connect to Baidu Pan only over IPv4 or IPv6"))
+		fmt.Println(pan.T("  --slice-retries <n>    Retry a failed upload slice up to <n> times (default: 3)"))
+		fmt.Println(pan.T("  --dry-run              Print modifying operations instead of executing them"))
+		fmt.Println(pan.T("  --read-only            Refuse every command and request that changes files in Baidu Pan"))
+		fmt.Println(pan.T("  --yes                  Answer yes to every confirmation of rm, mv, rn, prune-empty and update"))
+		fmt.Println(pan.T("  --static-token         Use the access token as it is, never refreshing it or writing the token file"))
+		fmt.Println(pan.T("  --no-hash-cache        Hash local files from scratch instead of reusing cached MD5s"))
+		fmt.Println(pan.T("  --notify               Show a desktop notification when a long transfer ends"))
+		fmt.Println(pan.T("  --stats                Print API calls, bytes, retries and cache hits when the command completes"))
+		fmt.Println(pan.T("  --stats-interval <d>   Print a one-line stats summary every <d> (e.g. 30s)"))
+		fmt.Println("")
+		fmt.Println(pan.T("Use 'go-bdfs <command> -h' for more information about a command."))
+		os.Exit(pan.ExitUsage)
 	}
 
 	// Parse command
@@ -102,41 +690,160 @@ func main() {
 	case "help", "-h", "--help":
 		showHelp()
 		return
+	case "selfupdate":
+		selfUpdateCommand()
+		return
+	case "doctor":
+		// Diagnoses the configuration and authorization problems that stop other commands
+		doctorCommand()
+		return
+	case "paths":
+		// Shows where files live even before the configuration is complete
+		pathsCommand()
+		return
 	}
 
 	// Load configuration from environment variables or TOML file
 	config, err := LoadConfig()
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error loading configuration: %v", err))
-		fmt.Println("You can set BDFS_CLIENT_ID, BDFS_CLIENT_SECRET, and BDFS_TOKEN_PATH environment variables")
-		fmt.Println("Or create a config file at $HOME/.local/app/bdfs/config.toml with the following format:")
+		pan.PrintError(pan.Tf("Error loading configuration: %v", err))
+		fmt.Println(pan.T("You can set BDFS_CLIENT_ID, BDFS_CLIENT_SECRET, and BDFS_TOKEN_PATH environment variables"))
+		path, _ := configFilePath()
+		fmt.Println(pan.Tf("Or create a config file at %s with the following format:", path))
 		fmt.Println("")
-		fmt.Println("Format (direct values):")
+		fmt.Println(pan.T("Format (direct values):"))
 		fmt.Println("client_id = \"your_client_id\"")
 		fmt.Println("client_secret = \"your_client_secret\"")
 		fmt.Println("token_path = \"path/to/your/token/file\"")
 		fmt.Println("")
-		fmt.Println("Alternatively, set the BDFS_CONFIG_FILE_PATH environment variable to point to your config file")
+		fmt.Println(pan.T("Alternatively, set the BDFS_CONFIG_FILE_PATH environment variable to point to your config file"))
 		fmt.Println("[×] Launch failed!ss")
 		os.Exit(1)
 	}
 
+	// Managing the hash cache needs no authorization
+	if strings.ToLower(cmd) == "hash-cache" {
+		hashCacheCommand(config.HashCachePath)
+		return
+	}
+
+	// Reading the transfer history needs no authorization
+	if strings.ToLower(cmd) == "history" {
+		historyCommand(config.HistoryPath)
+		return
+	}
+
+	// The daemon runs each job as a separate go-bdfs process, which authorizes itself
+	if strings.ToLower(cmd) == "daemon" {
+		daemonCommand(config)
+		return
+	}
+
+	// read_only in the configuration cannot be turned off from the command line
+	globals.ReadOnly = globals.ReadOnly || config.ReadOnly
+	globals.Yes = globals.Yes || config.AssumeYes
+	globals.StaticToken = globals.StaticToken || config.StaticToken
+
+	// --max-requests overrides the configured limit
+	if globals.MaxRequests < 0 && config.MaxRequests != nil {
+		globals.MaxRequests = *config.MaxRequests
+	}
+
+	clientOpts := globals.clientOptions()
+	transportOpts, err := config.transportOptions()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error in configuration: %v", err), err)
+	}
+	clientOpts = append(clientOpts, pan.WithTransportOptions(transportOpts))
+	endpoints, err := config.endpoints()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error in configuration: %v", err), err)
+	}
+	clientOpts = append(clientOpts, pan.WithEndpoints(endpoints))
+
+	// Other accounts (profiles) share the global options but not this account's index
+	profileOpts := slices.Clip(clientOpts)
+
+	// Tokens injected through the environment replace this account's token file
+	if accessToken, refreshToken, ok := injectedTokens(); ok {
+		clientOpts = append(clientOpts, pan.WithTokens(accessToken, refreshToken))
+	}
+
+	// Keep the local metadata index up to date once it has been built (or when managing it)
+	var index *pan.Index
+	if _, statErr := os.Stat(config.IndexPath); statErr == nil || strings.ToLower(cmd) == "index" {
+		index, err = pan.OpenIndex(config.IndexPath)
+		if err != nil {
+			pan.PrintError(pan.Tf("Local index unavailable: %v", err))
+		} else {
+			defer index.Close()
+			clientOpts = append(clientOpts, pan.WithIndex(index))
+		}
+	}
+
+	// Reuse slice MD5s of unchanged local files across uploads
+	if !globals.NoHashCache && (strings.ToLower(cmd) == "ul" || strings.ToLower(cmd) == "sync" || strings.ToLower(cmd) == "queue" || strings.ToLower(cmd) == "photos") {
+		if err := os.MkdirAll(filepath.Dir(config.HashCachePath), 0755); err != nil {
+			pan.PrintError(pan.Tf("Hash cache unavailable: %v", err))
+		} else if hashCache, err := pan.OpenHashCache(config.HashCachePath); err != nil {
+			pan.PrintError(pan.Tf("Hash cache unavailable: %v", err))
+		} else {
+			defer hashCache.Close()
+			clientOpts = append(clientOpts, pan.WithHashCache(hashCache))
+		}
+	}
+
+	// Stop transfers cleanly on Ctrl+C, saving how far they got so they can resume
+	if cmd := strings.ToLower(cmd); cmd == "ul" || cmd == "dl" || cmd == "sync" {
+		transferCtx = interruptContext()
+		clientOpts = append(clientOpts, pan.WithContext(transferCtx))
+		if err := os.MkdirAll(filepath.Dir(config.ResumePath), 0755); err != nil {
+			pan.PrintError(pan.Tf("Resume store unavailable: %v", err))
+		} else if resume, err := pan.OpenResumeStore(config.ResumePath); err != nil {
+			pan.PrintError(pan.Tf("Resume store unavailable: %v", err))
+		} else {
+			defer resume.Close()
+			clientOpts = append(clientOpts, pan.WithResumeStore(resume))
+		}
+	}
+
+	// Record every transfer in the history
+	if history, err := pan.OpenHistory(config.HistoryPath); err != nil {
+		pan.PrintError(pan.Tf("Transfer history unavailable: %v", err))
+	} else {
+		clientOpts = append(clientOpts, pan.WithHistory(history))
+	}
+
 	// For all other commands, load the client and perform authorization
-	client := pan.NewClient(config.ClientID, config.ClientSecret, config.TokenPath)
+	client := pan.NewClient(config.ClientID, config.ClientSecret, config.TokenPath, clientOpts...)
 
 	// Set a timeout for authorization
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
-	fmt.Println("Starting Baidu Pan authorization...")
+	pan.PrintSuccess(pan.T("Starting Baidu Pan authorization..."))
 
 	// Try to load existing tokens or perform device code authorization
 	err = client.Authorize(ctx)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Authorization failed: %v", err))
-		os.Exit(1)
+		pan.PrintError(pan.Tf("Authorization failed: %v", err))
+		os.Exit(authExitStatus(err))
 	}
 
+	startStatusReporting(client, globals.StatusPort)
+	if globals.StatsInterval > 0 {
+		go func() {
+			for range time.Tick(globals.StatsInterval) {
+				pan.PrintSuccess(pan.T("Stats: ") + client.Stats().Line())
+			}
+		}()
+	}
+	if globals.Stats {
+		defer func() { fmt.Print(pan.FormatStats(client.Stats())) }()
+	}
+
+	refuseIfReadOnly(strings.ToLower(cmd), os.Args[2:])
+
 	// Execute requested command
 	switch strings.ToLower(cmd) {
 	case "ls":
@@ -155,27 +862,194 @@ func main() {
 		mkdirCommand(client)
 	case "cp":
 		copyCommand(client)
+	case "task":
+		taskCommand(client)
 	case "if":
 		infoCommand(client)
+	case "preview":
+		previewCommand(client)
+	case "share":
+		shareCommand(client)
+	case "shares":
+		sharesCommand(client)
 	case "di":
 		diskInfoCommand(client)
 	case "ar":
 		refreshTokenCommand(client)
+	case "index":
+		indexCommand(client, index)
+	case "sync":
+		syncCommand(client)
+	case "xcopy":
+		xcopyCommand(client, config, profileOpts)
+	case "prune-empty":
+		pruneEmptyCommand(client)
+	case "report":
+		reportCommand(client)
+	case "speedtest":
+		speedtestCommand(client)
+	case "export":
+		exportCommand(client)
+	case "diff":
+		diffCommand(client)
+	case "snapshot":
+		snapshotCommand(client, config.SnapshotDir)
+	case "queue":
+		queueCommand(client, config.QueuePath)
+	case "photos":
+		photosCommand(client, config.PhotoIndexPath)
+	case "organize":
+		organizeCommand(client)
+	case "shell":
+		shellCommand(client)
 	default:
-		pan.PrintError(fmt.Sprintf("Unknown command: %s", cmd))
-		fmt.Println("Run 'go-bdfs' for usage information.")
+		pan.PrintError(pan.Tf("Unknown command: %s", cmd))
+		fmt.Println(pan.T("Run 'go-bdfs' for usage information."))
+		os.Exit(pan.ExitUsage)
+	}
+}
+
+func doctorCommand() {
+	doctorFlags := pflag.NewFlagSet("doctor", pflag.ExitOnError)
+	var asJSON bool
+	var help bool
+
+	doctorFlags.BoolVar(&asJSON, "json", false, pan.T("Print the check results as JSON"))
+	doctorFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for doctor command"))
+
+	if err := doctorFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		doctorFlags.PrintDefaults()
+		return
+	}
+
+	results, config := checkConfig()
+	if config != nil {
+		clientOpts := globals.clientOptions()
+		if transportOpts, err := config.transportOptions(); err == nil {
+			clientOpts = append(clientOpts, pan.WithTransportOptions(transportOpts))
+		}
+		if endpoints, err := config.endpoints(); err == nil {
+			clientOpts = append(clientOpts, pan.WithEndpoints(endpoints))
+		}
+		if accessToken, refreshToken, ok := injectedTokens(); ok {
+			clientOpts = append(clientOpts, pan.WithTokens(accessToken, refreshToken))
+		}
+		if config.StaticToken {
+			clientOpts = append(clientOpts, pan.WithStaticToken())
+		}
+		client := pan.NewClient(config.ClientID, config.ClientSecret, config.TokenPath, clientOpts...)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		results = append(results, client.Doctor(ctx)...)
+	}
+
+	failed := slices.ContainsFunc(results, func(r pan.CheckResult) bool { return r.Status == pan.CheckFail })
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding results: %v", err), err)
+		}
+		fmt.Println(string(data))
+	} else {
+		markers := map[pan.CheckStatus]string{pan.CheckOK: "✓", pan.CheckWarn: "!", pan.CheckFail: "×"}
+		for _, r := range results {
+			fmt.Printf("[%s] %s: %s\n", markers[r.Status], r.Name, r.Detail)
+			if r.Fix != "" {
+				fmt.Printf(pan.T("    Fix: %s\n"), r.Fix)
+			}
+		}
+	}
+	if failed {
 		os.Exit(1)
 	}
 }
 
-func listCommand(client *pan.Client) {
+// checkConfig checks that the configuration loads and that its optional settings are
+// valid, and returns the configuration, or nil when it could not be loaded
+func checkConfig() ([]pan.CheckResult, *Config) {
+	path, err := configFilePath()
+	if err != nil {
+		return []pan.CheckResult{{Name: "Configuration", Status: pan.CheckFail, Detail: err.Error()}}, nil
+	}
+	source := "environment variables (no file at " + path + ")"
+	if _, err := os.Stat(path); err == nil {
+		source = path
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return []pan.CheckResult{{
+			Name:   "Configuration",
+			Status: pan.CheckFail,
+			Detail: fmt.Sprintf("%s: %v", source, err),
+			Fix:    "Create " + path + " with client_id and client_secret, or set BDFS_CLIENT_ID and BDFS_CLIENT_SECRET",
+		}}, nil
+	}
+
+	results := []pan.CheckResult{{Name: "Configuration", Status: pan.CheckOK, Detail: "loaded from " + source}}
+	invalid := func(name string, err error, fix string) {
+		results = append(results, pan.CheckResult{Name: name, Status: pan.CheckFail, Detail: err.Error(), Fix: fix})
+	}
+	if _, err := config.webhook(); err != nil {
+		invalid("Webhook", err, "Set format to generic, slack, dingtalk or feishu in the [webhook] section")
+	}
+	if _, err := config.transportOptions(); err != nil {
+		invalid("Transport", err, "Fix the [transport] section; durations look like \"90s\" and files must be PEM encoded")
+	}
+	if _, err := config.endpoints(); err != nil {
+		invalid("Endpoints", err, "Set each value in the [endpoints] section to a base URL such as \"https://pan.example.com\"")
+	}
+	if config.NotifyAfter != "" {
+		if _, err := time.ParseDuration(config.NotifyAfter); err != nil {
+			invalid("Desktop notifications", err, "Set notify_after to a duration such as \"5m\"")
+		}
+	}
+	if config.CryptKeyFile != "" {
+		if _, err := pan.NewCipherFromKeyFile(config.CryptKeyFile); err != nil {
+			invalid("Encryption key", err, "Point crypt_key_file at a file holding 32 raw bytes or 64 hex characters")
+		}
+	}
+	for _, job := range config.Jobs {
+		if _, err := scheduledJob(job, "", nil); err != nil {
+			invalid("Scheduled job", err, "Fix the job's entry in the [[jobs]] section")
+		}
+	}
+	return results, config
+}
+
+func listCommand(client pan.PanClient) {
 	// Create a new flag set for the list command using pflag
 	listFlags := pflag.NewFlagSet("ls", pflag.ExitOnError)
 	var dir string
+	var typeNames []string
+	var mediaInfo bool
+	var minDuration, maxDuration time.Duration
+	var recursive bool
+	var maxDepth int
+	var fullPaths bool
+	var globs []string
+	var regexps []string
+	var output string
+	var print0 bool
 	var help bool
 
-	listFlags.StringVarP(&dir, "path", "p", "/", "Directory to list (default: /)")
-	listFlags.BoolVarP(&help, "help", "h", false, "Show help for list command")
+	listFlags.StringVarP(&dir, "path", "p", "/", pan.T("Directory to list (default: /)"))
+	listFlags.StringVar(&output, "output", "text", pan.T("Output format: text, or csv or tsv with a header row"))
+	listFlags.BoolVarP(&recursive, "recursive", "R", false, pan.T("List the whole subtree, each name indented by its depth"))
+	listFlags.IntVar(&maxDepth, "max-depth", 0, pan.T("List at most this many levels below the directory (implies -R; default: no limit)"))
+	listFlags.BoolVar(&fullPaths, "full-paths", false, pan.T("Print only the full path of each entry, one per line"))
+	listFlags.BoolVarP(&print0, "print0", "0", false, pan.T("Print only the full path of each entry, each followed by a NUL byte, for xargs -0"))
+	listFlags.StringArrayVar(&globs, "match", nil, pan.T("Only list entries whose name matches this glob, e.g. '*.mkv'; repeat for several"))
+	listFlags.StringArrayVar(&regexps, "regex", nil, pan.T("Only list entries whose name matches this regular expression; repeat for several"))
+	listFlags.StringSliceVarP(&typeNames, "type", "t", nil, pan.T("Only list entries of these types: image, video, audio, doc, archive, other or dir"))
+	listFlags.BoolVar(&mediaInfo, "media-info", false, pan.T("Show the duration, resolution and bitrate of videos and audio files"))
+	listFlags.DurationVar(&minDuration, "min-duration", 0, pan.T("Only list videos and audio files at least this long, e.g. 30s (implies --media-info)"))
+	listFlags.DurationVar(&maxDuration, "max-duration", 0, pan.T("Only list videos and audio files at most this long, e.g. 2m (implies --media-info)"))
+	listFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for list command"))
 
 	// Parse flags starting from os.Args[2] (after the 'list' command)
 	if err := listFlags.Parse(os.Args[2:]); err != nil {
@@ -189,25 +1063,120 @@ func listCommand(client *pan.Client) {
 		return
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Listing files in directory: %s", dir))
+	types := make(map[pan.FileType]bool)
+	for _, name := range typeNames {
+		fileType, err := pan.ParseFileType(name)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error: %v", err), err)
+		}
+		types[fileType] = true
+	}
 
-	files, err := client.ListFiles(dir)
+	if maxDepth < 0 {
+		exitUsage(pan.T("Error: --max-depth cannot be negative."))
+	}
+	names, err := pan.NewNameFilter(globs, regexps)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error listing files: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	recursive = recursive || maxDepth > 0
+	format, err := pan.ParseOutputFormat(output)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	if print0 && format != pan.OutputText {
+		exitUsage(pan.T("Error: --print0 cannot be combined with --output csv or tsv."))
+	}
+
+	// Records and NUL-terminated paths go to stdout alone, so they can be piped to other tools
+	if format == pan.OutputText && !print0 {
+		pan.PrintSuccess(pan.Tf("Listing files in directory: %s", dir))
+	}
+
+	var files []pan.FileInfo
+	if recursive {
+		// Without a depth limit the subtree is fetched with the recursive listall API
+		files, err = client.ListTree(context.Background(), dir, maxDepth)
+	} else {
+		files, err = client.ListFiles(dir)
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error listing files: %v", err), err)
+	}
+
+	if len(types) > 0 {
+		files = slices.DeleteFunc(files, func(file pan.FileInfo) bool { return !types[file.Type()] })
+	}
+	if !names.Empty() {
+		files = slices.DeleteFunc(files, func(file pan.FileInfo) bool { return !names.Match(file.ServerFilename) })
+	}
+
+	var media map[int64]pan.MediaInfo
+	if mediaInfo || minDuration > 0 || maxDuration > 0 {
+		media, err = client.GetMediaInfo(context.Background(), files)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error getting media info: %v", err), err)
+		}
+	}
+	if minDuration > 0 || maxDuration > 0 {
+		files = slices.DeleteFunc(files, func(file pan.FileInfo) bool {
+			info, ok := media[file.FsID]
+			return !ok || info.Duration < minDuration || (maxDuration > 0 && info.Duration > maxDuration)
+		})
 	}
 
-	if len(files) == 0 {
-		pan.PrintSuccess("No files found.")
+	if len(files) == 0 && format == pan.OutputText && !print0 {
+		pan.PrintSuccess(pan.T("No files found."))
 		return
 	}
 
-	// Sort files by filename in ascending order
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].ServerFilename < files[j].ServerFilename
-	})
+	// Sort files by filename in ascending order; a subtree is already in tree order
+	if !recursive {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].ServerFilename < files[j].ServerFilename
+		})
+	}
 
-	// Print files with the new format: <类型> | <文件名> | <文件路径> | <文件大小> | <创建时间> | <更新时间>
+	if print0 {
+		for _, file := range files {
+			fmt.Print(file.Path, "\x00")
+		}
+		return
+	}
+
+	var records *csv.Writer
+	if format != pan.OutputText {
+		records = pan.NewRecordWriter(os.Stdout, format)
+		defer func() {
+			if records.Flush(); records.Error() != nil {
+				pan.PrintErrorAndExit(pan.Tf("Error writing output: %v", records.Error()))
+			}
+		}()
+	}
+
+	if fullPaths {
+		if records != nil {
+			records.Write([]string{"path"})
+		}
+		for _, file := range files {
+			if records != nil {
+				records.Write([]string{file.Path})
+			} else {
+				fmt.Println(file.Path)
+			}
+		}
+		return
+	}
+
+	if records != nil {
+		header := []string{"type", "name", "path", "size", "ctime", "mtime", "category"}
+		if media != nil {
+			header = append(header, "duration", "resolution", "bitrate")
+		}
+		records.Write(header)
+	}
+
+	// Print files with the new format: <类型> | <文件名> | <文件路径> | <文件大小> | <创建时间> | <更新时间> | <分类>
 	for _, file := range files {
 		// Determine file type: D for directory, F for file
 		fileType := "F"
@@ -225,27 +1194,69 @@ func listCommand(client *pan.Client) {
 		ctime := time.Unix(file.ServerCtime, 0)
 		mtime := time.Unix(file.ServerMtime, 0)
 
-		// Output in the required format
-		fmt.Printf("%s | %s | %s | %s | %s | %s\n",
-			fileType,
-			file.ServerFilename,
+		// Entries of a subtree are indented by their depth below the listed directory
+		name := file.ServerFilename
+		if recursive && records == nil {
+			name = strings.Repeat("  ", pan.TreeDepth(dir, file.Path)-1) + name
+		}
+
+		fields := []string{
+			fileType,
+			name,
 			file.Path,
 			sizeStr,
 			ctime.Format("2006-01-02 15:04:05"),
-			mtime.Format("2006-01-02 15:04:05"))
+			mtime.Format("2006-01-02 15:04:05"),
+			string(file.Type()),
+		}
+		if media != nil {
+			// Media columns: <时长> | <分辨率> | <码率>, "-" where unknown
+			duration, resolution, bitrate := "-", "-", "-"
+			if info, ok := media[file.FsID]; ok {
+				duration = info.Duration.Round(time.Second).String()
+				if info.Resolution() != "" {
+					resolution = info.Resolution()
+				}
+				bitrate = pan.FormatBitrate(info.Bitrate)
+			}
+			fields = append(fields, duration, resolution, bitrate)
+		}
+
+		// Output in the required format
+		if records != nil {
+			records.Write(fields)
+		} else {
+			fmt.Println(strings.Join(fields, " | "))
+		}
 	}
 }
 
-func downloadCommand(client *pan.Client) {
+func downloadCommand(client pan.PanClient) {
 	// Create a new flag set for the download command using pflag
 	downloadFlags := pflag.NewFlagSet("dl", pflag.ExitOnError)
 	var filePath string
 	var outputPath string
+	var opts pan.DownloadOptions
+	var crypt bool
+	var split bool
+	var recursive bool
+	var transfers int
+	var fsID int64
 	var help bool
 
-	downloadFlags.StringVarP(&filePath, "source", "s", "", "File path in Baidu Pan to download (required)")
-	downloadFlags.StringVarP(&outputPath, "destination", "d", "", "Local output file path (optional, defaults to current directory with original filename)")
-	downloadFlags.BoolVarP(&help, "help", "h", false, "Show help for download command")
+	downloadFlags.StringVarP(&filePath, "source", "s", "", pan.T("File path in Baidu Pan to download (required)"))
+	downloadFlags.Int64Var(&fsID, "fsid", 0, pan.T("fs_id of the file or directory to download, instead of -s"))
+	downloadFlags.StringVarP(&outputPath, "destination", "d", "", pan.T("Local output file path (optional, defaults to current directory with original filename)"))
+	downloadFlags.BoolVar(&opts.NoPreserveTimes, "no-preserve-times", false, pan.T("Don't set the local file's modification time from the remote file"))
+	downloadFlags.BoolVar(&opts.NoVerify, "no-verify", false, pan.T("Skip MD5 verification of the downloaded file"))
+	downloadFlags.IntVar(&opts.VerifyRetries, "verify-retries", 2, pan.T("Number of re-downloads after an MD5 mismatch"))
+	downloadFlags.BoolVar(&crypt, "crypt", false, pan.T("Decrypt a file uploaded with --crypt"))
+	downloadFlags.BoolVar(&split, "split", false, pan.T("Reassemble a file uploaded with --split from its parts and manifest"))
+	downloadFlags.BoolVarP(&recursive, "recursive", "r", false, pan.T("Download a directory and everything beneath it"))
+	downloadFlags.IntVar(&transfers, "transfers", pan.DefaultDownloadTransfers, pan.T("Number of files downloaded concurrently with --recursive"))
+	downloadFlags.IntVar(&opts.Connections, "connections", 1, pan.T("Number of byte ranges of each file downloaded concurrently"))
+	downloadFlags.BoolVar(&opts.IgnoreSpace, "ignore-space", false, pan.T("Download even if the local disk seems too full"))
+	downloadFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for download command"))
 
 	// Parse flags starting from os.Args[2] (after the 'download' command)
 	if err := downloadFlags.Parse(os.Args[2:]); err != nil {
@@ -259,11 +1270,33 @@ func downloadCommand(client *pan.Client) {
 		return
 	}
 
+	if fsID != 0 {
+		filePath = fsIDPath(client, fsID, filePath)
+	}
+
 	// Check if file path is provided
 	if filePath == "" {
-		pan.PrintError("Error: -f or --file flag is required to specify the file to download")
+		pan.PrintError(pan.T("Error: -f or --file flag is required to specify the file to download"))
 		downloadFlags.PrintDefaults()
-		os.Exit(1)
+		os.Exit(pan.ExitUsage)
+	}
+
+	// An explicit zero means no retries; the zero value in DownloadOptions means the default
+	if opts.VerifyRetries == 0 {
+		opts.VerifyRetries = -1
+	}
+	if crypt {
+		opts.Cipher = loadCipher()
+	}
+	if transfers < 1 || opts.Connections < 1 {
+		exitUsage(pan.T("Error: --transfers and --connections must be at least 1."))
+	}
+	if recursive {
+		if split {
+			exitUsage(pan.T("Error: --split cannot be combined with --recursive."))
+		}
+		downloadDir(client, filePath, outputPath, transfers, opts)
+		return
 	}
 
 	// Determine the local output file path
@@ -272,32 +1305,113 @@ func downloadCommand(client *pan.Client) {
 		// If no output path is specified, use the original filename in the current directory
 		_, fileName := filepath.Split(filePath)
 		if fileName == "" {
-			pan.PrintError(fmt.Sprintf("Error: Invalid file path: %s", filePath))
+			pan.PrintError(pan.Tf("Error: Invalid file path: %s", filePath))
 			os.Exit(1)
 		}
 		localFilePath = fileName
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Downloading file '%s' from Baidu Pan to '%s'...", filePath, localFilePath))
+	pan.PrintSuccess(pan.Tf("Downloading file '%s' from Baidu Pan to '%s'...", filePath, localFilePath))
 
-	err := client.DownloadFileToPath(filePath, localFilePath)
+	var err error
+	started := time.Now()
+	if split {
+		err = client.DownloadFileSplit(filePath, localFilePath, opts)
+	} else {
+		err = client.DownloadFileToPathWithOptions(filePath, localFilePath, opts)
+	}
+	var size int64
+	if info, statErr := os.Stat(localFilePath); statErr == nil && err == nil {
+		size = info.Size()
+	}
+	notifyReport(transferReport("dl", started, size, err), false)
+	exitIfInterrupted(err)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error downloading file: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error downloading file: %v", err), err)
+	}
+
+	pan.PrintSuccess(pan.Tf("File downloaded successfully to: %s", localFilePath))
+}
+
+// downloadDir downloads the remote directory remoteDir into localDir, the current
+// directory's subdirectory of the same name by default, with transfers files at a time
+func downloadDir(client pan.PanClient, remoteDir, localDir string, transfers int, opts pan.DownloadOptions) {
+	if localDir == "" {
+		localDir = path.Base(path.Clean(remoteDir))
+	}
+
+	ctx := transferCtx
+	jobs, err := client.DownloadDirJobs(ctx, remoteDir, localDir)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error listing %s: %v", remoteDir, err), err)
+	}
+	if len(jobs) == 0 {
+		pan.PrintSuccess(pan.Tf("No files to download in %s.", remoteDir))
+		return
 	}
+	pan.PrintSuccess(pan.Tf("Downloading %d files from '%s' to '%s' (%d at a time)...", len(jobs), remoteDir, localDir, transfers))
 
-	pan.PrintSuccess(fmt.Sprintf("File downloaded successfully to: %s", localFilePath))
+	var failures []string
+	started := time.Now()
+	result, err := client.DownloadFiles(ctx, jobs, pan.DownloadFilesOptions{
+		Transfers: transfers,
+		Download:  opts,
+		Finished: func(job pan.DownloadJob, err error) {
+			if err != nil && !errors.Is(err, pan.ErrInterrupted) {
+				failures = append(failures, fmt.Sprintf("%s: %v", job.Remote, err))
+			}
+		},
+	})
+	notifyReport(pan.JobReport{
+		Job:      "dl",
+		Success:  result.Failed == 0 && err == nil,
+		Bytes:    result.Bytes,
+		Files:    result.Downloaded,
+		Duration: time.Since(started),
+		Started:  started,
+		Errors:   failures,
+	}, false)
+	for _, failure := range failures {
+		pan.PrintError(pan.Tf("Failed: %s", failure))
+	}
+	exitIfInterrupted(err)
+	if result.Failed > 0 {
+		pan.PrintError(pan.Tf("%d files downloaded (%s), %d failed.", result.Downloaded, pan.FormatBytes(result.Bytes), result.Failed))
+		os.Exit(pan.ExitPartial)
+	}
+	pan.PrintSuccess(pan.Tf("%d files downloaded (%s) to: %s", result.Downloaded, pan.FormatBytes(result.Bytes), localDir))
 }
 
-func uploadCommand(client *pan.Client) {
+func uploadCommand(client pan.PanClient) {
 	uploadFlags := pflag.NewFlagSet("ul", pflag.ExitOnError)
 	var localFilePath string
 	var remoteFilePath string
+	var opts pan.UploadOptions
+	var sliceSize string
+	var archive string
+	var crypt bool
+	var ifExists string
+	var transfers int
+	var filesFrom string
+	var links string
+	var special string
 	var help bool
 
-	uploadFlags.StringVarP(&localFilePath, "source", "s", "", "Local file path to upload (required)")
-	uploadFlags.StringVarP(&remoteFilePath, "destination", "d", "", "Remote file path in Baidu Pan (required, e.g., /path/to/your/file.txt)")
-	uploadFlags.BoolVarP(&help, "help", "h", false, "Show help for upload command")
+	uploadFlags.StringVarP(&localFilePath, "source", "s", "", pan.T("Local file path to upload (required)"))
+	uploadFlags.StringVarP(&remoteFilePath, "destination", "d", "", pan.T("Remote file path in Baidu Pan (required, e.g., /path/to/your/file.txt)"))
+	uploadFlags.BoolVar(&opts.NoPreserveTimes, "no-preserve-times", false, pan.T("Don't preserve the local modification time on the uploaded file"))
+	uploadFlags.BoolVar(&crypt, "crypt", false, pan.T("Encrypt the file with the configured key before uploading"))
+	uploadFlags.BoolVar(&opts.Split, "split", false, pan.T("Upload a file larger than the account's size limit as parts plus a manifest"))
+	uploadFlags.StringVar(&sliceSize, "slice-size", "", pan.T("Upload slice size, e.g. 4M or 16M (default: chosen from the account's VIP level)"))
+	uploadFlags.StringVar(&archive, "archive", "", pan.T("Upload a directory as a single archive: tar, tar.gz or zip"))
+	uploadFlags.StringVar(&ifExists, "if-exists", "overwrite", pan.T("What to do when the remote file exists: overwrite, rename, skip or fail"))
+	uploadFlags.BoolVar(&opts.NoRapid, "no-rapid", false, pan.T("Always transfer the content, even when Baidu Pan already holds an identical copy"))
+	uploadFlags.BoolVar(&opts.SkipIdentical, "skip-identical", false, pan.T("Skip the upload when the remote file has the same size and MD5"))
+	uploadFlags.IntVar(&transfers, "transfers", pan.DefaultUploadTransfers, pan.T("Number of files uploaded concurrently for a directory, glob or --files-from"))
+	uploadFlags.StringVar(&filesFrom, "files-from", "", pan.T("Upload the local paths listed in this file, one per line ('-' for stdin), into the -d directory"))
+	uploadFlags.StringVar(&links, "links", string(pan.LinksFollow), pan.T("Symbolic links in local directories: follow, skip or error"))
+	uploadFlags.StringVar(&special, "special", string(pan.SpecialSkip), pan.T("Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error"))
+	uploadFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for upload command"))
 
 	if err := uploadFlags.Parse(os.Args[2:]); err != nil {
 		return
@@ -308,39 +1422,228 @@ func uploadCommand(client *pan.Client) {
 		return
 	}
 
-	if localFilePath == "" {
-		pan.PrintError("Error: -f or --file flag is required to specify the local file to upload.")
+	if localFilePath == "" && filesFrom == "" {
+		pan.PrintError(pan.T("Error: -f or --file flag is required to specify the local file to upload."))
 		uploadFlags.PrintDefaults()
-		os.Exit(1)
+		os.Exit(pan.ExitUsage)
 	}
 
 	if remoteFilePath == "" {
-		pan.PrintError("Error: -d or --dir flag is required to specify the remote file path.")
+		pan.PrintError(pan.T("Error: -d or --dir flag is required to specify the remote file path."))
 		uploadFlags.PrintDefaults()
-		os.Exit(1)
+		os.Exit(pan.ExitUsage)
+	}
+
+	opts.SliceSize = parseSliceSize(sliceSize)
+	if crypt {
+		opts.Cipher = loadCipher()
+	}
+	policy, err := pan.ParseIfExists(ifExists)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	opts.IfExists = policy
+
+	if transfers < 1 {
+		exitUsage(pan.T("Error: --transfers must be at least 1."))
+	}
+	if archive != "" {
+		uploadArchive(client, localFilePath, remoteFilePath, archive, opts)
+		return
+	}
+
+	walk := pan.LocalWalkOptions{
+		Links:   parseLinks(links),
+		Special: parseSpecial(special),
+		Skipped: func(localPath, reason string) {
+			pan.PrintError(pan.Tf("Skipping %s: %s", reason, localPath))
+		},
+	}
+	if sources := uploadSources(localFilePath, filesFrom); sources != nil {
+		jobs, err := pan.UploadPathJobs(sources, remoteFilePath, walk)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error listing files to upload: %v", err), err)
+		}
+		uploadFiles(client, jobs, remoteFilePath, transfers, opts)
+		return
+	}
+	if info, err := os.Stat(localFilePath); err == nil && info.IsDir() {
+		jobs, err := pan.UploadDirJobs(localFilePath, remoteFilePath, walk)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error listing files to upload: %v", err), err)
+		}
+		uploadFiles(client, jobs, remoteFilePath, transfers, opts)
+		return
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Uploading local file '%s' to Baidu Pan as '%s'...", localFilePath, remoteFilePath))
+	pan.PrintSuccess(pan.Tf("Uploading local file '%s' to Baidu Pan as '%s'...", localFilePath, remoteFilePath))
 
-	err := client.UploadFile(localFilePath, remoteFilePath)
+	started := time.Now()
+	err = client.UploadFileWithOptions(localFilePath, remoteFilePath, opts)
+	if errors.Is(err, pan.ErrUploadSkipped) {
+		pan.PrintSuccess(fmt.Sprintf("%v.", err))
+		return
+	}
+	var size int64
+	if info, statErr := os.Stat(localFilePath); statErr == nil {
+		size = info.Size()
+	}
+	notifyReport(transferReport("ul", started, size, err), false)
+	exitIfInterrupted(err)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error uploading file: %v", err))
-		os.Exit(1)
+		pan.PrintError(pan.Tf("Error uploading file: %v", err))
+		if errors.Is(err, pan.ErrFileTooLarge) {
+			pan.PrintError(pan.T("Upload it with --split to store it as parts plus a manifest; 'dl --split' puts it back together."))
+		}
+		os.Exit(pan.ExitCode(err))
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was uploaded."))
+		return
 	}
 
 	_, fileName := filepath.Split(localFilePath)
-	pan.PrintSuccess(fmt.Sprintf("File '%s' uploaded successfully to '%s'.", fileName, remoteFilePath))
+	pan.PrintSuccess(pan.Tf("File '%s' uploaded successfully to '%s'.", fileName, remoteFilePath))
+}
+
+// uploadSources returns the local paths to upload into a remote directory: the lines of
+// the file filesFrom if set, or the matches of source when it is a glob rather than an
+// existing path. It returns nil for a single file or directory.
+func uploadSources(source, filesFrom string) []string {
+	if filesFrom != "" {
+		if source != "" {
+			exitUsage(pan.T("Error: -s and --files-from cannot be combined."))
+		}
+		in := os.Stdin
+		if filesFrom != "-" {
+			f, err := os.Open(filesFrom)
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error reading file list: %v", err), err)
+			}
+			defer f.Close()
+			in = f
+		}
+		paths, err := pan.ReadPathList(in)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error reading file list: %v", err), err)
+		}
+		return paths
+	}
+
+	if _, err := os.Lstat(source); err == nil || !strings.ContainsAny(source, "*?[") {
+		return nil
+	}
+	matches, err := filepath.Glob(source)
+	if err != nil {
+		exitUsage(pan.Tf("Error: invalid pattern %q: %v", source, err))
+	}
+	if len(matches) == 0 {
+		pan.PrintErrorAndExit(pan.Tf("Error: no local files match %s", source))
+	}
+	return matches
+}
+
+// uploadFiles uploads jobs into the remote directory remoteDir with transfers files at a
+// time
+func uploadFiles(client pan.PanClient, jobs []pan.UploadJob, remoteDir string, transfers int, opts pan.UploadOptions) {
+	if len(jobs) == 0 {
+		pan.PrintSuccess(pan.T("No files to upload."))
+		return
+	}
+	pan.PrintSuccess(pan.Tf("Uploading %d files to '%s' (%d at a time)...", len(jobs), remoteDir, transfers))
+
+	var failures []string
+	started := time.Now()
+	result, err := client.UploadFiles(transferCtx, jobs, pan.UploadFilesOptions{
+		Transfers: transfers,
+		Upload:    opts,
+		Finished: func(job pan.UploadJob, err error) {
+			if err != nil && !errors.Is(err, pan.ErrInterrupted) && !errors.Is(err, pan.ErrUploadSkipped) {
+				failures = append(failures, fmt.Sprintf("%s: %v", job.Local, err))
+			}
+		},
+	})
+	notifyReport(pan.JobReport{
+		Job:      "ul",
+		Success:  result.Failed == 0 && err == nil,
+		Bytes:    result.Bytes,
+		Files:    result.Uploaded,
+		Duration: time.Since(started),
+		Started:  started,
+		Errors:   failures,
+	}, false)
+	for _, failure := range failures {
+		pan.PrintError(pan.Tf("Failed: %s", failure))
+	}
+	exitIfInterrupted(err)
+	if result.Failed > 0 {
+		pan.PrintError(pan.Tf("%d files uploaded (%s), %d skipped, %d failed.", result.Uploaded, pan.FormatBytes(result.Bytes), result.Skipped, result.Failed))
+		os.Exit(pan.ExitPartial)
+	}
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was uploaded."))
+		return
+	}
+	pan.PrintSuccess(pan.Tf("%d files uploaded (%s), %d skipped, to: %s", result.Uploaded, pan.FormatBytes(result.Bytes), result.Skipped, remoteDir))
+}
+
+// uploadArchive uploads the local directory localDir to remotePath as a single archive
+func uploadArchive(client pan.PanClient, localDir, remotePath, archive string, opts pan.UploadOptions) {
+	format, err := pan.ParseArchiveFormat(archive)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	if opts.Split {
+		exitUsage(pan.T("Error: --split cannot be combined with --archive."))
+	}
+
+	pan.PrintSuccess(pan.Tf("Archiving local directory '%s' as %s and uploading it to '%s'...", localDir, format, remotePath))
+
+	started := time.Now()
+	err = client.UploadDirArchive(transferCtx, localDir, remotePath, format, opts)
+	var size int64
+	if info, statErr := client.GetFileInfoByPath(remotePath); statErr == nil && err == nil {
+		size = info.Size
+	}
+	notifyReport(transferReport("ul", started, size, err), false)
+	if err != nil && transferCtx.Err() != nil {
+		err = fmt.Errorf("%w: archive upload of %s stopped", pan.ErrInterrupted, localDir)
+	}
+	exitIfInterrupted(err)
+	if err != nil {
+		pan.PrintError(pan.Tf("Error uploading archive: %v", err))
+		if errors.Is(err, pan.ErrFileTooLarge) {
+			pan.PrintError(pan.T("Archive smaller parts of the directory separately, or upload it file by file with sync."))
+		}
+		os.Exit(pan.ExitCode(err))
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was uploaded."))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Directory '%s' uploaded successfully as '%s'.", localDir, remotePath))
 }
 
-func removeCommand(client *pan.Client) {
+func removeCommand(client pan.PanClient) {
 	removeFlags := pflag.NewFlagSet("rm", pflag.ExitOnError)
 	var remotePath string
 	var force bool
+	var fsID int64
+	var permanent bool
+	var restoreHint bool
+	var noWait bool
 	var help bool
 
-	removeFlags.StringVarP(&remotePath, "source", "s", "", "Remote file or directory path to remove (required)")
-	removeFlags.BoolVarP(&force, "force", "y", false, "Force removal without confirmation")
-	removeFlags.BoolVarP(&help, "help", "h", false, "Show help for remove command")
+	removeFlags.StringVarP(&remotePath, "source", "s", "", pan.T("Remote file or directory path to remove (required)"))
+	removeFlags.Int64Var(&fsID, "fsid", 0, pan.T("fs_id of the file or directory to remove, instead of -s"))
+	removeFlags.BoolVarP(&force, "force", "y", false, pan.T("Force removal without confirmation"))
+	removeFlags.BoolVar(&permanent, "permanent", false, pan.T("Delete without keeping the entry in the recycle bin (not supported by Baidu Pan's open API)"))
+	removeFlags.BoolVar(&restoreHint, "restore-hint", false, pan.T("After removing, print how to restore the entry from the recycle bin"))
+	removeFlags.BoolVar(&noWait, "no-wait", false, pan.T("Start the removal as a server-side task and print its id instead of waiting; see 'task'"))
+	removeFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for remove command"))
 
 	if err := removeFlags.Parse(os.Args[2:]); err != nil {
 		return
@@ -351,399 +1654,3266 @@ func removeCommand(client *pan.Client) {
 		return
 	}
 
+	if fsID != 0 {
+		remotePath = fsIDPath(client, fsID, remotePath)
+	}
 	if remotePath == "" {
-		pan.PrintError("Error: -r or --remote-path flag is required to specify the file or directory to remove.")
+		pan.PrintError(pan.T("Error: -r or --remote-path flag is required to specify the file or directory to remove."))
 		removeFlags.PrintDefaults()
-		os.Exit(1)
+		os.Exit(pan.ExitUsage)
 	}
 
-	// If not in force mode, ask for confirmation
-	if !force {
-		fmt.Printf("Are you sure you want to remove '%s'? This operation cannot be undone. (y/N): ", remotePath)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			pan.PrintSuccess("Remove operation cancelled.")
-			return
+	if permanent {
+		pan.PrintErrorAndExit(pan.T("Error: Baidu Pan's open API cannot delete permanently; removed entries always go to the recycle bin. Empty it in the Baidu Pan website or app."))
+	}
+
+	// Removed entries go to the recycle bin; its retention depends on the VIP level
+	days := pan.RecycleDaysNormal
+	var info *pan.FileInfo
+	if !globals.DryRun {
+		days, _ = client.RecycleRetention()
+		if restoreHint {
+			var err error
+			if info, err = client.GetFileInfoByPath(remotePath); err != nil {
+				pan.ExitWithError(pan.Tf("Error getting file information: %v", err), err)
+			}
 		}
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Removing '%s' from Baidu Pan...", remotePath))
+	// Ask for confirmation unless -y or --yes is set
+	prompt := pan.Tf("Are you sure you want to remove '%s'? It goes to the recycle bin and can be restored for %d days. (y/N): ", remotePath, days)
+	if !confirmed(force, prompt, pan.T("Remove operation cancelled.")) {
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Removing '%s' from Baidu Pan...", remotePath))
+
+	if noWait {
+		id, err := client.StartRemoveTask([]string{remotePath})
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error removing file: %v", err), err)
+		}
+		printStartedTask(id, pan.T("Dry run: nothing was removed."))
+		return
+	}
 
 	err := client.RemoveFile(remotePath)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error removing file: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error removing file: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was removed."))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("'%s' removed successfully from Baidu Pan.", remotePath))
+	if info == nil {
+		pan.PrintSuccess(pan.Tf("It is in the recycle bin and can be restored for %d days.", days))
+		return
+	}
+	fmt.Println(pan.Tf("To restore it, open the recycle bin in the Baidu Pan website or app before %s and restore '%s' (fs_id %d).",
+		pan.RecycleDeadline(time.Now(), days).Format("2006-01-02 15:04"), info.ServerFilename, info.FsID))
+	fmt.Println(pan.Tf("It is restored to its original path, %s.", remotePath))
+}
+
+func moveCommand(client pan.PanClient) {
+	moveFlags := pflag.NewFlagSet("mv", pflag.ExitOnError)
+	var sources []string
+	var destPath string
+	var force bool
+	var fsIDs []int64
+	var noWait bool
+	var help bool
+
+	moveFlags.StringArrayVarP(&sources, "source", "s", nil, pan.T("Source file or directory path to move; repeat to move several (required)"))
+	moveFlags.Int64SliceVar(&fsIDs, "fsid", nil, pan.T("fs_id of a file or directory to move, instead of -s; repeat or separate with commas"))
+	moveFlags.StringVarP(&destPath, "destination", "d", "", pan.T("Destination directory path (required)"))
+	moveFlags.BoolVarP(&force, "force", "y", false, pan.T("Force move without confirmation"))
+	moveFlags.BoolVar(&noWait, "no-wait", false, pan.T("Start the move as a server-side task and print its id instead of waiting; see 'task'"))
+	moveFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for move command"))
+
+	if err := moveFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		moveFlags.PrintDefaults()
+		return
+	}
+
+	if len(fsIDs) > 0 {
+		if len(sources) > 0 {
+			exitUsage(pan.T("Error: --fsid cannot be combined with paths."))
+		}
+		paths, err := client.PathsByFsID(context.Background(), fsIDs)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error looking up fs_ids: %v", err), err)
+		}
+		sources = paths
+	}
+	if len(sources) == 0 {
+		pan.PrintError(pan.T("Error: -s or --source flag is required to specify the file or directory to move."))
+		moveFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	if destPath == "" {
+		pan.PrintError(pan.T("Error: -d or --destination flag is required to specify the destination directory."))
+		moveFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	// Ask for confirmation unless -y or --yes is set
+	prompt := pan.Tf("Are you sure you want to move %d entries to '%s'? (y/N): ", len(sources), destPath)
+	if len(sources) == 1 {
+		prompt = pan.Tf("Are you sure you want to move '%s' to '%s'? (y/N): ", sources[0], destPath)
+	}
+	if !confirmed(force, prompt, pan.T("Move operation cancelled.")) {
+		return
+	}
+
+	for _, source := range sources {
+		pan.PrintSuccess(pan.Tf("Moving '%s' to '%s' in Baidu Pan...", source, destPath))
+	}
+
+	if noWait {
+		id, err := client.StartMoveTask(pan.MoveRequestsInto(sources, destPath))
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error moving file: %v", err), err)
+		}
+		printStartedTask(id, pan.T("Dry run: nothing was moved."))
+		return
+	}
+
+	errs, err := pan.BatchErrors(len(sources), client.MoveFilesInto(sources, destPath))
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error moving file: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was moved."))
+		return
+	}
+
+	failed := false
+	for i, source := range sources {
+		if errs[i] != nil {
+			pan.PrintError(pan.Tf("Error moving '%s': %v", source, errs[i]))
+			failed = true
+			continue
+		}
+		pan.PrintSuccess(pan.Tf("'%s' moved successfully to '%s' in Baidu Pan.", source, destPath))
+	}
+	if failed {
+		os.Exit(pan.ExitPartial)
+	}
+}
+
+func renameCommand(client pan.PanClient) {
+	renameFlags := pflag.NewFlagSet("rn", pflag.ExitOnError)
+	var sourcePath string
+	var newName string
+	var pattern string
+	var bulkOpts pan.BulkRenameOptions
+	var force bool
+	var help bool
+
+	renameFlags.StringVarP(&sourcePath, "source", "s", "", pan.T("Source file or directory path to rename (required)"))
+	renameFlags.StringVarP(&newName, "newname", "n", "", pan.T("New name for the file or directory (required)"))
+	renameFlags.StringVar(&pattern, "pattern", "", pan.T("Rename the entries of the directory -s with s/<regexp>/<replacement>/[gi] or a template such as 'Trip_%03d{ext}', instead of -n"))
+	renameFlags.StringVar(&bulkOpts.Match, "match", "", pan.T("With --pattern, only rename entries whose name matches this glob"))
+	renameFlags.BoolVar(&bulkOpts.Dirs, "dirs", false, pan.T("With --pattern, rename directories as well as files"))
+	renameFlags.IntVar(&bulkOpts.Start, "start", 1, pan.T("With --pattern, first value of the template's counter"))
+	renameFlags.BoolVarP(&force, "force", "y", false, pan.T("Force rename without confirmation"))
+	renameFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for rename command"))
+
+	if err := renameFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		renameFlags.PrintDefaults()
+		return
+	}
+
+	if sourcePath == "" {
+		pan.PrintError(pan.T("Error: -s or --source flag is required to specify the file or directory to rename."))
+		renameFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	if pattern != "" {
+		if newName != "" {
+			exitUsage(pan.T("Error: --pattern cannot be combined with -n."))
+		}
+		bulkRename(client, sourcePath, pattern, bulkOpts, force)
+		return
+	}
+
+	if newName == "" {
+		pan.PrintError(pan.T("Error: -n or --newname flag is required to specify the new name."))
+		renameFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	// Extract the parent directory from the source path to construct the new full path
+	dir := filepath.Dir(sourcePath)
+	if dir == "." {
+		dir = "/"
+	}
+	newPath := filepath.Join(dir, newName)
+
+	// Ask for confirmation unless -y or --yes is set
+	prompt := pan.Tf("Are you sure you want to rename '%s' to '%s'? (y/N): ", sourcePath, newPath)
+	if !confirmed(force, prompt, pan.T("Rename operation cancelled.")) {
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Renaming '%s' to '%s' in Baidu Pan...", sourcePath, newPath))
+
+	err := client.RenameFile(sourcePath, newName)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error renaming file: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was renamed."))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("'%s' renamed successfully to '%s' in Baidu Pan.", sourcePath, newPath))
+}
+
+// bulkRename renames the entries of dir with pattern in one batch, after showing the new
+// names and asking for confirmation unless force is set
+func bulkRename(client pan.PanClient, dir, pattern string, opts pan.BulkRenameOptions, force bool) {
+	renamePattern, err := pan.ParseRenamePattern(pattern)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+
+	files, err := client.ListFiles(dir)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error listing files: %v", err), err)
+	}
+	requests, err := pan.PlanBulkRename(files, renamePattern, opts)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	if len(requests) == 0 {
+		pan.PrintSuccess(pan.Tf("No names in '%s' change.", dir))
+		return
+	}
+
+	for _, req := range requests {
+		fmt.Printf("%s -> %s\n", path.Base(req.Path), req.NewName)
+	}
+
+	prompt := pan.Tf("Rename %d entries in '%s'? (y/N): ", len(requests), dir)
+	if !confirmed(force, prompt, pan.T("Rename operation cancelled.")) {
+		return
+	}
+
+	if err := client.RenameFiles(requests); err != nil {
+		pan.ExitWithError(pan.Tf("Error renaming file: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was renamed."))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Renamed %d entries in '%s'.", len(requests), dir))
+}
+
+func copyCommand(client pan.PanClient) {
+	copyFlags := pflag.NewFlagSet("cp", pflag.ExitOnError)
+	var sources []string
+	var destPath string
+	var noWait bool
+	var help bool
+
+	copyFlags.StringArrayVarP(&sources, "source", "s", nil, pan.T("Source file or directory path to copy; repeat to copy several into the destination directory (required)"))
+	copyFlags.StringVarP(&destPath, "destination", "d", "", pan.T("Destination file or directory path (required)"))
+	copyFlags.BoolVar(&noWait, "no-wait", false, pan.T("Start the copy as a server-side task and print its id instead of waiting; see 'task'"))
+	copyFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for copy command"))
+
+	if err := copyFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		copyFlags.PrintDefaults()
+		return
+	}
+
+	if len(sources) == 0 {
+		pan.PrintError(pan.T("Error: -s or --source flag is required to specify the source file or directory to copy."))
+		copyFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	if destPath == "" {
+		pan.PrintError(pan.T("Error: -d or --destination flag is required to specify the destination path."))
+		copyFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	for _, source := range sources {
+		pan.PrintSuccess(pan.Tf("Copying '%s' to '%s' in Baidu Pan...", source, destPath))
+	}
+
+	// Several sources always go into the destination directory; a single source is
+	// copied to the destination name unless it ends with a slash or looks like a directory.
+	// The copies run as server-side tasks, with progress counted in entries.
+	if noWait {
+		requests := pan.CopyRequestsInto(sources, destPath)
+		if len(sources) == 1 {
+			requests = []pan.CopyRequest{pan.NewCopyRequest(sources[0], destPath)}
+		}
+		id, err := client.StartCopyTask(requests)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error copying file: %v", err), err)
+		}
+		printStartedTask(id, pan.T("Dry run: nothing was copied."))
+		return
+	}
+
+	var batchErr error
+	if len(sources) == 1 {
+		batchErr = client.CopyFileWithOptions(sources[0], destPath, pan.CopyOptions{})
+	} else {
+		batchErr = client.CopyFilesIntoWithOptions(sources, destPath, pan.CopyOptions{})
+	}
+	errs, err := pan.BatchErrors(len(sources), batchErr)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error copying file: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was copied."))
+		return
+	}
+
+	failed := false
+	for i, source := range sources {
+		if errs[i] != nil {
+			pan.PrintError(pan.Tf("Error copying '%s': %v", source, errs[i]))
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(pan.ExitPartial)
+	}
+}
+
+// printStartedTask reports the task started by --no-wait, or dryRun in dry-run mode
+func printStartedTask(id int64, dryRun string) {
+	switch {
+	case globals.DryRun:
+		pan.PrintSuccess(dryRun)
+	case id == 0:
+		pan.PrintSuccess(pan.T("Baidu Pan carried out the operation at once."))
+	default:
+		pan.PrintSuccess(pan.Tf("Started task %d. Check on it with 'go-bdfs task query %d' or 'go-bdfs task wait %d'.", id, id, id))
+	}
+}
+
+func taskCommand(client pan.PanClient) {
+	taskFlags := pflag.NewFlagSet("task", pflag.ExitOnError)
+	var timeout time.Duration
+	var help bool
+
+	taskFlags.DurationVar(&timeout, "timeout", 0, pan.T("With wait, give up after this long, e.g. 30m (default: until the task finishes)"))
+	taskFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for task command"))
+
+	if err := taskFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs task <query|wait> <taskid> [--timeout <duration>]")
+		taskFlags.PrintDefaults()
+		return
+	}
+
+	if taskFlags.NArg() != 2 {
+		pan.PrintError(pan.T("Error: specify a task action, query or wait, and a task id."))
+		taskFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+	id, err := strconv.ParseInt(taskFlags.Arg(1), 10, 64)
+	if err != nil || id <= 0 {
+		exitUsage(pan.Tf("Error: invalid task id: %s", taskFlags.Arg(1)))
+	}
+
+	ctx := context.Background()
+	var task *pan.Task
+	switch action := taskFlags.Arg(0); action {
+	case "query":
+		task, err = client.QueryTask(ctx, id)
+	case "wait":
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		pan.PrintSuccess(pan.Tf("Waiting for task %d to finish...", id))
+		task, err = client.WaitTask(ctx, id)
+	default:
+		exitUsage(pan.Tf("Unknown task action: %s", action))
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error checking task %d: %v", id, err), err)
+	}
+
+	if !task.Finished() {
+		if task.Progress > 0 {
+			pan.PrintSuccess(pan.Tf("Task %d is %s (%d%%).", id, task.Status, task.Progress))
+		} else {
+			pan.PrintSuccess(pan.Tf("Task %d is %s.", id, task.Status))
+		}
+		return
+	}
+
+	failed := 0
+	for _, entry := range task.List {
+		if err := entry.Err(); err != nil {
+			pan.PrintError(pan.Tf("Error on '%s': %v", entry.From, err))
+			failed++
+		}
+	}
+	switch {
+	case task.Status == pan.TaskFailed:
+		pan.ExitWithError(pan.Tf("Task %d failed: %v", id, task.Err()), task.Err())
+	case failed > 0:
+		pan.PrintError(pan.Tf("Task %d finished, but %d of its %d entries failed.", id, failed, len(task.List)))
+		os.Exit(pan.ExitPartial)
+	}
+	pan.PrintSuccess(pan.Tf("Task %d succeeded.", id))
+}
+
+func xcopyCommand(client *pan.Client, config *Config, clientOpts []pan.Option) {
+	xcopyFlags := pflag.NewFlagSet("xcopy", pflag.ExitOnError)
+	var from string
+	var to string
+	var opts pan.UploadOptions
+	var sliceSize string
+	var help bool
+
+	xcopyFlags.StringVar(&from, "from", "", pan.T("Source as [profile:]path, e.g. work:/docs (required)"))
+	xcopyFlags.StringVar(&to, "to", "", pan.T("Destination as [profile:]path, e.g. home:/backup/docs (required)"))
+	xcopyFlags.BoolVar(&opts.NoPreserveTimes, "no-preserve-times", false, pan.T("Don't preserve the source modification times on the copies"))
+	xcopyFlags.StringVar(&sliceSize, "slice-size", "", pan.T("Upload slice size, e.g. 4M or 16M (default: chosen from the destination account's VIP level)"))
+	xcopyFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for xcopy command"))
+
+	if err := xcopyFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		xcopyFlags.PrintDefaults()
+		return
+	}
+
+	if from == "" || to == "" {
+		pan.PrintError(pan.T("Error: --from and --to flags are required to specify the source and destination."))
+		xcopyFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	opts.SliceSize = parseSliceSize(sliceSize)
+	srcProfile, srcPath := splitProfilePath(from)
+	dstProfile, dstPath := splitProfilePath(to)
+	src := profileClient(client, config, srcProfile, clientOpts)
+	dst := profileClient(client, config, dstProfile, clientOpts)
+
+	pan.PrintSuccess(pan.Tf("Copying '%s' to '%s'...", from, to))
+
+	if err := src.CopyToAccount(context.Background(), srcPath, dst, dstPath, opts); err != nil {
+		pan.ExitWithError(pan.Tf("Error copying between accounts: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: nothing was copied."))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("'%s' copied successfully to '%s'.", from, to))
+}
+
+// splitProfilePath splits "profile:/path" into its profile name and path. A path without
+// a profile prefix belongs to the default account and yields an empty profile name.
+func splitProfilePath(s string) (string, string) {
+	if name, p, ok := strings.Cut(s, ":"); ok && !strings.HasPrefix(s, "/") {
+		return name, p
+	}
+	return "", s
+}
+
+// profileClient returns an authorized client for the named profile, or defaultClient for
+// the empty name
+func profileClient(defaultClient *pan.Client, config *Config, name string, clientOpts []pan.Option) *pan.Client {
+	if name == "" {
+		return defaultClient
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		exitUsage(pan.Tf("Unknown profile %q; define it under [profiles.%s] in the configuration file", name, name))
+	}
+	if profile.ClientID == "" {
+		profile.ClientID = config.ClientID
+	}
+	if profile.ClientSecret == "" {
+		profile.ClientSecret = config.ClientSecret
+	}
+	if profile.TokenPath == "" {
+		pan.PrintErrorAndExit(pan.Tf("Profile %q has no token_path", name))
+	}
+
+	client := pan.NewClient(profile.ClientID, profile.ClientSecret, profile.TokenPath, clientOpts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	fmt.Printf(pan.T("Starting Baidu Pan authorization for profile '%s'...\n"), name)
+	if err := client.Authorize(ctx); err != nil {
+		pan.PrintError(pan.Tf("Authorization failed for profile %q: %v", name, err))
+		os.Exit(authExitStatus(err))
+	}
+	return client
+}
+
+func pruneEmptyCommand(client pan.PanClient) {
+	pruneFlags := pflag.NewFlagSet("prune-empty", pflag.ExitOnError)
+	var remotePath string
+	var force bool
+	var help bool
+
+	pruneFlags.StringVarP(&remotePath, "path", "p", "", pan.T("Remote directory to clean up (required)"))
+	pruneFlags.BoolVarP(&force, "force", "y", false, pan.T("Remove without confirmation"))
+	pruneFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for prune-empty command"))
+
+	if err := pruneFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		pruneFlags.PrintDefaults()
+		return
+	}
+
+	if remotePath == "" {
+		pan.PrintError(pan.T("Error: -p or --path flag is required to specify the directory to clean up."))
+		pruneFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	prompt := pan.Tf("Are you sure you want to remove every empty directory beneath '%s'? (y/N): ", remotePath)
+	if !confirmed(force, prompt, pan.T("Prune operation cancelled.")) {
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Removing empty directories beneath '%s'...", remotePath))
+
+	removed, err := client.PruneEmptyDirs(context.Background(), remotePath)
+	for _, dir := range removed {
+		if !globals.DryRun {
+			fmt.Printf(pan.T("Removed %s\n"), dir)
+		}
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error removing empty directories: %v", err), err)
+	}
+
+	if globals.DryRun {
+		pan.PrintSuccess(pan.Tf("Dry run: %d empty directories would be removed.", len(removed)))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Removed %d empty directories.", len(removed)))
+}
+
+func reportCommand(client pan.PanClient) {
+	reportFlags := pflag.NewFlagSet("report", pflag.ExitOnError)
+	var remotePath string
+	var asJSON bool
+	var output string
+	var top int
+	var help bool
+
+	reportFlags.StringVarP(&remotePath, "path", "p", "/", pan.T("Remote directory to report on"))
+	reportFlags.BoolVar(&asJSON, "json", false, pan.T("Print the report as JSON"))
+	reportFlags.StringVar(&output, "output", "text", pan.T("Output format: text, or csv or tsv with a header row"))
+	reportFlags.IntVar(&top, "top", 20, pan.T("Largest groups to show per table (0 for all; JSON, CSV and TSV output is never truncated)"))
+	reportFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for report command"))
+
+	if err := reportFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		reportFlags.PrintDefaults()
+		return
+	}
+
+	format, err := pan.ParseOutputFormat(output)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	if asJSON && format != pan.OutputText {
+		exitUsage(pan.T("Error: --json cannot be combined with --output csv or tsv."))
+	}
+
+	report, err := client.BuildStorageReport(context.Background(), remotePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error building storage report: %v", err), err)
+	}
+
+	if format != pan.OutputText {
+		if err := pan.WriteStorageReportRecords(pan.NewRecordWriter(os.Stdout, format), report); err != nil {
+			pan.ExitWithError(pan.Tf("Error writing output: %v", err), err)
+		}
+		return
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding storage report: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Print(pan.FormatStorageReport(report, top))
+}
+
+func speedtestCommand(client pan.PanClient) {
+	speedFlags := pflag.NewFlagSet("speedtest", pflag.ExitOnError)
+	var size string
+	var connections []int
+	var remoteDir string
+	var asJSON bool
+	var help bool
+
+	speedFlags.StringVar(&size, "size", "64M", pan.T("Data to transfer in each run, e.g. 64M or 1G"))
+	speedFlags.IntSliceVarP(&connections, "connections", "c", pan.DefaultSpeedTestConnections, pan.T("Concurrent connection counts to compare"))
+	speedFlags.StringVarP(&remoteDir, "path", "p", "/", pan.T("Remote directory for the temporary test files"))
+	speedFlags.BoolVar(&asJSON, "json", false, pan.T("Print the results as JSON"))
+	speedFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for speedtest command"))
+
+	if err := speedFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		speedFlags.PrintDefaults()
+		return
+	}
+
+	opts := pan.SpeedTestOptions{Connections: connections, Dir: remoteDir}
+	var err error
+	if opts.Size, err = pan.ParseSize(size); err != nil || opts.Size <= 0 {
+		exitUsage(pan.Tf("Error: invalid --size %q", size))
+	}
+	for _, n := range connections {
+		if n < 1 {
+			exitUsage(pan.Tf("Error: invalid connection count %d", n))
+		}
+	}
+	if !asJSON {
+		pan.PrintSuccess(pan.Tf("Testing with %s per run; this transfers %s in total.",
+			pan.FormatBytes(opts.Size), pan.FormatBytes(2*opts.Size*int64(len(connections)))))
+		opts.Progress = func(r pan.SpeedTestResult) {
+			fmt.Printf("%-8s | %2d connections | %s in %s | %s/s\n", r.Direction, r.Connections,
+				pan.FormatBytes(r.Bytes), r.Duration.Round(10*time.Millisecond), pan.FormatBytes(int64(r.BytesPerSecond())))
+		}
+	}
+
+	results, err := client.SpeedTest(context.Background(), opts)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error running speed test: %v", err), err)
+	}
+	if globals.DryRun {
+		return
+	}
+
+	if asJSON {
+		type result struct {
+			pan.SpeedTestResult
+			DurationSeconds float64 `json:"duration_seconds"`
+			BytesPerSecond  float64 `json:"bytes_per_second"`
+		}
+		out := make([]result, len(results))
+		for i, r := range results {
+			out[i] = result{r, r.Duration.Seconds(), r.BytesPerSecond()}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding results: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	pan.PrintSuccess(pan.Tf("Recommended: %d upload connections, %d download connections.",
+		pan.RecommendedConnections(results, "upload"), pan.RecommendedConnections(results, "download")))
+}
+
+func exportCommand(client pan.PanClient) {
+	exportFlags := pflag.NewFlagSet("export", pflag.ExitOnError)
+	var remotePath string
+	var output string
+	var help bool
+
+	exportFlags.StringVarP(&remotePath, "path", "p", "/", pan.T("Remote directory to export"))
+	exportFlags.StringVarP(&output, "output", "o", "", pan.T("File to write the manifest to (default: stdout)"))
+	exportFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for export command"))
+
+	if err := exportFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		exportFlags.PrintDefaults()
+		return
+	}
+
+	manifest, err := client.ExportManifest(context.Background(), remotePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error exporting manifest: %v", err), err)
+	}
+
+	if output == "" {
+		if err := pan.WriteManifest(os.Stdout, manifest); err != nil {
+			pan.ExitWithError(pan.Tf("Error writing manifest: %v", err), err)
+		}
+		return
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error creating manifest file: %v", err), err)
+	}
+	if err := pan.WriteManifest(file, manifest); err != nil {
+		file.Close()
+		pan.ExitWithError(pan.Tf("Error writing manifest: %v", err), err)
+	}
+	if err := file.Close(); err != nil {
+		pan.ExitWithError(pan.Tf("Error writing manifest: %v", err), err)
+	}
+
+	pan.PrintSuccess(pan.Tf("Exported %d files beneath '%s' to '%s'.", len(manifest.Files), manifest.Root, output))
+}
+
+func diffCommand(client pan.PanClient) {
+	diffFlags := pflag.NewFlagSet("diff", pflag.ExitOnError)
+	var manifestPath string
+	var remotePath string
+	var asJSON bool
+	var only string
+	var help bool
+
+	diffFlags.StringVar(&manifestPath, "manifest", "", pan.T("Manifest written by the export command (required)"))
+	diffFlags.StringVarP(&remotePath, "path", "p", "", pan.T("Remote directory to compare with (default: the manifest's root)"))
+	diffFlags.BoolVar(&asJSON, "json", false, pan.T("Print the differences as JSON"))
+	diffFlags.StringVar(&only, "only", "", pan.T("Print only the paths of added, removed or changed files"))
+	diffFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for diff command"))
+
+	if err := diffFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		diffFlags.PrintDefaults()
+		return
+	}
+
+	if manifestPath == "" {
+		pan.PrintError(pan.T("Error: --manifest flag is required to specify the manifest to compare with."))
+		diffFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening manifest: %v", err), err)
+	}
+	manifest, err := pan.ReadManifest(file)
+	file.Close()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error reading manifest: %v", err), err)
+	}
+	if remotePath == "" {
+		remotePath = manifest.Root
+	}
+
+	diff, err := client.DiffWithRemote(context.Background(), manifest, remotePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error comparing with remote: %v", err), err)
+	}
+
+	if printManifestDiff(diff, asJSON, only) {
+		return
+	}
+	if diff.Empty() {
+		pan.PrintSuccess(pan.Tf("No changes beneath '%s' since %s.", remotePath, manifest.GeneratedAt.Local().Format("2006-01-02 15:04:05")))
+		return
+	}
+	pan.PrintSuccess(pan.Tf("%d added, %d removed, %d changed.", len(diff.Added), len(diff.Removed), len(diff.Changed)))
+}
+
+// printManifestDiff prints diff as JSON, as bare paths of one kind of change (only is
+// "added", "removed" or "changed") or as a marked list. It reports whether the output
+// is machine-readable, in which case callers should print nothing else.
+func printManifestDiff(diff *pan.ManifestDiff, asJSON bool, only string) bool {
+	switch {
+	case asJSON:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding differences: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return true
+	case only == "added":
+		for _, entry := range diff.Added {
+			fmt.Println(entry.Path)
+		}
+		return true
+	case only == "removed":
+		for _, entry := range diff.Removed {
+			fmt.Println(entry.Path)
+		}
+		return true
+	case only == "changed":
+		for _, change := range diff.Changed {
+			fmt.Println(change.New.Path)
+		}
+		return true
+	case only != "":
+		exitUsage(pan.Tf("Invalid value for --only: %q (expected added, removed or changed)", only))
+	}
+
+	for _, entry := range diff.Added {
+		fmt.Printf("+ %s (%s)\n", entry.Path, pan.FormatBytes(entry.Size))
+	}
+	for _, entry := range diff.Removed {
+		fmt.Printf("- %s (%s)\n", entry.Path, pan.FormatBytes(entry.Size))
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("~ %s (%s -> %s)\n", change.New.Path, pan.FormatBytes(change.Old.Size), pan.FormatBytes(change.New.Size))
+	}
+	return false
+}
+
+func snapshotCommand(client pan.PanClient, snapshotDir string) {
+	snapshotFlags := pflag.NewFlagSet("snapshot", pflag.ExitOnError)
+	var root string
+	var force bool
+	var asJSON bool
+	var only string
+	var help bool
+
+	snapshotFlags.StringVarP(&root, "path", "p", "/", pan.T("Remote directory to snapshot (create only)"))
+	snapshotFlags.BoolVar(&force, "force", false, pan.T("Replace an existing snapshot with the same name (create only)"))
+	snapshotFlags.BoolVar(&asJSON, "json", false, pan.T("Print the differences as JSON (diff only)"))
+	snapshotFlags.StringVar(&only, "only", "", pan.T("Print only the paths of added, removed or changed files (diff only)"))
+	snapshotFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for snapshot command"))
+
+	if err := snapshotFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs snapshot create <name> [-p <path>] [--force]")
+		fmt.Println("       go-bdfs snapshot list")
+		fmt.Println("       go-bdfs snapshot diff <name> [<other>]   (compares with the remote tree, or with <other>)")
+		fmt.Println("       go-bdfs snapshot delete <name>")
+		snapshotFlags.PrintDefaults()
+		return
+	}
+
+	if snapshotFlags.NArg() < 1 {
+		pan.PrintError(pan.T("Error: specify a snapshot action: create, list, diff or delete."))
+		snapshotFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	store, err := pan.OpenSnapshotStore(snapshotDir)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening snapshot store: %v", err), err)
+	}
+
+	// requireName returns the snapshot name argument, exiting if it is missing
+	requireName := func() string {
+		if snapshotFlags.NArg() < 2 {
+			exitUsage(pan.T("Error: specify a snapshot name."))
+		}
+		return snapshotFlags.Arg(1)
+	}
+
+	switch action := snapshotFlags.Arg(0); action {
+	case "create":
+		name := requireName()
+		pan.PrintSuccess(pan.Tf("Creating snapshot '%s' of '%s'...", name, root))
+		manifest, err := client.ExportManifest(context.Background(), root)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error listing remote files: %v", err), err)
+		}
+		if err := store.Save(name, manifest, force); err != nil {
+			pan.ExitWithError(pan.Tf("Error saving snapshot: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Snapshot '%s' saved with %d files.", name, len(manifest.Files)))
+	case "list":
+		snapshots, err := store.List()
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error listing snapshots: %v", err), err)
+		}
+		if len(snapshots) == 0 {
+			pan.PrintSuccess(pan.T("No snapshots found."))
+			return
+		}
+		for _, snapshot := range snapshots {
+			fmt.Printf("%s | %s | %s | %d files | %s\n",
+				snapshot.Name,
+				snapshot.GeneratedAt.Local().Format("2006-01-02 15:04:05"),
+				snapshot.Root,
+				snapshot.Files,
+				pan.FormatBytes(snapshot.Bytes))
+		}
+	case "diff":
+		name := requireName()
+		oldManifest, err := store.Load(name)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error loading snapshot: %v", err), err)
+		}
+
+		var diff *pan.ManifestDiff
+		against := "the remote tree"
+		if snapshotFlags.NArg() >= 3 {
+			against = fmt.Sprintf("snapshot '%s'", snapshotFlags.Arg(2))
+			newManifest, err := store.Load(snapshotFlags.Arg(2))
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error loading snapshot: %v", err), err)
+			}
+			diff = pan.DiffManifests(oldManifest, newManifest)
+		} else {
+			diff, err = client.DiffWithRemote(context.Background(), oldManifest, oldManifest.Root)
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error comparing with remote: %v", err), err)
+			}
+		}
+
+		if printManifestDiff(diff, asJSON, only) {
+			return
+		}
+		pan.PrintSuccess(pan.Tf("Snapshot '%s' vs %s: %d added, %d removed, %d changed.",
+			name, against, len(diff.Added), len(diff.Removed), len(diff.Changed)))
+	case "delete":
+		name := requireName()
+		if err := store.Delete(name); err != nil {
+			pan.ExitWithError(pan.Tf("Error deleting snapshot: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Snapshot '%s' deleted.", name))
+	default:
+		exitUsage(pan.Tf("Unknown snapshot action: %s", action))
+	}
+}
+
+func historyCommand(historyPath string) {
+	historyFlags := pflag.NewFlagSet("history", pflag.ExitOnError)
+	var since string
+	var filter pan.HistoryFilter
+	var limit int
+	var summary bool
+	var asJSON bool
+	var help bool
+
+	historyFlags.StringVar(&since, "since", "", pan.T("Only transfers within this age (e.g. 24h, 7d) or since this date (2006-01-02)"))
+	historyFlags.StringVar(&filter.Direction, "direction", "", pan.T("Only uploads or downloads: upload or download"))
+	historyFlags.BoolVar(&filter.FailedOnly, "failed", false, pan.T("Only failed transfers"))
+	historyFlags.StringVarP(&filter.Path, "path", "p", "", pan.T("Only transfers whose local or remote path contains this text"))
+	historyFlags.IntVarP(&limit, "limit", "n", 50, pan.T("Show only the most recent N transfers (0 for all)"))
+	historyFlags.BoolVar(&summary, "summary", false, pan.T("Print totals instead of individual transfers"))
+	historyFlags.BoolVar(&asJSON, "json", false, pan.T("Print the transfers or summary as JSON"))
+	historyFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for history command"))
+
+	if err := historyFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		historyFlags.PrintDefaults()
+		return
+	}
+
+	if filter.Direction != "" && filter.Direction != "upload" && filter.Direction != "download" {
+		exitUsage(pan.Tf("Error: invalid --direction %q: use upload or download.", filter.Direction))
+	}
+	if since != "" {
+		if date, err := time.ParseInLocation("2006-01-02", since, time.Local); err == nil {
+			filter.Since = date
+		} else if age, err := pan.ParseAge(since); err == nil {
+			filter.Since = time.Now().Add(-age)
+		} else {
+			exitUsage(pan.Tf("Error: invalid --since %q: use an age such as 7d or a date such as 2024-01-31.", since))
+		}
+	}
+
+	history, err := pan.OpenHistory(historyPath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening transfer history: %v", err), err)
+	}
+	entries, err := history.Read(filter)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error reading transfer history: %v", err), err)
+	}
+
+	if summary {
+		s := pan.SummarizeHistory(entries)
+		if asJSON {
+			data, _ := json.MarshalIndent(s, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		fmt.Printf(pan.T("Transfers: %d (%d uploads, %d downloads, %d failed)\n"), s.Transfers, s.Uploads, s.Downloads, s.Failed)
+		fmt.Printf(pan.T("Uploaded: %s\n"), pan.FormatBytes(s.BytesUp))
+		fmt.Printf(pan.T("Downloaded: %s\n"), pan.FormatBytes(s.BytesDown))
+		fmt.Printf(pan.T("Time spent: %s\n"), time.Duration(s.Duration*float64(time.Second)).Round(time.Second))
+		fmt.Printf(pan.T("Average speed: %s/s\n"), pan.FormatBytes(int64(s.AverageSpeed)))
+		return
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	if asJSON {
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(entries) == 0 {
+		pan.PrintSuccess(pan.T("No transfers found."))
+		return
+	}
+	for _, entry := range entries {
+		result := "ok"
+		if !entry.Success {
+			result = "FAILED: " + entry.Error
+		}
+		local := entry.LocalPath
+		if local == "" {
+			local = "-"
+		}
+		fmt.Printf("%s | %s | %s | %s | %s | %s | %s/s | %s\n",
+			entry.Time.Local().Format("2006-01-02 15:04:05"),
+			entry.Direction,
+			local,
+			entry.RemotePath,
+			pan.FormatBytes(entry.Bytes),
+			time.Duration(entry.Duration*float64(time.Second)).Round(time.Second),
+			pan.FormatBytes(int64(entry.Speed)),
+			result)
+	}
+}
+
+func queueCommand(client pan.PanClient, queuePath string) {
+	queueFlags := pflag.NewFlagSet("queue", pflag.ExitOnError)
+	var sourcePath string
+	var destPath string
+	var download bool
+	var state string
+	var asJSON bool
+	var retryFailed bool
+	var sliceSize string
+	var links string
+	var special string
+	var help bool
+
+	queueFlags.StringVarP(&sourcePath, "source", "s", "", pan.T("File or directory to transfer: local, or remote with --download (add only)"))
+	queueFlags.StringVarP(&destPath, "destination", "d", "", pan.T("Destination path: remote, or local with --download (add only)"))
+	queueFlags.BoolVar(&download, "download", false, pan.T("Queue a download from Baidu Pan instead of an upload (add only)"))
+	queueFlags.StringVar(&state, "state", "", pan.T("Show or remove only items in this state: pending, running, done or failed (ls and rm)"))
+	queueFlags.BoolVar(&asJSON, "json", false, pan.T("Print the queue as JSON (ls only)"))
+	queueFlags.BoolVar(&retryFailed, "retry-failed", false, pan.T("Retry failed items as well as pending ones (run only)"))
+	queueFlags.StringVar(&sliceSize, "slice-size", "", pan.T("Upload slice size, e.g. 4M or 16M (run only)"))
+	queueFlags.StringVar(&links, "links", string(pan.LinksFollow), pan.T("Symbolic links in a local directory: follow, skip or error (add only)"))
+	queueFlags.StringVar(&special, "special", string(pan.SpecialSkip), pan.T("Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error (add only)"))
+	queueFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for queue command"))
+
+	if err := queueFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs queue add -s <source> -d <destination> [--download]")
+		fmt.Println("       go-bdfs queue ls [--state <state>] [--json]")
+		fmt.Println("       go-bdfs queue rm <id>... | --state <state>")
+		fmt.Println("       go-bdfs queue run [--retry-failed]")
+		queueFlags.PrintDefaults()
+		return
+	}
+
+	if queueFlags.NArg() < 1 {
+		pan.PrintError(pan.T("Error: specify a queue action: add, ls, rm or run."))
+		queueFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	var stateFilter pan.QueueState
+	switch pan.QueueState(state) {
+	case "", pan.QueuePending, pan.QueueRunning, pan.QueueDone, pan.QueueFailed:
+		stateFilter = pan.QueueState(state)
+	default:
+		exitUsage(pan.Tf("Error: invalid --state %q: use pending, running, done or failed.", state))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(queuePath), 0755); err != nil {
+		pan.ExitWithError(pan.Tf("Error creating queue directory: %v", err), err)
+	}
+	queue, err := pan.OpenQueue(queuePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening transfer queue: %v", err), err)
+	}
+	defer queue.Close()
+
+	switch action := queueFlags.Arg(0); action {
+	case "add":
+		if sourcePath == "" || destPath == "" {
+			exitUsage(pan.T("Error: -s or --source and -d or --destination flags are required."))
+		}
+		var items []pan.QueueItem
+		if download {
+			items, err = client.QueueDownloadItems(context.Background(), sourcePath, destPath)
+		} else {
+			items, err = pan.QueueUploadItems(sourcePath, destPath, pan.LocalWalkOptions{
+				Links:   parseLinks(links),
+				Special: parseSpecial(special),
+				Skipped: func(localPath, reason string) {
+					pan.PrintError(pan.Tf("Skipping %s: %s", reason, localPath))
+				},
+			})
+		}
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error listing files to queue: %v", err), err)
+		}
+		if len(items) == 0 {
+			pan.PrintSuccess(pan.T("No files to queue."))
+			return
+		}
+		if _, err := queue.Add(items...); err != nil {
+			pan.ExitWithError(pan.Tf("Error adding to transfer queue: %v", err), err)
+		}
+		var total int64
+		for _, item := range items {
+			total += item.Size
+		}
+		pan.PrintSuccess(pan.Tf("Queued %d transfers (%s). Run 'go-bdfs queue run' to start them.", len(items), pan.FormatBytes(total)))
+	case "ls":
+		items, err := queue.List()
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error reading transfer queue: %v", err), err)
+		}
+		if stateFilter != "" {
+			items = slices.DeleteFunc(items, func(item pan.QueueItem) bool { return item.State != stateFilter })
+		}
+		if asJSON {
+			data, err := json.MarshalIndent(items, "", "  ")
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error encoding queue: %v", err), err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		if len(items) == 0 {
+			pan.PrintSuccess(pan.T("The transfer queue is empty."))
+			return
+		}
+		for _, item := range items {
+			fmt.Printf("%d | %s | %s | %s -> %s | %s", item.ID, item.State, item.Op, item.Source, item.Destination, pan.FormatBytes(item.Size))
+			if item.Error != "" {
+				fmt.Printf(" | %s", item.Error)
+			}
+			fmt.Println()
+		}
+	case "rm":
+		if stateFilter != "" {
+			removed, err := queue.RemoveState(stateFilter)
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error updating transfer queue: %v", err), err)
+			}
+			pan.PrintSuccess(pan.Tf("Removed %d %s items from the queue.", removed, stateFilter))
+			return
+		}
+		if queueFlags.NArg() < 2 {
+			exitUsage(pan.T("Error: specify the IDs of the items to remove, or --state."))
+		}
+		for _, arg := range queueFlags.Args()[1:] {
+			id, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				exitUsage(pan.Tf("Error: invalid queue item ID %q", arg))
+			}
+			if err := queue.Remove(id); err != nil {
+				pan.ExitWithError(pan.Tf("Error removing queue item: %v", err), err)
+			}
+		}
+		pan.PrintSuccess(pan.Tf("Removed %d items from the queue.", queueFlags.NArg()-1))
+	case "run":
+		// A dry run would mark the items done without transferring them
+		if globals.DryRun {
+			items, err := queue.List()
+			if err != nil {
+				pan.ExitWithError(pan.Tf("Error reading transfer queue: %v", err), err)
+			}
+			pending := 0
+			for _, item := range items {
+				if item.State == pan.QueuePending || item.State == pan.QueueRunning || (retryFailed && item.State == pan.QueueFailed) {
+					fmt.Printf("%s | %s -> %s | %s\n", item.Op, item.Source, item.Destination, pan.FormatBytes(item.Size))
+					pending++
+				}
+			}
+			pan.PrintSuccess(pan.Tf("Dry run: %d transfers would run.", pending))
+			return
+		}
+
+		if retryFailed {
+			if _, err := queue.Requeue(pan.QueueFailed); err != nil {
+				pan.ExitWithError(pan.Tf("Error updating transfer queue: %v", err), err)
+			}
+		}
+
+		// The first Ctrl+C stops after the current transfer; a second one aborts it
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-ctx.Done():
+				stop()
+				pan.PrintSuccess(pan.T("Stopping after the current transfer; press Ctrl+C again to abort it."))
+			case <-finished:
+			}
+		}()
+
+		var bytes int64
+		var failures []string
+		opts := pan.QueueRunOptions{
+			Started: func(item pan.QueueItem) {
+				pan.PrintSuccess(fmt.Sprintf("[%d] %s %s -> %s (%s)", item.ID, item.Op, item.Source, item.Destination, pan.FormatBytes(item.Size)))
+			},
+			Finished: func(item pan.QueueItem, err error) {
+				if err != nil {
+					pan.PrintError(pan.Tf("[%d] failed: %v", item.ID, err))
+					failures = append(failures, fmt.Sprintf("%s: %v", item.Source, err))
+					return
+				}
+				bytes += item.Size
+			},
+		}
+		opts.Upload.SliceSize = parseSliceSize(sliceSize)
+
+		started := time.Now()
+		done, failed, err := client.RunQueue(ctx, queue, opts)
+		if done+failed > 0 {
+			notifyReport(pan.JobReport{
+				Job:      "queue",
+				Success:  failed == 0 && err == nil,
+				Bytes:    bytes,
+				Files:    done,
+				Duration: time.Since(started),
+				Started:  started,
+				Errors:   failures,
+			}, true)
+		}
+		if err != nil && ctx.Err() == nil {
+			pan.ExitWithError(pan.Tf("Error running transfer queue: %v", err), err)
+		}
+		summary := fmt.Sprintf("%d transfers completed, %d failed.", done, failed)
+		if ctx.Err() != nil {
+			pan.PrintSuccess(pan.T("Queue stopped: ") + summary + " Run 'go-bdfs queue run' to resume.")
+			return
+		}
+		if failed > 0 {
+			pan.PrintError(pan.T("Queue finished: ") + summary + " Retry with 'go-bdfs queue run --retry-failed'.")
+			os.Exit(pan.ExitPartial)
+		}
+		pan.PrintSuccess(pan.T("Queue finished: ") + summary)
+	default:
+		exitUsage(pan.Tf("Unknown queue action: %s", action))
+	}
+}
+
+func daemonCommand(config *Config) {
+	daemonFlags := pflag.NewFlagSet("daemon", pflag.ExitOnError)
+	var list bool
+	var logFile string
+	var name string
+	var help bool
+
+	daemonFlags.BoolVar(&list, "list", false, pan.T("List the configured jobs and their next run times, then exit"))
+	daemonFlags.StringVar(&logFile, "log-file", "", pan.T("Append daemon output to this file (install defaults to daemon.log in the state directory)"))
+	daemonFlags.StringVar(&name, "name", pan.DefaultServiceName, pan.T("Service name used by install, uninstall and status"))
+	daemonFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for daemon command"))
+
+	if err := daemonFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs daemon [--list] [--log-file <file>]")
+		fmt.Println("       go-bdfs daemon install|uninstall|status [--name <service>] [--log-file <file>]")
+		daemonFlags.PrintDefaults()
+		return
+	}
+
+	if daemonFlags.NArg() > 0 {
+		serviceCommand(config, daemonFlags.Arg(0), name, logFile)
+		return
+	}
+
+	if logFile != "" {
+		if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+			pan.ExitWithError(pan.Tf("Error creating log directory: %v", err), err)
+		}
+		f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error opening log file: %v", err), err)
+		}
+		defer f.Close()
+		os.Stdout = f
+		os.Stderr = f
+		pan.SetConsoleLogger(slog.New(pan.NewConsoleHandler(f, globals.logLevel())))
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error locating the go-bdfs executable: %v", err), err)
+	}
+
+	webhook, err := config.webhook()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error in webhook configuration: %v", err), err)
+	}
+	notify := func(report pan.JobReport) { sendReport(config, webhook, report) }
+
+	jobs := make([]pan.ScheduledJob, 0, len(config.Jobs))
+	for i, jobConfig := range config.Jobs {
+		job, err := scheduledJob(jobConfig, executable, notify)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error in job %d of the configuration: %v", i+1, err), err)
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		pan.PrintErrorAndExit(pan.T("Error: no jobs configured; add [[jobs]] entries to the configuration file."))
+	}
+
+	if list {
+		now := time.Now()
+		for _, job := range jobs {
+			nextRun := "never"
+			if next := job.Schedule.Next(now); !next.IsZero() {
+				nextRun = next.Format("2006-01-02 15:04")
+			}
+			fmt.Printf(pan.T("%s | %s | next run: %s\n"), job.Name, job.Schedule, nextRun)
+		}
+		return
+	}
+
+	run := func(ctx context.Context) {
+		pan.PrintSuccess(pan.Tf("Daemon started with %d jobs.", len(jobs)))
+		logger := slog.New(pan.NewConsoleHandler(os.Stdout, globals.logLevel()))
+		pan.RunScheduler(ctx, jobs, logger)
+		pan.PrintSuccess(pan.T("Daemon stopped."))
+	}
+
+	// Under the Windows service manager, stop requests arrive through the service handler
+	if isService, err := pan.RunAsService(name, run); isService || err != nil {
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error running as a service: %v", err), err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	run(ctx)
+}
+
+// serviceCommand installs, removes or reports on the system service that runs the daemon
+func serviceCommand(config *Config, action, name, logFile string) {
+	switch strings.ToLower(action) {
+	case "install":
+		if len(config.Jobs) == 0 {
+			pan.PrintErrorAndExit(pan.T("Error: no jobs configured; add [[jobs]] entries to the configuration file."))
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error locating the go-bdfs executable: %v", err), err)
+		}
+		if logFile == "" {
+			if logFile, err = pan.DefaultServiceLogFile(); err != nil {
+				pan.ExitWithError(pan.Tf("Error locating the log file: %v", err), err)
+			}
+		}
+		if logFile, err = filepath.Abs(logFile); err != nil {
+			pan.ExitWithError(pan.Tf("Error resolving the log file: %v", err), err)
+		}
+		if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+			pan.ExitWithError(pan.Tf("Error creating log directory: %v", err), err)
+		}
+
+		// The service must find the same configuration file, wherever it starts
+		env := pan.ServiceEnv()
+		if path, err := configFilePath(); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				if abs, err := filepath.Abs(path); err == nil {
+					env["BDFS_CONFIG_FILE_PATH"] = abs
+				}
+			}
+		}
+
+		args := append(globals.args(), "daemon", "--log-file", logFile)
+		if name != pan.DefaultServiceName {
+			args = append(args, "--name", name)
+		}
+		cfg := pan.ServiceConfig{
+			Name:        name,
+			Description: "go-bdfs scheduled jobs for Baidu Pan",
+			Executable:  executable,
+			Args:        args,
+			Env:         env,
+			LogFile:     logFile,
+		}
+		if err := pan.InstallService(cfg); err != nil {
+			pan.ExitWithError(pan.Tf("Error installing service: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Service '%s' installed and started; logging to %s", name, logFile))
+	case "uninstall":
+		if err := pan.UninstallService(name); err != nil {
+			pan.ExitWithError(pan.Tf("Error uninstalling service: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Service '%s' uninstalled.", name))
+	case "status":
+		status, err := pan.ServiceStatus(name)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error getting service status: %v", err), err)
+		}
+		fmt.Println(strings.TrimRight(status, "\n"))
+	default:
+		exitUsage(pan.Tf("Unknown daemon action: %s", action))
+	}
+}
+
+// scheduledJob validates a configured job and returns it as a scheduler job that runs
+// the command in a new go-bdfs process. When a run finishes, notify receives its report,
+// including the bytes and files the command reported through BDFS_JOB_REPORT.
+func scheduledJob(jobConfig JobConfig, executable string, notify func(pan.JobReport)) (pan.ScheduledJob, error) {
+	if jobConfig.Name == "" {
+		return pan.ScheduledJob{}, fmt.Errorf("job has no name")
+	}
+	if jobConfig.Command == "" {
+		return pan.ScheduledJob{}, fmt.Errorf("job %q has no command", jobConfig.Name)
+	}
+	if strings.EqualFold(jobConfig.Command, "daemon") {
+		return pan.ScheduledJob{}, fmt.Errorf("job %q cannot run the daemon command", jobConfig.Name)
+	}
+
+	schedule, err := pan.ParseSchedule(jobConfig.Schedule)
+	if err != nil {
+		return pan.ScheduledJob{}, fmt.Errorf("job %q: %w", jobConfig.Name, err)
+	}
+
+	var retryDelay time.Duration
+	if jobConfig.RetryDelay != "" {
+		if retryDelay, err = time.ParseDuration(jobConfig.RetryDelay); err != nil {
+			return pan.ScheduledJob{}, fmt.Errorf("job %q: invalid retry_delay: %w", jobConfig.Name, err)
+		}
+	}
+
+	args := append(globals.args(), jobConfig.Command)
+	args = append(args, jobConfig.Args...)
+	// Runs of a job never overlap, so each job can reuse one report file
+	reportPath := filepath.Join(os.TempDir(), fmt.Sprintf("bdfs-job-%d-%s.json", os.Getpid(), url.PathEscape(jobConfig.Name)))
+	return pan.ScheduledJob{
+		Name:       jobConfig.Name,
+		Schedule:   schedule,
+		Retries:    jobConfig.Retries,
+		RetryDelay: retryDelay,
+		Run: func(ctx context.Context) error {
+			os.Remove(reportPath)
+			cmd := exec.CommandContext(ctx, executable, args...)
+			cmd.Env = append(os.Environ(), "BDFS_JOB_REPORT="+reportPath)
+			cmd.Stdin = nil // Commands that ask for confirmation must be given -y or --yes
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			// Let the command stop cleanly on shutdown before it is killed
+			cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+			cmd.WaitDelay = 30 * time.Second
+			return cmd.Run()
+		},
+		Done: func(err error, attempts int, duration time.Duration) {
+			var report pan.JobReport
+			if data, readErr := os.ReadFile(reportPath); readErr == nil {
+				json.Unmarshal(data, &report)
+				os.Remove(reportPath)
+			}
+			report.Job = jobConfig.Name
+			report.Success = err == nil
+			report.Duration = duration
+			report.Started = time.Now().Add(-duration)
+			if err != nil && len(report.Errors) == 0 {
+				report.Errors = []string{fmt.Sprintf("%s failed after %d attempts: %v", jobConfig.Command, attempts, err)}
+			}
+			notify(report)
+		},
+	}, nil
+}
+
+func mkdirCommand(client pan.PanClient) {
+	mkdirFlags := pflag.NewFlagSet("md", pflag.ExitOnError)
+	var dirPath string
+	var help bool
+
+	mkdirFlags.StringVarP(&dirPath, "path", "p", "", pan.T("Directory path to create in Baidu Pan (required)"))
+	mkdirFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for mkdir command"))
+
+	if err := mkdirFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		mkdirFlags.PrintDefaults()
+		return
+	}
+
+	if dirPath == "" {
+		pan.PrintError(pan.T("Error: -d or --dir flag is required to specify the directory path to create."))
+		mkdirFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	pan.PrintSuccess(pan.Tf("Creating directory '%s' in Baidu Pan...", dirPath))
+
+	err := client.CreateDir(dirPath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error creating directory: %v", err), err)
+	}
+	pan.PrintSuccess(pan.Tf("Directory '%s' created successfully.", dirPath))
+}
+
+func infoCommand(client pan.PanClient) {
+	infoFlags := pflag.NewFlagSet("if", pflag.ExitOnError)
+	var filePaths []string
+	var fsIDs []int64
+	var jsonOutput bool
+	var help bool
+
+	infoFlags.StringArrayVarP(&filePaths, "path", "p", nil, pan.T("File path in Baidu Pan to get information for; repeat for several (required)"))
+	infoFlags.Int64SliceVar(&fsIDs, "fsid", nil, pan.T("fs_id of the file or directory, instead of -p; repeat or separate with commas"))
+	infoFlags.BoolVar(&jsonOutput, "json", false, pan.T("Print the information as a JSON array"))
+	infoFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for info command"))
+
+	if err := infoFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs if -p <path> [-p <path>...] | <path>... | --fsid <id>[,<id>...] [--json]")
+		infoFlags.PrintDefaults()
+		return
+	}
+
+	filePaths = append(filePaths, infoFlags.Args()...)
+	if len(fsIDs) > 0 && len(filePaths) > 0 {
+		exitUsage(pan.T("Error: --fsid cannot be combined with a path."))
+	}
+	if len(filePaths) == 0 && len(fsIDs) == 0 {
+		pan.PrintError(pan.T("Error: -p or --path flag is required to specify the file path to get information for."))
+		infoFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	var infos []pan.FileInfo
+	var err error
+	switch {
+	case len(fsIDs) > 0:
+		infos, err = client.FileInfosByFsID(context.Background(), fsIDs)
+	case len(filePaths) == 1:
+		if !jsonOutput {
+			pan.PrintSuccess(pan.Tf("Getting information for file: '%s' in Baidu Pan...", filePaths[0]))
+		}
+		var info *pan.FileInfo
+		if info, err = client.GetAndDisplayFileInfo(filePaths[0]); err == nil {
+			infos = []pan.FileInfo{*info}
+		}
+	default:
+		if !jsonOutput {
+			pan.PrintSuccess(pan.Tf("Getting information for %d files in Baidu Pan...", len(filePaths)))
+		}
+		infos, err = client.FileInfosByPath(context.Background(), filePaths)
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error getting file information: %v", err), err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for i := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(pan.FormatFileInfo(&infos[i]))
+	}
+}
+
+// fsIDPath returns the path of the file or directory with the given fs_id, for commands
+// taking --fsid instead of a path; path is the path also given, which is an error
+func fsIDPath(client pan.PanClient, fsID int64, path string) string {
+	if path != "" {
+		exitUsage(pan.T("Error: --fsid cannot be combined with a path."))
+	}
+	info, err := client.GetFileInfoByFsID(fsID)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error looking up fs_id %d: %v", fsID, err), err)
+	}
+	return info.Path
+}
+
+func previewCommand(client pan.PanClient) {
+	previewFlags := pflag.NewFlagSet("preview", pflag.ExitOnError)
+	var filePath string
+	var open bool
+	var help bool
+
+	previewFlags.StringVarP(&filePath, "path", "p", "", pan.T("Document path in Baidu Pan to preview (required)"))
+	previewFlags.BoolVarP(&open, "open", "o", false, pan.T("Open the preview in the default browser"))
+	previewFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for preview command"))
+
+	if err := previewFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs preview -p <path> [--open]")
+		previewFlags.PrintDefaults()
+		return
+	}
+
+	if filePath == "" && previewFlags.NArg() > 0 {
+		filePath = previewFlags.Arg(0)
+	}
+	if filePath == "" {
+		pan.PrintError(pan.T("Error: -p or --path flag is required to specify the document to preview."))
+		previewFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	preview, err := client.GetDocPreview(filePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error getting preview link for '%s': %v", filePath, err), err)
+	}
+
+	// Only the link goes to stdout, so it can be captured or piped to another program
+	fmt.Println(preview.URL)
+	if preview.ExpiresIn > 0 {
+		fmt.Fprintf(os.Stderr, "The link expires in %s.\n", time.Duration(preview.ExpiresIn)*time.Second)
+	}
+	if open {
+		if err := pan.OpenInBrowser(preview.URL); err != nil {
+			pan.ExitWithError(pan.Tf("Error: %v", err), err)
+		}
+	}
+}
+
+func shareCommand(client pan.PanClient) {
+	shareFlags := pflag.NewFlagSet("share", pflag.ExitOnError)
+	var paths []string
+	var fsIDs []int64
+	var password string
+	var expire string
+	var jsonOutput bool
+	var help bool
+
+	shareFlags.StringArrayVarP(&paths, "path", "p", nil, pan.T("File or directory to share; repeat to share several in one link (required)"))
+	shareFlags.Int64SliceVar(&fsIDs, "fsid", nil, pan.T("fs_id of a file or directory to share, instead of -p; repeat or separate with commas"))
+	shareFlags.StringVar(&password, "password", "auto", pan.T("4-character extraction code, or auto to generate one"))
+	shareFlags.StringVar(&expire, "expire", "7d", pan.T("How long the link is valid: 1d, 7d, 30d, 365d or forever"))
+	shareFlags.BoolVar(&jsonOutput, "json", false, pan.T("Print the share as JSON"))
+	shareFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for share command"))
+
+	if err := shareFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs share -p <path> [-p <path>...] | --fsid <id>[,<id>...] [--password auto|<code>] [--expire 1d|7d|30d|365d|forever] [--json]")
+		shareFlags.PrintDefaults()
+		return
+	}
+
+	paths = append(paths, shareFlags.Args()...)
+	if len(fsIDs) > 0 && len(paths) > 0 {
+		exitUsage(pan.T("Error: --fsid cannot be combined with paths."))
+	}
+	if len(paths) == 0 && len(fsIDs) == 0 {
+		pan.PrintError(pan.T("Error: -p or --path flag is required to specify what to share."))
+		shareFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+	period, err := pan.ParseSharePeriod(expire)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	opts := pan.ShareOptions{Period: period}
+	if password != "auto" {
+		if err := pan.ValidateSharePassword(password); err != nil {
+			pan.ExitWithError(pan.Tf("Error: %v", err), err)
+		}
+		opts.Password = password
+	}
+
+	var share *pan.Share
+	if len(fsIDs) > 0 {
+		share, err = client.CreateShareByFsID(fsIDs, opts)
+	} else {
+		share, err = client.CreateShare(paths, opts)
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error creating share: %v", err), err)
+	}
+	if share == nil { // Dry run
+		return
+	}
+
+	if jsonOutput {
+		output := struct {
+			*pan.Share
+			URL string `json:"url"` // Link with the extraction code embedded
+		}{share, share.URL()}
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding share: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Println(share.String())
+}
+
+func sharesCommand(client pan.PanClient) {
+	sharesFlags := pflag.NewFlagSet("shares", pflag.ExitOnError)
+	var jsonOutput bool
+	var help bool
+
+	sharesFlags.BoolVar(&jsonOutput, "json", false, pan.T("Print the shares as JSON"))
+	sharesFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for shares command"))
+
+	if err := sharesFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs shares [--json]")
+		sharesFlags.PrintDefaults()
+		return
+	}
+
+	shares, err := client.ListReceivedShares(context.Background())
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error listing received shares: %v", err), err)
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(shares, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding shares: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(shares) == 0 {
+		pan.PrintSuccess(pan.T("No received shares found."))
+		return
+	}
+	// One line per share: <分享ID> | <分享者> | <标题> | <接收时间> | <文件数>, then its files:
+	// <fs_id> | D|F | <文件名> | <文件大小>
+	for _, share := range shares {
+		fmt.Printf("%d | %s | %s | %s | %d\n",
+			share.ShareID,
+			share.FromName,
+			share.Title,
+			time.Unix(share.CTime, 0).Format("2006-01-02 15:04:05"),
+			len(share.Files))
+		for _, file := range share.Files {
+			fileType, sizeStr := "F", fmt.Sprintf("%d", file.Size)
+			if file.IsDir == 1 {
+				fileType, sizeStr = "D", "-"
+			}
+			fmt.Printf("    %d | %s | %s | %s\n", file.FsID, fileType, file.ServerFilename, sizeStr)
+		}
+	}
+}
+
+func diskInfoCommand(client pan.PanClient) {
+	diskInfoFlags := pflag.NewFlagSet("di", pflag.ExitOnError)
+	var help bool
+
+	diskInfoFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for disk info command"))
+
+	if err := diskInfoFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		diskInfoFlags.PrintDefaults()
+		return
+	}
+
+	pan.PrintSuccess(pan.T("Getting disk information from Baidu Pan..."))
+
+	diskInfo, err := client.GetDiskInfo()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error getting disk information: %v", err), err)
+	}
+
+	fmt.Print(pan.FormatDiskInfo(diskInfo))
+}
+
+func refreshTokenCommand(client pan.PanClient) {
+	refreshFlags := pflag.NewFlagSet("ar", pflag.ExitOnError)
+	var help bool
+
+	refreshFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for refresh token command"))
+
+	if err := refreshFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		refreshFlags.PrintDefaults()
+		return
+	}
+
+	pan.PrintSuccess(pan.T("Attempting to refresh access token..."))
+
+	// Check if there's a refresh token available
+	if client.HasValidToken() {
+		err := client.LoadTokens()
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error loading existing tokens: %v", err), err)
+		}
+
+		if !client.HasRefreshToken() {
+			pan.PrintError(pan.T("No refresh token available, cannot refresh access token."))
+			os.Exit(pan.ExitAuth)
+		}
+
+		err = client.RefreshToken()
+		if err != nil {
+			pan.PrintError(pan.Tf("Error refreshing token: %v", err))
+			os.Exit(authExitStatus(err))
+		}
+
+		if client.TokensInjected() {
+			pan.PrintSuccess(pan.T("Access token refreshed for this run only; injected tokens are never saved."))
+			return
+		}
+		pan.PrintSuccess(pan.T("Access token refreshed successfully and saved to .bdfs_certs"))
+	} else {
+		pan.PrintError(pan.T("No token file found, cannot refresh access token."))
+		os.Exit(pan.ExitAuth)
+	}
+}
+
+func indexCommand(client pan.PanClient, index *pan.Index) {
+	indexFlags := pflag.NewFlagSet("index", pflag.ExitOnError)
+	var root string
+	var help bool
+
+	indexFlags.StringVarP(&root, "path", "p", "/", pan.T("Remote directory to rebuild or prune (default: /)"))
+	indexFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for index command"))
+
+	if err := indexFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs index <rebuild|prune|update> [-p <path>]")
+		indexFlags.PrintDefaults()
+		return
+	}
+
+	if indexFlags.NArg() != 1 {
+		pan.PrintError(pan.T("Error: specify an index action: rebuild, prune or update."))
+		indexFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	if index == nil {
+		pan.PrintError(pan.T("Error: the local index could not be opened."))
+		os.Exit(1)
+	}
+
+	switch action := indexFlags.Arg(0); action {
+	case "rebuild":
+		pan.PrintSuccess(pan.Tf("Rebuilding local index for '%s'...", root))
+		count, err := client.RebuildIndex(index, root)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error rebuilding index: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Indexed %d entries under '%s'.", count, root))
+	case "prune":
+		pan.PrintSuccess(pan.Tf("Pruning local index for '%s'...", root))
+		count, err := client.PruneIndex(index, root)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error pruning index: %v", err), err)
+		}
+		pan.PrintSuccess(pan.Tf("Removed %d stale entries under '%s'.", count, root))
+	case "update":
+		pan.PrintSuccess(pan.T("Fetching changes since the last index update..."))
+		update, err := client.UpdateIndex(index)
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error updating index: %v", err), err)
+		}
+		if update.Reset {
+			pan.PrintSuccess(pan.Tf("Rebuilt the index from the full listing: %d entries.", update.Updated))
+		} else {
+			pan.PrintSuccess(pan.Tf("Updated %d entries and removed %d.", update.Updated, update.Removed))
+		}
+	default:
+		exitUsage(pan.Tf("Unknown index action: %s", action))
+	}
+}
+
+func hashCacheCommand(hashCachePath string) {
+	hashCacheFlags := pflag.NewFlagSet("hash-cache", pflag.ExitOnError)
+	var help bool
+
+	hashCacheFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for hash-cache command"))
+
+	if err := hashCacheFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs hash-cache clear")
+		hashCacheFlags.PrintDefaults()
+		return
+	}
+
+	if hashCacheFlags.NArg() != 1 {
+		pan.PrintError(pan.T("Error: specify a hash-cache action: clear."))
+		hashCacheFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	if action := hashCacheFlags.Arg(0); action != "clear" {
+		exitUsage(pan.Tf("Unknown hash-cache action: %s", action))
+	}
+
+	if _, err := os.Stat(hashCachePath); os.IsNotExist(err) {
+		pan.PrintSuccess(pan.T("Hash cache is already empty."))
+		return
+	}
+
+	hashCache, err := pan.OpenHashCache(hashCachePath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening hash cache: %v", err), err)
+	}
+	defer hashCache.Close()
+
+	count, err := hashCache.Clear()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error clearing hash cache: %v", err), err)
+	}
+	pan.PrintSuccess(pan.Tf("Removed %d cached entries.", count))
+}
+
+// dataPath is a file of go-bdfs and where it lives, as printed by paths
+type dataPath struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+func pathsCommand() {
+	pathsFlags := pflag.NewFlagSet("paths", pflag.ExitOnError)
+	var asJSON bool
+	var help bool
+
+	pathsFlags.BoolVar(&asJSON, "json", false, pan.T("Print the paths as JSON"))
+	pathsFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for paths command"))
+
+	if err := pathsFlags.Parse(os.Args[2:]); err != nil {
+		return
+	}
+
+	if help {
+		fmt.Println("Usage: go-bdfs paths [--json]")
+		pathsFlags.PrintDefaults()
+		return
+	}
+
+	dirs, err := pan.DefaultDataDirs()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	// Without credentials the configuration does not load, but its paths still default
+	config, err := LoadConfig()
+	if err != nil {
+		config = &Config{}
+		config.setDefaultPaths(dirs)
+	}
+	configPath, _ := configFilePath()
+	logFile, _ := pan.DefaultServiceLogFile()
+
+	paths := []dataPath{
+		{Name: "config directory", Path: dirs.Config},
+		{Name: "state directory", Path: dirs.State},
+		{Name: "cache directory", Path: dirs.Cache},
+		{Name: "config", Path: configPath},
+		{Name: "token", Path: config.TokenPath},
+		{Name: "index", Path: config.IndexPath},
+		{Name: "hash cache", Path: config.HashCachePath},
+		{Name: "snapshots", Path: config.SnapshotDir},
+		{Name: "queue", Path: config.QueuePath},
+		{Name: "history", Path: config.HistoryPath},
+		{Name: "photo index", Path: config.PhotoIndexPath},
+		{Name: "resume", Path: config.ResumePath},
+		{Name: "daemon log", Path: logFile},
+	}
+	// Files that could not be moved keep the directory of earlier versions in use
+	if legacyDir, err := pan.LegacyDataDir(); err == nil {
+		if _, err := os.Stat(legacyDir); err == nil {
+			paths = append(paths, dataPath{Name: "legacy directory", Path: legacyDir})
+		}
+	}
+	for i := range paths {
+		_, err := os.Stat(paths[i].Path)
+		paths[i].Exists = err == nil
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(paths, "", "  ")
+		if err != nil {
+			pan.ExitWithError(pan.Tf("Error encoding results: %v", err), err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	for _, p := range paths {
+		status := ""
+		if !p.Exists {
+			status = pan.T(" (not created yet)")
+		}
+		fmt.Printf("%-17s %s%s\n", p.Name, p.Path, status)
+	}
+}
+
+// filterFlags holds the include/exclude flags shared by recursive commands
+type filterFlags struct {
+	includes   []string
+	excludes   []string
+	filterFrom string
+	minSize    string
+	maxSize    string
+	minAge     string
+	maxAge     string
+}
+
+// addFilterFlags registers the filter flags on flags
+func addFilterFlags(flags *pflag.FlagSet) *filterFlags {
+	ff := &filterFlags{}
+	flags.StringArrayVar(&ff.includes, "include", nil, "Include files matching this glob pattern (repeatable)")
+	flags.StringArrayVar(&ff.excludes, "exclude", nil, "Exclude files matching this glob pattern (repeatable)")
+	flags.StringVar(&ff.filterFrom, "filter-from", "", "Read '+ pattern' / '- pattern' filter rules from a file")
+	flags.StringVar(&ff.minSize, "min-size", "", "Only files at least this size (e.g. 100K, 10M)")
+	flags.StringVar(&ff.maxSize, "max-size", "", "Only files at most this size (e.g. 1G)")
+	flags.StringVar(&ff.minAge, "min-age", "", "Only files modified at least this long ago (e.g. 12h, 7d)")
+	flags.StringVar(&ff.maxAge, "max-age", "", "Only files modified within this duration (e.g. 30d)")
+	return ff
+}
+
+// build converts the parsed flags into a filter; excludes are evaluated before includes,
+// followed by rules from --filter-from
+func (ff *filterFlags) build() (*pan.Filter, error) {
+	filter := pan.NewFilter()
+	for _, pattern := range ff.excludes {
+		if err := filter.AddExclude(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range ff.includes {
+		if err := filter.AddInclude(pattern); err != nil {
+			return nil, err
+		}
+	}
+	if ff.filterFrom != "" {
+		if err := filter.AddRulesFromFile(ff.filterFrom); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if ff.minSize != "" {
+		if filter.MinSize, err = pan.ParseSize(ff.minSize); err != nil {
+			return nil, err
+		}
+	}
+	if ff.maxSize != "" {
+		if filter.MaxSize, err = pan.ParseSize(ff.maxSize); err != nil {
+			return nil, err
+		}
+	}
+	if ff.minAge != "" {
+		if filter.MinAge, err = pan.ParseAge(ff.minAge); err != nil {
+			return nil, err
+		}
+	}
+	if ff.maxAge != "" {
+		if filter.MaxAge, err = pan.ParseAge(ff.maxAge); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// loadCipher builds the --crypt cipher from the configured key file or password
+func loadCipher() *pan.Cipher {
+	config, err := LoadConfig()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error loading configuration: %v", err), err)
+	}
+
+	var crypt *pan.Cipher
+	switch {
+	case config.CryptKeyFile != "":
+		crypt, err = pan.NewCipherFromKeyFile(config.CryptKeyFile)
+	case config.CryptPassword != "":
+		crypt, err = pan.NewCipherFromPassword(config.CryptPassword)
+	default:
+		err = fmt.Errorf("set crypt_key_file or crypt_password in the configuration (or BDFS_CRYPT_KEY_FILE / BDFS_CRYPT_PASSWORD)")
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error setting up encryption: %v", err), err)
+	}
+	return crypt
+}
+
+// webhook returns the configured webhook, or nil when none is configured
+// transportOptions parses the [transport] section
+// endpoints returns the hosts of the [endpoints] section, checking that they are URLs
+func (c *Config) endpoints() (pan.Endpoints, error) {
+	endpoints := pan.Endpoints{
+		OpenAPI: c.Endpoints.OpenAPI,
+		Pan:     c.Endpoints.Pan,
+		PCS:     c.Endpoints.PCS,
+		DPCS:    c.Endpoints.DPCS,
+	}
+	return endpoints, pan.ValidateEndpoints(endpoints)
+}
+
+func (c *Config) transportOptions() (pan.TransportOptions, error) {
+	opts := pan.TransportOptions{
+		MaxIdleConnsPerHost: c.Transport.MaxIdleConnsPerHost,
+		DisableKeepAlives:   c.Transport.DisableKeepAlives,
+		DNSServer:           c.Transport.DNSServer,
+		Hosts:               c.Transport.Hosts,
+	}
+	if opts.MaxIdleConnsPerHost < 0 {
+		return opts, fmt.Errorf("invalid max_idle_conns_per_host %d", opts.MaxIdleConnsPerHost)
+	}
+	family, err := pan.ParseIPFamily(c.Transport.IPFamily)
+	if err != nil {
+		return opts, err
+	}
+	opts.IPFamily = family
+	if globals.IPFamily != pan.IPAny {
+		opts.IPFamily = globals.IPFamily // --ipv4 or --ipv6
+	}
+	if err := pan.ValidateHosts(opts.Hosts); err != nil {
+		return opts, err
+	}
+	opts.TLSConfig, err = pan.LoadTLSConfig(pan.TLSFiles{
+		CAFile:         c.Transport.CAFile,
+		ClientCertFile: c.Transport.ClientCertFile,
+		ClientKeyFile:  c.Transport.ClientKeyFile,
+		MinVersion:     c.Transport.TLSMinVersion,
+	})
+	if err != nil {
+		return opts, err
+	}
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"idle_conn_timeout", c.Transport.IdleConnTimeout, &opts.IdleConnTimeout},
+		{"keep_alive", c.Transport.KeepAlive, &opts.KeepAlive},
+		{"tls_handshake_timeout", c.Transport.TLSHandshakeTimeout, &opts.TLSHandshakeTimeout},
+		{"response_header_timeout", c.Transport.ResponseHeaderTimeout, &opts.ResponseHeaderTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil || (duration < 0 && d.name != "keep_alive") {
+			return opts, fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.dest = duration
+	}
+	return opts, nil
+}
+
+func (c *Config) webhook() (*pan.Webhook, error) {
+	if c.Webhook.URL == "" {
+		return nil, nil
+	}
+	format, err := pan.ParseWebhookFormat(c.Webhook.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &pan.Webhook{URL: c.Webhook.URL, Format: format}, nil
+}
+
+// sendReport posts report to webhook unless only failures are to be reported
+func sendReport(config *Config, webhook *pan.Webhook, report pan.JobReport) {
+	if webhook == nil || (report.Success && config.Webhook.OnlyFailures) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := webhook.Notify(ctx, report); err != nil {
+		pan.PrintError(pan.Tf("Error sending webhook notification: %v", err))
+	}
+}
+
+// notifyReport reports the outcome of a command with a desktop notification, when enabled,
+// and to the configured webhook. Commands run by the daemon write the report to the file
+// named by BDFS_JOB_REPORT instead, and the daemon sends it once the job has finished.
+// Successful transfers smaller than the webhook's min_size are only sent to the webhook
+// when always is set.
+func notifyReport(report pan.JobReport, always bool) {
+	if path := os.Getenv("BDFS_JOB_REPORT"); path != "" {
+		if data, err := json.Marshal(report); err == nil {
+			os.WriteFile(path, data, 0600)
+		}
+		return
+	}
+	if globals.DryRun {
+		return
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return
+	}
+	if globals.Notify || config.NotifyDesktop {
+		notifyDesktop(config, report)
+	}
+
+	webhook, err := config.webhook()
+	if err != nil {
+		pan.PrintError(pan.Tf("Error in webhook configuration: %v", err))
+		return
+	}
+	if webhook == nil {
+		return
+	}
+
+	if report.Success && !always {
+		minSize := int64(1 << 30)
+		if config.Webhook.MinSize != "" {
+			if minSize, err = pan.ParseSize(config.Webhook.MinSize); err != nil {
+				pan.PrintError(pan.Tf("Error in webhook configuration: invalid min_size %q", config.Webhook.MinSize))
+				return
+			}
+		}
+		if report.Bytes < minSize {
+			return
+		}
+	}
+	sendReport(config, webhook, report)
+}
+
+// notifyDesktop shows a desktop notification for report if it took at least notify_after
+func notifyDesktop(config *Config, report pan.JobReport) {
+	minDuration := time.Minute
+	if config.NotifyAfter != "" {
+		var err error
+		if minDuration, err = time.ParseDuration(config.NotifyAfter); err != nil {
+			pan.PrintError(pan.Tf("Error in configuration: invalid notify_after %q", config.NotifyAfter))
+			return
+		}
+	}
+	if report.Duration < minDuration {
+		return
+	}
+
+	title := fmt.Sprintf("go-bdfs %s finished", report.Job)
+	message := fmt.Sprintf("%s in %s", pan.FormatBytes(report.Bytes), report.Duration.Round(time.Second))
+	if !report.Success {
+		title = fmt.Sprintf("go-bdfs %s failed", report.Job)
+		message = strings.Join(report.Errors, "\n")
+	}
+	if err := pan.DesktopNotify(title, message); err != nil {
+		pan.PrintError(err.Error())
+	}
+}
+
+// transferReport returns a report for a single-file transfer by the named command
+func transferReport(command string, started time.Time, size int64, err error) pan.JobReport {
+	report := pan.JobReport{
+		Job:      command,
+		Success:  err == nil,
+		Bytes:    size,
+		Files:    1,
+		Duration: time.Since(started),
+		Started:  started,
+	}
+	if err != nil {
+		report.Errors = []string{err.Error()}
+	}
+	return report
+}
+
+// interruptContext returns a context cancelled by the first SIGINT or SIGTERM, after which
+// transfers stop at the next safe point and save their progress. A second signal
+// terminates the process at once.
+func interruptContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		pan.PrintError(pan.T("Interrupted: finishing the current slice and saving progress; press Ctrl+C again to abort."))
+	}()
+	return ctx
+}
+
+// exitIfInterrupted reports a transfer stopped by interruption and exits with
+// pan.ExitInterrupted; other errors are left to the caller
+func exitIfInterrupted(err error) {
+	if !errors.Is(err, pan.ErrInterrupted) {
+		return
+	}
+	pan.PrintError(pan.Tf("Stopped: %v", err))
+	pan.PrintSuccess(pan.T("Run the same command again to continue; saved progress is resumed."))
+	os.Exit(pan.ExitInterrupted)
+}
+
+// mutatingCommands are the commands that change files or shares in Baidu Pan
+var mutatingCommands = map[string]bool{
+	"ul": true, "rm": true, "mv": true, "rn": true, "cp": true, "md": true, "share": true,
+	"xcopy": true, "prune-empty": true, "photos": true, "organize": true,
+}
+
+// refuseIfReadOnly exits before cmd does any work when it would change files in Baidu
+// Pan in read-only mode. Its help and dry runs are allowed; other commands, such as sync
+// and queue run, are refused by the client at their first change.
+func refuseIfReadOnly(cmd string, args []string) {
+	if !globals.ReadOnly || globals.DryRun || !mutatingCommands[cmd] {
+		return
+	}
+	if slices.Contains(args, "-h") || slices.Contains(args, "--help") {
+		return
+	}
+	pan.ExitWithError(pan.Tf("Error: %s changes files in Baidu Pan, which read-only mode does not allow.", cmd), pan.ErrReadOnly)
+}
+
+// confirmed asks the user to confirm a destructive operation with prompt and reports
+// whether to go ahead. It does not ask when force (the command's -y) or --yes is set, or
+// in a dry run, which changes nothing; when the answer is no, it prints cancelled.
+func confirmed(force bool, prompt, cancelled string) bool {
+	if force || globals.Yes || globals.DryRun {
+		return true
+	}
+	if pan.Confirm(prompt) {
+		return true
+	}
+	pan.PrintSuccess(cancelled)
+	return false
+}
+
+// stdinIsTerminal reports whether standard input is a terminal someone can answer a
+// prompt on, rather than a pipe, a file or nothing as under cron
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// exitUsage reports invalid flags or arguments and exits with pan.ExitUsage
+func exitUsage(message string) {
+	pan.PrintError(message)
+	os.Exit(pan.ExitUsage)
+}
+
+// authExitStatus returns the exit status of a failed authorization: pan.ExitAuth, unless
+// err is of a more specific kind such as a network error
+func authExitStatus(err error) int {
+	if code := pan.ExitCode(err); code != pan.ExitError {
+		return code
+	}
+	return pan.ExitAuth
+}
+
+// parseSliceSize parses the --slice-size flag, returning 0 (automatic) when it is empty
+func parseSliceSize(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	size, err := pan.ParseSize(s)
+	if err != nil || size <= 0 {
+		exitUsage(pan.Tf("Error: invalid --slice-size %q", s))
+	}
+	return size
+}
+
+// parseSpecial parses the --special flag
+func parseSpecial(s string) pan.SpecialFilePolicy {
+	special, err := pan.ParseSpecialFilePolicy(s)
+	if err != nil {
+		exitUsage(pan.Tf("Error: invalid --special %q: use skip, upload or error.", s))
+	}
+	return special
+}
+
+// parseLinks parses the --links flag
+func parseLinks(s string) pan.LinkPolicy {
+	links, err := pan.ParseLinkPolicy(s)
+	if err != nil {
+		exitUsage(pan.Tf("Error: invalid --links %q: use follow, skip or error.", s))
 	}
-
-	pan.PrintSuccess(fmt.Sprintf("'%s' removed successfully from Baidu Pan.", remotePath))
+	return links
 }
 
-func moveCommand(client *pan.Client) {
-	moveFlags := pflag.NewFlagSet("mv", pflag.ExitOnError)
+func photosCommand(client pan.PanClient, photoIndexPath string) {
+	photosFlags := pflag.NewFlagSet("photos", pflag.ExitOnError)
 	var sourcePath string
 	var destPath string
-	var force bool
+	var layout string
+	var sliceSize string
+	var links string
+	var special string
 	var help bool
 
-	moveFlags.StringVarP(&sourcePath, "source", "s", "", "Source file or directory path to move (required)")
-	moveFlags.StringVarP(&destPath, "destination", "d", "", "Destination directory path (required)")
-	moveFlags.BoolVarP(&force, "force", "y", false, "Force move without confirmation")
-	moveFlags.BoolVarP(&help, "help", "h", false, "Show help for move command")
+	photosFlags.StringVarP(&sourcePath, "source", "s", "", pan.T("Local directory of photos and videos to back up (required)"))
+	photosFlags.StringVarP(&destPath, "destination", "d", pan.DefaultPhotoDir, pan.T("Remote directory to back up into"))
+	photosFlags.StringVar(&layout, "layout", pan.DefaultPhotoLayout, pan.T("Date directories beneath the destination, from YYYY, MM and DD"))
+	photosFlags.StringVar(&sliceSize, "slice-size", "", pan.T("Upload slice size, e.g. 4M or 16M"))
+	photosFlags.StringVar(&links, "links", string(pan.LinksFollow), pan.T("Symbolic links in local directories: follow, skip or error"))
+	photosFlags.StringVar(&special, "special", string(pan.SpecialSkip), pan.T("Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error"))
+	photosFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for photos command"))
 
-	if err := moveFlags.Parse(os.Args[2:]); err != nil {
+	if err := photosFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
 	if help {
-		moveFlags.PrintDefaults()
+		fmt.Println("Usage: go-bdfs photos backup -s <local dir> [-d <remote dir>] [--layout YYYY/MM]")
+		photosFlags.PrintDefaults()
 		return
 	}
 
+	if photosFlags.NArg() < 1 || photosFlags.Arg(0) != "backup" {
+		pan.PrintError(pan.T("Error: specify a photos action: backup."))
+		photosFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
 	if sourcePath == "" {
-		pan.PrintError("Error: -s or --source flag is required to specify the file or directory to move.")
-		moveFlags.PrintDefaults()
-		os.Exit(1)
+		exitUsage(pan.T("Error: -s or --source flag is required."))
+	}
+	if strings.Trim(layout, "/") == "" || !strings.ContainsAny(layout, "YMD") {
+		exitUsage(pan.Tf("Error: invalid --layout %q: use YYYY, MM and DD, e.g. YYYY/MM", layout))
 	}
 
-	if destPath == "" {
-		pan.PrintError("Error: -d or --destination flag is required to specify the destination directory.")
-		moveFlags.PrintDefaults()
-		os.Exit(1)
+	if err := os.MkdirAll(filepath.Dir(photoIndexPath), 0755); err != nil {
+		pan.ExitWithError(pan.Tf("Error creating photo index directory: %v", err), err)
 	}
+	idx, err := pan.OpenPhotoIndex(photoIndexPath)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error opening photo index: %v", err), err)
+	}
+	defer idx.Close()
 
-	// If not in force mode, ask for confirmation
-	if !force {
-		fmt.Printf("Are you sure you want to move '%s' to '%s'? (y/N): ", sourcePath, destPath)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			pan.PrintSuccess("Move operation cancelled.")
-			return
-		}
+	opts := pan.PhotoBackupOptions{
+		RemoteDir: destPath,
+		Layout:    layout,
+		Links:     parseLinks(links),
+		Special:   parseSpecial(special),
+		Progress: func(localPath, remotePath, outcome string, err error) {
+			switch outcome {
+			case "uploaded":
+				if !globals.DryRun {
+					pan.PrintSuccess(fmt.Sprintf("%s -> %s", localPath, remotePath))
+				}
+			case "failed":
+				pan.PrintError(fmt.Sprintf("%s: %v", localPath, err))
+			}
+		},
 	}
+	opts.Upload.SliceSize = parseSliceSize(sliceSize)
 
-	pan.PrintSuccess(fmt.Sprintf("Moving '%s' to '%s' in Baidu Pan...", sourcePath, destPath))
+	pan.PrintSuccess(pan.Tf("Backing up photos and videos from '%s' to '%s'...", sourcePath, destPath))
+	started := time.Now()
+	result, err := client.BackupPhotos(context.Background(), sourcePath, idx, opts)
 
-	err := client.MoveFile(sourcePath, destPath)
-	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error moving file: %v", err))
-		os.Exit(1)
+	if !globals.DryRun {
+		report := pan.JobReport{
+			Job:      "photos backup",
+			Success:  err == nil && result.Failed == 0,
+			Bytes:    result.Bytes,
+			Files:    result.Uploaded,
+			Duration: time.Since(started),
+			Started:  started,
+		}
+		if err != nil {
+			report.Errors = []string{err.Error()}
+		} else if result.Failed > 0 {
+			report.Errors = []string{fmt.Sprintf("%d files failed to upload", result.Failed)}
+		}
+		notifyReport(report, true)
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("'%s' moved successfully to '%s' in Baidu Pan.", sourcePath, destPath))
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error backing up photos: %v", err), err)
+	}
+	summary := fmt.Sprintf("%d uploaded (%s), %d already backed up, %d failed",
+		result.Uploaded, pan.FormatBytes(result.Bytes), result.Duplicates, result.Failed)
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: ") + summary)
+		return
+	}
+	if result.Failed > 0 {
+		pan.PrintError(pan.T("Photo backup finished with errors: ") + summary)
+		os.Exit(pan.ExitPartial)
+	}
+	pan.PrintSuccess(pan.T("Photo backup complete: ") + summary)
 }
 
-func renameCommand(client *pan.Client) {
-	renameFlags := pflag.NewFlagSet("rn", pflag.ExitOnError)
+func organizeCommand(client pan.PanClient) {
+	organizeFlags := pflag.NewFlagSet("organize", pflag.ExitOnError)
 	var sourcePath string
-	var newName string
-	var force bool
+	var opts pan.OrganizeOptions
 	var help bool
 
-	renameFlags.StringVarP(&sourcePath, "source", "s", "", "Source file or directory path to rename (required)")
-	renameFlags.StringVarP(&newName, "newname", "n", "", "New name for the file or directory (required)")
-	renameFlags.BoolVarP(&force, "force", "y", false, "Force rename without confirmation")
-	renameFlags.BoolVarP(&help, "help", "h", false, "Show help for rename command")
+	organizeFlags.StringVarP(&sourcePath, "path", "p", pan.DefaultPhotoDir, pan.T("Remote directory whose photos to organize, recursively"))
+	organizeFlags.StringVarP(&opts.DestDir, "destination", "d", "", pan.T("Remote directory to create the date folders in (default: the --path directory)"))
+	organizeFlags.StringVar(&opts.Layout, "layout", pan.DefaultPhotoLayout, pan.T("Date directories beneath the destination, from YYYY, MM and DD"))
+	organizeFlags.BoolVar(&opts.Fallback, "use-mtime", false, pan.T("Date photos without an EXIF capture date by their modification time instead of leaving them"))
+	organizeFlags.IntVarP(&opts.Workers, "workers", "w", pan.DefaultOrganizeWorkers, pan.T("Number of photo headers to read concurrently"))
+	organizeFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for organize command"))
 
-	if err := renameFlags.Parse(os.Args[2:]); err != nil {
+	if err := organizeFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
 	if help {
-		renameFlags.PrintDefaults()
+		fmt.Println("Usage: go-bdfs organize [-p <remote dir>] [-d <remote dir>] [--layout YYYY/MM] [--use-mtime]")
+		organizeFlags.PrintDefaults()
 		return
 	}
-
-	if sourcePath == "" {
-		pan.PrintError("Error: -s or --source flag is required to specify the file or directory to rename.")
-		renameFlags.PrintDefaults()
-		os.Exit(1)
-	}
-
-	if newName == "" {
-		pan.PrintError("Error: -n or --newname flag is required to specify the new name.")
-		renameFlags.PrintDefaults()
-		os.Exit(1)
-	}
-
-	// Extract the parent directory from the source path to construct the new full path
-	dir := filepath.Dir(sourcePath)
-	if dir == "." {
-		dir = "/"
+	if strings.Trim(opts.Layout, "/") == "" || !strings.ContainsAny(opts.Layout, "YMD") {
+		exitUsage(pan.Tf("Error: invalid --layout %q: use YYYY, MM and DD, e.g. YYYY/MM", opts.Layout))
 	}
-	newPath := filepath.Join(dir, newName)
 
-	// If not in force mode, ask for confirmation
-	if !force {
-		fmt.Printf("Are you sure you want to rename '%s' to '%s'? (y/N): ", sourcePath, newPath)
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			pan.PrintSuccess("Rename operation cancelled.")
-			return
+	opts.Progress = func(remotePath, target, outcome string, err error) {
+		switch outcome {
+		case "moved":
+			if !globals.DryRun {
+				pan.PrintSuccess(fmt.Sprintf("%s -> %s", remotePath, target))
+			}
+		case "failed":
+			pan.PrintError(fmt.Sprintf("%s: %v", remotePath, err))
 		}
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Renaming '%s' to '%s' in Baidu Pan...", sourcePath, newPath))
-
-	err := client.RenameFile(sourcePath, newName)
+	pan.PrintSuccess(pan.Tf("Organizing photos in '%s' by capture date...", sourcePath))
+	result, err := client.OrganizePhotos(context.Background(), sourcePath, opts)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error renaming file: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error organizing photos: %v", err), err)
+	}
+	summary := fmt.Sprintf("%d moved, %d already in place, %d without a capture date, %d failed",
+		result.Moved, result.InPlace, result.Undated, result.Failed)
+	if globals.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: ") + summary)
+		return
+	}
+	if result.Failed > 0 {
+		pan.PrintError(pan.T("Organizing finished with errors: ") + summary)
+		os.Exit(pan.ExitPartial)
 	}
+	pan.PrintSuccess(pan.T("Photos organized: ") + summary)
+}
 
-	pan.PrintSuccess(fmt.Sprintf("'%s' renamed successfully to '%s' in Baidu Pan.", sourcePath, newPath))
+// shellSession is an interactive shell: the client and the remote working directory
+// relative paths are resolved against. The local working directory is the process's.
+type shellSession struct {
+	client pan.PanClient
+	cwd    string
 }
 
-func copyCommand(client *pan.Client) {
-	copyFlags := pflag.NewFlagSet("cp", pflag.ExitOnError)
-	var sourcePath string
-	var destPath string
+func shellCommand(client pan.PanClient) {
+	shellFlags := pflag.NewFlagSet("shell", pflag.ExitOnError)
+	var dir string
 	var help bool
 
-	copyFlags.StringVarP(&sourcePath, "source", "s", "", "Source file or directory path to copy (required)")
-	copyFlags.StringVarP(&destPath, "destination", "d", "", "Destination file or directory path (required)")
-	copyFlags.BoolVarP(&help, "help", "h", false, "Show help for copy command")
+	shellFlags.StringVarP(&dir, "path", "p", "/", pan.T("Remote directory to start in (default: /)"))
+	shellFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for shell command"))
 
-	if err := copyFlags.Parse(os.Args[2:]); err != nil {
+	if err := shellFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
 	if help {
-		copyFlags.PrintDefaults()
+		fmt.Println("Usage: go-bdfs shell [-p <remote dir>]")
+		shellFlags.PrintDefaults()
 		return
 	}
 
-	if sourcePath == "" {
-		pan.PrintError("Error: -s or --source flag is required to specify the source file or directory to copy.")
-		copyFlags.PrintDefaults()
-		os.Exit(1)
+	session := &shellSession{client: client, cwd: "/"}
+	if err := session.cd(dir); err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
 	}
+	pan.PrintSuccess(pan.T("Type 'help' for the shell commands, 'exit' to leave."))
 
-	if destPath == "" {
-		pan.PrintError("Error: -d or --destination flag is required to specify the destination path.")
-		copyFlags.PrintDefaults()
-		os.Exit(1)
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print(session.prompt())
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+		words, err := pan.SplitShellWords(scanner.Text())
+		if err != nil {
+			pan.PrintError(pan.Tf("Error: %v", err))
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if words[0] == "exit" || words[0] == "quit" {
+			return
+		}
+		session.run(words[0], words[1:])
 	}
+}
 
-	pan.PrintSuccess(fmt.Sprintf("Copying '%s' to '%s' in Baidu Pan...", sourcePath, destPath))
-
-	err := client.CopyFile(sourcePath, destPath)
+// prompt returns the prompt showing the remote and local working directories, with the
+// home directory shortened to ~
+func (s *shellSession) prompt() string {
+	local, err := os.Getwd()
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error copying file: %v", err))
-		os.Exit(1)
+		local = "?"
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		if rest, ok := strings.CutPrefix(local, home); ok && (rest == "" || strings.HasPrefix(rest, string(filepath.Separator))) {
+			local = "~" + rest
+		}
 	}
+	return fmt.Sprintf("bdfs:%s [%s]> ", s.cwd, local)
 }
 
-func mkdirCommand(client *pan.Client) {
-	mkdirFlags := pflag.NewFlagSet("md", pflag.ExitOnError)
-	var dirPath string
-	var help bool
+// cd makes dir, absolute or relative to the remote working directory, the new one
+func (s *shellSession) cd(dir string) error {
+	target := pan.ResolveRemotePath(s.cwd, dir)
+	if target != "/" {
+		info, err := s.client.GetFileInfoByPath(target)
+		if err != nil {
+			return err
+		}
+		if info.IsDir != 1 {
+			return fmt.Errorf("not a directory: %s", target)
+		}
+	}
+	s.cwd = target
+	return nil
+}
 
-	mkdirFlags.StringVarP(&dirPath, "path", "p", "", "Directory path to create in Baidu Pan (required)")
-	mkdirFlags.BoolVarP(&help, "help", "h", false, "Show help for mkdir command")
+// run runs one shell command, reporting its errors without leaving the shell
+func (s *shellSession) run(name string, args []string) {
+	switch name {
+	case "pwd":
+		fmt.Println(s.cwd)
+	case "cd":
+		dir := "/"
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		if err := s.cd(dir); err != nil {
+			pan.PrintError(pan.Tf("Error: %v", err))
+		}
+	case "lpwd":
+		local, err := os.Getwd()
+		if err != nil {
+			pan.PrintError(pan.Tf("Error: %v", err))
+			return
+		}
+		fmt.Println(local)
+	case "lcd":
+		// As in a shell, no directory means the home directory, and ~ stands for it
+		home, err := os.UserHomeDir()
+		dir := home
+		if len(args) > 0 {
+			dir = args[0]
+			if rest, ok := strings.CutPrefix(dir, "~"); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+				dir = home + rest
+			} else {
+				err = nil
+			}
+		}
+		if err == nil {
+			err = os.Chdir(dir)
+		}
+		if err != nil {
+			pan.PrintError(pan.Tf("Error: %v", err))
+		}
+	case "ls":
+		s.list(args)
+	case "get":
+		s.get(args)
+	case "put":
+		s.put(args)
+	case "help":
+		fmt.Println(pan.T("Shell commands (remote paths may be relative to the remote working directory):"))
+		fmt.Println(pan.T("  pwd, cd [<dir>]             Show or change the remote working directory (default: /)"))
+		fmt.Println(pan.T("  lpwd, lcd [<dir>]           Show or change the local working directory (default: home)"))
+		fmt.Println(pan.T("  ls [<dir>]                  List a remote directory (default: the working directory)"))
+		fmt.Println(pan.T("  get <remote> [<local>]      Download a file (default: into the local working directory)"))
+		fmt.Println(pan.T("  put <local> [<remote>]      Upload a file (default: into the remote working directory)"))
+		fmt.Println(pan.T("  help, exit                  Show this help, or leave the shell"))
+	default:
+		pan.PrintError(pan.Tf("Unknown shell command: %s (type 'help' for the list)", name))
+	}
+}
 
-	if err := mkdirFlags.Parse(os.Args[2:]); err != nil {
+// list prints the entries of the remote directory in args, or of the working directory
+func (s *shellSession) list(args []string) {
+	dir := s.cwd
+	if len(args) > 0 {
+		dir = pan.ResolveRemotePath(s.cwd, args[0])
+	}
+	files, err := s.client.ListFiles(dir)
+	if err != nil {
+		pan.PrintError(pan.Tf("Error listing files: %v", err))
 		return
 	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ServerFilename < files[j].ServerFilename
+	})
+	for _, file := range files {
+		fileType, size := "F", pan.FormatBytes(file.Size)
+		if file.IsDir == 1 {
+			fileType, size = "D", "-"
+		}
+		mtime := time.Unix(file.ServerMtime, 0).Format("2006-01-02 15:04:05")
+		fmt.Println(strings.Join([]string{fileType, file.ServerFilename, size, mtime}, " | "))
+	}
+}
 
-	if help {
-		mkdirFlags.PrintDefaults()
+// get downloads the remote file args[0] to args[1], by default to its name in the local
+// working directory; a local directory receives the file under its name
+func (s *shellSession) get(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		pan.PrintError(pan.T("Usage: get <remote> [<local>]"))
 		return
 	}
+	remotePath := pan.ResolveRemotePath(s.cwd, args[0])
+	localPath := path.Base(remotePath)
+	if len(args) == 2 {
+		localPath = args[1]
+		if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+			localPath = filepath.Join(localPath, path.Base(remotePath))
+		}
+	}
 
-	if dirPath == "" {
-		pan.PrintError("Error: -d or --dir flag is required to specify the directory path to create.")
-		mkdirFlags.PrintDefaults()
-		os.Exit(1)
+	info, err := s.client.GetFileInfoByPath(remotePath)
+	if err != nil {
+		pan.PrintError(pan.Tf("Error: %v", err))
+		return
 	}
+	if info.IsDir == 1 {
+		pan.PrintError(pan.Tf("Error: '%s' is a directory; download it with 'go-bdfs dl -r'.", remotePath))
+		return
+	}
+	if err := s.client.DownloadFileToPath(remotePath, localPath); err != nil {
+		pan.PrintError(pan.Tf("Error downloading file: %v", err))
+		return
+	}
+	pan.PrintSuccess(pan.Tf("File downloaded successfully to: %s", localPath))
+}
 
-	pan.PrintSuccess(fmt.Sprintf("Creating directory '%s' in Baidu Pan...", dirPath))
+// put uploads the local file args[0] to args[1], by default to its name in the remote
+// working directory; an existing remote directory receives the file under its name
+func (s *shellSession) put(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		pan.PrintError(pan.T("Usage: put <local> [<remote>]"))
+		return
+	}
+	localPath := args[0]
+	remotePath := pan.ResolveRemotePath(s.cwd, filepath.Base(localPath))
+	if len(args) == 2 {
+		remotePath = pan.ResolveRemotePath(s.cwd, args[1])
+		if info, err := s.client.GetFileInfoByPath(remotePath); remotePath == "/" || (err == nil && info.IsDir == 1) {
+			remotePath = path.Join(remotePath, filepath.Base(localPath))
+		}
+	}
 
-	err := client.CreateDir(dirPath)
-	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error creating directory: %v", err))
-		os.Exit(1)
+	if info, err := os.Stat(localPath); err != nil {
+		pan.PrintError(pan.Tf("Error: %v", err))
+		return
+	} else if info.IsDir() {
+		pan.PrintError(pan.Tf("Error: '%s' is a directory; upload it with 'go-bdfs sync'.", localPath))
+		return
+	}
+	if err := s.client.UploadFile(localPath, remotePath); err != nil {
+		pan.PrintError(pan.Tf("Error uploading file: %v", err))
+		return
 	}
-	pan.PrintSuccess(fmt.Sprintf("Directory '%s' created successfully.", dirPath))
+	pan.PrintSuccess(pan.Tf("File '%s' uploaded successfully to '%s'.", filepath.Base(localPath), remotePath))
 }
 
-func infoCommand(client *pan.Client) {
-	infoFlags := pflag.NewFlagSet("if", pflag.ExitOnError)
-	var filePath string
+func syncCommand(client pan.PanClient) {
+	syncFlags := pflag.NewFlagSet("sync", pflag.ExitOnError)
+	var sourcePath string
+	var destPath string
+	var download bool
+	var opts pan.SyncOptions
+	var sliceSize string
+	var crypt bool
+	var links string
+	var special string
+	var compare string
 	var help bool
 
-	infoFlags.StringVarP(&filePath, "path", "p", "", "File path in Baidu Pan to get information for (required)")
-	infoFlags.BoolVarP(&help, "help", "h", false, "Show help for info command")
+	syncFlags.StringVarP(&sourcePath, "source", "s", "", pan.T("Source directory: local, or remote with --download (required)"))
+	syncFlags.StringVarP(&destPath, "destination", "d", "", pan.T("Destination directory: remote, or local with --download (required)"))
+	syncFlags.BoolVar(&download, "download", false, pan.T("Sync from Baidu Pan to the local directory instead of uploading"))
+	syncFlags.BoolVar(&opts.Delete, "delete", false, pan.T("Mirror mode: delete destination files that are not present in the source"))
+	syncFlags.Float64Var(&opts.MaxDeletePercent, "max-delete", 50, pan.T("Abort if more than this percentage of destination files would be deleted (0 disables the check)"))
+	syncFlags.BoolVar(&opts.UseIndex, "use-index", false, pan.T("Read the remote tree from the local index instead of listing it"))
+	syncFlags.IntVar(&opts.ListWorkers, "list-workers", pan.DefaultWalkWorkers, pan.T("Number of remote directories to list concurrently"))
+	syncFlags.BoolVar(&opts.Upload.NoPreserveTimes, "no-preserve-times", false, pan.T("Don't preserve modification times on uploaded or downloaded files"))
+	syncFlags.BoolVar(&opts.Upload.NoRapid, "no-rapid", false, pan.T("Always transfer uploaded content, even when Baidu Pan already holds an identical copy"))
+	syncFlags.BoolVar(&crypt, "crypt", false, pan.T("Encrypt uploaded and decrypt downloaded files with the configured key"))
+	syncFlags.StringVar(&sliceSize, "slice-size", "", pan.T("Upload slice size, e.g. 4M or 16M (default: chosen from the account's VIP level)"))
+	syncFlags.BoolVar(&opts.Download.NoVerify, "no-verify", false, pan.T("Skip MD5 verification of downloaded files"))
+	syncFlags.BoolVar(&opts.Restart, "restart", false, pan.T("Compare the trees again instead of continuing an interrupted sync"))
+	syncFlags.StringVar(&links, "links", string(pan.LinksFollow), pan.T("Symbolic links in local directories: follow, skip or error"))
+	syncFlags.StringVar(&special, "special", string(pan.SpecialSkip), pan.T("Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error"))
+	syncFlags.StringVar(&compare, "compare", string(pan.CompareSizeMtime), pan.T("How to tell whether a file changed: size, mtime, md5 or size+mtime"))
+	filters := addFilterFlags(syncFlags)
+	syncFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for sync command"))
 
-	if err := infoFlags.Parse(os.Args[2:]); err != nil {
+	if err := syncFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
+	// --dry-run is a global flag, taken out of the arguments wherever it appears
+	opts.DryRun = globals.DryRun
+	opts.Download.NoPreserveTimes = opts.Upload.NoPreserveTimes
 
 	if help {
-		infoFlags.PrintDefaults()
+		syncFlags.PrintDefaults()
 		return
 	}
 
-	if filePath == "" {
-		pan.PrintError("Error: -f or --file flag is required to specify the file path to get information for.")
-		infoFlags.PrintDefaults()
-		os.Exit(1)
+	if sourcePath == "" || destPath == "" {
+		pan.PrintError(pan.T("Error: -s or --source and -d or --destination flags are required."))
+		syncFlags.PrintDefaults()
+		os.Exit(pan.ExitUsage)
+	}
+
+	filter, err := filters.build()
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error in filter rules: %v", err), err)
+	}
+	opts.Filter = filter
+	opts.Upload.SliceSize = parseSliceSize(sliceSize)
+	opts.Links = parseLinks(links)
+	opts.Special = parseSpecial(special)
+	if opts.Compare, err = pan.ParseCompareMode(compare); err != nil {
+		pan.ExitWithError(pan.Tf("Error: %v", err), err)
+	}
+	if crypt {
+		opts.Cipher = loadCipher()
+	}
+	if opts.Delete && stdinIsTerminal() {
+		// Unattended runs rely on --max-delete alone
+		opts.ConfirmDelete = func(result *pan.SyncResult) bool {
+			prompt := pan.Tf("This sync deletes %d entries from '%s' that are not in '%s'. Continue? (y/N): ", result.Deleted, destPath, sourcePath)
+			return confirmed(false, prompt, pan.T("Sync cancelled."))
+		}
 	}
 
-	pan.PrintSuccess(fmt.Sprintf("Getting information for file: '%s' in Baidu Pan...", filePath))
+	var result *pan.SyncResult
+	started := time.Now()
+	if download {
+		pan.PrintSuccess(pan.Tf("Synchronizing Baidu Pan '%s' to local '%s'...", sourcePath, destPath))
+		result, err = client.SyncDown(sourcePath, destPath, opts)
+	} else {
+		pan.PrintSuccess(pan.Tf("Synchronizing local '%s' to Baidu Pan '%s'...", sourcePath, destPath))
+		result, err = client.SyncUp(sourcePath, destPath, opts)
+	}
+
+	if result != nil && opts.DryRun {
+		for _, action := range result.Actions {
+			fmt.Printf("%s | %s | %s\n", action.Op, action.RelPath, pan.FormatBytes(action.Size))
+		}
+	}
+
+	if !opts.DryRun && !errors.Is(err, pan.ErrSyncCancelled) {
+		report := pan.JobReport{Job: "sync", Success: err == nil, Duration: time.Since(started), Started: started}
+		if result != nil {
+			report.Bytes = result.Bytes
+			report.Files = len(result.Actions)
+		}
+		if err != nil {
+			report.Errors = []string{err.Error()}
+		}
+		notifyReport(report, true)
+	}
 
-	fileInfo, err := client.GetAndDisplayFileInfo(filePath)
+	if result != nil && result.Resumed > 0 {
+		pan.PrintSuccess(pan.Tf("Continued an interrupted sync: %d operations were already done.", result.Resumed))
+	}
+	exitIfInterrupted(err)
+	if errors.Is(err, pan.ErrSyncCancelled) {
+		return
+	}
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error getting file information: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error synchronizing: %v", err), err)
 	}
 
-	fmt.Print(pan.FormatFileInfo(fileInfo))
+	summary := fmt.Sprintf("%d operations, %s to transfer, %d deletions", len(result.Actions), pan.FormatBytes(result.Bytes), result.Deleted)
+	if opts.DryRun {
+		pan.PrintSuccess(pan.T("Dry run: ") + summary)
+		return
+	}
+	pan.PrintSuccess(pan.T("Sync complete: ") + summary)
 }
 
-func diskInfoCommand(client *pan.Client) {
-	diskInfoFlags := pflag.NewFlagSet("di", pflag.ExitOnError)
+func versionCommand() {
+	versionFlags := pflag.NewFlagSet("version", pflag.ExitOnError)
+	var check bool
+	var feed string
 	var help bool
 
-	diskInfoFlags.BoolVarP(&help, "help", "h", false, "Show help for disk info command")
+	versionFlags.BoolVar(&check, "check", false, pan.T("Check the release feed for a newer version"))
+	versionFlags.StringVar(&feed, "feed", pan.DefaultReleaseFeed, pan.T("Release feed URL (GitHub latest release API or a mirror)"))
+	versionFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for version command"))
 
-	if err := diskInfoFlags.Parse(os.Args[2:]); err != nil {
+	if err := versionFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
 	if help {
-		diskInfoFlags.PrintDefaults()
+		versionFlags.PrintDefaults()
 		return
 	}
 
-	pan.PrintSuccess("Getting disk information from Baidu Pan...")
+	fmt.Printf(pan.T("go-bdfs version %s\n"), VERSION)
+	if !check {
+		return
+	}
 
-	diskInfo, err := client.GetDiskInfo()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	release, err := pan.LatestRelease(ctx, feed)
 	if err != nil {
-		pan.PrintError(fmt.Sprintf("Error getting disk information: %v", err))
-		os.Exit(1)
+		pan.ExitWithError(pan.Tf("Error checking for updates: %v", err), err)
 	}
-
-	fmt.Print(pan.FormatDiskInfo(diskInfo))
+	if pan.CompareVersions(release.Version, VERSION) <= 0 {
+		pan.PrintSuccess(pan.Tf("go-bdfs is up to date (latest release: %s).", release.Version))
+		return
+	}
+	pan.PrintSuccess(pan.Tf("A newer version is available: %s, released %s.", release.Version, release.Published.Format(time.DateOnly)))
+	if release.URL != "" {
+		fmt.Printf(pan.T("Release notes: %s\n"), release.URL)
+	}
+	fmt.Println(pan.T("Run 'go-bdfs selfupdate' to install it."))
 }
 
-func refreshTokenCommand(client *pan.Client) {
-	refreshFlags := pflag.NewFlagSet("ar", pflag.ExitOnError)
+// releasePublicKey is the base64-encoded Ed25519 key release checksums are signed with.
+// Release builds set it with -ldflags "-X main.releasePublicKey=..."; when it is empty,
+// selfupdate verifies downloads against the published checksums only.
+var releasePublicKey string
+
+func selfUpdateCommand() {
+	updateFlags := pflag.NewFlagSet("selfupdate", pflag.ExitOnError)
+	var feed string
+	var force bool
+	var reinstall bool
 	var help bool
 
-	refreshFlags.BoolVarP(&help, "help", "h", false, "Show help for refresh token command")
+	updateFlags.StringVar(&feed, "feed", pan.DefaultReleaseFeed, pan.T("Release feed URL (GitHub latest release API or a mirror)"))
+	updateFlags.BoolVarP(&force, "force", "y", false, pan.T("Update without confirmation"))
+	updateFlags.BoolVar(&reinstall, "reinstall", false, pan.T("Install the latest release even if it is not newer"))
+	updateFlags.BoolVarP(&help, "help", "h", false, pan.T("Show help for selfupdate command"))
 
-	if err := refreshFlags.Parse(os.Args[2:]); err != nil {
+	if err := updateFlags.Parse(os.Args[2:]); err != nil {
 		return
 	}
 
 	if help {
-		refreshFlags.PrintDefaults()
+		updateFlags.PrintDefaults()
 		return
 	}
 
-	pan.PrintSuccess("Attempting to refresh access token...")
-
-	// Check if there's a refresh token available
-	if client.HasValidToken() {
-		err := client.LoadTokens()
-		if err != nil {
-			pan.PrintError(fmt.Sprintf("Error loading existing tokens: %v", err))
-			os.Exit(1)
-		}
-
-		if !client.HasRefreshToken() {
-			pan.PrintError("No refresh token available, cannot refresh access token.")
-			os.Exit(1)
-		}
-
-		err = client.RefreshToken()
-		if err != nil {
-			pan.PrintError(fmt.Sprintf("Error refreshing token: %v", err))
-			os.Exit(1)
+	var opts pan.SelfUpdateOptions
+	if releasePublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(releasePublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			pan.PrintErrorAndExit(pan.T("Error: this binary was built with an invalid release key."))
 		}
-
-		pan.PrintSuccess("Access token refreshed successfully and saved to .bdfs_certs")
-	} else {
-		pan.PrintError("No token file found, cannot refresh access token.")
-		os.Exit(1)
+		opts.PublicKey = ed25519.PublicKey(key)
 	}
-}
-
-func versionCommand() {
-	versionFlags := pflag.NewFlagSet("version", pflag.ExitOnError)
-	var help bool
 
-	versionFlags.BoolVarP(&help, "help", "h", false, "Show help for version command")
+	exePath, err := os.Executable()
+	if err == nil {
+		exePath, err = filepath.EvalSymlinks(exePath)
+	}
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error locating the go-bdfs binary: %v", err), err)
+	}
 
-	if err := versionFlags.Parse(os.Args[2:]); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	release, err := pan.LatestRelease(ctx, feed)
+	if err != nil {
+		pan.ExitWithError(pan.Tf("Error checking for updates: %v", err), err)
+	}
+	if !reinstall && pan.CompareVersions(release.Version, VERSION) <= 0 {
+		pan.PrintSuccess(pan.Tf("go-bdfs %s is up to date.", VERSION))
 		return
 	}
 
-	if help {
-		versionFlags.PrintDefaults()
+	if globals.DryRun {
+		fmt.Printf("[dry-run] selfupdate: %s %s -> %s\n", exePath, VERSION, release.Version)
+		return
+	}
+	prompt := pan.Tf("Replace go-bdfs %s at '%s' with %s? (y/N): ", VERSION, exePath, release.Version)
+	if !confirmed(force, prompt, pan.T("Update cancelled.")) {
 		return
 	}
 
-	fmt.Printf("go-bdfs version %s\n", VERSION)
+	pan.PrintSuccess(pan.Tf("Downloading %s %s...", pan.ReleaseAssetName(), release.Version))
+	if err := pan.SelfUpdate(ctx, release, exePath, opts); err != nil {
+		pan.ExitWithError(pan.Tf("Error updating go-bdfs: %v", err), err)
+	}
+	if opts.PublicKey != nil {
+		pan.PrintSuccess(pan.Tf("Updated to %s (checksum and signature verified).", release.Version))
+	} else {
+		pan.PrintSuccess(pan.Tf("Updated to %s (checksum verified).", release.Version))
+	}
 }
 
 func showHelp() {
-	fmt.Println("go-bdfs: Baidu Pan client")
-	fmt.Println("Usage: go-bdfs <command> [arguments]")
+	fmt.Println(pan.T("go-bdfs: Baidu Pan client"))
+	fmt.Println(pan.T("Usage: go-bdfs <command> [arguments]"))
 	fmt.Println("")
-	fmt.Println("Commands:")
-	fmt.Println("  ls          List files in a directory")
-	fmt.Println("              Usage: go-bdfs ls -p <path>")
-	fmt.Println("              Flags: -p, --path <path> (default: /)")
+	fmt.Println(pan.T("Commands:"))
+	fmt.Println(pan.T("  ls          List files in a directory"))
+	fmt.Println("              Usage: go-bdfs ls -p <path> [-R] [--max-depth <n>] [--full-paths|-0] [--match <glob>] [--regex <regexp>] [-t image,video,...] [--media-info]")
+	fmt.Println("                     [--min-duration <d>] [--max-duration <d>] [--output text|csv|tsv]")
+	fmt.Println("              Flags: -p, --path <path> (default: /), -R, --recursive, --max-depth <n>, --full-paths, -0, --print0, --match <glob>, --regex <regexp>,")
+	fmt.Println("                     -t, --type <types>, --media-info, --min-duration, --max-duration (optional), --output <text|csv|tsv> (default: text)")
 	fmt.Println("")
-	fmt.Println("  dl          Download a file from Baidu Pan")
-	fmt.Println("              Usage: go-bdfs dl -s <source> -d <destination>")
-	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (optional)")
+	fmt.Println(pan.T("  dl          Download a file or, with -r, a directory from Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs dl -s <source>|--fsid <id> -d <destination> [-r] [--transfers <n>] [--connections <n>] [--ignore-space]")
+	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (optional), -r, --recursive, --transfers (default: 4), --connections (default: 1), --ignore-space")
 	fmt.Println("")
-	fmt.Println("  ul          Upload a file to Baidu Pan")
-	fmt.Println("              Usage: go-bdfs ul -s <source> -d <destination>")
-	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (required)")
+	fmt.Println(pan.T("  ul          Upload a file, or a directory, glob or file list in parallel"))
+	fmt.Println("              Usage: go-bdfs ul -s <source>|--files-from <file> -d <destination> [--transfers <n>] [--no-preserve-times] [--if-exists overwrite|rename|skip|fail] [--no-rapid] [--skip-identical]")
+	fmt.Println("              Flags: -s, --source <file|dir|glob> (required), -d, --destination <destination> (required), --files-from <file|->, --transfers (default: 4),")
+	fmt.Println("                     --no-preserve-times, --if-exists (default: overwrite), --no-rapid, --skip-identical (optional)")
 	fmt.Println("")
-	fmt.Println("  rm          Remove a file or directory from Baidu Pan")
-	fmt.Println("              Usage: go-bdfs rm -s <source> [-y]")
-	fmt.Println("              Flags: -s, --source <source> (required), -y, --force (optional)")
+	fmt.Println(pan.T("  rm          Remove a file or directory from Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs rm -s <source>|--fsid <id> [-y] [--restore-hint] [--no-wait]")
+	fmt.Println("              Flags: -s, --source <source> (required), -y, --force (optional), --restore-hint (optional), --no-wait (optional)")
 	fmt.Println("")
-	fmt.Println("  mv          Move a file or directory to another directory in Baidu Pan")
-	fmt.Println("              Usage: go-bdfs mv -s <source> -d <destination> [-y]")
-	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (required), -y, --force (optional)")
+	fmt.Println(pan.T("  mv          Move a file or directory to another directory in Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs mv -s <source> [-s <source>...]|--fsid <id>[,<id>...] -d <destination> [-y] [--no-wait]")
+	fmt.Println("              Flags: -s, --source <source> (required, repeatable), -d, --destination <destination> (required), -y, --force (optional),")
+	fmt.Println("                     --no-wait (optional)")
 	fmt.Println("")
-	fmt.Println("  rn          Rename a file or directory in Baidu Pan")
-	fmt.Println("              Usage: go-bdfs rn -s <source> -n <newname>")
+	fmt.Println(pan.T("  rn          Rename a file or directory in Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs rn -s <source> -n <newname> | -s <dir> --pattern s/<regexp>/<replacement>/[gi]|<template> [--match <glob>] [--dirs] [--start <n>]")
 	fmt.Println("              Flags: -s, --source <source> (required), -n, --newname <newname> (required)")
 	fmt.Println("")
-	fmt.Println("  md          Create a directory in Baidu Pan")
+	fmt.Println(pan.T("  md          Create a directory in Baidu Pan"))
 	fmt.Println("              Usage: go-bdfs md -p <path>")
 	fmt.Println("              Flags: -p, --path <path> (required)")
 	fmt.Println("")
-	fmt.Println("  cp          Copy a file or directory in Baidu Pan")
-	fmt.Println("              Usage: go-bdfs cp -s <source> -d <destination>")
-	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (required)")
+	fmt.Println(pan.T("  cp          Copy a file or directory in Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs cp -s <source> [-s <source>...] -d <destination> [--no-wait]")
+	fmt.Println("              Flags: -s, --source <source> (required, repeatable), -d, --destination <destination> (required), --no-wait (optional)")
 	fmt.Println("")
-	fmt.Println("  if          Get information about a file in Baidu Pan")
-	fmt.Println("              Usage: go-bdfs if -p <path>")
-	fmt.Println("              Flags: -p, --path <path> (required)")
+	fmt.Println(pan.T("  task        Check on an asynchronous copy, move or delete task started with --no-wait"))
+	fmt.Println("              Usage: go-bdfs task <query|wait> <taskid> [--timeout <duration>]")
+	fmt.Println("              Flags: --timeout <duration> (optional, wait only)")
+	fmt.Println("")
+	fmt.Println(pan.T("  if          Get information about a file in Baidu Pan"))
+	fmt.Println("              Usage: go-bdfs if -p <path> [-p <path>...] | <path>... | --fsid <id>[,<id>...] [--json]")
+	fmt.Println("              Flags: -p, --path <path> (required, repeatable), --fsid <id> (repeatable), --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  preview     Print a link to view a document in the browser without downloading it"))
+	fmt.Println("              Usage: go-bdfs preview -p <path> [--open]")
+	fmt.Println("              Flags: -p, --path <path> (required), -o, --open (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  share       Create a password-protected share link and print it with its extraction code on one line"))
+	fmt.Println("              Usage: go-bdfs share -p <path> [-p <path>...] | --fsid <id>[,<id>...] [--password auto|<code>] [--expire 1d|7d|30d|365d|forever] [--json]")
+	fmt.Println("              Flags: -p, --path <path> (required, repeatable), --password (default: auto), --expire (default: 7d), --json (optional)")
 	fmt.Println("")
-	fmt.Println("  di          Get disk information (storage usage) from Baidu Pan")
+	fmt.Println(pan.T("  shares      List the shares other users have sent to this account, with the fs_id of each shared file"))
+	fmt.Println("              Usage: go-bdfs shares [--json]")
+	fmt.Println("              Flags: --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  di          Get disk information (storage usage) from Baidu Pan"))
 	fmt.Println("              Usage: go-bdfs di")
 	fmt.Println("              Flags: -h, --help (optional)")
 	fmt.Println("")
-	fmt.Println("  ar          Refresh the access token using the refresh token")
+	fmt.Println(pan.T("  ar          Refresh the access token using the refresh token"))
 	fmt.Println("              Usage: go-bdfs ar")
 	fmt.Println("              Flags: -h, --help (optional)")
 	fmt.Println("")
-	fmt.Println("  version     Show the version information")
-	fmt.Println("              Usage: go-bdfs version")
-	fmt.Println("              Flags: -h, --help (optional)")
+	fmt.Println(pan.T("  index       Manage the local metadata index of remote files"))
+	fmt.Println("              Usage: go-bdfs index <rebuild|prune|update> [-p <path>]")
+	fmt.Println("              Flags: -p, --path <path> (default: /)")
+	fmt.Println("")
+	fmt.Println(pan.T("  sync        Synchronize a local directory with a Baidu Pan directory"))
+	fmt.Println("              Usage: go-bdfs sync -s <source> -d <destination> [--download] [--delete] [--dry-run] [--max-delete <percent>] [--use-index]")
+	fmt.Println("              Flags: -s, --source <source> (required), -d, --destination <destination> (required), --download (optional),")
+	fmt.Println("                     --delete (optional), --dry-run (optional), --max-delete <percent> (default: 50), --use-index (optional),")
+	fmt.Println("                     --no-preserve-times (optional), --no-rapid (optional), --restart (optional),")
+	fmt.Println("                     --links <follow|skip|error> (default: follow), --special <skip|upload|error> (default: skip),")
+	fmt.Println("                     --compare <size|mtime|md5|size+mtime> (default: size+mtime),")
+	fmt.Println("                     --include <glob>, --exclude <glob>, --filter-from <file>, --min-size, --max-size, --min-age, --max-age (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  xcopy       Copy a file or directory between two Baidu Pan accounts"))
+	fmt.Println("              Usage: go-bdfs xcopy --from [profile:]<path> --to [profile:]<path>")
+	fmt.Println("              Flags: --from (required), --to (required), --no-preserve-times, --slice-size (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  prune-empty Recursively remove directories that contain no files"))
+	fmt.Println("              Usage: go-bdfs prune-empty -p <path> [-y]")
+	fmt.Println("              Flags: -p, --path <path> (required), -y, --force (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  report      Summarize storage use by extension, top-level folder and age"))
+	fmt.Println("              Usage: go-bdfs report -p <path> [--json|--output csv|tsv] [--top <n>]")
+	fmt.Println("              Flags: -p, --path <path> (default: /), --json (optional), --output <text|csv|tsv> (default: text), --top <n> (default: 20)")
+	fmt.Println("")
+	fmt.Println(pan.T("  speedtest   Measure sustained upload and download throughput per connection count"))
+	fmt.Println("              Usage: go-bdfs speedtest [--size <size>] [-c 1,4,8] [-p <dir>] [--json]")
+	fmt.Println("              Flags: --size (default: 64M), -c, --connections (default: 1,4,8), -p, --path <dir> (default: /), --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  export      Export path, size, MD5, fs_id and timestamps of every file beneath a directory as JSON"))
+	fmt.Println("              Usage: go-bdfs export -p <path> [-o <file>]")
+	fmt.Println("              Flags: -p, --path <path> (default: /), -o, --output <file> (default: stdout)")
+	fmt.Println("")
+	fmt.Println(pan.T("  diff        Report files added, removed or changed since a manifest was exported"))
+	fmt.Println("              Usage: go-bdfs diff --manifest <file> [-p <path>] [--json] [--only <added|removed|changed>]")
+	fmt.Println("              Flags: --manifest <file> (required), -p, --path <path> (default: the manifest's root), --json, --only (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  snapshot    Manage named snapshots of the remote tree stored locally"))
+	fmt.Println("              Usage: go-bdfs snapshot <create|list|diff|delete> [<name> [<other>]] [-p <path>]")
+	fmt.Println("              Flags: -p, --path <path> (default: /), --force, --json, --only <added|removed|changed> (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  photos      Back up photos and videos into date folders (e.g. /Photos/2024/05), skipping content backed up before"))
+	fmt.Println("              Usage: go-bdfs photos backup -s <local dir> [-d <remote dir>] [--layout YYYY/MM]")
+	fmt.Println("              Flags: -s, --source (required), -d, --destination (default: /Photos), --layout, --slice-size, --links, --special (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  organize    Move remote photos into date folders by EXIF capture date, reading only their headers"))
+	fmt.Println("              Usage: go-bdfs organize [-p <remote dir>] [-d <remote dir>] [--layout YYYY/MM] [--use-mtime]")
+	fmt.Println("              Flags: -p, --path (default: /Photos), -d, --destination (default: the --path directory), --layout, --use-mtime, -w, --workers (default: 4) (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  shell       Interactive shell keeping remote and local working directories, both shown in the prompt"))
+	fmt.Println("              Usage: go-bdfs shell [-p <remote dir>]")
+	fmt.Println(pan.T("              Commands: pwd, cd, lpwd, lcd, ls, get, put, help, exit; remote paths may be relative"))
+	fmt.Println("")
+	fmt.Println(pan.T("  history     Show the transfer history, with filters or as a summary"))
+	fmt.Println("              Usage: go-bdfs history [--since <age|date>] [--direction upload|download] [--failed] [-p <text>] [--summary] [--json]")
+	fmt.Println("              Flags: --since, --direction, --failed, -p, --path, -n, --limit (default: 50), --summary, --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  stats       Run another command, then print its stats (same as the global --stats flag)"))
+	fmt.Println("              Usage: go-bdfs stats <command> [arguments]")
+	fmt.Println("")
+	fmt.Println(pan.T("  queue       Queue transfers on disk and run them, resuming after interruptions"))
+	fmt.Println("              Usage: go-bdfs queue add -s <source> -d <destination> [--download]")
+	fmt.Println("                     go-bdfs queue ls [--state <state>] [--json]")
+	fmt.Println("                     go-bdfs queue rm <id>... | --state <state>")
+	fmt.Println("                     go-bdfs queue run [--retry-failed]")
+	fmt.Println("              Flags: -s, -d, --download, --links, --special, --state, --json, --retry-failed, --slice-size (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  daemon      Run the jobs configured under [[jobs]] on their cron schedules until interrupted"))
+	fmt.Println("              Usage: go-bdfs daemon [--list] [--log-file <file>]")
+	fmt.Println("                     go-bdfs daemon install|uninstall|status [--name <service>] [--log-file <file>]")
+	fmt.Println("              Flags: --list, --log-file, --name (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  doctor      Check the configuration, token file, Baidu endpoints, token validity and clock skew"))
+	fmt.Println("              Usage: go-bdfs doctor [--json]")
+	fmt.Println("              Flags: --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  paths       Show the configuration, state and cache directories and every file in them, moving files from ~/.local/app/bdfs"))
+	fmt.Println("              Usage: go-bdfs paths [--json]")
+	fmt.Println("              Flags: --json (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  version     Show the version information, and with --check whether a newer release exists"))
+	fmt.Println("              Usage: go-bdfs version [--check] [--feed <url>]")
+	fmt.Println("              Flags: --check, --feed <url>, -h, --help (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  selfupdate  Download the latest release, verify its checksum (and signature) and replace this binary"))
+	fmt.Println("              Usage: go-bdfs selfupdate [-y] [--reinstall] [--feed <url>]")
+	fmt.Println("              Flags: -y, --force, --reinstall, --feed <url> (optional)")
+	fmt.Println("")
+	fmt.Println(pan.T("  help        Show this help message"))
+	fmt.Println("")
+	fmt.Println(pan.T("Global flags:"))
+	fmt.Println(pan.T("  --debug     Log HTTP requests (with tokens redacted) and responses to stderr"))
+	fmt.Println("  --dump-raw[=<file>]")
+	fmt.Println(pan.T("              Write every raw JSON API response, tokens redacted, to stderr or append it to <file>, one JSON object per line"))
+	fmt.Println("  --print-curl")
+	fmt.Println(pan.T("              Print an equivalent curl command for each API request to stderr, with ${BDFS_ACCESS_TOKEN} in place of the token;"))
+	fmt.Println(pan.T("              with --dry-run, modifying requests are printed by the dry run instead of being sent"))
+	fmt.Println(pan.T("  -q, --quiet Only print results (listings, info, reports), warnings and errors: no status messages or progress"))
+	fmt.Println("  -v, --verbose")
+	fmt.Println(pan.T("              Also print each API request (method, status, errno, request_id, duration) and other details"))
+	fmt.Println("  --metrics-addr <addr>")
+	fmt.Println(pan.T("              Serve Prometheus metrics on <addr>/metrics while the command runs (for long-running modes)"))
+	fmt.Println("  --status-port <port>")
+	fmt.Println(pan.T("              Serve the transfers in progress, their speeds and ETAs, and the queue on http://127.0.0.1:<port>/status"))
+	fmt.Println(pan.T("              (add ?format=json for JSON); on Unix, SIGUSR1 prints the same status to stderr"))
+	fmt.Println("  --max-qps <n>")
+	fmt.Println(pan.T("              Limit API requests per second for each endpoint to avoid Baidu's request limits"))
+	fmt.Println("  --max-requests <n>")
+	fmt.Println(pan.T("              Limit the API requests in flight at once across all workers (default: 16, or max_requests in the config; 0 for unlimited)"))
+	fmt.Println("  --ipv4, --ipv6")
+	fmt.Println(pan.T("              Connect only over IPv4 or IPv6, overriding ip_family in the [transport] section"))
+	fmt.Println("  --slice-retries <n>")
+	fmt.Println(pan.T("              Retry a failed upload slice up to <n> times with backoff before failing the upload (default: 3, 0 disables)"))
+	fmt.Println(pan.T("  --dry-run   Print the API operations rm, mv, rn, cp, ul and sync would perform (with byte totals) without executing them"))
+	fmt.Println("  --read-only")
+	fmt.Println(pan.T("              Refuse ul, rm, mv, rn, cp, md, share and other commands that change files in Baidu Pan (also read_only in the config)"))
+	fmt.Println("  --yes")
+	fmt.Println(pan.T("              Answer yes to every confirmation, as -y does for a single command (also assume_yes in the config)"))
+	fmt.Println("  --static-token")
+	fmt.Println(pan.T("              Use a long-lived access token as it is: never refresh it, rewrite the token file or start authorization (also static_token in the config)"))
 	fmt.Println("")
-	fmt.Println("  help        Show this help message")
+	fmt.Println(pan.T("Exit Status:"))
+	fmt.Println(pan.T("  0 success, 1 other failure, 2 invalid flags or arguments, 3 authorization failed,"))
+	fmt.Println(pan.T("  4 not found, 5 quota exceeded, 6 rate limited, 7 partial failure, 8 network error,"))
+	fmt.Println(pan.T("  130 interrupted"))
 	fmt.Println("")
-	fmt.Println("Use 'go-bdfs <command> -h' or 'go-bdfs <command> --help' for more information about a command.")
+	fmt.Println(pan.T("Use 'go-bdfs <command> -h' or 'go-bdfs <command> --help' for more information about a command."))
 }
@@ -0,0 +1,269 @@
+package pan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveFormat names a format UploadDirArchive can pack a directory into
+type ArchiveFormat string
+
+const (
+	ArchiveTar   ArchiveFormat = "tar"
+	ArchiveTarGz ArchiveFormat = "tar.gz"
+	ArchiveZip   ArchiveFormat = "zip"
+)
+
+// ParseArchiveFormat parses an archive format name: tar, tar.gz (or tgz) or zip
+func ParseArchiveFormat(s string) (ArchiveFormat, error) {
+	switch s {
+	case "tar":
+		return ArchiveTar, nil
+	case "tar.gz", "tgz":
+		return ArchiveTarGz, nil
+	case "zip":
+		return ArchiveZip, nil
+	}
+	return "", fmt.Errorf("unsupported archive format %q (expected tar, tar.gz or zip)", s)
+}
+
+// UploadDirArchive packs the local directory localDir into a single archive of the given
+// format and uploads it to remotePath. The archive is written to a temporary file first,
+// because Baidu Pan needs the size before the upload starts, and removed afterwards.
+// Regular files, directories and symlinks are archived; other file types are skipped.
+// opts.SliceSize and opts.Cipher apply to the archive; the other options are ignored.
+func (c *Client) UploadDirArchive(ctx context.Context, localDir, remotePath string, format ArchiveFormat, opts UploadOptions) error {
+	info, err := os.Stat(localDir)
+	if err != nil {
+		return fmt.Errorf("failed to get local directory info: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", localDir)
+	}
+
+	files, total, err := archiveSourceSize(localDir)
+	if err != nil {
+		return err
+	}
+
+	// A tar archive is at least as large as its files, so one that cannot be uploaded
+	// fails before it is built; compressed archives are only checked once built
+	limit, err := c.MaxUploadSize(opts.SliceSize)
+	if err != nil {
+		return err
+	}
+	if format == ArchiveTar && total > limit {
+		return fmt.Errorf("%w: the files to archive total %s, more than the %s this account can upload as one file",
+			ErrFileTooLarge, FormatBytes(total), FormatBytes(limit))
+	}
+
+	if c.DryRun() {
+		fmt.Fprintf(c.dryRunOutput, "[dry-run] archive (%s): %s -> %s (%d files, %s)\n",
+			format, localDir, remotePath, files, FormatBytes(total))
+		return nil
+	}
+
+	spool, err := os.CreateTemp("", "bdfs-archive-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive spool file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	c.logger.Info(fmt.Sprintf("Archiving %d files (%s) from %s as %s", files, FormatBytes(total), localDir, format))
+	progress := &archiveProgress{client: c, name: filepath.Base(localDir), total: total}
+	if err := writeArchive(spool, localDir, format, progress); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", localDir, err)
+	}
+	progress.print()
+	c.printProgress("\n")
+
+	size, err := spool.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to size archive spool file: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind archive spool file: %w", err)
+	}
+
+	return c.uploadReader(ctx, spool, size, remotePath, opts, time.Time{})
+}
+
+// archiveSourceSize returns the number and total size of the regular files beneath root
+func archiveSourceSize(root string) (int, int64, error) {
+	files, total := 0, int64(0)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return files, total, nil
+}
+
+// archiveEntry is one item beneath the archived directory
+type archiveEntry struct {
+	path string // Local path
+	name string // Slash-separated name relative to the archived directory
+	info fs.FileInfo
+	link string // Symlink target, for symlinks
+}
+
+// walkArchive calls fn for every directory, regular file and symlink beneath root
+func walkArchive(root string, fn func(archiveEntry) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := archiveEntry{path: p, name: filepath.ToSlash(rel), info: info}
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			entry.name += "/"
+		case mode&fs.ModeSymlink != 0:
+			if entry.link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case !mode.IsRegular():
+			return nil // Sockets, devices and pipes are not archived
+		}
+		return fn(entry)
+	})
+}
+
+// writeArchive writes the contents of root to w in the given format
+func writeArchive(w io.Writer, root string, format ArchiveFormat, progress *archiveProgress) error {
+	switch format {
+	case ArchiveTar:
+		return writeTar(w, root, progress)
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(w)
+		if err := writeTar(gz, root, progress); err != nil {
+			return err
+		}
+		return gz.Close()
+	case ArchiveZip:
+		return writeZip(w, root, progress)
+	}
+	return fmt.Errorf("unsupported archive format %q", format)
+}
+
+// writeTar writes the contents of root to w as a tar archive
+func writeTar(w io.Writer, root string, progress *archiveProgress) error {
+	tw := tar.NewWriter(w)
+	err := walkArchive(root, func(entry archiveEntry) error {
+		header, err := tar.FileInfoHeader(entry.info, entry.link)
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if entry.info.Mode().IsRegular() {
+			return copyArchiveFile(tw, entry.path, progress)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// writeZip writes the contents of root to w as a zip archive
+func writeZip(w io.Writer, root string, progress *archiveProgress) error {
+	zw := zip.NewWriter(w)
+	err := walkArchive(root, func(entry archiveEntry) error {
+		header, err := zip.FileInfoHeader(entry.info)
+		if err != nil {
+			return err
+		}
+		header.Name = entry.name
+		if entry.info.Mode().IsRegular() {
+			header.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.info.Mode().IsRegular():
+			return copyArchiveFile(fw, entry.path, progress)
+		case entry.link != "":
+			_, err = io.WriteString(fw, entry.link) // Zip stores the symlink target as its content
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// copyArchiveFile copies the local file at p into an archive entry, counting the bytes read
+func copyArchiveFile(w io.Writer, p string, progress *archiveProgress) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, io.TeeReader(f, progress))
+	return err
+}
+
+// archiveProgressInterval limits how often archiving progress is printed
+const archiveProgressInterval = 200 * time.Millisecond
+
+// archiveProgress reports the bytes read from the archived files
+type archiveProgress struct {
+	client  *Client
+	name    string
+	total   int64
+	read    int64
+	printed time.Time
+}
+
+// Write counts p as read from the source files, printing progress at most every archiveProgressInterval
+func (ap *archiveProgress) Write(p []byte) (int, error) {
+	ap.read += int64(len(p))
+	if time.Since(ap.printed) >= archiveProgressInterval {
+		ap.print()
+	}
+	return len(p), nil
+}
+
+func (ap *archiveProgress) print() {
+	ap.printed = time.Now()
+	percent := 100.0
+	if ap.total > 0 {
+		percent = float64(ap.read) / float64(ap.total) * 100
+	}
+	ap.client.printProgress("\rArchiving %s: %d / %d bytes (%.2f%%)", ap.name, ap.read, ap.total, percent)
+}
@@ -0,0 +1,100 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// partialFailureErrno is the errno the file manager returns when some entries of a batch
+// failed; the info list tells which
+const partialFailureErrno = 12
+
+// FileOpFailure is an entry of a file manager batch that failed
+type FileOpFailure struct {
+	Index int    // Position of the entry in the batch
+	Path  string // Source path
+	Dest  string // Destination path
+	Err   *errno.Error
+}
+
+// BatchError is returned by MoveFiles, CopyFiles and RenameFiles when some entries of a
+// batch failed; the others were carried out
+type BatchError struct {
+	Op       string // "move", "copy" or "rename"
+	Failures []FileOpFailure
+}
+
+func (e *BatchError) Error() string {
+	failures := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		failures[i] = fmt.Sprintf("%s -> %s (error code: %d)", failure.Path, failure.Dest, failure.Err.Code)
+	}
+	return fmt.Sprintf("failed to %s some files: %s", e.Op, strings.Join(failures, "; "))
+}
+
+// BatchErrors splits err, returned by a file manager batch of n entries, into the error
+// of each entry, nil for those that succeeded. It returns err itself when the whole
+// batch failed.
+func BatchErrors(n int, err error) ([]error, error) {
+	var batchErr *BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return nil, err
+	}
+	errs := make([]error, n)
+	if batchErr != nil {
+		for _, failure := range batchErr.Failures {
+			if failure.Index < n {
+				errs[failure.Index] = failure.Err
+			}
+		}
+	}
+	return errs, nil
+}
+
+// MoveFilesInto moves the files and directories at sources into destDir in one batch,
+// keeping their names. When some fail, the error is a *BatchError and the others are
+// moved.
+func (c *Client) MoveFilesInto(sources []string, destDir string) error {
+	return c.MoveFiles(MoveRequestsInto(sources, destDir))
+}
+
+// MoveRequestsInto returns the requests moving sources into destDir under their names,
+// as MoveFilesInto does
+func MoveRequestsInto(sources []string, destDir string) []MoveRequest {
+	destDir = strings.TrimRight(destDir, "/")
+	requests := make([]MoveRequest, len(sources))
+	for i, source := range sources {
+		source = strings.TrimRight(source, "/")
+		requests[i] = MoveRequest{Path: source, Dest: destDir, NewName: path.Base(source)}
+	}
+	return requests
+}
+
+// CopyFilesInto copies the files and directories at sources into destDir in one batch,
+// keeping their names. When some fail, the error is a *BatchError and the others are
+// copied.
+func (c *Client) CopyFilesInto(sources []string, destDir string) error {
+	return c.CopyFiles(CopyRequestsInto(sources, destDir))
+}
+
+// CopyRequestsInto returns the requests copying sources into destDir under their names,
+// as CopyFilesInto does
+func CopyRequestsInto(sources []string, destDir string) []CopyRequest {
+	destDir = strings.TrimRight(destDir, "/")
+	requests := make([]CopyRequest, len(sources))
+	for i, source := range sources {
+		source = strings.TrimRight(source, "/")
+		requests[i] = CopyRequest{Path: source, Dest: destDir, NewName: path.Base(source)}
+	}
+	return requests
+}
+
+// batchFailed reports whether the top-level errno of a file manager response means the
+// whole batch failed, rather than some of its entries as listed in its info
+func batchFailed(code int, infos int) bool {
+	return code != 0 && (code != partialFailureErrno || infos == 0)
+}
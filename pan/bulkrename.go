@@ -0,0 +1,191 @@
+package pan
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RenamePattern computes new names for the entries of a directory. It is either a sed
+// style substitution, s/<regexp>/<replacement>/[gi], or a template such as
+// "Trip_%03d{ext}" whose integer verb is replaced by a counter, {name} by the old name
+// without its extension and {ext} by the extension, including its dot.
+type RenamePattern struct {
+	re          *regexp.Regexp // Substitution; nil for a template
+	replacement string
+	all         bool // Replace every match rather than the first
+
+	template string // With each %% replaced by a NUL, so it is not taken for a verb
+	counter  bool   // The template has an integer verb
+}
+
+// backreference matches the sed style \1 references of a replacement
+var backreference = regexp.MustCompile(`\\([0-9])`)
+
+// templateVerb matches the integer verb of a template, such as %d or %03d
+var templateVerb = regexp.MustCompile(`%[-+ 0]*[0-9]*d`)
+
+// ParseRenamePattern parses a substitution starting with "s/" or a template. A name
+// cannot contain a slash, so anything else is a template.
+func ParseRenamePattern(s string) (*RenamePattern, error) {
+	if !strings.HasPrefix(s, "s/") {
+		return parseRenameTemplate(s)
+	}
+
+	parts := splitSubstitution(s[2:])
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid substitution %q: expected s/<regexp>/<replacement>/[gi]", s)
+	}
+	expr, replacement, flags := parts[0], parts[1], parts[2]
+	if expr == "" {
+		return nil, fmt.Errorf("invalid substitution %q: empty regexp", s)
+	}
+
+	pattern := &RenamePattern{replacement: backreference.ReplaceAllString(replacement, "${$1}")}
+	for _, flag := range flags {
+		switch flag {
+		case 'g':
+			pattern.all = true
+		case 'i':
+			expr = "(?i)" + expr
+		default:
+			return nil, fmt.Errorf("invalid substitution %q: unknown flag %q", s, flag)
+		}
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid substitution %q: %w", s, err)
+	}
+	pattern.re = re
+	return pattern, nil
+}
+
+// splitSubstitution splits the regexp, replacement and flags of a substitution at its
+// unescaped slashes; an escaped slash stands for a slash
+func splitSubstitution(s string) []string {
+	var parts []string
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '/':
+			current.WriteByte('/')
+			i++
+		case s[i] == '/':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	return append(parts, current.String())
+}
+
+// parseRenameTemplate checks a template and finds its counter
+func parseRenameTemplate(s string) (*RenamePattern, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty rename pattern")
+	}
+	if strings.Contains(s, "/") {
+		return nil, fmt.Errorf("invalid template %q: a name cannot contain '/'", s)
+	}
+	template := strings.ReplaceAll(s, "%%", "\x00")
+	verbs := templateVerb.FindAllStringIndex(template, -1)
+	if len(verbs) > 1 {
+		return nil, fmt.Errorf("invalid template %q: only one counter is allowed", s)
+	}
+	if strings.Contains(templateVerb.ReplaceAllString(template, ""), "%") {
+		return nil, fmt.Errorf("invalid template %q: only an integer counter such as %%d or %%03d is allowed; write %%%% for a percent sign", s)
+	}
+	return &RenamePattern{template: template, counter: len(verbs) == 1}, nil
+}
+
+// Apply returns the new name of name, with n as the value of a template's counter; a
+// substitution that does not match returns name unchanged
+func (p *RenamePattern) Apply(name string, n int) string {
+	if p.re != nil {
+		if p.all {
+			return p.re.ReplaceAllString(name, p.replacement)
+		}
+		loc := p.re.FindStringSubmatchIndex(name)
+		if loc == nil {
+			return name
+		}
+		expanded := p.re.ExpandString(nil, p.replacement, name, loc)
+		return name[:loc[0]] + string(expanded) + name[loc[1]:]
+	}
+
+	ext := path.Ext(name)
+	result := p.template
+	if p.counter {
+		verb := templateVerb.FindString(result)
+		result = strings.Replace(result, verb, fmt.Sprintf(verb, n), 1)
+	}
+	result = strings.ReplaceAll(result, "\x00", "%")
+	result = strings.ReplaceAll(result, "{name}", strings.TrimSuffix(name, ext))
+	return strings.ReplaceAll(result, "{ext}", ext)
+}
+
+// BulkRenameOptions selects the entries of a directory a pattern is applied to
+type BulkRenameOptions struct {
+	Match string // Glob the names must match, as path.Match; empty matches all
+	Dirs  bool   // Rename directories as well as files
+	Start int    // First value of a template's counter
+}
+
+// PlanBulkRename returns the renames of pattern applied to the entries of files, all in
+// one directory, in name order; entries whose name does not change are left out. It
+// fails, without renaming anything, when a new name is empty or invalid, when two
+// entries would get the same name, or when a new name is already taken in the
+// directory, since the file manager would otherwise silently store a copy under
+// another name.
+func PlanBulkRename(files []FileInfo, pattern *RenamePattern, opts BulkRenameOptions) ([]RenameRequest, error) {
+	if opts.Match != "" {
+		if _, err := path.Match(opts.Match, ""); err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %w", opts.Match, err)
+		}
+	}
+
+	sorted := make([]FileInfo, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ServerFilename < sorted[j].ServerFilename })
+
+	existing := make(map[string]bool, len(sorted))
+	for _, file := range sorted {
+		existing[file.ServerFilename] = true
+	}
+
+	var requests []RenameRequest
+	targets := make(map[string]string)
+	n := opts.Start
+	for _, file := range sorted {
+		name := file.ServerFilename
+		if file.IsDir == 1 && !opts.Dirs {
+			continue
+		}
+		if opts.Match != "" {
+			if ok, _ := path.Match(opts.Match, name); !ok {
+				continue
+			}
+		}
+
+		newName := pattern.Apply(name, n)
+		n++
+		if newName == name {
+			continue
+		}
+		if newName == "" || newName == "." || newName == ".." || strings.Contains(newName, "/") {
+			return nil, fmt.Errorf("invalid new name %q for %s", newName, name)
+		}
+		if other, ok := targets[newName]; ok {
+			return nil, fmt.Errorf("%s and %s would both be renamed to %s", other, name, newName)
+		}
+		if existing[newName] {
+			return nil, fmt.Errorf("cannot rename %s to %s: the name is already taken", name, newName)
+		}
+		targets[newName] = name
+		requests = append(requests, RenameRequest{Path: file.Path, NewName: newName})
+	}
+	return requests, nil
+}
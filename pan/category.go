@@ -0,0 +1,78 @@
+package pan
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FileType is a human-readable file category derived from Baidu's category code
+type FileType string
+
+const (
+	TypeDir     FileType = "dir"
+	TypeImage   FileType = "image"
+	TypeVideo   FileType = "video"
+	TypeAudio   FileType = "audio"
+	TypeDoc     FileType = "doc"
+	TypeArchive FileType = "archive"
+	TypeOther   FileType = "other" // Applications, torrents and everything else
+)
+
+// Baidu's category codes
+const (
+	categoryVideo    = 1
+	categoryAudio    = 2
+	categoryImage    = 3
+	categoryDocument = 4
+)
+
+// archiveExtensions are the extensions classed as archives; Baidu files them under "other"
+var archiveExtensions = map[string]bool{
+	".zip": true, ".rar": true, ".7z": true, ".tar": true, ".gz": true, ".tgz": true,
+	".bz2": true, ".xz": true, ".zst": true, ".iso": true, ".cab": true, ".lz": true,
+}
+
+// Type returns the file's category: TypeDir for directories, TypeArchive for files with
+// an archive extension, and otherwise the type of its Baidu category code
+func (f FileInfo) Type() FileType {
+	if f.IsDir == 1 {
+		return TypeDir
+	}
+	if archiveExtensions[strings.ToLower(path.Ext(f.ServerFilename))] {
+		return TypeArchive
+	}
+	switch f.Category {
+	case categoryVideo:
+		return TypeVideo
+	case categoryAudio:
+		return TypeAudio
+	case categoryImage:
+		return TypeImage
+	case categoryDocument:
+		return TypeDoc
+	}
+	return TypeOther
+}
+
+// ParseFileType parses a file type name, accepting common plurals and synonyms
+// such as "images", "photo", "music" or "document"
+func ParseFileType(name string) (FileType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "dir", "dirs", "directory", "folder":
+		return TypeDir, nil
+	case "image", "images", "photo", "photos", "picture":
+		return TypeImage, nil
+	case "video", "videos", "movie":
+		return TypeVideo, nil
+	case "audio", "music":
+		return TypeAudio, nil
+	case "doc", "docs", "document", "documents":
+		return TypeDoc, nil
+	case "archive", "archives":
+		return TypeArchive, nil
+	case "other":
+		return TypeOther, nil
+	}
+	return "", fmt.Errorf("unknown file type %q (expected image, video, audio, doc, archive, other or dir)", name)
+}
@@ -0,0 +1,198 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// diffURL is the PCS endpoint listing the changes made to the pan since a cursor
+const diffURL = "https://pcs.baidu.com/rest/2.0/pcs/file"
+
+// Change is a file or directory that was created, modified or deleted since a cursor
+type Change struct {
+	FileInfo
+	Deleted bool // The path no longer exists; only Path, FsID and IsDir are meaningful
+}
+
+// ChangeSet is the result of Changes
+type ChangeSet struct {
+	Changes []Change // In path order
+	Cursor  string   // Pass to the next call of Changes to get what changed after this one
+	Reset   bool     // The cursor was too old or unknown: local state must be rebuilt from scratch
+}
+
+// diffEntry is an entry of a diff response
+type diffEntry struct {
+	FsID        int64  `json:"fs_id"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	IsDir       int    `json:"isdir"`
+	IsDelete    int    `json:"isdelete"`
+	MD5         string `json:"md5"`
+	ServerCtime int64  `json:"server_ctime"`
+	ServerMtime int64  `json:"server_mtime"`
+	LocalMtime  int64  `json:"local_mtime"`
+}
+
+// diffResponse is a page of the diff API
+type diffResponse struct {
+	Entries   map[string]diffEntry `json:"entries"`
+	HasMore   bool                 `json:"has_more"`
+	Reset     bool                 `json:"reset"`
+	Cursor    string               `json:"cursor"`
+	ErrorCode int                  `json:"error_code"`
+	ErrorMsg  string               `json:"error_msg"`
+	RequestID int64                `json:"request_id"`
+}
+
+// Changes returns what changed on the pan since cursor, following every page of the
+// incremental listing. An empty cursor starts from scratch: Baidu then reports every
+// file with Reset set, and the returned cursor is the one to keep for the next run.
+func (c *Client) Changes(cursor string) (*ChangeSet, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	ctx := c.context()
+	set := &ChangeSet{}
+	if cursor == "" {
+		cursor = "null"
+	}
+	for {
+		page, err := c.diff(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		if page.Reset {
+			set.Reset = true
+		}
+		for _, entry := range page.Entries {
+			set.Changes = append(set.Changes, Change{
+				FileInfo: FileInfo{
+					FsID:           entry.FsID,
+					Path:           entry.Path,
+					ServerFilename: path.Base(entry.Path),
+					IsDir:          entry.IsDir,
+					Size:           entry.Size,
+					MD5:            entry.MD5,
+					ServerCtime:    entry.ServerCtime,
+					ServerMtime:    entry.ServerMtime,
+					LocalMtime:     entry.LocalMtime,
+				},
+				Deleted: entry.IsDelete != 0,
+			})
+		}
+		cursor = page.Cursor
+		if !page.HasMore || cursor == "" {
+			break
+		}
+	}
+
+	sort.Slice(set.Changes, func(i, j int) bool { return set.Changes[i].Path < set.Changes[j].Path })
+	set.Cursor = cursor
+	return set, nil
+}
+
+// diff fetches one page of changes after cursor
+func (c *Client) diff(ctx context.Context, cursor string) (_ *diffResponse, err error) {
+	ctx, span := c.startSpan(ctx, "diff")
+	defer func() { endSpan(span, err) }()
+
+	params := url.Values{}
+	params.Set("method", "diff")
+	params.Set("access_token", c.getAccessToken())
+	params.Set("cursor", cursor)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(diffURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var response diffResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("diff request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		return nil, err
+	}
+
+	setAPIResult(span, response.ErrorCode, response.RequestID)
+
+	if response.ErrorCode != 0 {
+		return nil, &errno.Error{Code: response.ErrorCode}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("diff request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return &response, nil
+}
+
+// IndexUpdate summarizes an incremental update of the local index
+type IndexUpdate struct {
+	Updated int  // Entries added or refreshed
+	Removed int  // Entries removed, including those beneath removed directories
+	Reset   bool // The whole index was rebuilt from the listing
+}
+
+// UpdateIndex brings idx up to date with the changes made on the pan since its last
+// update, then saves the new cursor. The first update, or one whose cursor Baidu no
+// longer knows, replaces the whole index.
+func (c *Client) UpdateIndex(idx *Index) (*IndexUpdate, error) {
+	cursor, err := idx.Cursor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index cursor: %w", err)
+	}
+
+	set, err := c.Changes(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	update := &IndexUpdate{Reset: set.Reset || cursor == ""}
+	if update.Reset {
+		if _, err := idx.Delete("/"); err != nil {
+			return nil, fmt.Errorf("failed to clear index: %w", err)
+		}
+	}
+
+	var updated []FileInfo
+	for _, change := range set.Changes {
+		if !change.Deleted {
+			updated = append(updated, change.FileInfo)
+			continue
+		}
+		n, err := idx.Delete(change.Path)
+		if err != nil {
+			return update, err
+		}
+		update.Removed += n
+	}
+	if err := idx.PutFileInfo(updated...); err != nil {
+		return update, err
+	}
+	update.Updated = len(updated)
+
+	if err := idx.SetCursor(set.Cursor); err != nil {
+		return update, fmt.Errorf("failed to save index cursor: %w", err)
+	}
+	return update, nil
+}
@@ -0,0 +1,79 @@
+package pan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompareMode says how a sync decides whether a file needs to be transferred again
+type CompareMode string
+
+const (
+	CompareSize      CompareMode = "size"       // Only the sizes are compared
+	CompareMtime     CompareMode = "mtime"      // Only the modification times are compared
+	CompareMD5       CompareMode = "md5"        // Files of equal size are compared by content MD5
+	CompareSizeMtime CompareMode = "size+mtime" // Sizes, then MD5s of files whose modification times differ
+)
+
+// ParseCompareMode parses "size", "mtime", "md5" or "size+mtime"; empty means CompareSizeMtime
+func ParseCompareMode(s string) (CompareMode, error) {
+	switch mode := CompareMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return CompareSizeMtime, nil
+	case CompareSize, CompareMtime, CompareMD5, CompareSizeMtime:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid comparison %q (expected size, mtime, md5 or size+mtime)", s)
+}
+
+// syncComparer returns the function telling whether the local file at rel beneath
+// localDir and its remote counterpart are the same under opts.Compare. Where an MD5 is
+// needed but cannot be compared, as for encrypted syncs or remote files listed without a
+// usable MD5, md5 falls back to the modification times and size+mtime counts the file
+// as changed.
+func (c *Client) syncComparer(localDir string, opts SyncOptions) func(rel string, local, remote syncEntry) bool {
+	md5Equal := func(rel string, remote syncEntry) (equal, ok bool) {
+		if opts.Cipher != nil || !isHexMD5(remote.md5) {
+			return false, false
+		}
+		localPath := filepath.Join(localDir, filepath.FromSlash(rel))
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return false, false
+		}
+		sum, err := c.localMD5(localPath, info)
+		if err != nil {
+			c.logger.Warn("Failed to hash local file for comparison", "path", localPath, "error", err)
+			return false, false
+		}
+		return strings.EqualFold(sum, remote.md5), true
+	}
+
+	return func(rel string, local, remote syncEntry) bool {
+		switch opts.Compare {
+		case CompareSize:
+			return local.size == remote.size
+		case CompareMtime:
+			return local.mtime == remote.mtime
+		case CompareMD5:
+			if local.size != remote.size {
+				return false
+			}
+			if equal, ok := md5Equal(rel, remote); ok {
+				return equal
+			}
+			return local.mtime == remote.mtime
+		default:
+			if local.size != remote.size {
+				return false
+			}
+			if local.mtime == remote.mtime {
+				return true
+			}
+			equal, _ := md5Equal(rel, remote)
+			return equal
+		}
+	}
+}
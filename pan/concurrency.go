@@ -0,0 +1,64 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// minTransferRate is the slowest rate, in bytes per second, a request carrying file data
+// may send at before it times out
+const minTransferRate = 32 * 1024
+
+// DefaultMaxRequests is how many API requests the CLI lets a client have in flight at
+// once unless configured otherwise
+const DefaultMaxRequests = 16
+
+// WithMaxRequests limits the API requests a client has in flight at once to n, across
+// all goroutines, so parallel walks and batch operations don't open hundreds of
+// connections and trip Baidu's abuse detection. Requests over the limit wait for a
+// free slot. Download and upload data connections are not counted. An n of zero or less
+// removes the limit.
+func WithMaxRequests(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.apiSlots = nil
+			return
+		}
+		c.apiSlots = make(chan struct{}, n)
+	}
+}
+
+// acquireAPISlot waits for a free API request slot and returns the function releasing
+// it, or fails when ctx is done first
+func (c *Client) acquireAPISlot(ctx context.Context) (func(), error) {
+	if c.apiSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.apiSlots <- struct{}{}:
+		return func() { <-c.apiSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a free API request slot: %w", ctx.Err())
+	}
+}
+
+// doTransfer sends a request carrying file data, such as an upload slice, which is not
+// counted against the API request limit and whose response is not inspected for an errno.
+// Its timeout grows with the size of the request; see transferTimeout.
+func (c *Client) doTransfer(req *http.Request) (*http.Response, error) {
+	client := *c.client
+	client.Timeout = transferTimeout(c.client.Timeout, req.ContentLength)
+	return c.send(&client, req, false)
+}
+
+// transferTimeout extends the API timeout by the time sending size bytes takes at
+// minTransferRate, so a 32MB slice on a slow uplink is not cut off after the 30 seconds
+// an API call gets. A timeout of zero stays unlimited.
+func transferTimeout(timeout time.Duration, size int64) time.Duration {
+	if timeout <= 0 || size <= 0 {
+		return timeout
+	}
+	return timeout + time.Duration(size)*time.Second/minTransferRate
+}
@@ -0,0 +1,78 @@
+package pan_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan"
+)
+
+func TestWithMaxRequests(t *testing.T) {
+	s := newTree(t)
+	const limit = 2
+
+	// The hooks run while a request holds its slot
+	var inFlight, peak atomic.Int32
+	c := s.NewClient(t, pan.WithMaxRequests(limit),
+		pan.WithRequestHook(func(*http.Request) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond) // Let other walkers pile up on the limit
+		}),
+		pan.WithResponseHook(func(*http.Response, error) {
+			inFlight.Add(-1)
+		}))
+
+	entries := 0
+	err := c.WalkParallel(context.Background(), "/tree", pan.WalkOptions{Workers: 8}, func(file pan.FileInfo, err error) error {
+		entries++
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries != 6+6*4+6*4*3 {
+		t.Errorf("walk visited %d entries, want %d", entries, 6+6*4+6*4*3)
+	}
+	if got := peak.Load(); got > limit {
+		t.Errorf("%d requests were in flight at once, want at most %d", got, limit)
+	}
+}
+
+func TestWithMaxRequestsCancel(t *testing.T) {
+	s := newTree(t)
+	entered, block := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	c := s.NewClient(t, pan.WithMaxRequests(1), pan.WithRequestHook(func(*http.Request) {
+		once.Do(func() { close(entered) })
+		<-block
+	}))
+
+	// The first walk holds the only slot until block is closed
+	started := make(chan error, 1)
+	go func() {
+		started <- c.WalkDir(context.Background(), "/tree", func(file pan.FileInfo, err error) error { return err })
+	}()
+
+	<-entered
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.WalkDir(ctx, "/tree", func(file pan.FileInfo, err error) error { return err })
+	close(block)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WalkDir waiting for a slot returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := <-started; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // CopyResponse represents the response from the copy API
@@ -35,6 +37,15 @@ type CopyRequest struct {
 
 // CopyFile copies a single file or directory from source path to destination directory in Baidu Pan
 func (c *Client) CopyFile(sourcePath, destPath string) error {
+	return c.CopyFiles([]CopyRequest{NewCopyRequest(sourcePath, destPath)})
+}
+
+// NewCopyRequest returns the request copying sourcePath to destPath, which is the
+// directory to copy into when it ends with a slash or has no extension, as CopyFile does
+func NewCopyRequest(sourcePath, destPath string) CopyRequest {
+	// A trailing slash marks the destination as a directory
+	intoDir := isDirectoryPath(destPath)
+
 	// Ensure paths are in the correct format
 	sourcePath = strings.TrimRight(sourcePath, "/")
 	destPath = strings.TrimRight(destPath, "/")
@@ -44,31 +55,37 @@ func (c *Client) CopyFile(sourcePath, destPath string) error {
 	newName := filepath.Base(destPath)
 
 	// If destPath is a directory, use the source filename
-	if strings.HasSuffix(destPath, "/") || isDirectoryPath(destPath) {
+	if intoDir {
 		destDir = destPath
 		newName = GetSourceFileName(sourcePath)
 	}
 
-	copyRequests := []CopyRequest{
-		{
-			Path:    sourcePath,
-			Dest:    destDir,
-			NewName: newName,
-		},
+	return CopyRequest{
+		Path:    sourcePath,
+		Dest:    destDir,
+		NewName: newName,
 	}
-	return c.CopyFiles(copyRequests)
 }
 
 // CopyFiles copies multiple files based on the provided CopyRequest structs
 func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	if len(copyRequests) == 0 {
 		return fmt.Errorf("no files specified for copy operation")
 	}
 
+	if c.DryRun() {
+		ops := make([]dryRunOperation, 0, len(copyRequests))
+		for _, req := range copyRequests {
+			ops = append(ops, dryRunOperation{path: req.Path, detail: fmt.Sprintf("%s -> %s/%s", req.Path, req.Dest, req.NewName)})
+		}
+		c.reportDryRun("filemanager copy", ops)
+		return nil
+	}
+
 	// Convert copy requests to JSON format for the POST body
 	copyRequestsJSON, err := json.Marshal(copyRequests)
 	if err != nil {
@@ -78,7 +95,7 @@ func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
 	// Prepare query parameters
 	params := url.Values{}
 	params.Add("method", "filemanager")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("opera", "copy")
 
 	// Additional parameters that might be required based on API documentation
@@ -86,7 +103,7 @@ func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
 	params.Add("channel", "chunlei")
 	params.Add("web", "1")
 	params.Add("app_id", "250528")
-	params.Add("bdstoken", c.accessToken) // Using access token as bdstoken (common practice)
+	params.Add("bdstoken", c.getAccessToken()) // Using access token as bdstoken (common practice)
 
 	// Create form data for POST body
 	formData := url.Values{}
@@ -95,7 +112,7 @@ func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
 	formData.Add("ondup", "newcopy")
 
 	// Create the request with form-encoded body
-	apiURL := fmt.Sprintf("https://pan.baidu.com/api/filemanager?%s", params.Encode())
+	apiURL := c.endpoint(fileManagerURL) + "?" + params.Encode()
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create copy request: %w", err)
@@ -105,7 +122,7 @@ func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Execute the request
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("copy request failed: %w", err)
 	}
@@ -128,27 +145,31 @@ func (c *Client) CopyFiles(copyRequests []CopyRequest) error {
 		return fmt.Errorf("failed to unmarshal copy response: %w", err)
 	}
 
-	if copyResponse.Errno != 0 {
-		return fmt.Errorf("copy API returned error code %d: %s", copyResponse.Errno, GetCopyErrorMessage(copyResponse.Errno))
+	if batchFailed(copyResponse.Errno, len(copyResponse.Info)) {
+		return &errno.Error{API: "copy", Code: copyResponse.Errno}
 	}
 
 	// Check if any individual files failed to copy
-	var failedCopies []string
+	var failedCopies []FileOpFailure
+	failed := make(map[int]bool)
 	for i, copyInfo := range copyResponse.Info {
-		if copyInfo.Errno != 0 {
+		if copyInfo.Errno != 0 && i < len(copyRequests) {
 			req := copyRequests[i] // Get the corresponding request
-			failedCopies = append(failedCopies, fmt.Sprintf("%s -> %s/%s (error code: %d)", req.Path, req.Dest, req.NewName, copyInfo.Errno))
+			failedCopies = append(failedCopies, FileOpFailure{Index: i, Path: req.Path, Dest: req.Dest + "/" + req.NewName, Err: &errno.Error{API: "copy", Code: copyInfo.Errno}})
+			failed[i] = true
 		}
 	}
 
-	if len(failedCopies) > 0 {
-		return fmt.Errorf("failed to copy some files: %s", strings.Join(failedCopies, "; "))
+	// Print success message for each successfully copied file
+	for i := range copyResponse.Info {
+		if i < len(copyRequests) && !failed[i] {
+			req := copyRequests[i]
+			c.logger.Info(fmt.Sprintf("File '%s' copied successfully to '%s/%s'", req.Path, req.Dest, req.NewName))
+		}
 	}
 
-	// Print success message for each successfully copied file
-	for i, _ := range copyResponse.Info {
-		req := copyRequests[i]
-		PrintSuccess(fmt.Sprintf("File '%s' copied successfully to '%s/%s'", req.Path, req.Dest, req.NewName))
+	if len(failedCopies) > 0 {
+		return &BatchError{Op: "copy", Failures: failedCopies}
 	}
 
 	return nil
@@ -160,46 +181,9 @@ func isDirectoryPath(path string) bool {
 	return strings.HasSuffix(path, "/") || filepath.Ext(path) == ""
 }
 
-// GetCopyErrorMessage returns a human-readable error message for common errno values
-func GetCopyErrorMessage(errno int) string {
-	switch errno {
-	case 0:
-		return "Success"
-	case 2:
-		return "Parameters error"
-	case 3:
-		return "User permission error"
-	case 4:
-		return "Request source error"
-	case 12:
-		return "Operation not allowed or path error"
-	case -9:
-		return "File does not exist"
-	case 111:
-		return "Another asynchronous task is currently executing"
-	case -7:
-		return "Invalid file name"
-	case 108:
-		return "Path error, path does not exist"
-	case 110:
-		return "Target path already exists"
-	case 112:
-		return "Same file already exists in the same directory"
-	case 113:
-		return "File or directory name contains forbidden words"
-	case 114:
-		return "Path too long"
-	case 115:
-		return "Target directory does not exist"
-	case 116:
-		return "Insufficient disk space"
-	case 117:
-		return "File too large"
-	case 31001:
-		return "User has been banned"
-	case 31026:
-		return "File contains illegal content"
-	default:
-		return fmt.Sprintf("Unknown error code: %d", errno)
-	}
+// GetCopyErrorMessage returns a human-readable error message for common errno values.
+//
+// Deprecated: use errno.Message.
+func GetCopyErrorMessage(code int) string {
+	return errno.Message(code)
 }
@@ -0,0 +1,183 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+const (
+	copyTaskBatchSize = 100 // Entries submitted per asynchronous copy task
+	copyTaskRetries   = 2   // Times the entries that failed are submitted again
+)
+
+// CopyOptions controls CopyFilesWithOptions
+type CopyOptions struct {
+	Progress func(done, total int) // Called after each task with the entries copied so far, if set
+}
+
+// copyEntry is a copy submitted to an asynchronous task, with index the position of the
+// CopyRequest it comes from
+type copyEntry struct {
+	CopyRequest
+	index int
+}
+
+// CopyFileWithOptions copies like CopyFile, as asynchronous tasks; see CopyFilesWithOptions
+func (c *Client) CopyFileWithOptions(sourcePath, destPath string, opts CopyOptions) error {
+	return c.CopyFilesWithOptions([]CopyRequest{NewCopyRequest(sourcePath, destPath)}, opts)
+}
+
+// CopyFilesIntoWithOptions copies like CopyFilesInto, as asynchronous tasks; see
+// CopyFilesWithOptions
+func (c *Client) CopyFilesIntoWithOptions(sources []string, destDir string, opts CopyOptions) error {
+	return c.CopyFilesWithOptions(CopyRequestsInto(sources, destDir), opts)
+}
+
+// CopyFilesWithOptions copies like CopyFiles, but submits the copies as asynchronous file
+// manager tasks and polls them until they finish, so large directories do not time out
+// the request. A directory copied to a destination that does not exist yet is created
+// and its entries copied one by one, which lets progress be reported as "N of M entries
+// copied" on the progress output and to opts.Progress. Entries that fail are submitted
+// again, alone, up to twice; those failing still are returned in a *BatchError whose
+// indexes are those of requests.
+func (c *Client) CopyFilesWithOptions(requests []CopyRequest, opts CopyOptions) error {
+	if c.DryRun() {
+		return c.CopyFiles(requests)
+	}
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("no files specified for copy operation")
+	}
+
+	ctx := c.context()
+	entries, err := c.expandCopyRequests(requests)
+	if err != nil {
+		return err
+	}
+
+	total, done := len(entries), 0
+	report := func() {
+		c.printProgress("\rCopied %d of %d entries", done, total)
+		if opts.Progress != nil {
+			opts.Progress(done, total)
+		}
+	}
+	defer c.printProgress("\n")
+	report()
+
+	var failures []FileOpFailure
+	for attempt := 0; len(entries) > 0; attempt++ {
+		if attempt > 0 {
+			c.logger.Warn(fmt.Sprintf("Retrying %d entries that failed to copy", len(entries)))
+		}
+		var failed []copyEntry
+		failures = failures[:0]
+		for start := 0; start < len(entries); start += copyTaskBatchSize {
+			batch := entries[start:min(start+copyTaskBatchSize, len(entries))]
+			codes, err := c.runCopyTask(ctx, batch)
+			if err != nil {
+				return err
+			}
+			for i, code := range codes {
+				entry := batch[i]
+				if code == 0 {
+					done++
+					c.logger.Info(fmt.Sprintf("File '%s' copied successfully to '%s/%s'", entry.Path, entry.Dest, entry.NewName))
+					continue
+				}
+				failed = append(failed, entry)
+				failures = append(failures, FileOpFailure{Index: entry.index, Path: entry.Path,
+					Dest: entry.Dest + "/" + entry.NewName, Err: &errno.Error{API: "copy", Code: code}})
+			}
+			report()
+		}
+		if attempt == copyTaskRetries {
+			break
+		}
+		entries = failed
+	}
+
+	if len(failures) > 0 {
+		return &BatchError{Op: "copy", Failures: failures}
+	}
+	return nil
+}
+
+// expandCopyRequests turns requests into the entries to copy. A directory whose
+// destination does not exist is created and replaced by its children, so its progress
+// can be followed; when the destination exists the server picks a new name for the copy,
+// which only a copy of the directory as a whole gets.
+func (c *Client) expandCopyRequests(requests []CopyRequest) ([]copyEntry, error) {
+	var entries []copyEntry
+	for i, req := range requests {
+		info, err := c.GetDetailedFileInfo(req.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info for %s: %w", req.Path, err)
+		}
+		target := path.Join(req.Dest, req.NewName)
+		if info.IsDir != 1 || c.remoteExists(target) {
+			entries = append(entries, copyEntry{CopyRequest: req, index: i})
+			continue
+		}
+
+		children, err := c.ListFiles(req.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", req.Path, err)
+		}
+		if err := c.EnsureRemoteDirExists(target); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		for _, child := range children {
+			entries = append(entries, copyEntry{
+				CopyRequest: CopyRequest{Path: child.Path, Dest: target, NewName: child.ServerFilename},
+				index:       i,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// remoteExists reports whether remotePath exists; lookups that fail otherwise count as existing
+func (c *Client) remoteExists(remotePath string) bool {
+	_, err := c.GetDetailedFileInfo(remotePath)
+	return err == nil || !(isNotFoundError(err) || strings.HasPrefix(err.Error(), "file not found"))
+}
+
+// runCopyTask copies batch as one asynchronous file manager task, waits for it to finish
+// and returns the errno of each entry, 0 for those copied. The error is for a task that
+// could not be submitted or followed.
+func (c *Client) runCopyTask(ctx context.Context, batch []copyEntry) ([]int, error) {
+	requests := make([]CopyRequest, len(batch))
+	paths := make([]string, len(batch))
+	for i, entry := range batch {
+		requests[i] = entry.CopyRequest
+		paths[i] = entry.Path
+	}
+
+	response, err := c.submitFileTask(ctx, "copy", requests)
+	if err != nil {
+		return nil, err
+	}
+	if response.TaskID == 0 {
+		// The server finished the copy at once and listed the outcome of each entry
+		codes := make([]int, len(batch))
+		for i := range codes {
+			if i < len(response.Info) {
+				codes[i] = response.Info[i].Errno
+			}
+		}
+		return codes, nil
+	}
+
+	task, err := c.WaitTask(ctx, response.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	return task.entryErrnos(paths), nil
+}
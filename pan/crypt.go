@@ -0,0 +1,251 @@
+package pan
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Encrypted file layout: an 8-byte magic and a 16-byte random salt, followed by chunks of
+// up to cryptChunkSize plaintext bytes, each sealed with AES-256-GCM. Every file ends with
+// a chunk flagged as final (possibly empty), so truncation is detected on decryption.
+const (
+	cryptMagic      = "BDFSCRY1"
+	cryptSaltSize   = 16
+	cryptHeaderSize = len(cryptMagic) + cryptSaltSize
+	cryptChunkSize  = 64 * 1024
+	cryptTagSize    = 16
+	cryptKeySize    = 32
+
+	// cryptPasswordSalt and cryptPasswordIterations derive the master key from a password
+	cryptPasswordSalt       = "go-bdfs/crypt/v1"
+	cryptPasswordIterations = 600000
+)
+
+// ErrCryptCorrupt is returned when encrypted content is truncated, tampered with,
+// or was encrypted with a different key
+var ErrCryptCorrupt = errors.New("encrypted content is corrupt or the key is wrong")
+
+// Cipher encrypts and decrypts file content with a 256-bit master key. Each file gets its
+// own key, derived from the master key and a random salt stored in the file header.
+type Cipher struct {
+	masterKey []byte
+}
+
+// NewCipher creates a cipher from a 32-byte master key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != cryptKeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", cryptKeySize, len(key))
+	}
+	return &Cipher{masterKey: append([]byte(nil), key...)}, nil
+}
+
+// NewCipherFromPassword derives the master key from password with PBKDF2-SHA256
+func NewCipherFromPassword(password string) (*Cipher, error) {
+	if password == "" {
+		return nil, fmt.Errorf("encryption password is empty")
+	}
+	key, err := pbkdf2.Key(sha256.New, password, []byte(cryptPasswordSalt), cryptPasswordIterations, cryptKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	return NewCipher(key)
+}
+
+// NewCipherFromKeyFile reads the master key from keyFile, which holds either 32 raw
+// bytes or 64 hex characters
+func NewCipherFromKeyFile(keyFile string) (*Cipher, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(data) == cryptKeySize {
+		return NewCipher(data)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key file must hold %d raw bytes or %d hex characters", cryptKeySize, cryptKeySize*2)
+	}
+	return NewCipher(key)
+}
+
+// EncryptedSize returns the size of the encrypted form of plainSize bytes
+func (c *Cipher) EncryptedSize(plainSize int64) int64 {
+	chunks := plainSize/cryptChunkSize + 1
+	return int64(cryptHeaderSize) + plainSize + chunks*cryptTagSize
+}
+
+// DecryptedSize returns the plaintext size of encSize bytes of encrypted content
+func (c *Cipher) DecryptedSize(encSize int64) (int64, error) {
+	body := encSize - int64(cryptHeaderSize)
+	if body < cryptTagSize {
+		return 0, ErrCryptCorrupt
+	}
+	full, rem := body/(cryptChunkSize+cryptTagSize), body%(cryptChunkSize+cryptTagSize)
+	if rem < cryptTagSize {
+		return 0, ErrCryptCorrupt
+	}
+	return full*cryptChunkSize + rem - cryptTagSize, nil
+}
+
+// fileAEAD returns the AES-256-GCM instance for the file with the given salt
+func (c *Cipher) fileAEAD(salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, c.masterKey, salt, "go-bdfs file key", cryptKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce for chunk index; file keys are unique, so a counter suffices
+func chunkNonce(index uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], index)
+	return nonce
+}
+
+// chunkAD returns the additional data binding a chunk's final flag
+func chunkAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// EncryptReader returns a reader yielding the encrypted form of everything read from r
+func (c *Cipher) EncryptReader(r io.Reader) (io.Reader, error) {
+	salt := make([]byte, cryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := c.fileAEAD(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte(cryptMagic), salt...)
+	return &encryptReader{
+		src:     r,
+		aead:    aead,
+		plain:   make([]byte, cryptChunkSize),
+		pending: header,
+	}, nil
+}
+
+// encryptReader seals its source chunk by chunk as it is read
+type encryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	plain   []byte // Buffer for the next plaintext chunk
+	pending []byte // Encrypted bytes not yet returned
+	index   uint64
+	done    bool
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(e.src, e.plain)
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			return 0, err
+		}
+		e.pending = e.aead.Seal(e.pending[:0], chunkNonce(e.index), e.plain[:n], chunkAD(final))
+		e.index++
+		e.done = final
+	}
+
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// DecryptWriter returns a writer that decrypts what is written to it into w. Close must be
+// called once all content is written; it returns ErrCryptCorrupt if the content was
+// truncated. Data is authenticated chunk by chunk before being passed to w.
+func (c *Cipher) DecryptWriter(w io.Writer) io.WriteCloser {
+	return &decryptWriter{cipher: c, dst: w}
+}
+
+// decryptWriter buffers encrypted input and opens each chunk once it is complete
+type decryptWriter struct {
+	cipher *Cipher
+	dst    io.Writer
+	aead   cipher.AEAD // Nil until the header has been read
+	buf    []byte
+	index  uint64
+	closed bool
+}
+
+func (d *decryptWriter) Write(p []byte) (int, error) {
+	if d.closed {
+		return 0, errors.New("write to closed decrypt writer")
+	}
+	d.buf = append(d.buf, p...)
+
+	if d.aead == nil {
+		if len(d.buf) < cryptHeaderSize {
+			return len(p), nil
+		}
+		if !bytes.Equal(d.buf[:len(cryptMagic)], []byte(cryptMagic)) {
+			return 0, fmt.Errorf("%w: missing header", ErrCryptCorrupt)
+		}
+		aead, err := d.cipher.fileAEAD(d.buf[len(cryptMagic):cryptHeaderSize])
+		if err != nil {
+			return 0, err
+		}
+		d.aead = aead
+		d.buf = d.buf[cryptHeaderSize:]
+	}
+
+	// A full chunk is only known not to be the final one once more data follows it
+	sealed := cryptChunkSize + cryptTagSize
+	for len(d.buf) > sealed {
+		if err := d.open(d.buf[:sealed], false); err != nil {
+			return 0, err
+		}
+		d.buf = d.buf[sealed:]
+	}
+	d.buf = append([]byte(nil), d.buf...) // Release consumed chunks
+	return len(p), nil
+}
+
+// Close decrypts the final chunk and fails if the content ended early
+func (d *decryptWriter) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	if d.aead == nil {
+		return fmt.Errorf("%w: missing header", ErrCryptCorrupt)
+	}
+	return d.open(d.buf, true)
+}
+
+// open authenticates and decrypts one sealed chunk, writing the plaintext to dst
+func (d *decryptWriter) open(sealed []byte, final bool) error {
+	plain, err := d.aead.Open(nil, chunkNonce(d.index), sealed, chunkAD(final))
+	if err != nil {
+		return ErrCryptCorrupt
+	}
+	d.index++
+	_, err = d.dst.Write(plain)
+	return err
+}
@@ -0,0 +1,132 @@
+package pan
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxCurlBodySize limits the request bodies written into curl commands
+const maxCurlBodySize = 4096
+
+// curlPrinter serializes the commands written by concurrent requests
+type curlPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithCurlOutput writes an equivalent curl command to w before each request is sent, to
+// reproduce and report API problems. Credentials are replaced by shell variables such
+// as ${BDFS_ACCESS_TOKEN}, to be set before running the command.
+func WithCurlOutput(w io.Writer) Option {
+	return func(c *Client) {
+		c.curl = &curlPrinter{w: w}
+	}
+}
+
+// printCurl writes the curl command of req, if enabled
+func (c *Client) printCurl(req *http.Request) {
+	if c.curl == nil {
+		return
+	}
+	command := CurlCommand(req)
+	c.curl.mu.Lock()
+	defer c.curl.mu.Unlock()
+	fmt.Fprintln(c.curl.w, command)
+}
+
+// CurlCommand returns a curl command sending req. Credential parameters of the URL and
+// of form bodies are replaced by shell variables named after them, e.g.
+// ${BDFS_ACCESS_TOKEN}; file data, such as upload slices, by a placeholder file name.
+func CurlCommand(req *http.Request) string {
+	args := []string{"curl"}
+	if req.Method != http.MethodGet {
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, `"`+curlPlaceholders(req.URL)+`"`)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "Authorization" || name == "Cookie" {
+			value = "REDACTED"
+		}
+		args = append(args, "-H", shellQuote(name+": "+value))
+	}
+
+	if body := curlBody(req); body != "" {
+		args = append(args, body)
+	}
+	return strings.Join(args, " ")
+}
+
+// curlBody returns the curl argument sending the body of req, or "" if it has none
+func curlBody(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	if req.GetBody == nil || strings.HasPrefix(contentType, "multipart/") || strings.HasPrefix(contentType, "application/octet-stream") {
+		return "--data-binary @<file>"
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "--data-binary @<file>"
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxCurlBodySize+1))
+	if err != nil || len(data) > maxCurlBodySize {
+		return "--data-binary @<file>"
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(data)); err == nil {
+			return `--data-raw "` + encodeWithPlaceholders(values) + `"`
+		}
+	}
+	return "--data-raw " + shellQuote(string(data))
+}
+
+// curlPlaceholders returns u with its credential parameters replaced by shell variables
+func curlPlaceholders(u *url.URL) string {
+	copied := *u
+	copied.RawQuery = encodeWithPlaceholders(u.Query())
+	return strings.NewReplacer(`"`, "%22", "`", "%60").Replace(copied.String())
+}
+
+// encodeWithPlaceholders encodes values as a query, with the values of credential
+// parameters replaced by shell variables; the rest is percent-encoded, so it is safe
+// within double quotes
+func encodeWithPlaceholders(values url.Values) string {
+	placeholders := make(map[string]string)
+	for _, key := range sensitiveParams {
+		if values.Has(key) {
+			name := "BDFS_" + strings.ToUpper(key)
+			if key == "bdstoken" {
+				name = "BDFS_ACCESS_TOKEN" // The client sends the access token as bdstoken
+			}
+			marker := "BDFSPLACEHOLDER" + strings.ToUpper(strings.ReplaceAll(key, "_", ""))
+			values.Set(key, marker)
+			placeholders[marker] = "${" + name + "}"
+		}
+	}
+	encoded := values.Encode()
+	for marker, variable := range placeholders {
+		encoded = strings.ReplaceAll(encoded, marker, variable)
+	}
+	return encoded
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
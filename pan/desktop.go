@@ -0,0 +1,65 @@
+package pan
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// windowsToastScript shows a toast notification with the title and body passed in the
+// BDFS_TOAST_TITLE and BDFS_TOAST_BODY environment variables, so neither needs quoting
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:BDFS_TOAST_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:BDFS_TOAST_BODY)) > $null
+$appID = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($appID).Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// DesktopNotify shows a desktop notification with the given title and message, using
+// notify-send on Linux and other Unix systems, Notification Center (osascript) on macOS
+// and a toast notification (PowerShell) on Windows
+func DesktopNotify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+		cmd.Env = append(os.Environ(), "BDFS_TOAST_TITLE="+title, "BDFS_TOAST_BODY="+message)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=go-bdfs", title, message)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to show desktop notification: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// OpenInBrowser opens rawURL in the default browser, using xdg-open on Linux and other
+// Unix systems, open on macOS and the URL protocol handler on Windows
+func OpenInBrowser(rawURL string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to open browser: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package pan
+
+import (
+	"context"
+	"strings"
+)
+
+// ManifestDiff lists the differences between two manifests. Paths are relative to
+// each manifest's root, so a tree can be compared after it has been moved.
+type ManifestDiff struct {
+	Added   []ManifestEntry  `json:"added"`   // Only in the new manifest
+	Removed []ManifestEntry  `json:"removed"` // Only in the old manifest
+	Changed []ManifestChange `json:"changed"` // In both, with different content
+}
+
+// ManifestChange holds the old and new entry of a changed file
+type ManifestChange struct {
+	Path string        `json:"path"` // Relative to the manifest roots
+	Old  ManifestEntry `json:"old"`
+	New  ManifestEntry `json:"new"`
+}
+
+// Empty reports whether the manifests had no differences
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffManifests compares two manifests. A file counts as changed when its size differs,
+// when both entries carry an MD5 and they differ, or, without MD5s to compare, when its
+// server modification time differs. Entries in the result are sorted by path.
+func DiffManifests(oldManifest, newManifest *Manifest) *ManifestDiff {
+	oldFiles := manifestByRelPath(oldManifest)
+	newFiles := manifestByRelPath(newManifest)
+	diff := &ManifestDiff{}
+
+	// Manifests are sorted by path, so walking them in order keeps the result sorted
+	for _, entry := range oldManifest.Files {
+		rel := manifestRelPath(oldManifest.Root, entry.Path)
+		newEntry, ok := newFiles[rel]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, entry)
+		case manifestEntryChanged(entry, newEntry):
+			diff.Changed = append(diff.Changed, ManifestChange{Path: rel, Old: entry, New: newEntry})
+		}
+	}
+	for _, entry := range newManifest.Files {
+		if _, ok := oldFiles[manifestRelPath(newManifest.Root, entry.Path)]; !ok {
+			diff.Added = append(diff.Added, entry)
+		}
+	}
+	return diff
+}
+
+// DiffWithRemote exports the current state of root and compares it with oldManifest
+func (c *Client) DiffWithRemote(ctx context.Context, oldManifest *Manifest, root string) (*ManifestDiff, error) {
+	current, err := c.ExportManifest(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	return DiffManifests(oldManifest, current), nil
+}
+
+// manifestEntryChanged reports whether two entries for the same path describe different content
+func manifestEntryChanged(a, b ManifestEntry) bool {
+	if a.Size != b.Size {
+		return true
+	}
+	if isHexMD5(a.MD5) && isHexMD5(b.MD5) {
+		return !strings.EqualFold(a.MD5, b.MD5)
+	}
+	return a.ServerMtime != b.ServerMtime
+}
+
+// manifestByRelPath indexes the entries of manifest by their path relative to its root
+func manifestByRelPath(manifest *Manifest) map[string]ManifestEntry {
+	files := make(map[string]ManifestEntry, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		files[manifestRelPath(manifest.Root, entry.Path)] = entry
+	}
+	return files
+}
+
+// manifestRelPath returns filePath relative to root
+func manifestRelPath(root, filePath string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(filePath, normalizeRemoteDir(root)), "/")
+}
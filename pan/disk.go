@@ -6,6 +6,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // DiskInfoResponse represents the response from the disk info API
@@ -20,27 +22,24 @@ type DiskInfoResponse struct {
 
 // GetDiskInfo gets the user's cloud storage usage information
 func (c *Client) GetDiskInfo() (*DiskInfoResponse, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
 	}
 
 	params := url.Values{}
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("checkfree", "1")      // Check free information
 	params.Add("checkexpire", "1")    // Check expiration information
 
 	// Baidu Pan quota API endpoint
-	apiURL := "https://pan.baidu.com/api/quota"
+	apiURL := c.endpoint("https://pan.baidu.com/api/quota")
 
 	req, err := http.NewRequest("GET", apiURL+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set User-Agent as required by Baidu Pan API
-	req.Header.Set("User-Agent", "pan.baidu.com")
-
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +61,7 @@ func (c *Client) GetDiskInfo() (*DiskInfoResponse, error) {
 	}
 
 	if response.Errno != 0 {
-		return nil, fmt.Errorf("API returned error code %d", response.Errno)
+		return nil, &errno.Error{Code: int(response.Errno)}
 	}
 
 	return &response, nil
@@ -0,0 +1,53 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrInsufficientSpace is returned when a download would not fit on the local disk
+var ErrInsufficientSpace = errors.New("not enough free disk space")
+
+// errFreeSpaceUnsupported is returned by freeSpace on platforms where it is not implemented
+var errFreeSpaceUnsupported = errors.New("free space check not supported on this platform")
+
+// FreeSpace returns the bytes available to the current user on the file system that
+// holds localPath, or that would hold it: the nearest existing parent is checked when
+// localPath does not exist yet
+func FreeSpace(localPath string) (int64, error) {
+	dir, err := filepath.Abs(localPath)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return freeSpace(dir)
+}
+
+// CheckFreeSpace returns an error wrapping ErrInsufficientSpace when need bytes would not
+// fit in the free space of the file system holding localPath. Platforms where the free
+// space cannot be read pass the check.
+func CheckFreeSpace(localPath string, need int64) error {
+	free, err := FreeSpace(localPath)
+	if errors.Is(err, errFreeSpaceUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check free disk space: %w", err)
+	}
+	if need > free {
+		return fmt.Errorf("%w: the download needs %s but only %s is free on the disk holding %s",
+			ErrInsufficientSpace, FormatBytes(need), FormatBytes(free), localPath)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package pan
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the bytes available to the current user on the volume holding dir
+func freeSpace(dir string) (int64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
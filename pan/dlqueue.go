@@ -0,0 +1,310 @@
+package pan
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultDownloadTransfers is the number of files DownloadFiles downloads concurrently by default
+const DefaultDownloadTransfers = 4
+
+// minDownloadSegment is the smallest byte range a file is split into for a segmented
+// download; smaller files use fewer connections
+const minDownloadSegment = 8 * 1024 * 1024
+
+// downloadProgressInterval is how often DownloadFiles and UploadFiles redraw their progress line
+const downloadProgressInterval = 500 * time.Millisecond
+
+// DownloadJob is one file for DownloadFiles to download
+type DownloadJob struct {
+	Remote string
+	Local  string
+	Size   int64 // Used for the overall progress; 0 if unknown
+}
+
+// DownloadFilesOptions controls DownloadFiles; the zero value uses the defaults
+type DownloadFilesOptions struct {
+	Transfers int             // Files downloaded concurrently; 0 uses DefaultDownloadTransfers
+	Download  DownloadOptions // Options for each file, including Connections per file
+
+	// Finished, if set, is called after each file with its outcome (err is nil on
+	// success). Calls are never concurrent.
+	Finished func(job DownloadJob, err error)
+}
+
+// DownloadFilesResult counts the outcomes of DownloadFiles
+type DownloadFilesResult struct {
+	Downloaded int
+	Failed     int
+	Bytes      int64 // Size of the files downloaded
+}
+
+// DownloadDirJobs returns a job for every file beneath the remote directory remoteDir,
+// downloading it to the same relative path beneath localDir
+func (c *Client) DownloadDirJobs(ctx context.Context, remoteDir, localDir string) ([]DownloadJob, error) {
+	root := path.Clean(remoteDir)
+	var jobs []DownloadJob
+	for file, err := range c.ListAll(ctx, root) {
+		if err != nil {
+			return nil, err
+		}
+		if file.IsDir != 0 {
+			continue
+		}
+		rel := manifestRelPath(root, file.Path)
+		jobs = append(jobs, DownloadJob{
+			Remote: file.Path,
+			Local:  filepath.Join(localDir, filepath.FromSlash(rel)),
+			Size:   file.Size,
+		})
+	}
+	return jobs, nil
+}
+
+// DownloadFiles downloads jobs with up to opts.Transfers files in flight, each split into
+// opts.Download.Connections byte ranges when large enough. Instead of a line per file, a
+// single progress line shows the files finished, the bytes downloaded and the overall
+// speed. A failed file is counted and the others are still downloaded. Cancelling ctx
+// stops starting new files and returns an error wrapping ErrInterrupted; the files in
+// flight stop as well when ctx is the client's context (see WithContext). Unless
+// opts.Download.IgnoreSpace is set, nothing is downloaded when the jobs' total size
+// exceeds the free space of the first job's local disk.
+func (c *Client) DownloadFiles(ctx context.Context, jobs []DownloadJob, opts DownloadFilesOptions) (DownloadFilesResult, error) {
+	var result DownloadFilesResult
+	transfers := opts.Transfers
+	if transfers <= 0 {
+		transfers = DefaultDownloadTransfers
+	}
+	fileOpts := opts.Download
+	fileOpts.NoProgress = true
+
+	var total int64
+	for _, job := range jobs {
+		total += job.Size
+	}
+	if !opts.Download.IgnoreSpace && len(jobs) > 0 {
+		if err := CheckFreeSpace(jobs[0].Local, total); err != nil {
+			return result, err
+		}
+	}
+
+	var mu sync.Mutex // Guards result and serializes opts.Finished
+	start := time.Now()
+	startBytes := c.Stats().BytesDown
+	progress := func() {
+		mu.Lock()
+		done := result.Downloaded + result.Failed
+		mu.Unlock()
+		bytes := c.Stats().BytesDown - startBytes
+		line := fmt.Sprintf("\r%d / %d files | %s", done, len(jobs), FormatBytes(bytes))
+		if total > 0 {
+			line += fmt.Sprintf(" / %s (%.2f%%)", FormatBytes(total), float64(bytes)/float64(total)*100)
+		}
+		if seconds := time.Since(start).Seconds(); seconds > 0 {
+			line += fmt.Sprintf(" | %s/s", FormatBytes(int64(float64(bytes)/seconds)))
+		}
+		c.printProgress("%s", line)
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(downloadProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress()
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+	queued := make([]string, len(jobs))
+	for i, job := range jobs {
+		queued[i] = "download " + job.Remote
+	}
+	c.setQueue(queued)
+	defer c.setQueue(nil)
+
+	queue := make(chan DownloadJob)
+	var wg sync.WaitGroup
+	for range min(transfers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				c.dequeue("download " + job.Remote)
+				err := c.DownloadFileToPathWithOptions(job.Remote, job.Local, fileOpts)
+				mu.Lock()
+				switch {
+				case errors.Is(err, ErrInterrupted):
+					// Neither downloaded nor failed: it resumes on the next run
+				case err != nil:
+					result.Failed++
+				default:
+					result.Downloaded++
+					result.Bytes += job.Size
+				}
+				if opts.Finished != nil {
+					opts.Finished(job, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	close(stopProgress)
+	<-progressDone
+	if len(jobs) > 0 {
+		progress()
+		c.printProgress("\n")
+	}
+	if ctx.Err() != nil {
+		return result, fmt.Errorf("%w: %d of %d files downloaded", ErrInterrupted, result.Downloaded, len(jobs))
+	}
+	return result, nil
+}
+
+// downloadSegments returns how many byte ranges a file of size bytes is downloaded in with
+// the given number of connections, keeping every range at least minDownloadSegment long
+func downloadSegments(size int64, connections int) int {
+	return int(max(1, min(int64(connections), size/minDownloadSegment)))
+}
+
+// downloadSegmented downloads the bytes of filePath not in done to localPath as up to
+// segments concurrent byte ranges, each written at its offset, and returns the MD5 of
+// the file when verify is set. Progress is written to output, if set. If ctx is
+// cancelled, the partial file is kept and the ranges on disk passed to save, if set, and
+// an error wrapping ErrInterrupted is returned.
+func (c *Client) downloadSegmented(ctx context.Context, filePath, localPath string, fileInfo *FileInfo, segments int, done []ByteRange, verify bool, output io.Writer, save func(done []ByteRange)) (string, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if len(done) == 0 {
+		flags |= os.O_TRUNC
+	}
+	outFile, err := os.OpenFile(localPath, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer outFile.Close()
+	if err := outFile.Truncate(fileInfo.Size); err != nil {
+		os.Remove(localPath)
+		return "", fmt.Errorf("failed to allocate local file: %w", err)
+	}
+
+	progress := &lockedWriter{w: &ProgressWriter{
+		writer:     io.Discard,
+		totalSize:  fileInfo.Size,
+		downloaded: DownloadState{Done: done}.Downloaded(),
+		fileName:   fileInfo.ServerFilename,
+		output:     output,
+		transfer:   transferFrom(ctx),
+	}}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ranges := splitRanges(missingRanges(done, fileInfo.Size), segments, minDownloadSegment)
+	written := make([]int64, len(ranges))
+	errs := make([]error, len(ranges))
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := c.openRange(ctx, filePath, r.Start, r.End-r.Start)
+			if err != nil {
+				errs[i] = err
+				cancel()
+				return
+			}
+			defer body.Close()
+			written[i], err = io.Copy(io.MultiWriter(io.NewOffsetWriter(outFile, r.Start), progress), body)
+			c.observeTransfer("download", written[i])
+			if err == nil && written[i] != r.End-r.Start {
+				err = fmt.Errorf("range at %d ended after %d of %d bytes", r.Start, written[i], r.End-r.Start)
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to write file content to local file: %w", err)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if output != nil {
+		fmt.Fprint(output, "\n")
+	}
+
+	if parent.Err() != nil {
+		outFile.Close()
+		if save == nil {
+			os.Remove(localPath)
+			return "", fmt.Errorf("%w: download of %s stopped", ErrInterrupted, filePath)
+		}
+		for i, r := range ranges {
+			done = append(done, ByteRange{r.Start, r.Start + written[i]})
+		}
+		done = mergeRanges(done)
+		save(done)
+		return "", fmt.Errorf("%w: %s of %s downloaded", ErrInterrupted, FormatBytes(DownloadState{Done: done}.Downloaded()), filePath)
+	}
+	// Report the failure that cancelled the other ranges rather than their cancellation
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		outFile.Close()
+		os.Remove(localPath)
+		return "", firstErr
+	}
+
+	if !verify {
+		return "", outFile.Close()
+	}
+	// The ranges arrive out of order, so the file is hashed once it is complete
+	if _, err := outFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := md5.New()
+	if _, err := io.Copy(hash, outFile); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", localPath, err)
+	}
+	if err := outFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close local file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// lockedWriter serializes writes to w from concurrent goroutines
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
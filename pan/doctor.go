@@ -0,0 +1,281 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// CheckStatus is the outcome of a health check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"   // Nothing to do
+	CheckWarn CheckStatus = "warn" // Works, but should be fixed
+	CheckFail CheckStatus = "fail" // Will make commands fail
+)
+
+// CheckResult is the outcome of one health check with, when it did not pass, how to fix it
+type CheckResult struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Fix    string      `json:"fix,omitempty"`
+}
+
+// maxClockSkew is the largest difference from Baidu's clock that is not reported; token
+// expiry is tracked with the local clock
+const maxClockSkew = time.Minute
+
+// doctorEndpoints are the Baidu hosts the client talks to
+var doctorEndpoints = []struct{ name, url string }{
+	{"openapi.baidu.com (authorization)", "https://openapi.baidu.com/"},
+	{"pan.baidu.com (file API)", "https://pan.baidu.com/"},
+	{"d.pcs.baidu.com (uploads and downloads)", "https://d.pcs.baidu.com/"},
+}
+
+// Doctor checks everything the client needs to work: the token file and its directory,
+// the reachability of each Baidu endpoint, the local clock, and whether the stored token
+// is still accepted. It never modifies the token file. Tokens given with WithTokens are
+// checked in place of the token file.
+func (c *Client) Doctor(ctx context.Context) []CheckResult {
+	var results []CheckResult
+	tokenUsable := c.getAccessToken() != ""
+	if c.tokensInjected {
+		results = append(results, checkInjectedTokens(tokenUsable))
+	} else {
+		// In static-token mode the token file is never written, so its directory may be read-only
+		if !c.staticToken {
+			results = c.checkTokenDir()
+		}
+		var tokenResults []CheckResult
+		tokenResults, tokenUsable = c.checkTokenFile()
+		results = append(results, tokenResults...)
+	}
+
+	endpointResults, skew, measured := c.checkEndpoints(ctx)
+	results = append(results, endpointResults...)
+	results = append(results, checkClock(skew, measured))
+
+	if tokenUsable {
+		results = append(results, c.checkTokenAccepted())
+	}
+	return results
+}
+
+// checkInjectedTokens reports on tokens given with WithTokens; hasAccessToken is false
+// when only a refresh token was given, which Authorize exchanges for an access token
+func checkInjectedTokens(hasAccessToken bool) CheckResult {
+	if hasAccessToken {
+		return CheckResult{Name: "Injected token", Status: CheckOK, Detail: "an access token was given; the token file is not used"}
+	}
+	return CheckResult{
+		Name:   "Injected token",
+		Status: CheckWarn,
+		Detail: "only a refresh token was given; it is exchanged for an access token when a command runs",
+		Fix:    "Also inject the access token to skip the refresh",
+	}
+}
+
+// checkTokenDir checks that new tokens can be written next to the token file
+func (c *Client) checkTokenDir() []CheckResult {
+	dir := filepath.Dir(c.tokenFile)
+	result := CheckResult{Name: "Token directory", Detail: dir}
+
+	f, err := os.CreateTemp(dir, ".bdfs-doctor-*")
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+		result.Fix = fmt.Sprintf("Create the directory and make it writable by this user (mkdir -p %s), or point token_path elsewhere", dir)
+		return []CheckResult{result}
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	result.Status = CheckOK
+	result.Detail = dir + " is writable"
+	return []CheckResult{result}
+}
+
+// checkTokenFile checks the token file's permissions, contents and expiry, and loads the
+// tokens it holds. It reports whether an access token that has not expired was loaded.
+func (c *Client) checkTokenFile() ([]CheckResult, bool) {
+	info, err := os.Stat(c.tokenFile)
+	if os.IsNotExist(err) {
+		return []CheckResult{{
+			Name:   "Token file",
+			Status: CheckWarn,
+			Detail: c.tokenFile + " does not exist yet",
+			Fix:    "Run any command (e.g. 'go-bdfs di') to authorize with the device code flow",
+		}}, false
+	}
+	if err != nil {
+		return []CheckResult{{Name: "Token file", Status: CheckFail, Detail: err.Error(), Fix: "Check the permissions of the token file's directory"}}, false
+	}
+
+	var results []CheckResult
+	perm := CheckResult{Name: "Token file permissions", Status: CheckOK, Detail: info.Mode().Perm().String()}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		perm.Status = CheckWarn
+		perm.Detail = fmt.Sprintf("%s is readable by other users (%s)", c.tokenFile, info.Mode().Perm())
+		perm.Fix = "chmod 600 " + c.tokenFile
+	}
+	results = append(results, perm)
+
+	data, err := os.ReadFile(c.tokenFile)
+	if err == nil {
+		var tokenFile TokenFile
+		if err = json.Unmarshal(data, &tokenFile); err == nil && tokenFile.AccessToken == "" {
+			err = fmt.Errorf("no access token in file")
+		}
+	}
+	if err != nil {
+		return append(results, CheckResult{
+			Name:   "Token file",
+			Status: CheckFail,
+			Detail: fmt.Sprintf("%s cannot be used: %v", c.tokenFile, err),
+			Fix:    fmt.Sprintf("Delete %s and run any command to authorize again", c.tokenFile),
+		}), false
+	}
+	if err := c.LoadTokens(); err != nil {
+		return append(results, CheckResult{Name: "Token file", Status: CheckFail, Detail: err.Error()}), false
+	}
+
+	c.mu.RLock()
+	expires := c.tokenCreatedAt.Add(time.Duration(c.expiresIn) * time.Second)
+	hasRefresh := c.refreshToken != ""
+	c.mu.RUnlock()
+
+	expiry := CheckResult{Name: "Token expiry", Status: CheckOK, Detail: "access token expires " + expires.Format(time.DateTime)}
+	switch {
+	case c.staticToken && expires.Before(time.Now()):
+		expiry.Status = CheckFail
+		expiry.Detail = "access token expired " + expires.Format(time.DateTime) + " and static-token mode does not refresh it"
+		expiry.Fix = fmt.Sprintf("Replace %s with a token that has not expired", c.tokenFile)
+	case c.staticToken:
+		expiry.Detail += "; static-token mode does not refresh it"
+	case c.IsTokenExpired() && !hasRefresh:
+		expiry.Status = CheckFail
+		expiry.Detail = "access token expires " + expires.Format(time.DateTime) + " and there is no refresh token"
+		expiry.Fix = fmt.Sprintf("Delete %s and run any command to authorize again", c.tokenFile)
+	case expires.Before(time.Now()):
+		expiry.Status = CheckWarn
+		expiry.Detail = "access token expired " + expires.Format(time.DateTime)
+		expiry.Fix = "Run 'go-bdfs ar' to refresh it (commands also refresh it automatically)"
+	case c.IsTokenExpired():
+		expiry.Detail += "; it will be refreshed on the next command"
+	}
+	return append(results, expiry), expires.After(time.Now())
+}
+
+// checkEndpoints checks that each Baidu endpoint answers over HTTPS and returns how far
+// the first one's clock is ahead of the local clock; measured is false if none reported its time
+func (c *Client) checkEndpoints(ctx context.Context) (results []CheckResult, skew time.Duration, measured bool) {
+	for _, endpoint := range doctorEndpoints {
+		result := CheckResult{Name: "Endpoint " + endpoint.name}
+		start := time.Now()
+		date, err := c.probe(ctx, c.endpoint(endpoint.url))
+		if err != nil {
+			result.Status = CheckFail
+			result.Detail = err.Error()
+			result.Fix = "Check your network connection, DNS, proxy (HTTPS_PROXY) and firewall settings for this host"
+		} else {
+			result.Status = CheckOK
+			result.Detail = fmt.Sprintf("reachable in %s", time.Since(start).Round(time.Millisecond))
+			if !measured && !date.IsZero() {
+				// Compare with the local time halfway through the request
+				skew = date.Sub(start.Add(time.Since(start) / 2))
+				measured = true
+			}
+		}
+		results = append(results, result)
+	}
+	return results, skew, measured
+}
+
+// probe sends a HEAD request to endpoint and returns the server's Date header; any HTTP
+// response counts as reachable
+func (c *Client) probe(ctx context.Context, endpoint string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	return date, nil
+}
+
+// checkClock reports how far Baidu's clock is ahead of the local clock
+func checkClock(skew time.Duration, measured bool) CheckResult {
+	result := CheckResult{Name: "Clock"}
+	if !measured {
+		result.Status = CheckWarn
+		result.Detail = "could not compare with Baidu's clock"
+		return result
+	}
+	// The Date header has one-second resolution
+	skew = skew.Round(time.Second)
+	if skew.Abs() <= maxClockSkew {
+		result.Status = CheckOK
+		result.Detail = fmt.Sprintf("within %s of Baidu's clock", maxClockSkew)
+		return result
+	}
+	result.Status = CheckWarn
+	if skew > 0 {
+		result.Detail = fmt.Sprintf("local clock is %s behind Baidu's", skew)
+	} else {
+		result.Detail = fmt.Sprintf("local clock is %s ahead of Baidu's", -skew)
+	}
+	result.Fix = "Enable time synchronization (NTP); token expiry and refresh are timed with the local clock"
+	return result
+}
+
+// checkTokenAccepted checks that the API accepts the loaded access token
+func (c *Client) checkTokenAccepted() CheckResult {
+	result := CheckResult{Name: "Access token"}
+	info, err := c.GetUserInfo()
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// The request never got an answer; its URL would reveal the token
+		result.Status = CheckWarn
+		result.Detail = fmt.Sprintf("could not be checked: %v", urlErr.Err)
+		return result
+	}
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("rejected by the API: %v", err)
+		result.Fix = "Run 'go-bdfs ar' to refresh it; if that fails, delete the token file and authorize again"
+		if c.tokensInjected {
+			result.Fix = "Inject a valid access token, or a refresh token to exchange for one"
+		}
+		return result
+	}
+	result.Status = CheckOK
+	result.Detail = fmt.Sprintf("valid for %s (%s)", info.NetdiskName, vipName(info.VIPType))
+	return result
+}
+
+// vipName returns the name of a VIP level
+func vipName(vipType int) string {
+	switch vipType {
+	case 1:
+		return "VIP"
+	case 2:
+		return "SVIP"
+	}
+	return "normal account"
+}
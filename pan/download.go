@@ -1,31 +1,45 @@
 package pan
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DownloadFile downloads a file from Baidu Pan
 func (c *Client) DownloadFile(filePath string) (*http.Response, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
-	}
-
-	params := url.Values{}
-	params.Add("method", "download")
-	params.Add("access_token", c.accessToken)
-	params.Add("path", filePath)
+	return c.downloadFile(context.Background(), filePath)
+}
 
-	req, err := http.NewRequest("GET", downloadFileURL+"?"+params.Encode(), nil)
+// downloadFile issues the download request for filePath bound to ctx
+func (c *Client) downloadFile(ctx context.Context, filePath string) (*http.Response, error) {
+	req, err := c.newDownloadRequest(ctx, filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.downloadClient.Do(req) // Use downloadClient with longer timeout
+	return c.doDownload(req) // Use downloadClient with longer timeout
+}
+
+// newDownloadRequest builds the download request for filePath bound to ctx: a GET of the
+// file's dlink, which is looked up first
+func (c *Client) newDownloadRequest(ctx context.Context, filePath string) (*http.Request, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	return c.newDlinkRequest(ctx, filePath)
 }
 
 // ProgressWriter wraps an io.Writer and reports progress
@@ -34,11 +48,14 @@ type ProgressWriter struct {
 	totalSize  int64
 	downloaded int64
 	fileName   string
+	output     io.Writer       // Destination for progress lines, nil to disable
+	transfer   *activeTransfer // Transfer reported by Status, nil if untracked
 }
 
 func (pw *ProgressWriter) Write(p []byte) (int, error) {
 	n, err := pw.writer.Write(p)
 	pw.downloaded += int64(n)
+	pw.transfer.add(n)
 
 	// Calculate percentage
 	var percent float64
@@ -47,19 +64,59 @@ func (pw *ProgressWriter) Write(p []byte) (int, error) {
 	}
 
 	// Print progress on the same line
-	fmt.Printf("\rDownloading %s: %d / %d bytes (%.2f%%)",
-		pw.fileName, pw.downloaded, pw.totalSize, percent)
-	os.Stdout.Sync()
+	if pw.output != nil {
+		fmt.Fprintf(pw.output, "\rDownloading %s: %d / %d bytes (%.2f%%)",
+			pw.fileName, pw.downloaded, pw.totalSize, percent)
+	}
 
 	return n, err
 }
 
+// defaultVerifyRetries is how many times a download is retried after an MD5 mismatch
+const defaultVerifyRetries = 2
+
+// DownloadOptions controls optional download behaviour; the zero value uses the defaults
+type DownloadOptions struct {
+	NoPreserveTimes bool    // Don't set the local file's modification time from the remote metadata
+	NoVerify        bool    // Skip comparing the downloaded file's MD5 against the remote metadata
+	VerifyRetries   int     // Re-downloads after an MD5 mismatch; 0 uses the default, negative disables retries
+	Cipher          *Cipher // Decrypt content uploaded with the same key, nil to save it as-is
+	Connections     int     // Byte ranges of one file downloaded concurrently; <= 1 downloads it as one stream
+	NoProgress      bool    // Don't print the per-file progress line, e.g. when a caller reports overall progress
+	IgnoreSpace     bool    // Start the download even if the local disk seems too full for it
+}
+
+// verifyRetries returns the effective number of retries after an MD5 mismatch
+func (o DownloadOptions) verifyRetries() int {
+	switch {
+	case o.VerifyRetries < 0:
+		return 0
+	case o.VerifyRetries == 0:
+		return defaultVerifyRetries
+	default:
+		return o.VerifyRetries
+	}
+}
+
 // DownloadFileToPath downloads a file from Baidu Pan and saves it to the specified local path
 func (c *Client) DownloadFileToPath(filePath, localPath string) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	return c.DownloadFileToPathWithOptions(filePath, localPath, DownloadOptions{})
+}
+
+// DownloadFileToPathWithOptions downloads a file from Baidu Pan to localPath with the given options.
+// By default the local MD5 is verified against the remote metadata and the remote
+// modification time is restored on the local file.
+func (c *Client) DownloadFileToPathWithOptions(filePath, localPath string, opts DownloadOptions) (err error) {
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
+	ctx, span := c.startSpan(c.context(), "download", attribute.String("bdfs.path", filePath))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
+	var size int64
+	defer func() { c.recordTransfer("download", localPath, filePath, size, start, err) }()
+
 	// Check if the directory for the local path exists, create if not
 	dir := filepath.Dir(localPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -70,60 +127,279 @@ func (c *Client) DownloadFileToPath(filePath, localPath string) error {
 	fileInfo, err := c.GetFileInfo(filePath)
 	if err != nil {
 		// If we can't get file info, proceed with download anyway but without size info
-		PrintError(fmt.Sprintf("Warning: Could not get file size information: %v", err))
+		c.logger.Warn("Could not get file size information", "error", err)
+	} else {
+		size = fileInfo.Size
+		if !opts.IgnoreSpace {
+			if err := CheckFreeSpace(localPath, size); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Only verify when the remote metadata carries a usable MD5
+	expectedMD5 := ""
+	if !opts.NoVerify && fileInfo != nil && isHexMD5(fileInfo.MD5) {
+		expectedMD5 = strings.ToLower(fileInfo.MD5)
+	}
+
+	output := c.progressOutput
+	if opts.NoProgress {
+		output = nil
+	}
+	segments := 1
+	if opts.Cipher == nil && fileInfo != nil {
+		segments = downloadSegments(fileInfo.Size, opts.Connections)
 	}
 
+	// Continue an interrupted download whose partial file and state were kept
+	var done []ByteRange
+	var saveProgress func(done []ByteRange)
+	if c.resume != nil && opts.Cipher == nil && fileInfo != nil {
+		done = c.downloadResume(localPath, filePath, fileInfo)
+		if len(done) > 0 {
+			c.logger.Info(fmt.Sprintf("Resuming download of '%s': %s of %s already downloaded",
+				filePath, FormatBytes(DownloadState{Done: done}.Downloaded()), FormatBytes(fileInfo.Size)))
+		}
+		saveProgress = func(done []ByteRange) { c.saveDownloadResume(localPath, filePath, fileInfo, done) }
+	}
+
+	transfer := c.beginTransfer("download", filePath, size)
+	defer c.endTransfer(transfer)
+	ctx = withTransfer(ctx, transfer)
+
+	retries := opts.verifyRetries()
+	for attempt := 0; ; attempt++ {
+		transfer.done.Store(DownloadState{Done: done}.Downloaded())
+		var localMD5 string
+		if segments > 1 || len(done) > 0 {
+			localMD5, err = c.downloadSegmented(ctx, filePath, localPath, fileInfo, segments, done, expectedMD5 != "", output, saveProgress)
+		} else {
+			localMD5, err = c.downloadAttempt(ctx, filePath, localPath, fileInfo, opts.Cipher, output, saveProgress)
+		}
+		done = nil // A retry after an MD5 mismatch starts over
+		if err != nil {
+			if !errors.Is(err, ErrInterrupted) {
+				c.forgetDownloadResume(localPath, filePath)
+			}
+			return err
+		}
+		if expectedMD5 == "" || localMD5 == expectedMD5 {
+			break
+		}
+
+		// The local copy is corrupt: never leave it behind
+		os.Remove(localPath)
+		c.forgetDownloadResume(localPath, filePath)
+		if attempt >= retries {
+			return fmt.Errorf("MD5 mismatch for %s after %d attempts: expected %s, got %s", filePath, attempt+1, expectedMD5, localMD5)
+		}
+		c.logger.Warn("Downloaded file failed MD5 verification, retrying",
+			"path", filePath, "expected", expectedMD5, "got", localMD5, "attempt", attempt+1)
+	}
+	span.SetAttributes(attribute.Bool("bdfs.verified", expectedMD5 != ""))
+	c.forgetDownloadResume(localPath, filePath)
+
+	c.metrics.observeTransferDuration("download", start)
+
+	// Restore the remote modification time so later syncs compare correctly
+	if !opts.NoPreserveTimes && fileInfo != nil {
+		if mtime := fileInfo.ModTime(); mtime.Unix() > 0 {
+			if err := os.Chtimes(localPath, mtime, mtime); err != nil {
+				return fmt.Errorf("failed to set modification time on local file: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// downloadAttempt downloads filePath to localPath once, decrypting it when crypt is set,
+// and returns the MD5 of the downloaded (possibly encrypted) content. Progress is
+// written to output, if set. If ctx is cancelled mid-download, the partial file is kept
+// and passed to save, if set, and an error wrapping ErrInterrupted is returned.
+func (c *Client) downloadAttempt(ctx context.Context, filePath, localPath string, fileInfo *FileInfo, crypt *Cipher, output io.Writer, save func(done []ByteRange)) (string, error) {
+	span := trace.SpanFromContext(ctx)
+
 	// Download the file content
-	resp, err := c.DownloadFile(filePath)
+	resp, err := c.openDownload(ctx, filePath)
 	if err != nil {
-		return fmt.Errorf("failed to download file from Baidu Pan: %w", err)
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%w: download of %s not started", ErrInterrupted, filePath)
+		}
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("download request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	// Create the local file
 	outFile, err := os.Create(localPath)
 	if err != nil {
-		return fmt.Errorf("failed to create local file: %w", err)
+		return "", fmt.Errorf("failed to create local file: %w", err)
 	}
 	defer outFile.Close()
 
+	// Hash the content as it is written so verification needs no second pass. The hash
+	// covers the downloaded bytes, which is what the remote MD5 describes.
+	var sink io.Writer = outFile
+	var decrypter io.WriteCloser
+	if crypt != nil {
+		decrypter = crypt.DecryptWriter(outFile)
+		sink = decrypter
+	}
+	hash := md5.New()
+	dest := io.MultiWriter(sink, hash)
+
 	// Create progress writer if we have file info
 	var writer io.Writer
 	if fileInfo != nil {
 		progressWriter := &ProgressWriter{
-			writer:     outFile,
+			writer:     dest,
 			totalSize:  fileInfo.Size,
 			downloaded: 0,
 			fileName:   fileInfo.ServerFilename,
+			output:     output,
+			transfer:   transferFrom(ctx),
 		}
 		writer = progressWriter
 	} else {
 		// If we don't have file info, just use the outFile directly
 		_, fileName := filepath.Split(filePath)
-		fmt.Printf("Downloading %s...\n", fileName)
-		writer = outFile
+		c.logger.Info(fmt.Sprintf("Downloading %s...", fileName))
+		writer = dest
 	}
 
 	// Copy the response body to the local file with progress reporting
 	buf := make([]byte, 32*1024) // 32KB buffer
-	_, err = io.CopyBuffer(writer, resp.Body, buf)
+	written, err := io.CopyBuffer(writer, resp.Body, buf)
+	span.SetAttributes(attribute.Int64("bdfs.bytes", written))
+	c.observeTransfer("download", written)
+	if err != nil && ctx.Err() != nil {
+		if output != nil {
+			fmt.Fprint(output, "\n")
+		}
+		outFile.Close()
+		if save != nil && decrypter == nil {
+			save([]ByteRange{{0, written}})
+		} else {
+			os.Remove(localPath)
+		}
+		return "", fmt.Errorf("%w: %s of %s downloaded", ErrInterrupted, FormatBytes(written), filePath)
+	}
 	if err != nil {
 		// Clean up the partially downloaded file if there's an error
+		outFile.Close()
 		os.Remove(localPath)
-		return fmt.Errorf("failed to write file content to local file: %w", err)
+		return "", fmt.Errorf("failed to write file content to local file: %w", err)
 	}
 
 	// Print final progress and newline
-	if fileInfo != nil {
-		fmt.Printf("\n") // Newline after progress is complete
+	if fileInfo != nil && output != nil {
+		fmt.Fprint(output, "\n") // Newline after progress is complete
 	}
 
-	return nil
+	if decrypter != nil {
+		if err := decrypter.Close(); err != nil {
+			outFile.Close()
+			os.Remove(localPath)
+			return "", fmt.Errorf("failed to decrypt %s: %w", filePath, err)
+		}
+	}
+
+	if err := outFile.Close(); err != nil {
+		return "", fmt.Errorf("failed to close local file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// openDownload starts downloading filePath and returns the successful response
+func (c *Client) openDownload(ctx context.Context, filePath string) (*http.Response, error) {
+	resp, err := c.downloadFile(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from Baidu Pan: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		c.forgetDownloadLink(filePath) // It may have expired
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("download request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return resp, nil
+}
+
+// DownloadOption configures a DownloadTo call
+type DownloadOption func(*DownloadOptions)
+
+// WithoutVerify skips comparing the streamed content's MD5 against the remote metadata
+func WithoutVerify() DownloadOption {
+	return func(o *DownloadOptions) {
+		o.NoVerify = true
+	}
+}
+
+// DownloadTo streams the remote file at remotePath into w and returns the number of bytes
+// written. Nothing touches the local filesystem. Unless WithoutVerify is given, the content
+// is hashed as it streams and an error is returned if it does not match the remote MD5;
+// since the bytes have already been written, callers should discard w's content on error.
+func (c *Client) DownloadTo(ctx context.Context, remotePath string, w io.Writer, opts ...DownloadOption) (written int64, err error) {
+	if c.getAccessToken() == "" {
+		return 0, ErrNotAuthorized
+	}
+
+	var options DownloadOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx, span := c.startSpan(ctx, "download", attribute.String("bdfs.path", remotePath))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
+	defer func() { c.recordTransfer("download", "", remotePath, written, start, err) }()
+
+	expectedMD5 := ""
+	if !options.NoVerify {
+		fileInfo, err := c.GetFileInfo(remotePath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get file info for verification: %w", err)
+		}
+		if isHexMD5(fileInfo.MD5) {
+			expectedMD5 = strings.ToLower(fileInfo.MD5)
+		}
+	}
+
+	resp, err := c.openDownload(ctx, remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	hash := md5.New()
+	buf := make([]byte, 32*1024) // 32KB buffer
+	written, err = io.CopyBuffer(io.MultiWriter(w, hash), resp.Body, buf)
+	span.SetAttributes(attribute.Int64("bdfs.bytes", written))
+	c.observeTransfer("download", written)
+	if err != nil {
+		return written, fmt.Errorf("failed to stream file content: %w", err)
+	}
+
+	if localMD5 := hex.EncodeToString(hash.Sum(nil)); expectedMD5 != "" && localMD5 != expectedMD5 {
+		return written, fmt.Errorf("MD5 mismatch for %s: expected %s, got %s", remotePath, expectedMD5, localMD5)
+	}
+	span.SetAttributes(attribute.Bool("bdfs.verified", expectedMD5 != ""))
+
+	c.metrics.observeTransferDuration("download", start)
+	return written, nil
+}
+
+// isHexMD5 reports whether s looks like a plain hex-encoded MD5 digest
+func isHexMD5(s string) bool {
+	if len(s) != md5.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
 }
 
 // ReadFileContent reads the content of a file from Baidu Pan
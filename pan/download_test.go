@@ -0,0 +1,105 @@
+package pan_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+// remoteContent returns n bytes of content that differ at every offset within 256 bytes
+func remoteContent(n int) []byte {
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	return content
+}
+
+func TestDownloadFileToPath(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	content := remoteContent(16 << 20) // Large enough for two ranges
+	s.WriteFile("/dir/data.bin", content)
+	c := s.NewClient(t)
+
+	for _, connections := range []int{1, 2} {
+		before := s.Calls("download")
+		local := filepath.Join(t.TempDir(), "data.bin")
+		err := c.DownloadFileToPathWithOptions("/dir/data.bin", local, pan.DownloadOptions{Connections: connections})
+		if err != nil {
+			t.Fatalf("downloading with %d connections: %v", connections, err)
+		}
+		got, err := os.ReadFile(local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("downloading with %d connections wrote %d bytes that differ from the %d on the server", connections, len(got), len(content))
+		}
+		if calls := s.Calls("download") - before; calls != connections {
+			t.Errorf("downloading with %d connections sent %d requests", connections, calls)
+		}
+	}
+}
+
+func TestDownloadTo(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	s.WriteFile("/a.txt", []byte("hello, world"))
+	c := s.NewClient(t)
+
+	var buf bytes.Buffer
+	n, err := c.DownloadTo(context.Background(), "/a.txt", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 12 || buf.String() != "hello, world" {
+		t.Errorf("DownloadTo wrote %d bytes %q", n, buf.String())
+	}
+
+	if _, err := c.DownloadTo(context.Background(), "/missing.txt", io.Discard); err == nil {
+		t.Error("downloading a missing file succeeded")
+	}
+}
+
+func TestOpenReadAt(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	content := remoteContent(4096)
+	s.WriteFile("/a.bin", content)
+	c := s.NewClient(t)
+
+	f, err := c.Open("/a.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Size() != int64(len(content)) {
+		t.Errorf("Size is %d, want %d", f.Size(), len(content))
+	}
+
+	buf := make([]byte, 100)
+	if _, err := f.ReadAt(buf, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, content[1000:1100]) {
+		t.Error("ReadAt returned bytes from the wrong offset")
+	}
+
+	if _, err := f.Seek(4000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rest, content[4000:]) {
+		t.Errorf("reading after Seek returned %d bytes, want the last %d", len(rest), len(content)-4000)
+	}
+}
@@ -0,0 +1,43 @@
+package pan
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithDryRun makes the client report the modifying API operations it would perform to w
+// (with byte totals) instead of executing them. Read-only calls such as listings still run.
+func WithDryRun(w io.Writer) Option {
+	return func(c *Client) {
+		c.dryRunOutput = w
+	}
+}
+
+// DryRun reports whether the client is in dry-run mode
+func (c *Client) DryRun() bool {
+	return c.dryRunOutput != nil
+}
+
+// dryRunOperation describes one operation skipped in dry-run mode
+type dryRunOperation struct {
+	path   string // Remote path the operation applies to, used to look up its size
+	detail string // Human-readable description, e.g. "/a -> /b/a"
+}
+
+// reportDryRun writes the operations an API call would perform, looking up each item's size for the byte total
+func (c *Client) reportDryRun(api string, ops []dryRunOperation) {
+	var total int64
+	for _, op := range ops {
+		size := "size unknown"
+		if info, err := c.GetDetailedFileInfo(op.path); err == nil {
+			if info.IsDir == 1 {
+				size = "directory"
+			} else {
+				size = FormatBytes(info.Size)
+				total += info.Size
+			}
+		}
+		fmt.Fprintf(c.dryRunOutput, "[dry-run] %s: %s (%s)\n", api, op.detail, size)
+	}
+	fmt.Fprintf(c.dryRunOutput, "[dry-run] %s: %d item(s), %s in files\n", api, len(ops), FormatBytes(total))
+}
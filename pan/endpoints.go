@@ -0,0 +1,87 @@
+package pan
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// fileManagerURL is the API that copies, moves, renames and deletes files
+const fileManagerURL = "https://pan.baidu.com/api/filemanager"
+
+// Endpoints are the base URLs of the Baidu hosts the client talks to, such as
+// "https://pan.baidu.com". An empty field keeps Baidu's own host. Override them to test
+// against a mock server, to go through an enterprise mirror, or to follow Baidu when it
+// moves an API to another host.
+type Endpoints struct {
+	OpenAPI string // Authorization and token refresh (default: https://openapi.baidu.com)
+	Pan     string // File, share, account and task APIs (default: https://pan.baidu.com)
+	PCS     string // Changes since a cursor, for snapshots (default: https://pcs.baidu.com)
+	DPCS    string // Upload server lookup and slice uploads (default: https://d.pcs.baidu.com)
+}
+
+// DefaultEndpoints are Baidu's own hosts
+var DefaultEndpoints = Endpoints{
+	OpenAPI: "https://openapi.baidu.com",
+	Pan:     "https://pan.baidu.com",
+	PCS:     "https://pcs.baidu.com",
+	DPCS:    "https://d.pcs.baidu.com",
+}
+
+// WithEndpoints sends requests to the non-empty base URLs of e instead of Baidu's hosts;
+// check them first with ValidateEndpoints. When DPCS is set, slices are uploaded to it
+// alone rather than to the servers Baidu suggests, which a mirror could not reach.
+func WithEndpoints(e Endpoints) Option {
+	return func(c *Client) {
+		c.endpoints = e
+	}
+}
+
+// ValidateEndpoints checks that each non-empty field of e is an http or https URL with a
+// host and no query
+func ValidateEndpoints(e Endpoints) error {
+	for _, field := range e.fields() {
+		if field.base == "" {
+			continue
+		}
+		u, err := url.Parse(field.base)
+		if err != nil {
+			return fmt.Errorf("invalid %s endpoint %q: %w", field.name, field.base, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" {
+			return fmt.Errorf("invalid %s endpoint %q: expected a URL such as %s", field.name, field.base, field.def)
+		}
+	}
+	return nil
+}
+
+// endpointField is a field of Endpoints with its name and default
+type endpointField struct {
+	name, base, def string
+}
+
+// fields returns the fields of e with their names and defaults
+func (e Endpoints) fields() []endpointField {
+	return []endpointField{
+		{"openapi", e.OpenAPI, DefaultEndpoints.OpenAPI},
+		{"pan", e.Pan, DefaultEndpoints.Pan},
+		{"pcs", e.PCS, DefaultEndpoints.PCS},
+		{"d_pcs", e.DPCS, DefaultEndpoints.DPCS},
+	}
+}
+
+// endpoint returns rawURL, an API URL on one of Baidu's hosts, on the host configured
+// with WithEndpoints instead, or on the alternate host the client failed over to
+func (c *Client) endpoint(rawURL string) string {
+	for _, field := range c.endpoints.fields() {
+		rest, ok := strings.CutPrefix(rawURL, field.def)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		if field.base != "" {
+			return strings.TrimSuffix(field.base, "/") + rest
+		}
+		return c.failover.base(field.def) + rest
+	}
+	return rawURL
+}
@@ -0,0 +1,259 @@
+// Package errno catalogs the error numbers returned by Baidu Pan's APIs, with a message
+// and a retry classification for each.
+package errno
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Class tells whether a request that failed with an errno is worth sending again
+type Class int
+
+const (
+	Permanent Class = iota // Sending the same request again fails the same way
+	Retryable              // A transient server-side failure; the request may succeed later
+	Throttled              // The client is being rate limited; retry after a delay
+	Auth                   // The access token is invalid or expired; refresh it or authorize again
+)
+
+// String returns the name of the class
+func (c Class) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case Throttled:
+		return "throttled"
+	case Auth:
+		return "auth"
+	}
+	return "permanent"
+}
+
+// Entry describes an errno
+type Entry struct {
+	Message string
+	Class   Class
+}
+
+// OAuth is the api under which the catalog holds the errors of Baidu's OAuth endpoints
+const OAuth = "oauth"
+
+// key identifies an entry of the catalog. Most errnos mean the same thing across the
+// file manager, xpan and PCS APIs and have an empty api; an api is set where an
+// endpoint gives a code its own meaning, as share does with 110. code is the decimal
+// errno, or the error string of an OAuth response, such as "invalid_grant".
+type key struct {
+	api  string
+	code string
+}
+
+// catalog holds every errno the client knows
+var catalog = map[key]Entry{
+	// File manager, xpan and PCS
+	{"", "0"}:  {"Success", Permanent},
+	{"", "2"}:  {"Parameters error", Permanent},
+	{"", "3"}:  {"User permission error", Permanent},
+	{"", "4"}:  {"Request source error", Permanent},
+	{"", "6"}:  {"User is not allowed to access this data", Auth},
+	{"", "10"}: {"File already exists", Permanent},
+	{"", "12"}: {"Operation not allowed or path error", Permanent},
+	{"", "-3"}: {"File does not exist", Permanent},
+	// -6 usually means the access token was rejected. Baidu also returns it now and then
+	// when requests come too fast, so the client sends the request once more before failing.
+	{"", "-6"}:    {"Authentication failed: the access token is invalid or expired, or requests are too frequent", Auth},
+	{"", "-7"}:    {"Invalid file name", Permanent},
+	{"", "-8"}:    {"File or directory already exists", Permanent},
+	{"", "-9"}:    {"File does not exist", Permanent},
+	{"", "-10"}:   {"Cloud storage is full", Permanent},
+	{"", "108"}:   {"Path error, path does not exist", Permanent},
+	{"", "110"}:   {"Target path already exists", Permanent},
+	{"", "111"}:   {"Another asynchronous task is currently executing", Retryable},
+	{"", "112"}:   {"Same file already exists in the same directory", Permanent},
+	{"", "113"}:   {"File or directory name contains forbidden words", Permanent},
+	{"", "114"}:   {"Path too long", Permanent},
+	{"", "115"}:   {"Target directory does not exist", Permanent},
+	{"", "116"}:   {"Insufficient disk space", Permanent},
+	{"", "117"}:   {"File too large", Permanent},
+	{"", "2131"}:  {"Share does not exist", Permanent},
+	{"", "9019"}:  {"Request limit reached", Throttled},
+	{"", "31001"}: {"User has been banned", Permanent},
+	{"", "31021"}: {"Network error on the server", Retryable},
+	{"", "31023"}: {"Parameters error", Permanent},
+	{"", "31024"}: {"No permission to access this path", Permanent},
+	{"", "31026"}: {"File contains illegal content", Permanent},
+	{"", "31034"}: {"Request limit reached", Throttled},
+	{"", "31045"}: {"Access token is invalid", Auth},
+	{"", "31061"}: {"File already exists", Permanent},
+	{"", "31062"}: {"Invalid file name", Permanent},
+	{"", "31063"}: {"Parent directory does not exist", Permanent},
+	{"", "31064"}: {"No permission to write to this path", Permanent},
+	{"", "31065"}: {"Directory is full", Permanent},
+	{"", "31066"}: {"File does not exist", Permanent},
+	{"", "31067"}: {"Server failed to process the file", Retryable},
+	{"", "31068"}: {"Server failed to create the file", Retryable},
+	{"", "31069"}: {"Server failed to copy the file", Retryable},
+	{"", "31070"}: {"Server failed to delete the file", Retryable},
+	{"", "31071"}: {"Server failed to read the file's metadata", Retryable},
+	{"", "31072"}: {"Server failed to move the file", Retryable},
+	{"", "31073"}: {"Server failed to rename the file", Retryable},
+	{"", "31079"}: {"File MD5 not found", Permanent},
+	{"", "31081"}: {"Server failed to create the file from its slices", Retryable},
+	{"", "31083"}: {"Server failed to update the file from its slices", Retryable},
+	{"", "31190"}: {"Slices are incomplete", Permanent},
+	{"", "31218"}: {"Storage quota exceeded", Permanent},
+	{"", "31219"}: {"Too many files uploaded", Throttled},
+	{"", "31220"}: {"Traffic quota exceeded", Throttled},
+	{"", "31299"}: {"First slice must be at least 4 MB", Permanent},
+	{"", "31326"}: {"Request rejected by anti-hotlinking; check the User-Agent", Permanent},
+	{"", "31360"}: {"Link has expired", Permanent},
+	{"", "31362"}: {"Signature error", Permanent},
+	{"", "31363"}: {"Block missing in superfile", Permanent},
+	{"", "31364"}: {"Slice exceeds the size limit", Permanent},
+	{"", "31365"}: {"File exceeds the total size limit", Permanent},
+	{"", "42000"}: {"Requests are too frequent", Throttled},
+	{"", "42001"}: {"Random check failed", Permanent},
+	{"", "42211"}: {"Failed to query image details", Retryable},
+	{"", "42213"}: {"Shared directory authentication failed", Permanent},
+	{"", "42214"}: {"Failed to query file information", Retryable},
+	{"", "9100"}:  {"Account is banned (level 1)", Permanent},
+	{"", "9200"}:  {"Account is banned (level 2)", Permanent},
+	{"", "9300"}:  {"Account is banned (level 3)", Permanent},
+	{"", "9400"}:  {"Account is banned (level 4)", Permanent},
+	{"", "9500"}:  {"Account is banned (level 5)", Permanent},
+
+	// Codes the share API gives its own meaning
+	{"share", "-6"}:  {"Access token is invalid or expired", Auth},
+	{"share", "110"}: {"Sharing is restricted for this account", Permanent},
+	{"share", "115"}: {"The file is not allowed to be shared", Permanent},
+
+	// The document preview API rejects the token with -6 rather than throttling
+	{"doc preview", "-6"}: {"Access token is invalid or expired", Auth},
+
+	// OAuth: the error_code of openapi responses and the error of token responses
+	{OAuth, "1"}:                      {"Unknown error", Retryable},
+	{OAuth, "2"}:                      {"Service temporarily unavailable", Retryable},
+	{OAuth, "3"}:                      {"Unsupported open API method", Permanent},
+	{OAuth, "4"}:                      {"Open API request limit reached", Throttled},
+	{OAuth, "6"}:                      {"No permission to access this data", Auth},
+	{OAuth, "17"}:                     {"Open API daily request limit reached", Throttled},
+	{OAuth, "18"}:                     {"Open API QPS limit reached", Throttled},
+	{OAuth, "19"}:                     {"Open API total request limit reached", Throttled},
+	{OAuth, "100"}:                    {"Invalid parameter", Permanent},
+	{OAuth, "110"}:                    {"Access token is invalid or no longer valid", Auth},
+	{OAuth, "111"}:                    {"Access token has expired", Auth},
+	{OAuth, "authorization_pending"}:  {"The user has not authorized the device yet", Retryable},
+	{OAuth, "slow_down"}:              {"Polling too fast; slow down", Throttled},
+	{OAuth, "expired_token"}:          {"The device code has expired; start the authorization again", Auth},
+	{OAuth, "access_denied"}:          {"The user denied the authorization", Auth},
+	{OAuth, "invalid_grant"}:          {"The refresh token or code is invalid or expired", Auth},
+	{OAuth, "invalid_client"}:         {"The app key or secret is invalid", Permanent},
+	{OAuth, "invalid_request"}:        {"The request is missing a parameter or is malformed", Permanent},
+	{OAuth, "invalid_scope"}:          {"The requested scope is invalid", Permanent},
+	{OAuth, "unsupported_grant_type"}: {"The grant type is not supported", Permanent},
+}
+
+// Lookup returns the entry of errno as most APIs mean it
+func Lookup(errno int) (Entry, bool) {
+	return LookupAPI("", errno)
+}
+
+// LookupAPI returns the entry of errno as returned by api, such as "share", falling back
+// to its common meaning
+func LookupAPI(api string, errno int) (Entry, bool) {
+	return lookup(api, strconv.Itoa(errno))
+}
+
+// LookupOAuth returns the entry of the error string of an OAuth token response, such as
+// "authorization_pending"
+func LookupOAuth(name string) (Entry, bool) {
+	return lookup(OAuth, name)
+}
+
+// lookup returns the entry of code for api, or the common entry when api has none
+func lookup(api, code string) (Entry, bool) {
+	if entry, ok := catalog[key{api, code}]; ok {
+		return entry, true
+	}
+	if api == OAuth {
+		return Entry{}, false
+	}
+	entry, ok := catalog[key{"", code}]
+	return entry, ok
+}
+
+// Message returns a human-readable message for errno
+func Message(errno int) string {
+	return APIMessage("", errno)
+}
+
+// APIMessage returns a human-readable message for errno as returned by api
+func APIMessage(api string, errno int) string {
+	if entry, ok := LookupAPI(api, errno); ok {
+		return entry.Message
+	}
+	return fmt.Sprintf("Unknown error code: %d", errno)
+}
+
+// ClassOf returns the class of errno; unknown codes are Permanent
+func ClassOf(errno int) Class {
+	entry, _ := Lookup(errno)
+	return entry.Class
+}
+
+// IsThrottled reports whether errno indicates Baidu Pan is rate limiting the client
+func IsThrottled(errno int) bool {
+	return ClassOf(errno) == Throttled
+}
+
+// Error is an errno returned by a Baidu Pan API
+type Error struct {
+	API     string // The API that failed, e.g. "delete"; empty if unnamed
+	Code    int
+	Message string // The message from the response; empty to use the catalog's
+}
+
+func (e *Error) Error() string {
+	name := "API"
+	if e.API != "" {
+		name = e.API + " API"
+	}
+	message := e.Message
+	if message == "" {
+		message = APIMessage(e.API, e.Code)
+	}
+	return fmt.Sprintf("%s returned error code %d: %s", name, e.Code, message)
+}
+
+// Class returns the class of the error's code as returned by its API
+func (e *Error) Class() Class {
+	entry, _ := LookupAPI(e.API, e.Code)
+	return entry.Class
+}
+
+// Code returns the errno carried by err, if it wraps an *Error
+func Code(err error) (int, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code, true
+	}
+	return 0, false
+}
+
+// Is reports whether err wraps an *Error with the given errno
+func Is(err error, errno int) bool {
+	code, ok := Code(err)
+	return ok && code == errno
+}
+
+// IsRetryable reports whether err wraps an *Error whose errno is transient or a rate
+// limit, so sending the request again later may succeed
+func IsRetryable(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	class := e.Class()
+	return class == Retryable || class == Throttled
+}
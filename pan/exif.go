@@ -0,0 +1,170 @@
+package pan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// exifHeaderSize is how much of the start of a photo is read to find its EXIF data. In a
+// JPEG it is held in an APP1 segment of at most 64KB that precedes the image data; raw
+// formats built on TIFF keep it near the start as well.
+const exifHeaderSize = 128 * 1024
+
+// ErrNoExifDate is returned when an image header records no capture date
+var ErrNoExifDate = errors.New("no EXIF capture date")
+
+// EXIF tags holding capture dates and the pointer to the EXIF sub-IFD
+const (
+	exifTagDateTime           = 0x0132 // IFD0: when the file was last changed, usually the capture time
+	exifTagExifIFD            = 0x8769 // IFD0: offset of the EXIF sub-IFD
+	exifTagDateTimeOriginal   = 0x9003 // EXIF IFD: when the photo was taken
+	exifTagOffsetTimeOriginal = 0x9011 // EXIF IFD: UTC offset of DateTimeOriginal, e.g. "+08:00"
+)
+
+// ExifDate returns the capture date recorded in header, the start of a JPEG or of a
+// TIFF-based image such as DNG, CR2, NEF or ARW: its DateTimeOriginal, or failing that
+// its DateTime. EXIF dates carry no time zone unless OffsetTimeOriginal is set, so they
+// are otherwise taken as local time. ErrNoExifDate is returned for images without one.
+func ExifDate(header []byte) (time.Time, error) {
+	tiff, err := exifTIFF(header)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, fmt.Errorf("invalid TIFF header in EXIF data")
+	}
+
+	ifd0, err := readIFD(tiff, order, order.Uint32(tiff[4:8]))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if entry, ok := ifd0[exifTagExifIFD]; ok {
+		exif, err := readIFD(tiff, order, order.Uint32(entry[8:12]))
+		if err == nil {
+			if date, ok := exifString(tiff, order, exif, exifTagDateTimeOriginal); ok {
+				offset, _ := exifString(tiff, order, exif, exifTagOffsetTimeOriginal)
+				if t, err := parseExifDate(date, offset); err == nil {
+					return t, nil
+				}
+			}
+		}
+	}
+	if date, ok := exifString(tiff, order, ifd0, exifTagDateTime); ok {
+		if t, err := parseExifDate(date, ""); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrNoExifDate
+}
+
+// exifTIFF returns the TIFF structure holding the EXIF data in header: the payload of a
+// JPEG's "Exif" APP1 segment, or header itself for a TIFF-based image
+func exifTIFF(header []byte) ([]byte, error) {
+	if len(header) >= 8 && (string(header[:4]) == "II*\x00" || string(header[:4]) == "MM\x00*") {
+		return header, nil
+	}
+	if len(header) < 4 || header[0] != 0xFF || header[1] != 0xD8 {
+		return nil, fmt.Errorf("not a JPEG or TIFF image")
+	}
+
+	for i := 2; i+4 <= len(header); {
+		if header[i] != 0xFF {
+			return nil, fmt.Errorf("corrupt JPEG segment at offset %d", i)
+		}
+		marker := header[i+1]
+		if marker == 0xFF { // Fill byte
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // Image data or end of image: no more metadata
+			break
+		}
+		length := int(binary.BigEndian.Uint16(header[i+2 : i+4]))
+		if length < 2 {
+			return nil, fmt.Errorf("corrupt JPEG segment at offset %d", i)
+		}
+		segment := header[i+4 : min(i+2+length, len(header))]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) && len(segment) >= 14 {
+			return segment[6:], nil
+		}
+		i += 2 + length
+	}
+	return nil, ErrNoExifDate
+}
+
+// readIFD returns the 12-byte entries of the image file directory at offset in tiff, keyed by tag
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) (map[uint16][]byte, error) {
+	if int64(offset)+2 > int64(len(tiff)) {
+		return nil, fmt.Errorf("EXIF directory at %d lies beyond the header", offset)
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	entries := make(map[uint16][]byte, count)
+	for i := 0; i < count; i++ {
+		start := int(offset) + 2 + 12*i
+		if start+12 > len(tiff) {
+			break
+		}
+		entry := tiff[start : start+12]
+		entries[order.Uint16(entry)] = entry
+	}
+	return entries, nil
+}
+
+// exifString returns the value of the ASCII tag in ifd, without its terminating NUL
+func exifString(tiff []byte, order binary.ByteOrder, ifd map[uint16][]byte, tag uint16) (string, bool) {
+	entry, ok := ifd[tag]
+	if !ok || order.Uint16(entry[2:4]) != 2 { // Type 2 is ASCII
+		return "", false
+	}
+	count := order.Uint32(entry[4:8])
+	value := entry[8:12]
+	if count > 4 {
+		offset := order.Uint32(entry[8:12])
+		if int64(offset)+int64(count) > int64(len(tiff)) {
+			return "", false
+		}
+		value = tiff[offset : offset+count]
+	} else {
+		value = value[:count]
+	}
+	return strings.TrimRight(string(value), "\x00 "), true
+}
+
+// parseExifDate parses an EXIF date such as "2024:05:17 14:03:22" with an optional UTC
+// offset such as "+08:00"
+func parseExifDate(date, offset string) (time.Time, error) {
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", date+offset); err == nil {
+			return t, nil
+		}
+	}
+	// Unset dates are written as "0000:00:00 00:00:00", which fails to parse
+	return time.ParseInLocation("2006:01:02 15:04:05", date, time.Local)
+}
+
+// localExifDate returns the EXIF capture date of a local image
+func localExifDate(localPath string) (time.Time, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	header, err := io.ReadAll(io.LimitReader(f, exifHeaderSize))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ExifDate(header)
+}
@@ -0,0 +1,83 @@
+package pan
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// Exit statuses of go-bdfs, so scripts can tell failures apart without parsing stderr
+const (
+	ExitOK          = 0   // The command succeeded
+	ExitError       = 1   // Any failure not covered below
+	ExitUsage       = 2   // Invalid flags or arguments
+	ExitAuth        = 3   // Not authorized, or the access token was rejected
+	ExitNotFound    = 4   // A remote or local file does not exist
+	ExitQuota       = 5   // The storage or traffic quota of the account is used up
+	ExitRateLimited = 6   // Baidu Pan kept rate limiting the client
+	ExitPartial     = 7   // Some of the files or entries a command worked on failed
+	ExitNetwork     = 8   // A connection failed, timed out or was cut short
+	ExitInterrupted = 130 // Stopped by SIGINT or SIGTERM; the transfer can be resumed
+)
+
+// ErrNotAuthorized is returned by requests made before the client has an access token
+var ErrNotAuthorized = errors.New("no access token, please authorize first")
+
+// Errnos that mean a file or share does not exist, or the account is out of space or
+// traffic; the others are told apart by their class
+var (
+	notFoundErrnos = []int{-9, -3, 108, 2131, 31066}
+	quotaErrnos    = []int{-10, 116, 31218, 31220}
+)
+
+// ExitCode returns the exit status for a command that failed with err, ExitOK for nil
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, ErrInterrupted) {
+		return ExitInterrupted
+	}
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return ExitPartial
+	}
+	if errors.Is(err, ErrNotAuthorized) || errors.Is(err, ErrTokenExpired) {
+		return ExitAuth
+	}
+
+	var apiErr *errno.Error
+	if errors.As(err, &apiErr) {
+		for _, code := range notFoundErrnos {
+			if apiErr.Code == code {
+				return ExitNotFound
+			}
+		}
+		for _, code := range quotaErrnos {
+			if apiErr.Code == code {
+				return ExitQuota
+			}
+		}
+		switch apiErr.Class() {
+		case errno.Auth:
+			return ExitAuth
+		case errno.Throttled:
+			return ExitRateLimited
+		}
+		return ExitError
+	}
+
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrQueueItemNotFound) ||
+		strings.Contains(err.Error(), "file not found") {
+		return ExitNotFound
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ExitNetwork
+	}
+	return ExitError
+}
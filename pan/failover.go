@@ -0,0 +1,124 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+)
+
+// hostFailoverThreshold is how many requests in a row must fail on a host before the
+// client switches to the next one for the rest of the session
+const hostFailoverThreshold = 3
+
+// endpointAlternates lists the documented hosts serving the same APIs as each of Baidu's
+// hosts, in the order they are tried. The PCS file APIs answer on pcs, c.pcs and d.pcs
+// alike; openapi and pan have no alternate.
+var endpointAlternates = map[string][]string{
+	DefaultEndpoints.PCS:  {"https://c.pcs.baidu.com", "https://d.pcs.baidu.com"},
+	DefaultEndpoints.DPCS: {"https://c.pcs.baidu.com", "https://pcs.baidu.com"},
+}
+
+// hostFailover tracks which host serves each of Baidu's hosts that has alternates, and
+// the failures in a row of the hosts in use. Hosts set with WithEndpoints never fail over.
+type hostFailover struct {
+	mu       sync.Mutex
+	current  map[string]int // Index in hostsOf of the host in use, by default base URL
+	failures map[string]int // Requests that failed in a row, by base URL
+}
+
+// hostsOf returns def, a default base URL, followed by its alternates
+func hostsOf(def string) []string {
+	return append([]string{def}, endpointAlternates[def]...)
+}
+
+// base returns the base URL in use for def, a default base URL
+func (f *hostFailover) base(def string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return hostsOf(def)[f.current[def]]
+}
+
+// record counts the outcome of a request to u. When it is the failure that makes a host
+// in use reach hostFailoverThreshold, the next host takes over and is returned.
+func (f *hostFailover) record(u *url.URL, failed bool) (string, bool) {
+	base := u.Scheme + "://" + u.Host
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !failed {
+		delete(f.failures, base)
+		return "", false
+	}
+	if f.failures == nil {
+		f.failures = make(map[string]int)
+		f.current = make(map[string]int)
+	}
+
+	inUse, next := false, ""
+	for def := range endpointAlternates {
+		hosts := hostsOf(def)
+		if hosts[f.current[def]] != base {
+			continue
+		}
+		inUse = true
+		if f.failures[base]+1 >= hostFailoverThreshold {
+			f.current[def] = (f.current[def] + 1) % len(hosts)
+			next = hosts[f.current[def]]
+		}
+	}
+	switch {
+	case !inUse:
+		return "", false
+	case next == "":
+		f.failures[base]++
+		return "", false
+	}
+	delete(f.failures, base)
+	return next, true
+}
+
+// hostFailed reports whether a request failed in a way that another host may not: a
+// server error, or a connection that was refused or reset
+func hostFailed(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// failOver records the outcome of req, and when it was the failure that made the client
+// switch hosts, returns req addressed to the new host so it can be sent again. resp is
+// then closed.
+func (c *Client) failOver(req *http.Request, resp *http.Response, err error) (*http.Request, bool) {
+	next, switched := c.failover.record(req.URL, hostFailed(resp, err))
+	if !switched {
+		return nil, false
+	}
+	failed := req.URL.Scheme + "://" + req.URL.Host
+	c.debugf("!!! %s failed %d times in a row, switching to %s\n", failed, hostFailoverThreshold, next)
+	c.logger.Debug(fmt.Sprintf("Failing over from %s to %s", failed, next), "method", apiMethod(req))
+
+	nextURL, parseErr := url.Parse(next)
+	if parseErr != nil || (req.Body != nil && req.GetBody == nil) {
+		return nil, false
+	}
+	retry := req.Clone(req.Context())
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		retry.Body = body
+	}
+	retry.URL.Scheme = nextURL.Scheme
+	retry.URL.Host = nextURL.Host
+	retry.Host = ""
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return retry, true
+}
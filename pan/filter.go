@@ -0,0 +1,280 @@
+package pan
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// filterRule is a single include or exclude glob
+type filterRule struct {
+	include bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// Filter decides which paths take part in recursive operations, rclone-style.
+// Rules are evaluated in the order they were added and the first matching rule wins.
+// A pattern without "/" matches the base name anywhere in the tree; a pattern containing "/"
+// matches the whole relative path ("/" at the start anchors it to the root); "*" matches within
+// a path segment and "**" matches across segments. If include rules exist, files matching no
+// rule are excluded. Size and age limits apply to files only.
+type Filter struct {
+	rules   []filterRule
+	MinSize int64         // Minimum file size in bytes (0 for no limit)
+	MaxSize int64         // Maximum file size in bytes (0 for no limit)
+	MinAge  time.Duration // Only files modified at least this long ago (0 for no limit)
+	MaxAge  time.Duration // Only files modified within this duration (0 for no limit)
+}
+
+// NewFilter creates an empty filter that matches everything
+func NewFilter() *Filter {
+	return &Filter{}
+}
+
+// AddInclude appends an include rule for pattern
+func (f *Filter) AddInclude(pattern string) error {
+	return f.addRule(true, pattern)
+}
+
+// AddExclude appends an exclude rule for pattern
+func (f *Filter) AddExclude(pattern string) error {
+	return f.addRule(false, pattern)
+}
+
+// AddRulesFromFile appends rules read from a filter file. Each line is "+ pattern" (include)
+// or "- pattern" (exclude); blank lines and lines starting with "#" or ";" are ignored.
+func (f *Filter) AddRulesFromFile(filterFile string) error {
+	file, err := os.Open(filterFile)
+	if err != nil {
+		return fmt.Errorf("failed to open filter file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+ "):
+			err = f.AddInclude(strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "- "):
+			err = f.AddExclude(strings.TrimSpace(line[2:]))
+		default:
+			err = fmt.Errorf("rule must start with '+ ' or '- '")
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filterFile, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// addRule compiles pattern and appends it to the rule list
+func (f *Filter) addRule(include bool, pattern string) error {
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid filter pattern %q: %w", pattern, err)
+	}
+	f.rules = append(f.rules, filterRule{include: include, pattern: pattern, re: re})
+	return nil
+}
+
+// IsEmpty reports whether the filter has no rules or limits
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.rules) == 0 && f.MinSize == 0 && f.MaxSize == 0 && f.MinAge == 0 && f.MaxAge == 0)
+}
+
+// fingerprint describes the rules and limits of f, so a saved sync plan is only reused
+// with the same filter
+func (f *Filter) fingerprint() string {
+	if f.IsEmpty() {
+		return ""
+	}
+	var b strings.Builder
+	for _, rule := range f.rules {
+		fmt.Fprintf(&b, "%t %s\n", rule.include, rule.pattern)
+	}
+	fmt.Fprintf(&b, "%d %d %s %s", f.MinSize, f.MaxSize, f.MinAge, f.MaxAge)
+	return b.String()
+}
+
+// Match reports whether the file at relPath (slash-separated, relative to the operation root) is included
+func (f *Filter) Match(relPath string, size int64, modTime time.Time) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.MinSize > 0 && size < f.MinSize {
+		return false
+	}
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+	if !modTime.IsZero() {
+		age := time.Since(modTime)
+		if f.MinAge > 0 && age < f.MinAge {
+			return false
+		}
+		if f.MaxAge > 0 && age > f.MaxAge {
+			return false
+		}
+	}
+
+	hasInclude := false
+	for _, rule := range f.rules {
+		if rule.re.MatchString(relPath) {
+			return rule.include
+		}
+		hasInclude = hasInclude || rule.include
+	}
+	return !hasInclude
+}
+
+// MatchDir reports whether recursion into the directory at relPath is allowed.
+// Only exclude rules prune directories, so include rules for files deeper in the tree still apply.
+func (f *Filter) MatchDir(relPath string) bool {
+	if f == nil {
+		return true
+	}
+	for _, rule := range f.rules {
+		if rule.re.MatchString(relPath) {
+			return rule.include
+		}
+	}
+	return true
+}
+
+// globToRegexp converts a filter glob to an anchored regular expression
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	switch {
+	case strings.HasPrefix(pattern, "/"):
+		// Anchored to the root of the operation
+		sb.WriteString("^")
+		pattern = pattern[1:]
+	default:
+		// Unanchored patterns match the trailing path segments
+		sb.WriteString("(^|/)")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			sb.WriteString(pattern[i : i+end+1])
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// filterTree removes entries excluded by f, including everything beneath excluded directories
+func filterTree(tree map[string]syncEntry, f *Filter) map[string]syncEntry {
+	if f.IsEmpty() {
+		return tree
+	}
+
+	filtered := make(map[string]syncEntry, len(tree))
+	for rel, entry := range tree {
+		if !ancestorsIncluded(rel, f) {
+			continue
+		}
+		if entry.isDir {
+			if f.MatchDir(rel) {
+				filtered[rel] = entry
+			}
+			continue
+		}
+		if f.Match(rel, entry.size, time.Unix(entry.mtime, 0)) {
+			filtered[rel] = entry
+		}
+	}
+	return filtered
+}
+
+// ancestorsIncluded reports whether every parent directory of rel passes the filter
+func ancestorsIncluded(rel string, f *Filter) bool {
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if !f.MatchDir(dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSize parses a size such as "512", "10K", "1.5M" or "2G" (binary units) into bytes
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	multiplier := int64(1)
+	switch s[len(s)-1] {
+	case 'K':
+		multiplier = 1 << 10
+	case 'M':
+		multiplier = 1 << 20
+	case 'G':
+		multiplier = 1 << 30
+	case 'T':
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// ParseAge parses a duration such as "90m", "12h", "7d" or "2w"
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(value * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
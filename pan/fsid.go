@@ -0,0 +1,103 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+)
+
+// FileInfosByFsID returns the files and directories with the given fs_ids, in the same
+// order, looked up with the filemetas API up to 100 at a time. An fs_id that matches
+// nothing is an error.
+func (c *Client) FileInfosByFsID(ctx context.Context, ids []int64) ([]FileInfo, error) {
+	found := make(map[int64]FileInfo, len(ids))
+	for start := 0; start < len(ids); start += maxFileMetasIDs {
+		response, err := c.fileMetas(ctx, ids[start:min(start+maxFileMetasIDs, len(ids))], url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range response.List {
+			name := meta.Filename
+			if name == "" {
+				name = path.Base(meta.Path)
+			}
+			found[meta.FsID] = FileInfo{
+				FsID:           meta.FsID,
+				Path:           meta.Path,
+				ServerFilename: name,
+				IsDir:          meta.IsDir,
+				Size:           meta.Size,
+				MD5:            meta.MD5,
+				Category:       meta.Category,
+				ServerCtime:    meta.ServerCtime,
+				ServerMtime:    meta.ServerMtime,
+			}
+		}
+	}
+
+	infos := make([]FileInfo, 0, len(ids))
+	for _, id := range ids {
+		info, ok := found[id]
+		if !ok || info.Path == "" {
+			return nil, fmt.Errorf("no file with fs_id %d", id)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// GetFileInfoByFsID returns the file or directory with the given fs_id
+func (c *Client) GetFileInfoByFsID(fsID int64) (*FileInfo, error) {
+	infos, err := c.FileInfosByFsID(context.Background(), []int64{fsID})
+	if err != nil {
+		return nil, err
+	}
+	return &infos[0], nil
+}
+
+// PathsByFsID returns the paths of the files and directories with the given fs_ids, in
+// the same order
+func (c *Client) PathsByFsID(ctx context.Context, ids []int64) ([]string, error) {
+	infos, err := c.FileInfosByFsID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(infos))
+	for i, info := range infos {
+		paths[i] = info.Path
+	}
+	return paths, nil
+}
+
+// RemoveFilesByFsID removes the files and directories with the given fs_ids. The file
+// manager only takes paths, so they are looked up first.
+func (c *Client) RemoveFilesByFsID(ids []int64) error {
+	paths, err := c.PathsByFsID(context.Background(), ids)
+	if err != nil {
+		return err
+	}
+	return c.RemoveFiles(paths)
+}
+
+// MoveFilesByFsID moves the files and directories with the given fs_ids into destDir,
+// keeping their names
+func (c *Client) MoveFilesByFsID(ids []int64, destDir string) error {
+	paths, err := c.PathsByFsID(context.Background(), ids)
+	if err != nil {
+		return err
+	}
+	return c.MoveFilesInto(paths, destDir)
+}
+
+// DownloadFileByFsID downloads the file with the given fs_id to localPath
+func (c *Client) DownloadFileByFsID(fsID int64, localPath string, opts DownloadOptions) error {
+	info, err := c.GetFileInfoByFsID(fsID)
+	if err != nil {
+		return err
+	}
+	if info.IsDir == 1 {
+		return fmt.Errorf("%s is a directory", info.Path)
+	}
+	return c.DownloadFileToPathWithOptions(info.Path, localPath, opts)
+}
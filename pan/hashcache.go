@@ -0,0 +1,156 @@
+package pan
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// hashCacheBucket is the bbolt bucket holding one entry per local file
+var hashCacheBucket = []byte("hashes")
+
+// HashEntry holds the MD5s computed for a local file at a given size and modification time
+type HashEntry struct {
+	Path      string   `json:"path"` // Absolute local path
+	Size      int64    `json:"size"`
+	Mtime     int64    `json:"mtime"`      // Local modification time (Unix nanoseconds)
+	MD5       string   `json:"md5"`        // MD5 of the whole file content
+	SliceSize int64    `json:"slice_size"` // Slice size the slice MD5s were computed with
+	SliceMD5s []string `json:"slice_md5s"`
+}
+
+// HashCache is a persistent local cache of file and slice MD5s, backed by bbolt.
+// Entries are keyed by absolute path and only returned while the file's size and
+// modification time are unchanged, so unchanged files are never re-hashed.
+type HashCache struct {
+	db *bolt.DB
+}
+
+// OpenHashCache opens (creating if needed) the hash cache database at dbPath
+func OpenHashCache(dbPath string) (*HashCache, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hash cache %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(hashCacheBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize hash cache: %w", err)
+	}
+
+	return &HashCache{db: db}, nil
+}
+
+// Close closes the hash cache database
+func (hc *HashCache) Close() error {
+	return hc.db.Close()
+}
+
+// Lookup returns the cached hashes for localPath, or nil if there are none or the
+// file's size or modification time no longer match
+func (hc *HashCache) Lookup(localPath string, size int64, mtime time.Time) (*HashEntry, error) {
+	key, err := hashCacheKey(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *HashEntry
+	err = hc.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(hashCacheBucket).Get(key)
+		if data == nil {
+			return nil
+		}
+		entry = &HashEntry{}
+		return json.Unmarshal(data, entry)
+	})
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	if entry.Size != size || entry.Mtime != mtime.UnixNano() {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Store saves entry, replacing any cached hashes for the same path
+func (hc *HashCache) Store(entry HashEntry) error {
+	key, err := hashCacheKey(entry.Path)
+	if err != nil {
+		return err
+	}
+	entry.Path = string(key)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return hc.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(hashCacheBucket).Put(key, data)
+	})
+}
+
+// Clear removes every cached entry and returns how many were removed
+func (hc *HashCache) Clear() (int, error) {
+	removed := 0
+	err := hc.db.Update(func(tx *bolt.Tx) error {
+		removed = tx.Bucket(hashCacheBucket).Stats().KeyN
+		if err := tx.DeleteBucket(hashCacheBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(hashCacheBucket)
+		return err
+	})
+	return removed, err
+}
+
+// hashCacheKey returns the cache key for localPath: its absolute, cleaned form
+func hashCacheKey(localPath string) ([]byte, error) {
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", localPath, err)
+	}
+	return []byte(absPath), nil
+}
+
+// WithHashCache reuses MD5s from hc for unchanged local files and records newly computed ones
+func WithHashCache(hc *HashCache) Option {
+	return func(c *Client) {
+		c.hashCache = hc
+	}
+}
+
+// cachedSliceMD5s returns the cached slice MD5s for a local file when they were computed
+// with sliceSize and the file is unchanged, or nil otherwise
+func (c *Client) cachedSliceMD5s(localPath string, size int64, mtime time.Time, sliceSize int64) []string {
+	if c.hashCache == nil {
+		return nil
+	}
+	entry, err := c.hashCache.Lookup(localPath, size, mtime)
+	if err != nil {
+		c.logger.Warn("Failed to read hash cache", "path", localPath, "error", err)
+		return nil
+	}
+	if entry == nil || entry.SliceSize != sliceSize {
+		c.observeHashCache(false)
+		return nil
+	}
+	c.observeHashCache(true)
+	return entry.SliceMD5s
+}
+
+// storeHashes records the MD5s computed for a local file
+func (c *Client) storeHashes(entry HashEntry) {
+	if c.hashCache == nil {
+		return
+	}
+	if err := c.hashCache.Store(entry); err != nil {
+		c.logger.Warn("Failed to update hash cache", "path", entry.Path, "error", err)
+	}
+}
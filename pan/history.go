@@ -0,0 +1,189 @@
+package pan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry records one completed or failed file transfer
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`      // When the transfer ended
+	Direction  string    `json:"direction"` // "upload" or "download"
+	LocalPath  string    `json:"local_path,omitempty"`
+	RemotePath string    `json:"remote_path"`
+	Bytes      int64     `json:"bytes"`
+	Duration   float64   `json:"duration_seconds"`
+	Speed      float64   `json:"bytes_per_second"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// History is an append-only transfer log stored as JSON lines, one entry per transfer
+type History struct {
+	path string
+	mu   sync.Mutex
+}
+
+// OpenHistory returns the transfer history stored in the file at path, creating its
+// directory if needed. The file itself is created by the first recorded transfer.
+func OpenHistory(path string) (*History, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &History{path: path}, nil
+}
+
+// Append adds entry to the end of the history
+func (h *History) Append(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Close()
+}
+
+// HistoryFilter selects history entries; zero fields match everything
+type HistoryFilter struct {
+	Since      time.Time // Only transfers that ended at or after this time
+	Direction  string    // "upload" or "download"
+	FailedOnly bool      // Only failed transfers
+	Path       string    // Only transfers whose local or remote path contains this text
+}
+
+// match reports whether entry is selected by the filter
+func (f HistoryFilter) match(entry HistoryEntry) bool {
+	switch {
+	case !f.Since.IsZero() && entry.Time.Before(f.Since):
+		return false
+	case f.Direction != "" && entry.Direction != f.Direction:
+		return false
+	case f.FailedOnly && entry.Success:
+		return false
+	case f.Path != "" && !strings.Contains(entry.LocalPath, f.Path) && !strings.Contains(entry.RemotePath, f.Path):
+		return false
+	}
+	return true
+}
+
+// Read returns the entries selected by filter, oldest first. A missing history file
+// holds no entries; lines that cannot be parsed, such as one cut short by a crash, are skipped.
+func (h *History) Read(filter HistoryFilter) ([]HistoryEntry, error) {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// HistorySummary aggregates history entries
+type HistorySummary struct {
+	Transfers    int     `json:"transfers"`
+	Failed       int     `json:"failed"`
+	Uploads      int     `json:"uploads"`
+	Downloads    int     `json:"downloads"`
+	BytesUp      int64   `json:"bytes_up"`   // Bytes of successful uploads
+	BytesDown    int64   `json:"bytes_down"` // Bytes of successful downloads
+	Duration     float64 `json:"duration_seconds"`
+	AverageSpeed float64 `json:"average_bytes_per_second"` // Bytes of successful transfers per second spent on them
+}
+
+// SummarizeHistory totals the transfers, failures, bytes and time in entries
+func SummarizeHistory(entries []HistoryEntry) HistorySummary {
+	var s HistorySummary
+	var successBytes int64
+	var successSeconds float64
+	for _, entry := range entries {
+		s.Transfers++
+		s.Duration += entry.Duration
+		if entry.Direction == "upload" {
+			s.Uploads++
+		} else {
+			s.Downloads++
+		}
+		if !entry.Success {
+			s.Failed++
+			continue
+		}
+		if entry.Direction == "upload" {
+			s.BytesUp += entry.Bytes
+		} else {
+			s.BytesDown += entry.Bytes
+		}
+		successBytes += entry.Bytes
+		successSeconds += entry.Duration
+	}
+	if successSeconds > 0 {
+		s.AverageSpeed = float64(successBytes) / successSeconds
+	}
+	return s
+}
+
+// WithHistory records every upload and download the client performs in h
+func WithHistory(h *History) Option {
+	return func(c *Client) {
+		c.history = h
+	}
+}
+
+// recordTransfer adds a transfer that started at start and ended now to the history
+func (c *Client) recordTransfer(direction, localPath, remotePath string, bytes int64, start time.Time, err error) {
+	if c.history == nil {
+		return
+	}
+	duration := time.Since(start).Seconds()
+	entry := HistoryEntry{
+		Time:       time.Now(),
+		Direction:  direction,
+		LocalPath:  localPath,
+		RemotePath: remotePath,
+		Bytes:      bytes,
+		Duration:   duration,
+		Success:    err == nil,
+	}
+	if duration > 0 && err == nil {
+		entry.Speed = float64(bytes) / duration
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := c.history.Append(entry); err != nil {
+		c.logger.Warn("Failed to record transfer history", "path", remotePath, "error", err)
+	}
+}
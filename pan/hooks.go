@@ -0,0 +1,38 @@
+package pan
+
+import "net/http"
+
+// WithRequestHook calls hook with every API request just before it is sent, including
+// each retry of a throttled request, so embedders can add headers or audit requests
+// without replacing the transport. Hooks run in the order they were added. The URL
+// carries the access token; redact it with RedactURL before logging.
+func WithRequestHook(hook func(*http.Request)) Option {
+	return func(c *Client) {
+		c.requestHooks = append(c.requestHooks, hook)
+	}
+}
+
+// WithResponseHook calls hook after every API request with its response, or with a nil
+// response and the error when no response was received, e.g. for metrics or auditing.
+// JSON and text bodies have already been read and are replayed to the caller, so hooks
+// may read resp.Body only if they restore it; they must not close it. Hooks run in the
+// order they were added.
+func WithResponseHook(hook func(*http.Response, error)) Option {
+	return func(c *Client) {
+		c.responseHooks = append(c.responseHooks, hook)
+	}
+}
+
+// runRequestHooks calls the hooks of WithRequestHook with req
+func (c *Client) runRequestHooks(req *http.Request) {
+	for _, hook := range c.requestHooks {
+		hook(req)
+	}
+}
+
+// runResponseHooks calls the hooks of WithResponseHook with resp and err
+func (c *Client) runResponseHooks(resp *http.Response, err error) {
+	for _, hook := range c.responseHooks {
+		hook(resp, err)
+	}
+}
@@ -0,0 +1,79 @@
+package pan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Language is a language the CLI's messages can be shown in
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageChinese Language = "zh" // Simplified Chinese
+)
+
+// language is the language T translates into. It is set once at startup, before any
+// goroutines print messages.
+var language = LanguageEnglish
+
+// catalogs maps each language other than English to its translations, keyed by the
+// English message or format string
+var catalogs = map[Language]map[string]string{
+	LanguageChinese: zhMessages,
+}
+
+// SetLanguage selects the language of the messages returned by T and Tf
+func SetLanguage(lang Language) {
+	language = lang
+}
+
+// CurrentLanguage returns the language selected with SetLanguage
+func CurrentLanguage() Language {
+	return language
+}
+
+// ParseLanguage parses a language name or locale such as "en", "zh", "zh_CN.UTF-8" or
+// "zh-Hans"
+func ParseLanguage(s string) (Language, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	name, _, _ = strings.Cut(name, ".") // Drop the encoding of a locale
+	switch {
+	case name == "en" || strings.HasPrefix(name, "en_") || strings.HasPrefix(name, "en-") || name == "english" || name == "c" || name == "posix":
+		return LanguageEnglish, nil
+	case name == "zh" || strings.HasPrefix(name, "zh_") || strings.HasPrefix(name, "zh-") || name == "chinese" || name == "cn":
+		return LanguageChinese, nil
+	}
+	return "", fmt.Errorf("unsupported language %q (expected en or zh)", s)
+}
+
+// LanguageFromLocale returns the language of the system locale, from LC_ALL,
+// LC_MESSAGES or LANG: Chinese for zh locales, otherwise English
+func LanguageFromLocale() Language {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if lang, err := ParseLanguage(value); err == nil {
+				return lang
+			}
+			return LanguageEnglish
+		}
+	}
+	return LanguageEnglish
+}
+
+// T returns the translation of the English message msg into the selected language, or
+// msg itself when there is none
+func T(msg string) string {
+	if translated, ok := catalogs[language][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Tf formats args with the translation of the English format string, as fmt.Sprintf.
+// Translations keep the verbs of the format, reordering them with explicit argument
+// indexes where the sentence needs it.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
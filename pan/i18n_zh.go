@@ -0,0 +1,643 @@
+package pan
+
+// zhMessages holds the Simplified Chinese translations of the CLI's messages, keyed by
+// the English message or format string
+var zhMessages = map[string]string{
+	"Invalid value for --stats-interval: %q":                                               "--stats-interval 的值无效: %q",
+	"Invalid value for --status-port: %q":                                                  "--status-port 的值无效: %q",
+	"Invalid value for --max-qps: %q":                                                      "--max-qps 的值无效: %q",
+	"Invalid value for --slice-retries: %q":                                                "--slice-retries 的值无效: %q",
+	"Error: --quiet and --verbose cannot be combined.":                                     "错误: --quiet 与 --verbose 不能同时使用。",
+	"Metrics server stopped: %v":                                                           "指标服务已停止: %v",
+	"Status server stopped: %v":                                                            "状态服务已停止: %v",
+	"go-bdfs: Baidu Pan client":                                                            "go-bdfs: 百度网盘客户端",
+	"Usage: go-bdfs <command> [arguments]":                                                 "用法: go-bdfs <命令> [参数]",
+	"Commands:":                                                                            "命令:",
+	"  ls          List files in a directory":                                              "  ls          列出目录中的文件",
+	"  dl          Download a file or, with -r, a directory from Baidu Pan":                "  dl          从百度网盘下载文件（加 -r 下载目录）",
+	"  ul          Upload a file, or a directory, glob or file list in parallel":           "  ul          上传文件，或并行上传目录、通配符匹配的文件或文件列表",
+	"  rm          Remove a file or directory from Baidu Pan":                              "  rm          删除百度网盘中的文件或目录",
+	"  mv          Move a file or directory to another directory in Baidu Pan":             "  mv          将百度网盘中的文件或目录移动到另一个目录",
+	"  rn          Rename a file or directory in Baidu Pan":                                "  rn          重命名百度网盘中的文件或目录",
+	"  md          Create a directory in Baidu Pan":                                        "  md          在百度网盘中创建目录",
+	"  cp          Copy a file or directory in Baidu Pan":                                  "  cp          复制百度网盘中的文件或目录",
+	"  if          Get information about a file in Baidu Pan":                              "  if          查看百度网盘中文件的信息",
+	"  preview     Get a browser link to preview an office document, PDF or text file":     "  preview     获取在浏览器中预览办公文档、PDF 或文本文件的链接",
+	"  share       Create a share link with an extraction code and expiry":                 "  share       创建带提取码和有效期的分享链接",
+	"  shares      List shares received from other users, with their files' fs_ids":        "  shares      列出其他用户分享给你的内容及文件的 fs_id",
+	"  di          Get disk information (storage usage) from Baidu Pan":                    "  di          查看百度网盘的容量使用情况",
+	"  ar          Refresh the access token using the refresh token":                       "  ar          使用 refresh token 刷新 access token",
+	"  index       Manage the local metadata index (rebuild, prune)":                       "  index       管理本地元数据索引（rebuild、prune）",
+	"  sync        Synchronize a local directory with a Baidu Pan directory":               "  sync        同步本地目录与百度网盘目录",
+	"  xcopy       Copy a file or directory between two Baidu Pan accounts":                "  xcopy       在两个百度网盘账号之间复制文件或目录",
+	"  prune-empty Remove directories that contain no files":                               "  prune-empty 删除不含任何文件的目录",
+	"  report      Summarize storage use by extension, folder and age":                     "  report      按扩展名、文件夹和时间统计存储占用",
+	"  speedtest   Measure upload and download throughput with 1, 4 and 8 connections":     "  speedtest   分别用 1、4、8 个连接测量上传和下载速度",
+	"  export      Export a manifest of every file beneath a directory":                    "  export      导出目录下所有文件的清单",
+	"  diff        Compare an exported manifest with the current remote state":             "  diff        将导出的清单与网盘当前状态进行比较",
+	"  snapshot    Manage named snapshots of the remote tree (create, list, diff, delete)": "  snapshot    管理网盘目录树的命名快照（create、list、diff、delete）",
+	"  queue       Manage the persistent transfer queue (add, ls, rm, run)":                "  queue       管理持久化传输队列（add、ls、rm、run）",
+	"  photos      Back up photos and videos into dated folders, skipping duplicates":      "  photos      将照片和视频备份到按日期命名的文件夹，跳过重复内容",
+	"  organize    Move remote photos into dated folders by their EXIF capture dates":      "  organize    按 EXIF 拍摄日期将网盘中的照片移入日期文件夹",
+	"  history     Show past transfers, with filters and summaries":                        "  history     查看传输历史，支持筛选和汇总",
+	"  stats       Run a command and print its API calls, bytes, retries and cache hits":   "  stats       运行命令并输出其 API 调用、流量、重试和缓存命中情况",
+	"  hash-cache  Manage the local file hash cache (clear)":                               "  hash-cache  管理本地文件哈希缓存（clear）",
+	"  daemon      Run the scheduled jobs from the configuration file":                     "  daemon      运行配置文件中的定时任务",
+	"  doctor      Check the configuration, token, endpoints and clock, suggesting fixes":  "  doctor      检查配置、令牌、接口连通性和时钟，并给出修复建议",
+	"  selfupdate  Download, verify and install the latest release":                        "  selfupdate  下载、校验并安装最新版本",
+	"  version     Show the version information (--check for a newer release)":             "  version     显示版本信息（--check 检查新版本）",
+	"Global flags:": "全局参数:",
+	"  --debug     Log HTTP requests and responses to stderr":                                                    "  --debug     将 HTTP 请求和响应输出到 stderr",
+	"  -q, --quiet            Only print results, warnings and errors":                                           "  -q, --quiet            只输出结果、警告和错误",
+	"  -v, --verbose          Also print a line per API request and other details":                               "  -v, --verbose          额外输出每个 API 请求及其他细节",
+	"  --metrics-addr <addr>  Serve Prometheus metrics on <addr>/metrics":                                        "  --metrics-addr <addr>  在 <addr>/metrics 提供 Prometheus 指标",
+	"  --status-port <port>   Serve the live status on http://127.0.0.1:<port>/status (also printed on SIGUSR1)": "  --status-port <port>   在 http://127.0.0.1:<port>/status 提供实时状态（收到 SIGUSR1 时也会输出）",
+	"  --max-qps <n>          Limit API requests per second per endpoint":                                        "  --max-qps <n>          限制每个接口每秒的 API 请求数",
+	"  --slice-retries <n>    Retry a failed upload slice up to <n> times (default: 3)":                          "  --slice-retries <n>    上传分片失败时最多重试 <n> 次（默认: 3）",
+	"  --dry-run              Print modifying operations instead of executing them":                              "  --dry-run              只打印将执行的修改操作而不实际执行",
+	"  --no-hash-cache        Hash local files from scratch instead of reusing cached MD5s":                      "  --no-hash-cache        重新计算本地文件哈希，不使用缓存的 MD5",
+	"  --notify               Show a desktop notification when a long transfer ends":                             "  --notify               耗时较长的传输结束时显示桌面通知",
+	"  --stats                Print API calls, bytes, retries and cache hits when the command completes":         "  --stats                命令结束时输出 API 调用、流量、重试和缓存命中情况",
+	"  --stats-interval <d>   Print a one-line stats summary every <d> (e.g. 30s)":                               "  --stats-interval <d>   每隔 <d>（如 30s）输出一行统计摘要",
+	"Use 'go-bdfs <command> -h' for more information about a command.":                                           "使用 'go-bdfs <命令> -h' 查看命令的详细说明。",
+	"Error loading configuration: %v":                                                                            "加载配置出错: %v",
+	"You can set BDFS_CLIENT_ID, BDFS_CLIENT_SECRET, and BDFS_TOKEN_PATH environment variables":                  "可以设置环境变量 BDFS_CLIENT_ID、BDFS_CLIENT_SECRET 和 BDFS_TOKEN_PATH",
+	"Format (direct values):": "格式（直接填写值）:",
+	"Alternatively, set the BDFS_CONFIG_FILE_PATH environment variable to point to your config file": "也可以设置环境变量 BDFS_CONFIG_FILE_PATH 指向你的配置文件",
+	"Local index unavailable: %v":          "本地索引不可用: %v",
+	"Hash cache unavailable: %v":           "哈希缓存不可用: %v",
+	"Resume store unavailable: %v":         "断点续传记录不可用: %v",
+	"Transfer history unavailable: %v":     "传输历史不可用: %v",
+	"Starting Baidu Pan authorization...":  "开始百度网盘授权...",
+	"Authorization failed: %v":             "授权失败: %v",
+	"Stats: ":                              "统计: ",
+	"Unknown command: %s":                  "未知命令: %s",
+	"Run 'go-bdfs' for usage information.": "运行 'go-bdfs' 查看用法。",
+	"Error encoding results: %v":           "编码结果出错: %v",
+	"    Fix: %s\n":                        "    修复建议: %s\n",
+	"Error: %v":                            "错误: %v",
+	"Listing files in directory: %s":       "正在列出目录中的文件: %s",
+	"Error listing files: %v":              "列出文件出错: %v",
+	"Error getting media info: %v":         "获取媒体信息出错: %v",
+	"No files found.":                      "未找到文件。",
+	"Error: -f or --file flag is required to specify the file to download": "错误: 需要用 -f 或 --file 指定要下载的文件",
+	"Error: --transfers and --connections must be at least 1.":             "错误: --transfers 和 --connections 至少为 1。",
+	"Error: --split cannot be combined with --recursive.":                  "错误: --split 不能与 --recursive 同时使用。",
+	"Error: Invalid file path: %s":                                         "错误: 文件路径无效: %s",
+	"Downloading file '%s' from Baidu Pan to '%s'...":                      "正在从百度网盘下载文件 '%s' 到 '%s'...",
+	"Error downloading file: %v":                                           "下载文件出错: %v",
+	"File downloaded successfully to: %s":                                  "文件已成功下载到: %s",
+	"Error listing %s: %v":                                                 "列出 %s 出错: %v",
+	"No files to download in %s.":                                          "%s 中没有可下载的文件。",
+	"Downloading %d files from '%s' to '%s' (%d at a time)...":             "正在下载 %d 个文件，从 '%s' 到 '%s'（同时 %d 个）...",
+	"Failed: %s":                           "失败: %s",
+	"%d files downloaded (%s), %d failed.": "已下载 %d 个文件（%s），%d 个失败。",
+	"%d files downloaded (%s) to: %s":      "已下载 %d 个文件（%s）到: %s",
+	"Error: -f or --file flag is required to specify the local file to upload.":               "错误: 需要用 -f 或 --file 指定要上传的本地文件。",
+	"Error: -d or --dir flag is required to specify the remote file path.":                    "错误: 需要用 -d 或 --dir 指定网盘文件路径。",
+	"Uploading local file '%s' to Baidu Pan as '%s'...":                                       "正在将本地文件 '%s' 上传到百度网盘 '%s'...",
+	"Error uploading file: %v":                                                                "上传文件出错: %v",
+	"Dry run: nothing was uploaded.":                                                          "演练模式: 未上传任何内容。",
+	"File '%s' uploaded successfully to '%s'.":                                                "文件 '%s' 已成功上传到 '%s'。",
+	"Error: --split cannot be combined with --archive.":                                       "错误: --split 不能与 --archive 同时使用。",
+	"Archiving local directory '%s' as %s and uploading it to '%s'...":                        "正在将本地目录 '%s' 打包为 %s 并上传到 '%s'...",
+	"Error uploading archive: %v":                                                             "上传压缩包出错: %v",
+	"Directory '%s' uploaded successfully as '%s'.":                                           "目录 '%s' 已成功上传为 '%s'。",
+	"Error: -r or --remote-path flag is required to specify the file or directory to remove.": "错误: 需要用 -r 或 --remote-path 指定要删除的文件或目录。",
+	"Remove operation cancelled.":                                                             "已取消删除。",
+	"Removing '%s' from Baidu Pan...":                                                         "正在从百度网盘删除 '%s'...",
+	"Error removing file: %v":                                                                 "删除文件出错: %v",
+	"Dry run: nothing was removed.":                                                           "演练模式: 未删除任何内容。",
+	"'%s' removed successfully from Baidu Pan.":                                               "已成功从百度网盘删除 '%s'。",
+	"Error: -s or --source flag is required to specify the file or directory to move.":        "错误: 需要用 -s 或 --source 指定要移动的文件或目录。",
+	"Error: -d or --destination flag is required to specify the destination directory.":       "错误: 需要用 -d 或 --destination 指定目标目录。",
+	"Are you sure you want to move '%s' to '%s'? (y/N): ":                                     "确定要将 '%s' 移动到 '%s' 吗？(y/N): ",
+	"Move operation cancelled.":                                                               "已取消移动。",
+	"Moving '%s' to '%s' in Baidu Pan...":                                                     "正在百度网盘中将 '%s' 移动到 '%s'...",
+	"Error moving file: %v":                                                                   "移动文件出错: %v",
+	"Dry run: nothing was moved.":                                                             "演练模式: 未移动任何内容。",
+	"'%s' moved successfully to '%s' in Baidu Pan.":                                           "已成功在百度网盘中将 '%s' 移动到 '%s'。",
+	"Error: -s or --source flag is required to specify the file or directory to rename.":      "错误: 需要用 -s 或 --source 指定要重命名的文件或目录。",
+	"Error: -n or --newname flag is required to specify the new name.":                        "错误: 需要用 -n 或 --newname 指定新名称。",
+	"Are you sure you want to rename '%s' to '%s'? (y/N): ":                                   "确定要将 '%s' 重命名为 '%s' 吗？(y/N): ",
+	"Rename operation cancelled.":                                                             "已取消重命名。",
+	"Renaming '%s' to '%s' in Baidu Pan...":                                                   "正在百度网盘中将 '%s' 重命名为 '%s'...",
+	"Error renaming file: %v":                                                                 "重命名文件出错: %v",
+	"Dry run: nothing was renamed.":                                                           "演练模式: 未重命名任何内容。",
+	"'%s' renamed successfully to '%s' in Baidu Pan.":                                         "已成功在百度网盘中将 '%s' 重命名为 '%s'。",
+	"Error: -s or --source flag is required to specify the source file or directory to copy.": "错误: 需要用 -s 或 --source 指定要复制的源文件或目录。",
+	"Error: -d or --destination flag is required to specify the destination path.":            "错误: 需要用 -d 或 --destination 指定目标路径。",
+	"Copying '%s' to '%s' in Baidu Pan...":                                                    "正在百度网盘中将 '%s' 复制到 '%s'...",
+	"Error copying file: %v":                                                                  "复制文件出错: %v",
+	"Dry run: nothing was copied.":                                                            "演练模式: 未复制任何内容。",
+	"Error: --from and --to flags are required to specify the source and destination.":        "错误: 需要用 --from 和 --to 指定源和目标。",
+	"Copying '%s' to '%s'...":                                                                 "正在将 '%s' 复制到 '%s'...",
+	"Error copying between accounts: %v":                                                      "跨账号复制出错: %v",
+	"'%s' copied successfully to '%s'.":                                                       "已成功将 '%s' 复制到 '%s'。",
+	"Unknown profile %q; define it under [profiles.%s] in the configuration file":             "未知账号配置 %q；请在配置文件的 [profiles.%s] 下定义",
+	"Profile %q has no token_path":                                                            "账号配置 %q 缺少 token_path",
+	"Starting Baidu Pan authorization for profile '%s'...\n":                                  "开始为账号配置 '%s' 进行百度网盘授权...\n",
+	"Authorization failed for profile %q: %v":                                                 "账号配置 %q 授权失败: %v",
+	"Error: -p or --path flag is required to specify the directory to clean up.":              "错误: 需要用 -p 或 --path 指定要清理的目录。",
+	"Are you sure you want to remove every empty directory beneath '%s'? (y/N): ":             "确定要删除 '%s' 下的所有空目录吗？(y/N): ",
+	"Prune operation cancelled.":                                                              "已取消清理。",
+	"Removing empty directories beneath '%s'...":                                              "正在删除 '%s' 下的空目录...",
+	"Removed %s\n":                                                                   "已删除 %s\n",
+	"Error removing empty directories: %v":                                           "删除空目录出错: %v",
+	"Dry run: %d empty directories would be removed.":                                "演练模式: 将删除 %d 个空目录。",
+	"Removed %d empty directories.":                                                  "已删除 %d 个空目录。",
+	"Error building storage report: %v":                                              "生成存储报告出错: %v",
+	"Error encoding storage report: %v":                                              "编码存储报告出错: %v",
+	"Error: invalid --size %q":                                                       "错误: --size 无效: %q",
+	"Error: invalid connection count %d":                                             "错误: 连接数无效: %d",
+	"Testing with %s per run; this transfers %s in total.":                           "每轮测试传输 %s；总共将传输 %s。",
+	"Error running speed test: %v":                                                   "测速出错: %v",
+	"Recommended: %d upload connections, %d download connections.":                   "建议: 上传 %d 个连接，下载 %d 个连接。",
+	"Error exporting manifest: %v":                                                   "导出清单出错: %v",
+	"Error writing manifest: %v":                                                     "写入清单出错: %v",
+	"Error creating manifest file: %v":                                               "创建清单文件出错: %v",
+	"Exported %d files beneath '%s' to '%s'.":                                        "已将 '%[2]s' 下的 %[1]d 个文件导出到 '%[3]s'。",
+	"Error: --manifest flag is required to specify the manifest to compare with.":    "错误: 需要用 --manifest 指定要比较的清单。",
+	"Error opening manifest: %v":                                                     "打开清单出错: %v",
+	"Error reading manifest: %v":                                                     "读取清单出错: %v",
+	"Error comparing with remote: %v":                                                "与网盘比较出错: %v",
+	"No changes beneath '%s' since %s.":                                              "自 %[2]s 以来 '%[1]s' 下没有变化。",
+	"%d added, %d removed, %d changed.":                                              "新增 %d 个，删除 %d 个，修改 %d 个。",
+	"Error encoding differences: %v":                                                 "编码差异出错: %v",
+	"Invalid value for --only: %q (expected added, removed or changed)":              "--only 的值无效: %q（应为 added、removed 或 changed）",
+	"Error: specify a snapshot action: create, list, diff or delete.":                "错误: 请指定快照操作: create、list、diff 或 delete。",
+	"Error opening snapshot store: %v":                                               "打开快照存储出错: %v",
+	"Error: specify a snapshot name.":                                                "错误: 请指定快照名称。",
+	"Creating snapshot '%s' of '%s'...":                                              "正在创建快照 '%s'（'%s'）...",
+	"Error listing remote files: %v":                                                 "列出网盘文件出错: %v",
+	"Error saving snapshot: %v":                                                      "保存快照出错: %v",
+	"Snapshot '%s' saved with %d files.":                                             "快照 '%s' 已保存，共 %d 个文件。",
+	"Error listing snapshots: %v":                                                    "列出快照出错: %v",
+	"No snapshots found.":                                                            "没有快照。",
+	"Error loading snapshot: %v":                                                     "加载快照出错: %v",
+	"Snapshot '%s' vs %s: %d added, %d removed, %d changed.":                         "快照 '%s' 与 %s 相比: 新增 %d 个，删除 %d 个，修改 %d 个。",
+	"Error deleting snapshot: %v":                                                    "删除快照出错: %v",
+	"Snapshot '%s' deleted.":                                                         "快照 '%s' 已删除。",
+	"Unknown snapshot action: %s":                                                    "未知快照操作: %s",
+	"Error: invalid --direction %q: use upload or download.":                         "错误: --direction 无效: %q，应为 upload 或 download。",
+	"Error: invalid --since %q: use an age such as 7d or a date such as 2024-01-31.": "错误: --since 无效: %q，应为 7d 这样的时长或 2024-01-31 这样的日期。",
+	"Error opening transfer history: %v":                                             "打开传输历史出错: %v",
+	"Error reading transfer history: %v":                                             "读取传输历史出错: %v",
+	"Transfers: %d (%d uploads, %d downloads, %d failed)\n":                          "传输: %d 次（上传 %d 次，下载 %d 次，失败 %d 次）\n",
+	"Uploaded: %s\n":                                                                 "已上传: %s\n",
+	"Downloaded: %s\n":                                                               "已下载: %s\n",
+	"Time spent: %s\n":                                                               "耗时: %s\n",
+	"Average speed: %s/s\n":                                                          "平均速度: %s/s\n",
+	"No transfers found.":                                                            "没有传输记录。",
+	"Error: specify a queue action: add, ls, rm or run.":                             "错误: 请指定队列操作: add、ls、rm 或 run。",
+	"Error: invalid --state %q: use pending, running, done or failed.":               "错误: --state 无效: %q，应为 pending、running、done 或 failed。",
+	"Error creating queue directory: %v":                                             "创建队列目录出错: %v",
+	"Error opening transfer queue: %v":                                               "打开传输队列出错: %v",
+	"Error: -s or --source and -d or --destination flags are required.":              "错误: 需要指定 -s/--source 和 -d/--destination。",
+	"Error listing files to queue: %v":                                               "列出待入队文件出错: %v",
+	"No files to queue.":                                                             "没有可加入队列的文件。",
+	"Error adding to transfer queue: %v":                                             "加入传输队列出错: %v",
+	"Queued %d transfers (%s). Run 'go-bdfs queue run' to start them.":               "已加入 %d 个传输（%s）。运行 'go-bdfs queue run' 开始传输。",
+	"Error reading transfer queue: %v":                                               "读取传输队列出错: %v",
+	"Error encoding queue: %v":                                                       "编码队列出错: %v",
+	"The transfer queue is empty.":                                                   "传输队列为空。",
+	"Error updating transfer queue: %v":                                              "更新传输队列出错: %v",
+	"Removed %d %s items from the queue.":                                            "已从队列中移除 %d 个 %s 状态的项目。",
+	"Error: specify the IDs of the items to remove, or --state.":                     "错误: 请指定要移除的项目 ID，或使用 --state。",
+	"Error: invalid queue item ID %q":                                                "错误: 队列项目 ID 无效: %q",
+	"Error removing queue item: %v":                                                  "移除队列项目出错: %v",
+	"Removed %d items from the queue.":                                               "已从队列中移除 %d 个项目。",
+	"Dry run: %d transfers would run.":                                               "演练模式: 将执行 %d 个传输。",
+	"Stopping after the current transfer; press Ctrl+C again to abort it.":           "当前传输完成后停止；再次按 Ctrl+C 立即中止。",
+	"[%d] failed: %v":                                                                "[%d] 失败: %v",
+	"Error running transfer queue: %v":                                               "运行传输队列出错: %v",
+	"Queue stopped: ":                                                                "队列已停止: ",
+	"Queue finished: ":                                                               "队列已完成: ",
+	"Unknown queue action: %s":                                                       "未知队列操作: %s",
+	"Error creating log directory: %v":                                               "创建日志目录出错: %v",
+	"Error opening log file: %v":                                                     "打开日志文件出错: %v",
+	"Error locating the go-bdfs executable: %v":                                      "找不到 go-bdfs 可执行文件: %v",
+	"Error in webhook configuration: %v":                                             "webhook 配置有误: %v",
+	"Error in job %d of the configuration: %v":                                       "配置中的第 %d 个任务有误: %v",
+	"Error: no jobs configured; add [[jobs]] entries to the configuration file.":     "错误: 没有配置任务；请在配置文件中添加 [[jobs]]。",
+	"%s | %s | next run: %s\n":                                                       "%s | %s | 下次运行: %s\n",
+	"Daemon started with %d jobs.":                                                   "守护进程已启动，共 %d 个任务。",
+	"Daemon stopped.":                                                                "守护进程已停止。",
+	"Error running as a service: %v":                                                 "以服务方式运行出错: %v",
+	"Error locating the log file: %v":                                                "找不到日志文件: %v",
+	"Error resolving the log file: %v":                                               "解析日志文件路径出错: %v",
+	"Error installing service: %v":                                                   "安装服务出错: %v",
+	"Service '%s' installed and started; logging to %s":                              "服务 '%s' 已安装并启动；日志写入 %s",
+	"Error uninstalling service: %v":                                                 "卸载服务出错: %v",
+	"Service '%s' uninstalled.":                                                      "服务 '%s' 已卸载。",
+	"Error getting service status: %v":                                               "获取服务状态出错: %v",
+	"Unknown daemon action: %s":                                                      "未知守护进程操作: %s",
+	"Error: -d or --dir flag is required to specify the directory path to create.":   "错误: 需要用 -d 或 --dir 指定要创建的目录路径。",
+	"Creating directory '%s' in Baidu Pan...":                                        "正在百度网盘中创建目录 '%s'...",
+	"Error creating directory: %v":                                                   "创建目录出错: %v",
+	"Directory '%s' created successfully.":                                           "目录 '%s' 创建成功。",
+	"Error: -f or --file flag is required to specify the file path to get information for.":      "错误: 需要用 -f 或 --file 指定要查看信息的文件路径。",
+	"Getting information for file: '%s' in Baidu Pan...":                                         "正在获取百度网盘中文件 '%s' 的信息...",
+	"Error getting file information: %v":                                                         "获取文件信息出错: %v",
+	"Error: -p or --path flag is required to specify the document to preview.":                   "错误: 需要用 -p 或 --path 指定要预览的文档。",
+	"Error getting preview link for '%s': %v":                                                    "获取 '%s' 的预览链接出错: %v",
+	"Error: -p or --path flag is required to specify what to share.":                             "错误: 需要用 -p 或 --path 指定要分享的内容。",
+	"Error creating share: %v":                                                                   "创建分享出错: %v",
+	"Error encoding share: %v":                                                                   "编码分享出错: %v",
+	"Error listing received shares: %v":                                                          "列出收到的分享出错: %v",
+	"Error encoding shares: %v":                                                                  "编码分享列表出错: %v",
+	"No received shares found.":                                                                  "没有收到的分享。",
+	"Getting disk information from Baidu Pan...":                                                 "正在获取百度网盘容量信息...",
+	"Error getting disk information: %v":                                                         "获取容量信息出错: %v",
+	"Attempting to refresh access token...":                                                      "正在刷新 access token...",
+	"Error loading existing tokens: %v":                                                          "加载现有令牌出错: %v",
+	"No refresh token available, cannot refresh access token.":                                   "没有 refresh token，无法刷新 access token。",
+	"Error refreshing token: %v":                                                                 "刷新令牌出错: %v",
+	"Access token refreshed successfully and saved to .bdfs_certs":                               "access token 刷新成功并已保存到 .bdfs_certs",
+	"No token file found, cannot refresh access token.":                                          "找不到令牌文件，无法刷新 access token。",
+	"Error: specify an index action: rebuild, prune or update.":                                  "错误: 请指定索引操作: rebuild、prune 或 update。",
+	"Error: the local index could not be opened.":                                                "错误: 无法打开本地索引。",
+	"Rebuilding local index for '%s'...":                                                         "正在重建 '%s' 的本地索引...",
+	"Error rebuilding index: %v":                                                                 "重建索引出错: %v",
+	"Indexed %d entries under '%s'.":                                                             "已索引 '%[2]s' 下的 %[1]d 个条目。",
+	"Pruning local index for '%s'...":                                                            "正在清理 '%s' 的本地索引...",
+	"Error pruning index: %v":                                                                    "清理索引出错: %v",
+	"Removed %d stale entries under '%s'.":                                                       "已移除 '%[2]s' 下 %[1]d 个过期条目。",
+	"Unknown index action: %s":                                                                   "未知索引操作: %s",
+	"Error: specify a hash-cache action: clear.":                                                 "错误: 请指定哈希缓存操作: clear。",
+	"Unknown hash-cache action: %s":                                                              "未知哈希缓存操作: %s",
+	"Hash cache is already empty.":                                                               "哈希缓存已经为空。",
+	"Error opening hash cache: %v":                                                               "打开哈希缓存出错: %v",
+	"Error clearing hash cache: %v":                                                              "清空哈希缓存出错: %v",
+	"Removed %d cached entries.":                                                                 "已移除 %d 条缓存。",
+	"Error setting up encryption: %v":                                                            "设置加密出错: %v",
+	"Error sending webhook notification: %v":                                                     "发送 webhook 通知出错: %v",
+	"Error in webhook configuration: invalid min_size %q":                                        "webhook 配置有误: min_size 无效: %q",
+	"Error in configuration: invalid notify_after %q":                                            "配置有误: notify_after 无效: %q",
+	"Interrupted: finishing the current slice and saving progress; press Ctrl+C again to abort.": "已中断: 正在完成当前分片并保存进度；再次按 Ctrl+C 立即中止。",
+	"Stopped: %v": "已停止: %v",
+	"Run the same command again to continue; saved progress is resumed.": "再次运行相同命令即可继续，将从保存的进度恢复。",
+	"Error: invalid --slice-size %q":                                     "错误: --slice-size 无效: %q",
+	"Error: specify a photos action: backup.":                            "错误: 请指定照片操作: backup。",
+	"Error: -s or --source flag is required.":                            "错误: 需要指定 -s 或 --source。",
+	"Error: invalid --layout %q: use YYYY, MM and DD, e.g. YYYY/MM":      "错误: --layout 无效: %q，请用 YYYY、MM 和 DD 组合，如 YYYY/MM",
+	"Error creating photo index directory: %v":                           "创建照片索引目录出错: %v",
+	"Error opening photo index: %v":                                      "打开照片索引出错: %v",
+	"Backing up photos and videos from '%s' to '%s'...":                  "正在将照片和视频从 '%s' 备份到 '%s'...",
+	"Error backing up photos: %v":                                        "备份照片出错: %v",
+	"Dry run: ":                                                          "演练模式: ",
+	"Photo backup finished with errors: ":                                "照片备份完成但有错误: ",
+	"Photo backup complete: ":                                            "照片备份完成: ",
+	"Organizing photos in '%s' by capture date...":                       "正在按拍摄日期整理 '%s' 中的照片...",
+	"Error organizing photos: %v":                                        "整理照片出错: %v",
+	"Organizing finished with errors: ":                                  "整理完成但有错误: ",
+	"Photos organized: ":                                                 "照片整理完成: ",
+	"Error in filter rules: %v":                                          "过滤规则有误: %v",
+	"Synchronizing Baidu Pan '%s' to local '%s'...":                      "正在将百度网盘 '%s' 同步到本地 '%s'...",
+	"Synchronizing local '%s' to Baidu Pan '%s'...":                      "正在将本地 '%s' 同步到百度网盘 '%s'...",
+	"Error synchronizing: %v":                                            "同步出错: %v",
+	"Sync complete: ":                                                    "同步完成: ",
+	"go-bdfs version %s\n":                                               "go-bdfs 版本 %s\n",
+	"Error checking for updates: %v":                                     "检查更新出错: %v",
+	"go-bdfs is up to date (latest release: %s).":                        "go-bdfs 已是最新版本（最新发布: %s）。",
+	"A newer version is available: %s, released %s.":                     "有新版本可用: %s，发布于 %s。",
+	"Release notes: %s\n":                                                "发布说明: %s\n",
+	"Run 'go-bdfs selfupdate' to install it.":                            "运行 'go-bdfs selfupdate' 安装。",
+	"Error: this binary was built with an invalid release key.":          "错误: 此程序构建时使用了无效的发布密钥。",
+	"Error locating the go-bdfs binary: %v":                              "找不到 go-bdfs 程序: %v",
+	"go-bdfs %s is up to date.":                                          "go-bdfs %s 已是最新版本。",
+	"Replace go-bdfs %s at '%s' with %s? (y/N): ":                        "将 '%[2]s' 处的 go-bdfs %[1]s 替换为 %[3]s？(y/N): ",
+	"Update cancelled.":                                                  "已取消更新。",
+	"Downloading %s %s...":                                               "正在下载 %s %s...",
+	"Error updating go-bdfs: %v":                                         "更新 go-bdfs 出错: %v",
+	"Updated to %s (checksum and signature verified).":                   "已更新到 %s（校验和与签名均已验证）。",
+	"Updated to %s (checksum verified).":                                 "已更新到 %s（校验和已验证）。",
+	"  preview     Print a link to view a document in the browser without downloading it":                                        "  preview     输出在浏览器中查看文档的链接，无需下载",
+	"  share       Create a password-protected share link and print it with its extraction code on one line":                     "  share       创建带提取码的分享链接，并在一行中输出链接和提取码",
+	"  shares      List the shares other users have sent to this account, with the fs_id of each shared file":                    "  shares      列出其他用户分享给本账号的内容及每个文件的 fs_id",
+	"  index       Manage the local metadata index of remote files":                                                              "  index       管理网盘文件的本地元数据索引",
+	"  prune-empty Recursively remove directories that contain no files":                                                         "  prune-empty 递归删除不含任何文件的目录",
+	"  report      Summarize storage use by extension, top-level folder and age":                                                 "  report      按扩展名、顶层文件夹和时间统计存储占用",
+	"  speedtest   Measure sustained upload and download throughput per connection count":                                        "  speedtest   按不同连接数测量持续的上传和下载速度",
+	"  export      Export path, size, MD5, fs_id and timestamps of every file beneath a directory as JSON":                       "  export      以 JSON 导出目录下每个文件的路径、大小、MD5、fs_id 和时间戳",
+	"  diff        Report files added, removed or changed since a manifest was exported":                                         "  diff        报告自导出清单以来新增、删除或修改的文件",
+	"  snapshot    Manage named snapshots of the remote tree stored locally":                                                     "  snapshot    管理保存在本地的网盘目录树命名快照",
+	"  photos      Back up photos and videos into date folders (e.g. /Photos/2024/05), skipping content backed up before":        "  photos      将照片和视频备份到日期文件夹（如 /Photos/2024/05），跳过已备份的内容",
+	"  organize    Move remote photos into date folders by EXIF capture date, reading only their headers":                        "  organize    按 EXIF 拍摄日期将网盘照片移入日期文件夹，只读取文件头",
+	"  history     Show the transfer history, with filters or as a summary":                                                      "  history     查看传输历史，可筛选或汇总",
+	"  stats       Run another command, then print its stats (same as the global --stats flag)":                                  "  stats       运行另一个命令并输出其统计信息（等同于全局参数 --stats）",
+	"  queue       Queue transfers on disk and run them, resuming after interruptions":                                           "  queue       将传输保存到磁盘队列并执行，中断后可继续",
+	"  daemon      Run the jobs configured under [[jobs]] on their cron schedules until interrupted":                             "  daemon      按 cron 计划运行 [[jobs]] 中配置的任务，直到被中断",
+	"  doctor      Check the configuration, token file, Baidu endpoints, token validity and clock skew":                          "  doctor      检查配置、令牌文件、百度接口、令牌有效性和时钟偏差",
+	"  version     Show the version information, and with --check whether a newer release exists":                                "  version     显示版本信息，加 --check 检查是否有新版本",
+	"  selfupdate  Download the latest release, verify its checksum (and signature) and replace this binary":                     "  selfupdate  下载最新版本，验证校验和（及签名）并替换当前程序",
+	"  help        Show this help message":                                                                                       "  help        显示本帮助信息",
+	"  --debug     Log HTTP requests (with tokens redacted) and responses to stderr":                                             "  --debug     将 HTTP 请求（令牌已隐去）和响应输出到 stderr",
+	"  -q, --quiet Only print results (listings, info, reports), warnings and errors: no status messages or progress":            "  -q, --quiet 只输出结果（列表、信息、报告）、警告和错误，不输出状态消息和进度",
+	"              Also print each API request (method, status, errno, request_id, duration) and other details":                  "              额外输出每个 API 请求（方法、状态码、errno、request_id、耗时）及其他细节",
+	"              Serve Prometheus metrics on <addr>/metrics while the command runs (for long-running modes)":                   "              命令运行期间在 <addr>/metrics 提供 Prometheus 指标（适用于长时间运行的模式）",
+	"              Serve the transfers in progress, their speeds and ETAs, and the queue on http://127.0.0.1:<port>/status":      "              在 http://127.0.0.1:<port>/status 提供进行中的传输、速度、剩余时间和队列",
+	"              (add ?format=json for JSON); on Unix, SIGUSR1 prints the same status to stderr":                               "              （加 ?format=json 输出 JSON）；在 Unix 上收到 SIGUSR1 时将同样的状态输出到 stderr",
+	"              Limit API requests per second for each endpoint to avoid Baidu's request limits":                              "              限制每个接口每秒的 API 请求数，避免触发百度的频率限制",
+	"              Retry a failed upload slice up to <n> times with backoff before failing the upload (default: 3, 0 disables)":  "              上传分片失败时退避重试最多 <n> 次后才判定上传失败（默认: 3，0 表示不重试）",
+	"  --dry-run   Print the API operations rm, mv, rn, cp, ul and sync would perform (with byte totals) without executing them": "  --dry-run   打印 rm、mv、rn、cp、ul 和 sync 将执行的 API 操作（含字节总数）而不实际执行",
+	"Use 'go-bdfs <command> -h' or 'go-bdfs <command> --help' for more information about a command.":                             "使用 'go-bdfs <命令> -h' 或 'go-bdfs <命令> --help' 查看命令的详细说明。",
+	"Print the check results as JSON": "以 JSON 输出检查结果",
+	"Show help for doctor command":    "显示 doctor 命令的帮助",
+	"Directory to list (default: /)":  "要列出的目录（默认: /）",
+	"Only list entries of these types: image, video, audio, doc, archive, other or dir":    "只列出这些类型的条目: image、video、audio、doc、archive、other 或 dir",
+	"Show the duration, resolution and bitrate of videos and audio files":                  "显示视频和音频文件的时长、分辨率和码率",
+	"Only list videos and audio files at least this long, e.g. 30s (implies --media-info)": "只列出不短于此时长的视频和音频，如 30s（隐含 --media-info）",
+	"Only list videos and audio files at most this long, e.g. 2m (implies --media-info)":   "只列出不长于此时长的视频和音频，如 2m（隐含 --media-info）",
+	"Show help for list command":                    "显示 list 命令的帮助",
+	"File path in Baidu Pan to download (required)": "要下载的百度网盘文件路径（必填）",
+	"Local output file path (optional, defaults to current directory with original filename)":         "本地保存路径（可选，默认保存到当前目录并使用原文件名）",
+	"Don't set the local file's modification time from the remote file":                               "不按网盘文件设置本地文件的修改时间",
+	"Skip MD5 verification of the downloaded file":                                                    "跳过下载文件的 MD5 校验",
+	"Number of re-downloads after an MD5 mismatch":                                                    "MD5 不一致时重新下载的次数",
+	"Decrypt a file uploaded with --crypt":                                                            "解密用 --crypt 上传的文件",
+	"Reassemble a file uploaded with --split from its parts and manifest":                             "根据分段和清单重组用 --split 上传的文件",
+	"Download a directory and everything beneath it":                                                  "下载目录及其下的所有内容",
+	"Number of files downloaded concurrently with --recursive":                                        "使用 --recursive 时同时下载的文件数",
+	"Number of byte ranges of each file downloaded concurrently":                                      "每个文件同时下载的字节区间数",
+	"Download even if the local disk seems too full":                                                  "即使本地磁盘空间看起来不足也下载",
+	"Show help for download command":                                                                  "显示 download 命令的帮助",
+	"Local file path to upload (required)":                                                            "要上传的本地文件路径（必填）",
+	"Remote file path in Baidu Pan (required, e.g., /path/to/your/file.txt)":                          "百度网盘中的目标文件路径（必填，如 /path/to/your/file.txt）",
+	"Don't preserve the local modification time on the uploaded file":                                 "不在上传的文件上保留本地修改时间",
+	"Encrypt the file with the configured key before uploading":                                       "上传前用配置的密钥加密文件",
+	"Upload a file larger than the account's size limit as parts plus a manifest":                     "将超过账号大小限制的文件分段上传并附带清单",
+	"Upload slice size, e.g. 4M or 16M (default: chosen from the account's VIP level)":                "上传分片大小，如 4M 或 16M（默认: 按账号会员等级选择）",
+	"Upload a directory as a single archive: tar, tar.gz or zip":                                      "将目录打包为单个压缩包上传: tar、tar.gz 或 zip",
+	"What to do when the remote file exists: overwrite, rename, skip or fail":                         "网盘文件已存在时的处理方式: overwrite、rename、skip 或 fail",
+	"Always transfer the content, even when Baidu Pan already holds an identical copy":                "总是传输内容，即使百度网盘已有相同文件",
+	"Skip the upload when the remote file has the same size and MD5":                                  "网盘文件大小和 MD5 相同时跳过上传",
+	"Show help for upload command":                                                                    "显示 upload 命令的帮助",
+	"Remote file or directory path to remove (required)":                                              "要删除的网盘文件或目录路径（必填）",
+	"Force removal without confirmation":                                                              "不经确认直接删除",
+	"Show help for remove command":                                                                    "显示 remove 命令的帮助",
+	"Source file or directory path to move (required)":                                                "要移动的源文件或目录路径（必填）",
+	"Destination directory path (required)":                                                           "目标目录路径（必填）",
+	"Force move without confirmation":                                                                 "不经确认直接移动",
+	"Show help for move command":                                                                      "显示 move 命令的帮助",
+	"Source file or directory path to rename (required)":                                              "要重命名的源文件或目录路径（必填）",
+	"New name for the file or directory (required)":                                                   "文件或目录的新名称（必填）",
+	"Force rename without confirmation":                                                               "不经确认直接重命名",
+	"Show help for rename command":                                                                    "显示 rename 命令的帮助",
+	"Source file or directory path to copy (required)":                                                "要复制的源文件或目录路径（必填）",
+	"Destination file or directory path (required)":                                                   "目标文件或目录路径（必填）",
+	"Show help for copy command":                                                                      "显示 copy 命令的帮助",
+	"Source as [profile:]path, e.g. work:/docs (required)":                                            "源，格式为 [账号配置:]路径，如 work:/docs（必填）",
+	"Destination as [profile:]path, e.g. home:/backup/docs (required)":                                "目标，格式为 [账号配置:]路径，如 home:/backup/docs（必填）",
+	"Don't preserve the source modification times on the copies":                                      "不在副本上保留源文件的修改时间",
+	"Upload slice size, e.g. 4M or 16M (default: chosen from the destination account's VIP level)":    "上传分片大小，如 4M 或 16M（默认: 按目标账号会员等级选择）",
+	"Show help for xcopy command":                                                                     "显示 xcopy 命令的帮助",
+	"Remote directory to clean up (required)":                                                         "要清理的网盘目录（必填）",
+	"Remove without confirmation":                                                                     "不经确认直接删除",
+	"Show help for prune-empty command":                                                               "显示 prune-empty 命令的帮助",
+	"Remote directory to report on":                                                                   "要统计的网盘目录",
+	"Print the report as JSON":                                                                        "以 JSON 输出报告",
+	"Largest groups to show per table (0 for all; JSON, CSV and TSV output is never truncated)":       "每个表格显示的最大分组数（0 表示全部；JSON、CSV 和 TSV 输出不截断）",
+	"Show help for report command":                                                                    "显示 report 命令的帮助",
+	"Data to transfer in each run, e.g. 64M or 1G":                                                    "每轮传输的数据量，如 64M 或 1G",
+	"Concurrent connection counts to compare":                                                         "要比较的并发连接数",
+	"Remote directory for the temporary test files":                                                   "存放临时测试文件的网盘目录",
+	"Print the results as JSON":                                                                       "以 JSON 输出结果",
+	"Show help for speedtest command":                                                                 "显示 speedtest 命令的帮助",
+	"Remote directory to export":                                                                      "要导出的网盘目录",
+	"File to write the manifest to (default: stdout)":                                                 "写入清单的文件（默认: 标准输出）",
+	"Show help for export command":                                                                    "显示 export 命令的帮助",
+	"Manifest written by the export command (required)":                                               "export 命令生成的清单（必填）",
+	"Remote directory to compare with (default: the manifest's root)":                                 "要比较的网盘目录（默认: 清单的根目录）",
+	"Print the differences as JSON":                                                                   "以 JSON 输出差异",
+	"Print only the paths of added, removed or changed files":                                         "只输出新增、删除或修改的文件路径",
+	"Show help for diff command":                                                                      "显示 diff 命令的帮助",
+	"Remote directory to snapshot (create only)":                                                      "要创建快照的网盘目录（仅 create）",
+	"Replace an existing snapshot with the same name (create only)":                                   "替换同名的已有快照（仅 create）",
+	"Print the differences as JSON (diff only)":                                                       "以 JSON 输出差异（仅 diff）",
+	"Print only the paths of added, removed or changed files (diff only)":                             "只输出新增、删除或修改的文件路径（仅 diff）",
+	"Show help for snapshot command":                                                                  "显示 snapshot 命令的帮助",
+	"Only transfers within this age (e.g. 24h, 7d) or since this date (2006-01-02)":                   "只显示此时长内（如 24h、7d）或此日期（2006-01-02）之后的传输",
+	"Only uploads or downloads: upload or download":                                                   "只显示上传或下载: upload 或 download",
+	"Only failed transfers":                                                                           "只显示失败的传输",
+	"Only transfers whose local or remote path contains this text":                                    "只显示本地或网盘路径包含此文本的传输",
+	"Show only the most recent N transfers (0 for all)":                                               "只显示最近 N 次传输（0 表示全部）",
+	"Print totals instead of individual transfers":                                                    "输出汇总而不是逐条传输",
+	"Print the transfers or summary as JSON":                                                          "以 JSON 输出传输记录或汇总",
+	"Show help for history command":                                                                   "显示 history 命令的帮助",
+	"File or directory to transfer: local, or remote with --download (add only)":                      "要传输的文件或目录: 本地路径，加 --download 时为网盘路径（仅 add）",
+	"Destination path: remote, or local with --download (add only)":                                   "目标路径: 网盘路径，加 --download 时为本地路径（仅 add）",
+	"Queue a download from Baidu Pan instead of an upload (add only)":                                 "加入从百度网盘下载的任务而不是上传（仅 add）",
+	"Show or remove only items in this state: pending, running, done or failed (ls and rm)":           "只显示或移除此状态的项目: pending、running、done 或 failed（ls 和 rm）",
+	"Print the queue as JSON (ls only)":                                                               "以 JSON 输出队列（仅 ls）",
+	"Retry failed items as well as pending ones (run only)":                                           "除待处理项目外也重试失败的项目（仅 run）",
+	"Upload slice size, e.g. 4M or 16M (run only)":                                                    "上传分片大小，如 4M 或 16M（仅 run）",
+	"Show help for queue command":                                                                     "显示 queue 命令的帮助",
+	"List the configured jobs and their next run times, then exit":                                    "列出已配置的任务及下次运行时间后退出",
+	"Service name used by install, uninstall and status":                                              "install、uninstall 和 status 使用的服务名称",
+	"Show help for daemon command":                                                                    "显示 daemon 命令的帮助",
+	"Directory path to create in Baidu Pan (required)":                                                "要在百度网盘中创建的目录路径（必填）",
+	"Show help for mkdir command":                                                                     "显示 mkdir 命令的帮助",
+	"File path in Baidu Pan to get information for (required)":                                        "要查看信息的百度网盘文件路径（必填）",
+	"Show help for info command":                                                                      "显示 info 命令的帮助",
+	"Document path in Baidu Pan to preview (required)":                                                "要预览的百度网盘文档路径（必填）",
+	"Open the preview in the default browser":                                                         "在默认浏览器中打开预览",
+	"Show help for preview command":                                                                   "显示 preview 命令的帮助",
+	"File or directory to share; repeat to share several in one link (required)":                      "要分享的文件或目录；可重复指定以在一个链接中分享多个（必填）",
+	"4-character extraction code, or auto to generate one":                                            "4 位提取码，或 auto 自动生成",
+	"How long the link is valid: 1d, 7d, 30d, 365d or forever":                                        "链接有效期: 1d、7d、30d、365d 或 forever",
+	"Print the share as JSON":                                                                         "以 JSON 输出分享",
+	"Show help for share command":                                                                     "显示 share 命令的帮助",
+	"Print the shares as JSON":                                                                        "以 JSON 输出分享列表",
+	"Show help for shares command":                                                                    "显示 shares 命令的帮助",
+	"Show help for disk info command":                                                                 "显示 disk info 命令的帮助",
+	"Show help for refresh token command":                                                             "显示 refresh token 命令的帮助",
+	"Remote directory to rebuild or prune (default: /)":                                               "要重建或清理的网盘目录（默认: /）",
+	"Show help for index command":                                                                     "显示 index 命令的帮助",
+	"Show help for hash-cache command":                                                                "显示 hash-cache 命令的帮助",
+	"Local directory of photos and videos to back up (required)":                                      "要备份的照片和视频所在的本地目录（必填）",
+	"Remote directory to back up into":                                                                "备份到的网盘目录",
+	"Date directories beneath the destination, from YYYY, MM and DD":                                  "目标目录下的日期目录格式，由 YYYY、MM 和 DD 组成",
+	"Upload slice size, e.g. 4M or 16M":                                                               "上传分片大小，如 4M 或 16M",
+	"Show help for photos command":                                                                    "显示 photos 命令的帮助",
+	"Remote directory whose photos to organize, recursively":                                          "要递归整理照片的网盘目录",
+	"Remote directory to create the date folders in (default: the --path directory)":                  "创建日期文件夹的网盘目录（默认: --path 指定的目录）",
+	"Date photos without an EXIF capture date by their modification time instead of leaving them":     "没有 EXIF 拍摄日期的照片按修改时间归档，而不是保留原位",
+	"Number of photo headers to read concurrently":                                                    "同时读取的照片文件头数量",
+	"Show help for organize command":                                                                  "显示 organize 命令的帮助",
+	"Source directory: local, or remote with --download (required)":                                   "源目录: 本地路径，加 --download 时为网盘路径（必填）",
+	"Destination directory: remote, or local with --download (required)":                              "目标目录: 网盘路径，加 --download 时为本地路径（必填）",
+	"Sync from Baidu Pan to the local directory instead of uploading":                                 "从百度网盘同步到本地目录，而不是上传",
+	"Mirror mode: delete destination files that are not present in the source":                        "镜像模式: 删除目标中源不存在的文件",
+	"Abort if more than this percentage of destination files would be deleted (0 disables the check)": "将删除的目标文件超过此百分比时中止（0 表示不检查）",
+	"Read the remote tree from the local index instead of listing it":                                 "从本地索引读取网盘目录树而不是在线列出",
+	"Number of remote directories to list concurrently":                                               "同时列出的网盘目录数",
+	"Don't preserve modification times on uploaded or downloaded files":                               "不在上传或下载的文件上保留修改时间",
+	"Always transfer uploaded content, even when Baidu Pan already holds an identical copy":           "总是传输上传内容，即使百度网盘已有相同文件",
+	"Encrypt uploaded and decrypt downloaded files with the configured key":                           "用配置的密钥加密上传的文件、解密下载的文件",
+	"Skip MD5 verification of downloaded files":                                                       "跳过下载文件的 MD5 校验",
+	"Show help for sync command":                                                                      "显示 sync 命令的帮助",
+	"Check the release feed for a newer version":                                                      "检查发布源是否有新版本",
+	"Release feed URL (GitHub latest release API or a mirror)":                                        "发布源地址（GitHub 最新发布 API 或镜像）",
+	"Show help for version command":                                                                   "显示 version 命令的帮助",
+	"Update without confirmation":                                                                     "不经确认直接更新",
+	"Install the latest release even if it is not newer":                                              "即使不是更新的版本也安装最新发布",
+	"Show help for selfupdate command":                                                                "显示 selfupdate 命令的帮助",
+
+	"Invalid value for --dump-raw: expected --dump-raw=<file>":                                                                     "--dump-raw 的值无效: 应为 --dump-raw=<文件>",
+	"Failed to open raw dump file: %v":                                                                                             "无法打开原始响应转储文件: %v",
+	"  --dump-raw[=<file>]    Write each raw API response as JSON to stderr or <file>":                                             "  --dump-raw[=<file>]    将每个原始 API 响应以 JSON 写入 stderr 或 <file>",
+	"              Write every raw JSON API response, tokens redacted, to stderr or append it to <file>, one JSON object per line": "              将每个原始 JSON API 响应（令牌已隐去）写入 stderr 或追加到 <file>，每行一个 JSON 对象",
+
+	"fs_id of the file or directory to download, instead of -s":                            "要下载的文件或目录的 fs_id，代替 -s",
+	"fs_id of the file or directory to remove, instead of -s":                              "要删除的文件或目录的 fs_id，代替 -s",
+	"fs_id of the file or directory to move, instead of -s":                                "要移动的文件或目录的 fs_id，代替 -s",
+	"fs_id of the file or directory, instead of -p":                                        "文件或目录的 fs_id，代替 -p",
+	"fs_id of a file or directory to share, instead of -p; repeat or separate with commas": "要分享的文件或目录的 fs_id，代替 -p；可重复或用逗号分隔",
+	"Error: --fsid cannot be combined with paths.":                                         "错误: --fsid 不能与路径同时使用。",
+	"Error: --fsid cannot be combined with a path.":                                        "错误: --fsid 不能与路径同时使用。",
+	"Error looking up fs_id %d: %v":                                                        "查找 fs_id %d 时出错: %v",
+
+	"Rename the entries of the directory -s with s/<regexp>/<replacement>/[gi] or a template such as 'Trip_%03d{ext}', instead of -n": "用 s/<正则>/<替换>/[gi] 或模板（如 'Trip_%03d{ext}'）重命名目录 -s 中的条目，代替 -n",
+	"With --pattern, only rename entries whose name matches this glob":                                                                "与 --pattern 一起使用时，只重命名名称匹配此通配符的条目",
+	"With --pattern, rename directories as well as files":                                                                             "与 --pattern 一起使用时，同时重命名目录",
+	"With --pattern, first value of the template's counter":                                                                           "与 --pattern 一起使用时，模板计数器的起始值",
+	"Error: --pattern cannot be combined with -n.":                                                                                    "错误: --pattern 不能与 -n 同时使用。",
+	"No names in '%s' change.":                                                                                                        "'%s' 中没有名称需要更改。",
+	"Rename %d entries in '%s'? (y/N): ":                                                                                              "重命名 '%[2]s' 中的 %[1]d 个条目？(y/N): ",
+	"Renamed %d entries in '%s'.":                                                                                                     "已重命名 '%[2]s' 中的 %[1]d 个条目。",
+
+	"Source file or directory path to move; repeat to move several (required)":            "要移动的源文件或目录路径；可重复以移动多个（必需）",
+	"fs_id of a file or directory to move, instead of -s; repeat or separate with commas": "要移动的文件或目录的 fs_id，代替 -s；可重复或用逗号分隔",
+	"Error looking up fs_ids: %v":                               "查找 fs_id 时出错: %v",
+	"Are you sure you want to move %d entries to '%s'? (y/N): ": "确定要将 %d 个条目移动到 '%s' 吗？(y/N): ",
+	"Error moving '%s': %v":                                     "移动 '%s' 时出错: %v",
+	"Source file or directory path to copy; repeat to copy several into the destination directory (required)": "要复制的源文件或目录路径；可重复以将多个复制到目标目录（必需）",
+	"Error copying '%s': %v": "复制 '%s' 时出错: %v",
+
+	"Delete without keeping the entry in the recycle bin (not supported by Baidu Pan's open API)":                                                    "删除时不保留在回收站中（百度网盘开放 API 不支持）",
+	"After removing, print how to restore the entry from the recycle bin":                                                                            "删除后显示如何从回收站恢复该条目",
+	"Error: Baidu Pan's open API cannot delete permanently; removed entries always go to the recycle bin. Empty it in the Baidu Pan website or app.": "错误: 百度网盘开放 API 无法永久删除；删除的条目总是进入回收站。请在百度网盘网页或 App 中清空回收站。",
+	"Are you sure you want to remove '%s'? It goes to the recycle bin and can be restored for %d days. (y/N): ":                                      "确定要删除 '%s' 吗？它将进入回收站，可在 %d 天内恢复。(y/N): ",
+	"It is in the recycle bin and can be restored for %d days.":                                                                                      "它已进入回收站，可在 %d 天内恢复。",
+	"To restore it, open the recycle bin in the Baidu Pan website or app before %s and restore '%s' (fs_id %d).":                                     "如需恢复，请在 %s 之前打开百度网盘网页或 App 的回收站并恢复 '%s'（fs_id %d）。",
+	"It is restored to its original path, %s.":                                                                                                       "它将恢复到原路径 %s。",
+
+	"Invalid value for --max-requests: %q":                                                                                                    "--max-requests 的值无效: %q",
+	"  --max-requests <n>     Limit API requests in flight at once (default: 16, 0 for unlimited)":                                            "  --max-requests <n>     限制同时进行的 API 请求数（默认 16，0 表示不限制）",
+	"              Limit the API requests in flight at once across all workers (default: 16, or max_requests in the config; 0 for unlimited)": "              限制所有工作线程同时进行的 API 请求数（默认 16，或配置中的 max_requests；0 表示不限制）",
+
+	"Error in configuration: %v": "配置错误: %v",
+
+	"  --ipv4, --ipv6         Connect to Baidu Pan only over IPv4 or IPv6":                          "  --ipv4, --ipv6         仅通过 IPv4 或 IPv6 连接百度网盘",
+	"              Connect only over IPv4 or IPv6, overriding ip_family in the [transport] section": "              仅通过 IPv4 或 IPv6 连接，覆盖 [transport] 中的 ip_family",
+
+	"  --print-curl           Print an equivalent curl command for each API request to stderr":                                        "  --print-curl           将每个 API 请求对应的 curl 命令输出到 stderr",
+	"              Print an equivalent curl command for each API request to stderr, with ${BDFS_ACCESS_TOKEN} in place of the token;": "              将每个 API 请求对应的 curl 命令输出到 stderr，令牌以 ${BDFS_ACCESS_TOKEN} 代替；",
+	"              with --dry-run, modifying requests are printed by the dry run instead of being sent":                               "              与 --dry-run 一起使用时，修改类请求由演练输出而不会发送",
+	"Fetching changes since the last index update...":                                                                                 "正在获取自上次索引更新以来的变更...",
+	"Error updating index: %v":                                                                       "更新索引出错: %v",
+	"Rebuilt the index from the full listing: %d entries.":                                           "已根据完整列表重建索引: %d 个条目。",
+	"Updated %d entries and removed %d.":                                                             "已更新 %d 个条目，移除 %d 个。",
+	"Compare the trees again instead of continuing an interrupted sync":                              "重新比较目录树，而不是继续中断的同步",
+	"Continued an interrupted sync: %d operations were already done.":                                "已继续中断的同步: 之前已完成 %d 个操作。",
+	"Error: invalid --links %q: use follow, skip or error.":                                          "错误: 无效的 --links %q: 请使用 follow、skip 或 error。",
+	"Symbolic links in local directories: follow, skip or error":                                     "本地目录中的符号链接: follow（跟随）、skip（跳过）或 error（报错）",
+	"Symbolic links in a local directory: follow, skip or error (add only)":                          "本地目录中的符号链接: follow（跟随）、skip（跳过）或 error（报错）（仅 add）",
+	"Error: invalid --special %q: use skip, upload or error.":                                        "错误: 无效的 --special %q: 请使用 skip、upload 或 error。",
+	"Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error":            "套接字、管道、设备和稀疏文件: skip（跳过）、upload（上传，仅限稀疏文件）或 error（报错）",
+	"Sockets, pipes, devices and sparse files: skip, upload (sparse files only) or error (add only)": "套接字、管道、设备和稀疏文件: skip（跳过）、upload（上传，仅限稀疏文件）或 error（报错）（仅 add）",
+	"Skipping %s: %s": "跳过%s: %s",
+	"Archive smaller parts of the directory separately, or upload it file by file with sync.":          "请分别归档目录中较小的部分，或使用 sync 逐个文件上传。",
+	"Upload it with --split to store it as parts plus a manifest; 'dl --split' puts it back together.": "请使用 --split 将其分块上传并附带清单；'dl --split' 可将其还原。",
+	"How to tell whether a file changed: size, mtime, md5 or size+mtime":                               "判断文件是否变化的方式: size、mtime、md5 或 size+mtime",
+	"  task        Check on an asynchronous copy, move or delete task (query, wait)":                   "  task        查看异步复制、移动或删除任务（query、wait）",
+	"Start the removal as a server-side task and print its id instead of waiting; see 'task'":          "以服务端任务方式开始删除并输出任务 ID，不等待完成；参见 'task'",
+	"Start the move as a server-side task and print its id instead of waiting; see 'task'":             "以服务端任务方式开始移动并输出任务 ID，不等待完成；参见 'task'",
+	"Start the copy as a server-side task and print its id instead of waiting; see 'task'":             "以服务端任务方式开始复制并输出任务 ID，不等待完成；参见 'task'",
+	"Baidu Pan carried out the operation at once.":                                                     "百度网盘已立即完成该操作。",
+	"Started task %d. Check on it with 'go-bdfs task query %d' or 'go-bdfs task wait %d'.":             "已启动任务 %d。可使用 'go-bdfs task query %d' 或 'go-bdfs task wait %d' 查看进度。",
+	"With wait, give up after this long, e.g. 30m (default: until the task finishes)":                  "与 wait 一起使用时，超过该时长后停止等待，如 30m（默认: 直到任务结束）",
+	"Show help for task command":                                  "显示 task 命令的帮助",
+	"Error: specify a task action, query or wait, and a task id.": "错误: 请指定任务操作（query 或 wait）和任务 ID。",
+	"Error: invalid task id: %s":                                  "错误: 无效的任务 ID: %s",
+	"Waiting for task %d to finish...":                            "正在等待任务 %d 完成...",
+	"Unknown task action: %s":                                     "未知的任务操作: %s",
+	"Error checking task %d: %v":                                  "查询任务 %d 出错: %v",
+	"Task %d is %s (%d%%).":                                       "任务 %d 状态: %s（%d%%）。",
+	"Task %d is %s.":                                              "任务 %d 状态: %s。",
+	"Error on '%s': %v":                                           "'%s' 出错: %v",
+	"Task %d failed: %v":                                          "任务 %d 失败: %v",
+	"Task %d finished, but %d of its %d entries failed.":          "任务 %d 已结束，但有 %d 个条目失败（共 %d 个）。",
+	"Task %d succeeded.":                                          "任务 %d 已成功完成。",
+	"  task        Check on an asynchronous copy, move or delete task started with --no-wait": "  task        查看使用 --no-wait 启动的异步复制、移动或删除任务",
+	"List the whole subtree, each name indented by its depth":                                 "列出整个子目录树，名称按层级缩进",
+	"List at most this many levels below the directory (implies -R; default: no limit)":       "最多列出目录下的这么多层（隐含 -R；默认: 不限）",
+	"Print only the full path of each entry, one per line":                                    "每行只输出一个条目的完整路径",
+	"Error: --max-depth cannot be negative.":                                                  "错误: --max-depth 不能为负数。",
+	"Only list entries whose name matches this glob, e.g. '*.mkv'; repeat for several":        "只列出名称匹配该通配符的条目，如 '*.mkv'；可重复指定多个",
+	"Only list entries whose name matches this regular expression; repeat for several":        "只列出名称匹配该正则表达式的条目；可重复指定多个",
+	"Output format: text, or csv or tsv with a header row":                                    "输出格式: text，或带表头行的 csv 或 tsv",
+	"Error writing output: %v":                                                                "写入输出出错: %v",
+	"Error: --json cannot be combined with --output csv or tsv.":                              "错误: --json 不能与 --output csv 或 tsv 同时使用。",
+	"Print only the full path of each entry, each followed by a NUL byte, for xargs -0":       "只输出每个条目的完整路径，并以 NUL 字节结尾，供 xargs -0 使用",
+	"Error: --print0 cannot be combined with --output csv or tsv.":                            "错误: --print0 不能与 --output csv 或 tsv 同时使用。",
+	"File path in Baidu Pan to get information for; repeat for several (required)":            "要查看信息的百度网盘文件路径；可重复指定多个（必填）",
+	"fs_id of the file or directory, instead of -p; repeat or separate with commas":           "文件或目录的 fs_id，代替 -p；可重复指定或用逗号分隔",
+	"Print the information as a JSON array":                                                   "以 JSON 数组输出信息",
+	"Error: -p or --path flag is required to specify the file path to get information for.":   "错误：需要使用 -p 或 --path 指定要查看信息的文件路径。",
+	"Getting information for %d files in Baidu Pan...":                                        "正在获取百度网盘中 %d 个文件的信息...",
+	"Exit Status:": "退出状态：",
+	"  0 success, 1 other failure, 2 invalid flags or arguments, 3 authorization failed,":  "  0 成功，1 其他失败，2 参数或选项无效，3 授权失败，",
+	"  4 not found, 5 quota exceeded, 6 rate limited, 7 partial failure, 8 network error,": "  4 未找到，5 超出配额，6 请求受限，7 部分失败，8 网络错误，",
+	"  130 interrupted": "  130 被中断",
+	"  --read-only            Refuse every command and request that changes files in Baidu Pan":                                           "  --read-only            拒绝所有会更改百度网盘文件的命令和请求",
+	"Error: %s changes files in Baidu Pan, which read-only mode does not allow.":                                                          "错误：%s 会更改百度网盘中的文件，只读模式下不允许。",
+	"              Refuse ul, rm, mv, rn, cp, md, share and other commands that change files in Baidu Pan (also read_only in the config)": "              拒绝 ul、rm、mv、rn、cp、md、share 等会更改百度网盘文件的命令（也可在配置中设置 read_only）",
+	"  --yes                  Answer yes to every confirmation of rm, mv, rn, prune-empty and update":                                     "  --yes                  对 rm、mv、rn、prune-empty 和 update 的所有确认都回答是",
+	"              Answer yes to every confirmation, as -y does for a single command (also assume_yes in the config)":                     "              对所有确认都回答是，相当于为每个命令指定 -y（也可在配置中设置 assume_yes）",
+	"  shell       Browse Baidu Pan interactively with cd, ls, get and put":                                                               "  shell       以交互方式浏览百度网盘，支持 cd、ls、get 和 put",
+	"  shell       Interactive shell keeping remote and local working directories, both shown in the prompt":                              "  shell       交互式 shell，维护远程和本地工作目录，并都显示在提示符中",
+	"              Commands: pwd, cd, lpwd, lcd, ls, get, put, help, exit; remote paths may be relative":                                  "              命令：pwd、cd、lpwd、lcd、ls、get、put、help、exit；远程路径可以是相对路径",
+	"Remote directory to start in (default: /)":                                                                                           "起始的远程目录（默认：/）",
+	"Show help for shell command":                                                               "显示 shell 命令的帮助",
+	"Type 'help' for the shell commands, 'exit' to leave.":                                      "输入 'help' 查看 shell 命令，输入 'exit' 退出。",
+	"Shell commands (remote paths may be relative to the remote working directory):":            "Shell 命令（远程路径可以相对于远程工作目录）：",
+	"  pwd, cd [<dir>]             Show or change the remote working directory (default: /)":    "  pwd, cd [<dir>]             显示或切换远程工作目录（默认：/）",
+	"  lpwd, lcd [<dir>]           Show or change the local working directory (default: home)":  "  lpwd, lcd [<dir>]           显示或切换本地工作目录（默认：主目录）",
+	"  ls [<dir>]                  List a remote directory (default: the working directory)":    "  ls [<dir>]                  列出远程目录（默认：工作目录）",
+	"  get <remote> [<local>]      Download a file (default: into the local working directory)": "  get <remote> [<local>]      下载文件（默认：到本地工作目录）",
+	"  put <local> [<remote>]      Upload a file (default: into the remote working directory)":  "  put <local> [<remote>]      上传文件（默认：到远程工作目录）",
+	"  help, exit                  Show this help, or leave the shell":                          "  help, exit                  显示此帮助，或退出 shell",
+	"Unknown shell command: %s (type 'help' for the list)":                                      "未知的 shell 命令：%s（输入 'help' 查看列表）",
+	"Usage: get <remote> [<local>]":                                                             "用法：get <remote> [<local>]",
+	"Error: '%s' is a directory; download it with 'go-bdfs dl -r'.":                             "错误：'%s' 是目录，请使用 'go-bdfs dl -r' 下载。",
+	"Usage: put <local> [<remote>]":                                                             "用法：put <local> [<remote>]",
+	"Error: '%s' is a directory; upload it with 'go-bdfs sync'.":                                "错误：'%s' 是目录，请使用 'go-bdfs sync' 上传。",
+	"Could not move %s to the new data directories, using it where it is: %v":                   "无法将 %s 移动到新的数据目录，将在原位置继续使用：%v",
+	"Moved %s to %s": "已将 %s 移动到 %s",
+	"Or create a config file at %s with the following format:":                                  "或者按以下格式创建配置文件 %s：",
+	"Append daemon output to this file (install defaults to daemon.log in the state directory)": "将守护进程输出追加到此文件（install 默认为状态目录中的 daemon.log）",
+	"Print the paths as JSON":     "以 JSON 格式打印路径",
+	"Show help for paths command": "显示 paths 命令的帮助",
+	" (not created yet)":          "（尚未创建）",
+	"  paths       Show where the configuration, token, caches and state are kept":                                                                            "  paths       显示配置、令牌、缓存和状态文件的存放位置",
+	"  paths       Show the configuration, state and cache directories and every file in them, moving files from ~/.local/app/bdfs":                           "  paths       显示配置、状态和缓存目录及其中的每个文件，并从 ~/.local/app/bdfs 迁移文件",
+	"Access token refreshed for this run only; injected tokens are never saved.":                                                                              "访问令牌仅在本次运行中刷新；注入的令牌不会被保存。",
+	"  --static-token         Use the access token as it is, never refreshing it or writing the token file":                                                   "  --static-token         按原样使用访问令牌，从不刷新令牌或写入令牌文件",
+	"              Use a long-lived access token as it is: never refresh it, rewrite the token file or start authorization (also static_token in the config)": "              按原样使用长期有效的访问令牌：从不刷新令牌、重写令牌文件或发起授权（也可在配置中设置 static_token）",
+	"Number of files uploaded concurrently for a directory, glob or --files-from":                                                                             "上传目录、通配符或 --files-from 时同时上传的文件数",
+	"Upload the local paths listed in this file, one per line ('-' for stdin), into the -d directory":                                                         "将此文件中逐行列出的本地路径（'-' 表示标准输入）上传到 -d 目录",
+	"Error: --transfers must be at least 1.":                                                                                                                  "错误：--transfers 至少为 1。",
+	"Error listing files to upload: %v":                                                                                                                       "列出待上传文件时出错：%v",
+	"Error: -s and --files-from cannot be combined.":                                                                                                          "错误：-s 与 --files-from 不能同时使用。",
+	"Error reading file list: %v":                                                    "读取文件列表时出错：%v",
+	"Error: invalid pattern %q: %v":                                                  "错误：无效的模式 %q：%v",
+	"Error: no local files match %s":                                                 "错误：没有匹配 %s 的本地文件",
+	"No files to upload.":                                                            "没有需要上传的文件。",
+	"Uploading %d files to '%s' (%d at a time)...":                                   "正在上传 %d 个文件到 '%s'（每次 %d 个）...",
+	"%d files uploaded (%s), %d skipped, %d failed.":                                 "已上传 %d 个文件（%s），跳过 %d 个，失败 %d 个。",
+	"%d files uploaded (%s), %d skipped, to: %s":                                     "已上传 %d 个文件（%s），跳过 %d 个，目标：%s",
+	"This sync deletes %d entries from '%s' that are not in '%s'. Continue? (y/N): ": "此次同步将从 '%[2]s' 删除 %[1]d 个不在 '%[3]s' 中的条目。是否继续？(y/N): ",
+	"Sync cancelled.":                                                                "同步已取消。",
+}
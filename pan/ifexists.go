@@ -0,0 +1,87 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// IfExists is what an upload does when a file already exists at its remote path
+type IfExists string
+
+const (
+	IfExistsOverwrite IfExists = "overwrite" // Replace the existing file (the default)
+	IfExistsRename    IfExists = "rename"    // Let Baidu Pan store the upload under a new name, e.g. "file(1).txt"
+	IfExistsSkip      IfExists = "skip"      // Leave the existing file and upload nothing
+	IfExistsFail      IfExists = "fail"      // Leave the existing file and fail with ErrRemoteExists
+)
+
+// ErrRemoteExists is returned by uploads with IfExistsFail when the remote path is taken
+var ErrRemoteExists = errors.New("remote file already exists")
+
+// ErrUploadSkipped is returned by uploads with IfExistsSkip when the remote path is taken,
+// or with SkipIdentical when it holds the same content; nothing was transferred
+var ErrUploadSkipped = errors.New("upload skipped")
+
+// ParseIfExists parses an overwrite policy: overwrite, rename, skip or fail
+func ParseIfExists(s string) (IfExists, error) {
+	switch policy := IfExists(strings.ToLower(strings.TrimSpace(s))); policy {
+	case IfExistsOverwrite, IfExistsRename, IfExistsSkip, IfExistsFail:
+		return policy, nil
+	case "":
+		return IfExistsOverwrite, nil
+	}
+	return "", fmt.Errorf("invalid --if-exists %q (expected overwrite, rename, skip or fail)", s)
+}
+
+// rtype returns the precreate and create APIs' rtype for the policy: 0 fails on a path
+// conflict, 1 renames and 3 overwrites. Skip and fail are checked before uploading, so a
+// file that appears meanwhile is not overwritten.
+func (p IfExists) rtype() string {
+	switch p {
+	case IfExistsRename:
+		return "1"
+	case IfExistsSkip, IfExistsFail:
+		return "0"
+	}
+	return "3"
+}
+
+// checkRemote applies the skip and fail policies of opts.IfExists, and opts.SkipIdentical,
+// to remotePath with a single metadata lookup, before any content is read or transferred.
+// localInfo is nil for streamed uploads, which SkipIdentical does not apply to.
+func (c *Client) checkRemote(remotePath string, opts UploadOptions, localPath string, localInfo fs.FileInfo) error {
+	// Encrypted content differs on every upload, so it never matches the remote copy
+	skipIdentical := opts.SkipIdentical && opts.Cipher == nil && localInfo != nil
+	if opts.IfExists != IfExistsSkip && opts.IfExists != IfExistsFail && !skipIdentical {
+		return nil
+	}
+
+	remote, err := c.GetDetailedFileInfo(remotePath)
+	switch {
+	case err != nil && (isNotFoundError(err) || strings.HasPrefix(err.Error(), "file not found")):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to check whether %s exists: %w", remotePath, err)
+	case opts.IfExists == IfExistsSkip:
+		c.logger.Info(fmt.Sprintf("File '%s' already exists on Baidu Pan. Skipping upload.", remotePath))
+		return fmt.Errorf("%s already exists: %w", remotePath, ErrUploadSkipped)
+	case opts.IfExists == IfExistsFail:
+		return fmt.Errorf("%w: %s", ErrRemoteExists, remotePath)
+	}
+
+	// Only hash the local file when the sizes leave a match possible
+	if remote.IsDir == 1 || remote.Size != localInfo.Size() || !isHexMD5(remote.MD5) {
+		return nil
+	}
+	localMD5, err := c.localMD5(localPath, localInfo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(localMD5, remote.MD5) {
+		return nil
+	}
+	c.logger.Info(fmt.Sprintf("File '%s' on Baidu Pan is identical to the local file. Skipping upload.", remotePath))
+	return fmt.Errorf("%s is identical to the local file: %w", remotePath, ErrUploadSkipped)
+}
@@ -0,0 +1,381 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// indexBucket is the bbolt bucket holding one entry per remote path
+var indexBucket = []byte("files")
+
+// indexMetaBucket is the bbolt bucket holding the state of the index itself
+var indexMetaBucket = []byte("meta")
+
+// cursorKey holds the cursor of the last incremental update, see UpdateIndex
+var cursorKey = []byte("cursor")
+
+// IndexEntry is the locally cached metadata for a single remote path
+type IndexEntry struct {
+	Path       string `json:"path"`
+	FsID       int64  `json:"fs_id"`
+	Size       int64  `json:"size"`
+	Mtime      int64  `json:"mtime"`       // Server modification time (Unix seconds)
+	LocalMtime int64  `json:"local_mtime"` // Client-reported modification time (Unix seconds)
+	MD5        string `json:"md5,omitempty"`
+	IsDir      bool   `json:"isdir"`
+	IndexedAt  int64  `json:"indexed_at"` // When the entry was last refreshed (Unix seconds)
+}
+
+// Index is a persistent local index of remote path -> metadata, backed by bbolt.
+// It lets sync and verify operations consult remote state without re-listing the whole pan.
+type Index struct {
+	db *bolt.DB
+}
+
+// OpenIndex opens (creating it and its directory if needed) the index database at dbPath
+func OpenIndex(dbPath string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(indexBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(indexMetaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize index: %w", err)
+	}
+
+	return &Index{db: db}, nil
+}
+
+// Close closes the index database
+func (idx *Index) Close() error {
+	return idx.db.Close()
+}
+
+// Get returns the entry for remotePath, or nil if the path is not indexed
+func (idx *Index) Get(remotePath string) (*IndexEntry, error) {
+	var entry *IndexEntry
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(indexBucket).Get([]byte(remotePath))
+		if data == nil {
+			return nil
+		}
+		entry = &IndexEntry{}
+		return json.Unmarshal(data, entry)
+	})
+	return entry, err
+}
+
+// Put stores entries, replacing any existing entries for the same paths
+func (idx *Index) Put(entries ...IndexEntry) error {
+	now := time.Now().Unix()
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(indexBucket)
+		for _, entry := range entries {
+			entry.IndexedAt = now
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(entry.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PutFileInfo stores the metadata of listed remote files
+func (idx *Index) PutFileInfo(files ...FileInfo) error {
+	entries := make([]IndexEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, IndexEntry{
+			Path:       file.Path,
+			FsID:       file.FsID,
+			Size:       file.Size,
+			Mtime:      file.ServerMtime,
+			LocalMtime: file.LocalMtime,
+			MD5:        file.MD5,
+			IsDir:      file.IsDir == 1,
+		})
+	}
+	return idx.Put(entries...)
+}
+
+// Delete removes remotePath and, if it is a directory, everything indexed beneath it
+func (idx *Index) Delete(remotePath string) (int, error) {
+	removed := 0
+	err := idx.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(indexBucket)
+		var keys [][]byte
+		scanPrefix(bucket, remotePath, func(key, _ []byte) bool {
+			keys = append(keys, append([]byte(nil), key...))
+			return true
+		})
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed = len(keys)
+		return nil
+	})
+	return removed, err
+}
+
+// Rename moves the entry for oldPath, and everything indexed beneath it, to newPath
+func (idx *Index) Rename(oldPath, newPath string) error {
+	oldPath = normalizeRemoteDir(oldPath)
+	newPath = normalizeRemoteDir(newPath)
+
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(indexBucket)
+		moved := make(map[string][]byte)
+		scanPrefix(bucket, oldPath, func(key, value []byte) bool {
+			moved[string(key)] = append([]byte(nil), value...)
+			return true
+		})
+
+		for key, value := range moved {
+			var entry IndexEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			entry.Path = newPath + strings.TrimPrefix(key, oldPath)
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(entry.Path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Cursor returns the cursor saved by the last incremental update, or "" if there is none
+func (idx *Index) Cursor() (string, error) {
+	var cursor string
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		cursor = string(tx.Bucket(indexMetaBucket).Get(cursorKey))
+		return nil
+	})
+	return cursor, err
+}
+
+// SetCursor saves the cursor the next incremental update starts from; "" clears it
+func (idx *Index) SetCursor(cursor string) error {
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		if cursor == "" {
+			return tx.Bucket(indexMetaBucket).Delete(cursorKey)
+		}
+		return tx.Bucket(indexMetaBucket).Put(cursorKey, []byte(cursor))
+	})
+}
+
+// Walk calls fn for every entry at or beneath root in path order
+func (idx *Index) Walk(root string, fn func(IndexEntry) error) error {
+	return idx.db.View(func(tx *bolt.Tx) error {
+		var walkErr error
+		scanPrefix(tx.Bucket(indexBucket), root, func(_, value []byte) bool {
+			var entry IndexEntry
+			if walkErr = json.Unmarshal(value, &entry); walkErr != nil {
+				return false
+			}
+			walkErr = fn(entry)
+			return walkErr == nil
+		})
+		return walkErr
+	})
+}
+
+// scanPrefix visits root itself and every key beneath it until fn returns false
+func scanPrefix(bucket *bolt.Bucket, root string, fn func(key, value []byte) bool) {
+	root = normalizeRemoteDir(root)
+	prefix := root + "/"
+	if root == "/" {
+		prefix = "/"
+	}
+
+	cursor := bucket.Cursor()
+	if root != "/" {
+		if value := bucket.Get([]byte(root)); value != nil {
+			if !fn([]byte(root), value) {
+				return
+			}
+		}
+	}
+	for key, value := cursor.Seek([]byte(prefix)); key != nil && strings.HasPrefix(string(key), prefix); key, value = cursor.Next() {
+		if !fn(key, value) {
+			return
+		}
+	}
+}
+
+// normalizeRemoteDir cleans a remote path and strips any trailing slash
+func normalizeRemoteDir(remotePath string) string {
+	if remotePath == "" {
+		return "/"
+	}
+	return path.Clean("/" + remotePath)
+}
+
+// WithIndex keeps idx up to date as the client uploads, creates, moves, renames and removes files
+func WithIndex(idx *Index) Option {
+	return func(c *Client) {
+		c.index = idx
+	}
+}
+
+// RebuildIndex discards the indexed entries beneath root and re-lists the remote tree into idx
+func (c *Client) RebuildIndex(idx *Index, root string) (int, error) {
+	root = normalizeRemoteDir(root)
+	if _, err := idx.Delete(root); err != nil {
+		return 0, fmt.Errorf("failed to clear index: %w", err)
+	}
+
+	count := 0
+	batch := make([]FileInfo, 0, 1000)
+	err := c.WalkParallel(context.Background(), root, WalkOptions{}, func(file FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to list remote tree: %w", err)
+		}
+		batch = append(batch, file)
+		if len(batch) == cap(batch) {
+			if err := idx.PutFileInfo(batch...); err != nil {
+				return err
+			}
+			count += len(batch)
+			batch = batch[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := idx.PutFileInfo(batch...); err != nil {
+		return count, err
+	}
+	return count + len(batch), nil
+}
+
+// PruneIndex removes indexed entries beneath root that no longer exist on Baidu Pan.
+// Each indexed directory is listed once; children missing from the listing are dropped along with their subtrees.
+func (c *Client) PruneIndex(idx *Index, root string) (int, error) {
+	root = normalizeRemoteDir(root)
+
+	// Collect the indexed directories to check, including root itself
+	dirs := []string{root}
+	err := idx.Walk(root, func(entry IndexEntry) error {
+		if entry.IsDir && entry.Path != root {
+			dirs = append(dirs, entry.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	gone := make(map[string]bool)
+	for _, dir := range dirs {
+		if isBeneathAny(dir, gone) {
+			continue
+		}
+
+		files, err := c.ListFiles(dir)
+		if err != nil {
+			return pruned, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+		present := make(map[string]bool, len(files))
+		for _, file := range files {
+			present[file.Path] = true
+		}
+
+		// Find direct children of dir that are indexed but no longer listed
+		var missing []string
+		err = idx.Walk(dir, func(entry IndexEntry) error {
+			if entry.Path != dir && path.Dir(entry.Path) == dir && !present[entry.Path] {
+				missing = append(missing, entry.Path)
+			}
+			return nil
+		})
+		if err != nil {
+			return pruned, err
+		}
+
+		for _, missingPath := range missing {
+			n, err := idx.Delete(missingPath)
+			if err != nil {
+				return pruned, err
+			}
+			pruned += n
+			gone[missingPath] = true
+		}
+	}
+
+	return pruned, nil
+}
+
+// isBeneathAny reports whether p equals or lies beneath any path in dirs
+func isBeneathAny(p string, dirs map[string]bool) bool {
+	for dir := range dirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexUpdate records fresh metadata in the client's index, if one is configured
+func (c *Client) indexUpdate(entries ...IndexEntry) {
+	if c.index == nil {
+		return
+	}
+	if err := c.index.Put(entries...); err != nil {
+		c.logger.Warn("Failed to update local index", "error", err)
+	}
+}
+
+// indexRename moves oldPath and its subtree to newPath in the client's index, if one is configured
+func (c *Client) indexRename(oldPath, newPath string) {
+	if c.index == nil {
+		return
+	}
+	if err := c.index.Rename(oldPath, newPath); err != nil {
+		c.logger.Warn("Failed to update local index", "error", err)
+	}
+}
+
+// indexRemove drops remotePath and its subtree from the client's index, if one is configured
+func (c *Client) indexRemove(remotePath string) {
+	if c.index == nil {
+		return
+	}
+	if _, err := c.index.Delete(remotePath); err != nil {
+		c.logger.Warn("Failed to update local index", "error", err)
+	}
+}
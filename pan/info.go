@@ -1,20 +1,24 @@
 package pan
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // GetFileInfoByPath gets information about a specific file or directory by its path
 // This method uses the list API with a filter to get information about a single file
 func (c *Client) GetFileInfoByPath(filePath string) (*FileInfo, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
 	}
 
 	// Use the list API with filename filter to get specific file info
@@ -36,17 +40,17 @@ func (c *Client) GetFileInfoByPath(filePath string) (*FileInfo, error) {
 
 	params := url.Values{}
 	params.Add("method", "list")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("dir", dirPath)
 	params.Add("filename", filename) // Filter by filename
 	params.Add("folder", "0")
 
-	req, err := http.NewRequest("GET", listFilesURL+"?"+params.Encode(), nil)
+	req, err := http.NewRequest("GET", c.endpoint(listFilesURL)+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +72,7 @@ func (c *Client) GetFileInfoByPath(filePath string) (*FileInfo, error) {
 	}
 
 	if response.Errno != 0 {
-		return nil, fmt.Errorf("API returned error code %d", response.Errno)
+		return nil, &errno.Error{Code: response.Errno}
 	}
 
 	// Find the file with matching path
@@ -84,22 +88,22 @@ func (c *Client) GetFileInfoByPath(filePath string) (*FileInfo, error) {
 // GetDetailedFileInfo gets detailed information about a file using the meta API
 // This is more efficient than listing files when you only need info about one file
 func (c *Client) GetDetailedFileInfo(filePath string) (*FileInfo, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
 	}
 
 	// Use the meta API to get detailed information about a single file
 	params := url.Values{}
 	params.Add("method", "meta")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("path", filePath)
 
-	req, err := http.NewRequest("GET", listFilesURL+"?"+params.Encode(), nil)
+	req, err := http.NewRequest("GET", c.endpoint(listFilesURL)+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +129,7 @@ func (c *Client) GetDetailedFileInfo(filePath string) (*FileInfo, error) {
 	}
 
 	if metaResponse.Errno != 0 {
-		return nil, fmt.Errorf("API returned error code %d", metaResponse.Errno)
+		return nil, &errno.Error{Code: metaResponse.Errno}
 	}
 
 	if len(metaResponse.List) == 0 {
@@ -151,6 +155,72 @@ func (c *Client) GetAndDisplayFileInfo(filePath string) (*FileInfo, error) {
 	return fileInfo, nil
 }
 
+// FileInfosByPath returns the files and directories at filePaths, in the same order. Each
+// parent directory is listed once to find the fs_ids, and their metadata is then fetched
+// with a single batched filemetas call (one per 100 files). A path that matches nothing
+// is an error.
+func (c *Client) FileInfosByPath(ctx context.Context, filePaths []string) ([]FileInfo, error) {
+	wanted := make(map[string]map[string]bool) // Names wanted in each parent directory
+	for _, p := range filePaths {
+		if p == "/" {
+			continue // The root has no parent to list it
+		}
+		dir, name := path.Split(path.Clean(p))
+		dir = path.Clean(dir)
+		if wanted[dir] == nil {
+			wanted[dir] = make(map[string]bool)
+		}
+		wanted[dir][name] = true
+	}
+
+	fsIDs := make(map[string]int64, len(filePaths))
+	for dir, names := range wanted {
+		left := len(names)
+		for file, err := range c.ListIter(ctx, dir, ListOpts{}) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+			}
+			if names[file.ServerFilename] {
+				fsIDs[path.Join(dir, file.ServerFilename)] = file.FsID
+				if left--; left == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	ids := make([]int64, 0, len(filePaths))
+	for _, p := range filePaths {
+		if p == "/" {
+			continue
+		}
+		id, ok := fsIDs[path.Clean(p)]
+		if !ok {
+			return nil, fmt.Errorf("file not found: %s", p)
+		}
+		ids = append(ids, id)
+	}
+	metas, err := c.FileInfosByFsID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]FileInfo, 0, len(filePaths))
+	for _, p := range filePaths {
+		if p == "/" {
+			root, err := c.GetDetailedFileInfo(p)
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, *root)
+			continue
+		}
+		infos = append(infos, metas[0])
+		metas = metas[1:]
+	}
+	return infos, nil
+}
+
 // FormatFileInfo formats the file information in a human-readable way
 func FormatFileInfo(fileInfo *FileInfo) string {
 	var result strings.Builder
@@ -164,12 +234,21 @@ func FormatFileInfo(fileInfo *FileInfo) string {
 	result.WriteString(fmt.Sprintf("  File ID: %d\n", fileInfo.FsID))
 	result.WriteString(fmt.Sprintf("  Created: %s\n", FormatTime(fileInfo.ServerCtime)))
 	result.WriteString(fmt.Sprintf("  Modified: %s\n", FormatTime(fileInfo.ServerMtime)))
-	result.WriteString(fmt.Sprintf("  Category: %d\n", fileInfo.Category))
+	result.WriteString(fmt.Sprintf("  Category: %d (%s)\n", fileInfo.Category, fileInfo.Type()))
 	result.WriteString(fmt.Sprintf("  Real Category: %s\n", fileInfo.RealCategory))
 
 	return result.String()
 }
 
+// ModTime returns the file's modification time: the client-reported local mtime when
+// it was preserved on upload, otherwise the server modification time
+func (f *FileInfo) ModTime() time.Time {
+	if f.LocalMtime > 0 {
+		return time.Unix(f.LocalMtime, 0)
+	}
+	return time.Unix(f.ServerMtime, 0)
+}
+
 // MapFileType converts the isdir field to a readable file type
 func MapFileType(isDir int) string {
 	if isDir == 1 {
@@ -0,0 +1,29 @@
+package pan
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInterrupted is returned when a transfer stops because the client's context was
+// cancelled, e.g. by Ctrl+C. Uploads and downloads of local files save their progress
+// first when the client has a resume store.
+var ErrInterrupted = errors.New("interrupted")
+
+// WithContext sets the context of operations that take none, such as UploadFile,
+// DownloadFileToPath and the sync methods. When it is cancelled, uploads finish the
+// slice in flight and stop, downloads stop at once, and sync stops before its next
+// operation; each returns an error wrapping ErrInterrupted.
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) {
+		c.ctx = ctx
+	}
+}
+
+// context returns the context set with WithContext, or context.Background()
+func (c *Client) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
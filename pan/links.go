@@ -0,0 +1,123 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// LinkPolicy says what syncs and recursive uploads do with the symbolic links of a local tree
+type LinkPolicy string
+
+const (
+	LinksFollow LinkPolicy = "follow" // Treat a link as what it points to; a link to a directory containing it is an error
+	LinksSkip   LinkPolicy = "skip"   // Leave links out, as if they were not there
+	LinksError  LinkPolicy = "error"  // Fail on the first link
+)
+
+// ErrSymlink is returned, wrapped with the path, for a symbolic link found under LinksError
+var ErrSymlink = errors.New("symbolic link found")
+
+// ParseLinkPolicy parses "follow", "skip" or "error"; empty means LinksFollow
+func ParseLinkPolicy(s string) (LinkPolicy, error) {
+	switch policy := LinkPolicy(s); policy {
+	case "":
+		return LinksFollow, nil
+	case LinksFollow, LinksSkip, LinksError:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid link policy %q (expected follow, skip or error)", s)
+}
+
+// LocalWalkOptions controls how syncs and recursive uploads walk a local directory tree
+type LocalWalkOptions struct {
+	Links   LinkPolicy                     // Symbolic links; empty follows them
+	Special SpecialFilePolicy              // Sockets, named pipes, devices and sparse files; empty skips them
+	Skipped func(localPath, reason string) // Called for each special file left out, if set
+}
+
+// localWalk returns the options of a walk of a local tree to upload, reporting the
+// special files left out as warnings
+func (c *Client) localWalk(links LinkPolicy, special SpecialFilePolicy) LocalWalkOptions {
+	return LocalWalkOptions{Links: links, Special: special, Skipped: func(localPath, reason string) {
+		c.logger.Warn(fmt.Sprintf("Skipping %s: %s", reason, localPath))
+	}}
+}
+
+// walkLocalFunc is called by walkLocal for each entry, with rel its slash-separated path
+// relative to the root and info describing it, or what it points to for a followed link
+type walkLocalFunc func(p, rel string, info fs.FileInfo) error
+
+// walkLocal calls fn for every file and directory beneath the local directory root, in
+// lexical order, handling symbolic links and special files according to opts. root
+// itself may be a link.
+func walkLocal(root string, opts LocalWalkOptions, fn walkLocalFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", root)
+	}
+	return walkLocalDir(root, "", opts, []fs.FileInfo{info}, fn)
+}
+
+// walkLocalDir walks the directory dir at rel, whose ancestors, itself included, are
+// ancestors; a followed link to any of them would loop forever
+func walkLocalDir(dir, rel string, opts LocalWalkOptions, ancestors []fs.FileInfo, fn walkLocalFunc) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			switch opts.Links {
+			case LinksSkip:
+				continue
+			case LinksError:
+				return fmt.Errorf("%w: %s", ErrSymlink, p)
+			}
+			if info, err = os.Stat(p); err != nil {
+				return fmt.Errorf("broken symbolic link %s: %w", p, err)
+			}
+			if info.IsDir() {
+				for _, ancestor := range ancestors {
+					if os.SameFile(info, ancestor) {
+						return fmt.Errorf("symbolic link loop: %s points to a directory containing it", p)
+					}
+				}
+			}
+		}
+
+		if kind := specialFileKind(info); kind != "" && (kind != sparseFileKind || opts.Special != SpecialUpload) {
+			if opts.Special == SpecialError {
+				return fmt.Errorf("%w: %s is a %s", ErrSpecialFile, p, kind)
+			}
+			if opts.Skipped != nil {
+				opts.Skipped(p, kind)
+			}
+			continue
+		}
+
+		if err := fn(p, entryRel, info); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := walkLocalDir(p, entryRel, opts, append(ancestors, info), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -1,32 +1,114 @@
 package pan
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"iter"
 	"net/http"
 	"net/url"
+	"path"
+	"strconv"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// ListFiles lists files in a directory
+// maxListPageSize is the largest number of entries the list API returns per request
+const maxListPageSize = 1000
+
+// ListOpts configures ListIter
+type ListOpts struct {
+	PageSize    int    // Entries fetched per request, up to 1000; <= 0 uses the maximum
+	Order       string // Sort field: "name", "time" or "size"; empty uses the server default (name)
+	Desc        bool   // Sort in descending order
+	FoldersOnly bool   // Only list directories
+}
+
+// ListFiles lists files in a directory, fetching every page
 func (c *Client) ListFiles(dirPath string) ([]FileInfo, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
+	return c.listFiles(c.context(), dirPath)
+}
+
+// listFiles lists every entry of dirPath, stopping the request in flight when ctx is
+// cancelled
+func (c *Client) listFiles(ctx context.Context, dirPath string) ([]FileInfo, error) {
+	var files []FileInfo
+	for file, err := range c.ListIter(ctx, dirPath, ListOpts{}) {
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// ListIter returns an iterator over the entries of dirPath that fetches pages on demand,
+// so consumers can stop early on huge directories. A failed request, or cancelling ctx,
+// yields the error once and ends the iteration.
+func (c *Client) ListIter(ctx context.Context, dirPath string, opts ListOpts) iter.Seq2[FileInfo, error] {
+	pageSize := opts.PageSize
+	if pageSize <= 0 || pageSize > maxListPageSize {
+		pageSize = maxListPageSize
 	}
 
+	return func(yield func(FileInfo, error) bool) {
+		for start := 0; ; start += pageSize {
+			page, err := c.listPage(ctx, dirPath, opts, start, pageSize)
+			if err != nil {
+				yield(FileInfo{}, err)
+				return
+			}
+			for _, file := range page {
+				if !yield(file, nil) {
+					return
+				}
+			}
+			if len(page) < pageSize {
+				return
+			}
+		}
+	}
+}
+
+// listPage lists up to limit entries of dirPath starting at offset start
+func (c *Client) listPage(ctx context.Context, dirPath string, opts ListOpts, start, limit int) (_ []FileInfo, err error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	ctx, span := c.startSpan(ctx, "list",
+		attribute.String("bdfs.dir", dirPath),
+		attribute.Int("bdfs.start", start))
+	defer func() { endSpan(span, err) }()
+
 	params := url.Values{}
 	params.Add("method", "list")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("dir", dirPath)
-	params.Add("folder", "0") // 0 for all files, 1 for folders only
+	params.Add("start", strconv.Itoa(start))
+	params.Add("limit", strconv.Itoa(limit))
+	if opts.FoldersOnly {
+		params.Add("folder", "1") // 1 for folders only
+	} else {
+		params.Add("folder", "0") // 0 for all files
+	}
+	if opts.Order != "" {
+		params.Add("order", opts.Order)
+	}
+	if opts.Desc {
+		params.Add("desc", "1")
+	}
 
-	req, err := http.NewRequest("GET", listFilesURL+"?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(listFilesURL)+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -47,17 +129,20 @@ func (c *Client) ListFiles(dirPath string) ([]FileInfo, error) {
 		return nil, err
 	}
 
+	setAPIResult(span, response.Errno, response.RequestID)
+
 	if response.Errno != 0 {
-		return nil, fmt.Errorf("API returned error code %d", response.Errno)
+		return nil, &errno.Error{Code: response.Errno}
 	}
 
+	span.SetAttributes(attribute.Int("bdfs.entries", len(response.List)))
 	return response.List, nil
 }
 
 // GetFileInfo gets information about a specific file
 func (c *Client) GetFileInfo(filePath string) (*FileInfo, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
 	}
 
 	// List files in the parent directory and find our file
@@ -84,33 +169,92 @@ func (c *Client) GetFileInfo(filePath string) (*FileInfo, error) {
 	return nil, fmt.Errorf("file not found: %s", filePath)
 }
 
-// Walk recursively walks through directories and files
+// WalkDirFunc is called by WalkDir for each remote file or directory. When listing a
+// directory fails, it is called again for that directory with the error; returning nil
+// then skips the directory and continues the walk. Returning fs.SkipDir for a directory
+// skips its contents, and for a file skips the remaining entries of its parent directory.
+// Returning fs.SkipAll stops the walk without error; any other error stops the walk and
+// is returned by WalkDir.
+type WalkDirFunc func(info FileInfo, err error) error
+
+// WalkDir walks the remote tree beneath root depth-first, in listing order, calling fn for
+// every entry. root itself is not passed to fn. The walk stops when ctx is cancelled.
+func (c *Client) WalkDir(ctx context.Context, root string, fn WalkDirFunc) error {
+	err := c.walkDir(ctx, normalizeRemoteDir(root), fn)
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkDir lists dir and visits its entries, recursing into subdirectories
+func (c *Client) walkDir(ctx context.Context, dir string, fn WalkDirFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	files, err := c.listFiles(ctx, dir)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fn(FileInfo{Path: dir, ServerFilename: path.Base(dir), IsDir: 1}, err)
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(file, nil); err != nil {
+			if err == fs.SkipDir && file.IsDir == 1 {
+				continue
+			}
+			return err
+		}
+
+		if file.IsDir == 1 {
+			if err := c.walkDir(ctx, file.Path, fn); err != nil && err != fs.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Walk recursively walks through directories and files. The first listing error stops
+// the walk and is delivered on the error channel once the file channel is closed.
 func (c *Client) Walk(rootPath string) (<-chan FileInfo, <-chan error) {
 	fileChan := make(chan FileInfo)
 	errChan := make(chan error, 1)
 
 	go func() {
 		defer close(fileChan)
-		c.WalkRecursive(rootPath, fileChan, errChan)
+		err := c.WalkDir(context.Background(), rootPath, func(info FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			fileChan <- info
+			return nil
+		})
+		if err != nil {
+			errChan <- err
+		}
 	}()
 
 	return fileChan, errChan
 }
 
+// WalkRecursive sends every entry beneath path to fileChan and listing errors to errChan.
+//
+// Deprecated: errChan must have room for every error or the walk blocks; use WalkDir.
 func (c *Client) WalkRecursive(path string, fileChan chan<- FileInfo, errChan chan<- error) {
-	files, err := c.ListFiles(path)
-	if err != nil {
-		errChan <- err
-		return
-	}
-
-	for _, file := range files {
-		fileChan <- file
-
-		// If it's a directory, recurse into it
-		if file.IsDir == 1 {
-			subPath := file.Path
-			c.WalkRecursive(subPath, fileChan, errChan)
+	c.WalkDir(context.Background(), path, func(info FileInfo, err error) error {
+		if err != nil {
+			errChan <- err
+			return nil
 		}
-	}
+		fileChan <- info
+		return nil
+	})
 }
@@ -0,0 +1,91 @@
+package pan_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+func TestListFiles(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	s.WriteFile("/dir/a.txt", []byte("hello"))
+	s.WriteFile("/dir/sub/b.txt", []byte("world!"))
+	s.Mkdir("/dir/empty")
+	c := s.NewClient(t)
+
+	files, err := c.ListFiles("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]pan.FileInfo)
+	for _, file := range files {
+		got[file.Path] = file
+	}
+	if len(got) != 3 {
+		t.Fatalf("ListFiles returned %v, want 3 entries", files)
+	}
+	if a := got["/dir/a.txt"]; a.IsDir != 0 || a.Size != 5 || a.ServerFilename != "a.txt" {
+		t.Errorf("a.txt listed as %+v", a)
+	}
+	for _, dir := range []string{"/dir/sub", "/dir/empty"} {
+		if got[dir].IsDir != 1 {
+			t.Errorf("%s listed as %+v, want a directory", dir, got[dir])
+		}
+	}
+}
+
+func TestListIterPages(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	for i := range 5 {
+		s.WriteFile(fmt.Sprintf("/dir/f%d", i), []byte{byte(i)})
+	}
+	c := s.NewClient(t)
+
+	var names []string
+	for file, err := range c.ListIter(context.Background(), "/dir", pan.ListOpts{PageSize: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.ServerFilename)
+	}
+	if len(names) != 5 {
+		t.Errorf("ListIter yielded %v, want 5 entries", names)
+	}
+	if calls := s.Calls("list"); calls != 3 {
+		t.Errorf("ListIter sent %d list requests for 5 entries in pages of 2, want 3", calls)
+	}
+
+	// Stopping early fetches no further pages
+	for range c.ListIter(context.Background(), "/dir", pan.ListOpts{PageSize: 2}) {
+		break
+	}
+	if calls := s.Calls("list"); calls != 4 {
+		t.Errorf("stopping after the first entry sent %d list requests, want 1", calls-3)
+	}
+}
+
+func TestListFilesErrors(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	c := s.NewClient(t, pan.WithThrottleRetry(2, 0))
+
+	if _, err := c.ListFiles("/missing"); !errno.Is(err, -9) {
+		t.Errorf("listing a missing directory returned %v, want errno -9", err)
+	}
+
+	// A rate limit is retried
+	s.Mkdir("/dir")
+	s.Fail("list", 31034)
+	if _, err := c.ListFiles("/dir"); err != nil {
+		t.Errorf("listing after a rate limit returned %v", err)
+	}
+	if calls := s.Calls("list"); calls != 3 {
+		t.Errorf("server received %d list requests, want 3", calls)
+	}
+}
@@ -0,0 +1,167 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// listAllURL is the multimedia endpoint serving the recursive listall method
+const listAllURL = "https://pan.baidu.com/rest/2.0/xpan/multimedia"
+
+// ListAllResponse represents one page of the recursive listall API
+type ListAllResponse struct {
+	Errno     int        `json:"errno"`
+	HasMore   int        `json:"has_more"` // 1 while further pages remain
+	Cursor    int        `json:"cursor"`   // Start offset of the next page
+	List      []FileInfo `json:"list"`
+	RequestID int64      `json:"request_id"`
+}
+
+// ListAll returns an iterator over every file and directory beneath dirPath, fetched
+// with the recursive listall API in pages of up to 1000 entries. This needs far fewer
+// requests than walking the tree directory by directory, but entries arrive in server
+// order. A failed request yields the error once and ends the iteration.
+func (c *Client) ListAll(ctx context.Context, dirPath string) iter.Seq2[FileInfo, error] {
+	return func(yield func(FileInfo, error) bool) {
+		for cursor := 0; ; {
+			page, err := c.listAllPage(ctx, dirPath, cursor)
+			if err != nil {
+				yield(FileInfo{}, err)
+				return
+			}
+			for _, file := range page.List {
+				if !yield(file, nil) {
+					return
+				}
+			}
+			if page.HasMore == 0 || page.Cursor <= cursor {
+				return
+			}
+			cursor = page.Cursor
+		}
+	}
+}
+
+// listAllPage fetches the page of the recursive listing of dirPath starting at cursor
+func (c *Client) listAllPage(ctx context.Context, dirPath string, cursor int) (_ *ListAllResponse, err error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	ctx, span := c.startSpan(ctx, "listall",
+		attribute.String("bdfs.dir", dirPath),
+		attribute.Int("bdfs.start", cursor))
+	defer func() { endSpan(span, err) }()
+
+	params := url.Values{}
+	params.Add("method", "listall")
+	params.Add("access_token", c.getAccessToken())
+	params.Add("path", dirPath)
+	params.Add("recursion", "1")
+	params.Add("start", strconv.Itoa(cursor))
+	params.Add("limit", strconv.Itoa(maxListPageSize))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(listAllURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listall request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response ListAllResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	setAPIResult(span, response.Errno, response.RequestID)
+
+	if response.Errno != 0 {
+		return nil, &errno.Error{Code: response.Errno}
+	}
+
+	span.SetAttributes(attribute.Int("bdfs.entries", len(response.List)))
+	return &response, nil
+}
+
+// ListTree returns every file and directory beneath dirPath down to maxDepth levels, 1
+// being the entries of dirPath itself and 0 or less meaning no limit, in tree order:
+// each directory is followed by its entries, sorted by name. Without a limit the tree is
+// fetched with ListAll; with one, level by level with the list API, so nothing below
+// maxDepth is fetched.
+func (c *Client) ListTree(ctx context.Context, dirPath string, maxDepth int) ([]FileInfo, error) {
+	var files []FileInfo
+	if maxDepth <= 0 {
+		for file, err := range c.ListAll(ctx, dirPath) {
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+	} else {
+		dirs := []string{dirPath}
+		for depth := 1; depth <= maxDepth && len(dirs) > 0; depth++ {
+			var next []string
+			for _, dir := range dirs {
+				entries, err := c.ListFiles(dir)
+				if err != nil {
+					return nil, err
+				}
+				for _, entry := range entries {
+					if entry.IsDir == 1 {
+						next = append(next, entry.Path)
+					}
+				}
+				files = append(files, entries...)
+			}
+			dirs = next
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return lessTreePath(files[i].Path, files[j].Path)
+	})
+	return files, nil
+}
+
+// TreeDepth returns how many levels p lies beneath root: 1 for its entries, 0 for root itself
+func TreeDepth(root, p string) int {
+	rel := strings.TrimPrefix(path.Clean(p), normalizeRemoteDir(root))
+	return strings.Count(strings.TrimPrefix(rel, "/"), "/") + 1
+}
+
+// lessTreePath orders slash-separated paths element by element, so a directory's entries
+// come right after it and before its next sibling
+func lessTreePath(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
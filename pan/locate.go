@@ -0,0 +1,161 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// locateUploadURL is the PCS API that returns the upload servers nearest to the user
+const locateUploadURL = "https://d.pcs.baidu.com/rest/2.0/pcs/file"
+
+// superfilePath is the path of the slice upload API on every PCS upload server
+const superfilePath = "/rest/2.0/pcs/superfile2"
+
+// UploadServer is a PCS server that accepts slice uploads
+type UploadServer struct {
+	Server string `json:"server"` // e.g. "https://xafj-ct11.pcs.baidu.com"
+}
+
+// LocateUploadResponse is the response of the locateupload API
+type LocateUploadResponse struct {
+	ErrorCode  int            `json:"error_code"`
+	ErrorMsg   string         `json:"error_msg"`
+	Host       string         `json:"host"`
+	Servers    []UploadServer `json:"servers"`
+	BakServers []UploadServer `json:"bak_servers"`
+	RequestID  int64          `json:"request_id"`
+}
+
+// LocateUpload asks Baidu Pan which PCS servers should receive the slices of the upload
+// uploadID to remotePath and returns their base URLs, nearest first followed by the
+// backup servers, without duplicates
+func (c *Client) LocateUpload(ctx context.Context, remotePath, uploadID string) ([]string, error) {
+	params := url.Values{}
+	params.Add("method", "locateupload")
+	params.Add("appid", "250528")
+	params.Add("access_token", c.getAccessToken())
+	params.Add("path", remotePath)
+	params.Add("uploadid", uploadID)
+	params.Add("upload_version", "2.0")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(locateUploadURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("locate upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("locate upload request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response LocateUploadResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse locate upload response: %w", err)
+	}
+	if response.ErrorCode != 0 {
+		return nil, &errno.Error{API: "locate upload", Code: response.ErrorCode, Message: response.ErrorMsg}
+	}
+
+	var servers []string
+	seen := make(map[string]bool)
+	for _, server := range append(response.Servers, response.BakServers...) {
+		base := uploadServerBase(server.Server)
+		if base == "" || seen[base] {
+			continue
+		}
+		seen[base] = true
+		servers = append(servers, base)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("locate upload API returned no servers")
+	}
+	return servers, nil
+}
+
+// uploadServerBase normalizes a server returned by locateupload to an https base URL;
+// plain host names and http URLs are both returned
+func uploadServerBase(server string) string {
+	server = strings.TrimSuffix(strings.TrimSpace(server), "/")
+	if server == "" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(server, "http://"); ok {
+		server = rest
+	}
+	if !strings.HasPrefix(server, "https://") {
+		server = "https://" + server
+	}
+	return server
+}
+
+// uploadHosts holds the slice upload URLs of one upload, in order of preference, and
+// which of them is in use. Failing over moves to the next one, wrapping around.
+type uploadHosts struct {
+	mu      sync.Mutex
+	urls    []string
+	current int
+}
+
+// url returns the slice upload URL in use
+func (h *uploadHosts) url() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.urls[h.current]
+}
+
+// failover switches to the next upload URL after failed; it reports false when there is
+// no other one. When concurrent uploads fail on the same server, only the first switches.
+func (h *uploadHosts) failover(failed string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.urls) < 2 {
+		return h.urls[h.current], false
+	}
+	if h.urls[h.current] == failed {
+		h.current = (h.current + 1) % len(h.urls)
+	}
+	return h.urls[h.current], true
+}
+
+// locateUploadHosts returns the slice upload URLs for an upload: the servers chosen by
+// locateupload, or d.pcs.baidu.com alone when they cannot be located. The default
+// server stays last in the list so failover can always fall back to it. A d.pcs host
+// set with WithEndpoints is used alone.
+func (c *Client) locateUploadHosts(ctx context.Context, remotePath, uploadID string) *uploadHosts {
+	hosts := &uploadHosts{}
+	if c.endpoints.DPCS != "" {
+		hosts.urls = []string{c.endpoint(uploadSuperfileURL)}
+		return hosts
+	}
+	servers, err := c.LocateUpload(ctx, remotePath, uploadID)
+	if err != nil {
+		c.logger.Warn("Failed to locate upload servers, using the default one", "path", remotePath, "error", err)
+	}
+	fallback := c.endpoint(uploadSuperfileURL) // d.pcs.baidu.com, or the host it failed over to
+	for _, server := range servers {
+		if server+superfilePath != fallback {
+			hosts.urls = append(hosts.urls, server+superfilePath)
+		}
+	}
+	hosts.urls = append(hosts.urls, fallback)
+	c.logger.Debug(fmt.Sprintf("Uploading slices to %s", hosts.urls[0]), "path", remotePath, "servers", len(hosts.urls))
+	return hosts
+}
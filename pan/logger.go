@@ -0,0 +1,85 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ConsoleHandler is a slog.Handler that writes records in the CLI's "[✓] message" / "[×] message" format.
+// Records at warning level or above use the error marker; attributes are appended as key=value pairs.
+type ConsoleHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+// NewConsoleHandler creates a ConsoleHandler writing records at or above level to w
+func NewConsoleHandler(w io.Writer, level slog.Leveler) *ConsoleHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &ConsoleHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+// Enabled reports whether records at the given level are written
+func (h *ConsoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle formats and writes a single record
+func (h *ConsoleHandler) Handle(_ context.Context, r slog.Record) error {
+	marker := "✓"
+	if r.Level >= slog.LevelWarn {
+		marker = "×"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[%s] %s", marker, r.Message))
+	for _, attr := range h.attrs {
+		sb.WriteString(fmt.Sprintf(" %s=%v", attr.Key, attr.Value))
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		sb.WriteString(fmt.Sprintf(" %s=%v", attr.Key, attr.Value))
+		return true
+	})
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+// WithAttrs returns a handler that appends attrs to every record
+func (h *ConsoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup returns the handler unchanged; groups are flattened in console output
+func (h *ConsoleHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// consoleLogger backs PrintSuccess and PrintError
+var consoleLogger = slog.New(NewConsoleHandler(os.Stdout, slog.LevelInfo))
+
+// SetConsoleLogger replaces the logger used by PrintSuccess and PrintError
+func SetConsoleLogger(logger *slog.Logger) {
+	consoleLogger = logger
+}
+
+// printProgress writes a progress fragment to the configured progress output, if any
+func (c *Client) printProgress(format string, args ...interface{}) {
+	if c.progressOutput == nil {
+		return
+	}
+	fmt.Fprintf(c.progressOutput, format, args...)
+}
@@ -0,0 +1,90 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// manifestVersion is the format version written by ExportManifest
+const manifestVersion = 1
+
+// Manifest is a snapshot of the files beneath a remote directory
+type Manifest struct {
+	Version     int             `json:"version"`
+	Root        string          `json:"root"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Files       []ManifestEntry `json:"files"` // Sorted by path
+}
+
+// ManifestEntry records one remote file in a manifest
+type ManifestEntry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	MD5         string `json:"md5,omitempty"`
+	FsID        int64  `json:"fs_id"`
+	ServerCtime int64  `json:"server_ctime"`
+	ServerMtime int64  `json:"server_mtime"`
+	LocalCtime  int64  `json:"local_ctime,omitempty"`
+	LocalMtime  int64  `json:"local_mtime,omitempty"`
+}
+
+// manifestEntry converts a listed file into a manifest entry
+func manifestEntry(file FileInfo) ManifestEntry {
+	return ManifestEntry{
+		Path:        file.Path,
+		Size:        file.Size,
+		MD5:         file.MD5,
+		FsID:        file.FsID,
+		ServerCtime: file.ServerCtime,
+		ServerMtime: file.ServerMtime,
+		LocalCtime:  file.LocalCTime,
+		LocalMtime:  file.LocalMtime,
+	}
+}
+
+// ExportManifest lists every file beneath root with the recursive listall API and
+// returns them as a manifest. Directories are not included.
+func (c *Client) ExportManifest(ctx context.Context, root string) (*Manifest, error) {
+	root = normalizeRemoteDir(root)
+	manifest := &Manifest{Version: manifestVersion, Root: root, GeneratedAt: time.Now().UTC()}
+
+	for file, err := range c.ListAll(ctx, root) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", root, err)
+		}
+		if file.IsDir == 0 {
+			manifest.Files = append(manifest.Files, manifestEntry(file))
+		}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	return manifest, nil
+}
+
+// WriteManifest writes manifest to w as indented JSON
+func WriteManifest(w io.Writer, manifest *Manifest) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest decodes a manifest written by WriteManifest
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+	return &manifest, nil
+}
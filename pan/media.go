@@ -0,0 +1,166 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxFileMetasIDs is the largest number of fs_ids the filemetas API accepts per request
+const maxFileMetasIDs = 100
+
+// MediaInfo is the playback metadata Baidu Pan extracts from a video or audio file
+type MediaInfo struct {
+	Duration time.Duration
+	Width    int   // Video only
+	Height   int   // Video only
+	Bitrate  int64 // Average bits per second over the whole file
+}
+
+// Resolution returns the video's resolution, e.g. "1920x1080", or "" if unknown
+func (m MediaInfo) Resolution() string {
+	if m.Width == 0 || m.Height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", m.Width, m.Height)
+}
+
+// FormatBitrate formats a bitrate in bits per second, e.g. "4.2 Mbps"
+func FormatBitrate(bps int64) string {
+	switch {
+	case bps >= 1000*1000:
+		return fmt.Sprintf("%.1f Mbps", float64(bps)/1000/1000)
+	case bps >= 1000:
+		return fmt.Sprintf("%d kbps", bps/1000)
+	}
+	return fmt.Sprintf("%d bps", bps)
+}
+
+// FileMetasResponse represents the response from the filemetas API
+type FileMetasResponse struct {
+	Errno int `json:"errno"`
+	List  []struct {
+		FsID        int64   `json:"fs_id"`
+		Path        string  `json:"path"`
+		Filename    string  `json:"filename"`
+		IsDir       int     `json:"isdir"`
+		Size        int64   `json:"size"`
+		MD5         string  `json:"md5"`
+		Category    int     `json:"category"`
+		ServerCtime int64   `json:"server_ctime"`
+		ServerMtime int64   `json:"server_mtime"`
+		Duration    float64 `json:"duration"`   // Seconds
+		Resolution  string  `json:"resolution"` // e.g. "width:1920,height:1080"
+		Dlink       string  `json:"dlink"`      // Download link, when requested with dlink=1
+	} `json:"list"`
+	RequestID int64 `json:"request_id"`
+}
+
+// GetMediaInfo fetches the duration and resolution of the video and audio files among
+// files with the filemetas API, up to 100 files per request, and returns them keyed by
+// fs_id. Other files, and media files Baidu Pan has not analyzed, are not in the result.
+func (c *Client) GetMediaInfo(ctx context.Context, files []FileInfo) (map[int64]MediaInfo, error) {
+	var ids []int64
+	for _, file := range files {
+		if t := file.Type(); t == TypeVideo || t == TypeAudio {
+			ids = append(ids, file.FsID)
+		}
+	}
+
+	media := make(map[int64]MediaInfo, len(ids))
+	for start := 0; start < len(ids); start += maxFileMetasIDs {
+		params := url.Values{}
+		params.Add("needmedia", "1")
+		params.Add("extra", "1")
+		response, err := c.fileMetas(ctx, ids[start:min(start+maxFileMetasIDs, len(ids))], params)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range response.List {
+			if meta.Duration <= 0 {
+				continue
+			}
+			info := MediaInfo{Duration: time.Duration(meta.Duration * float64(time.Second))}
+			info.Width, info.Height = parseResolution(meta.Resolution)
+			info.Bitrate = int64(float64(meta.Size*8) / meta.Duration)
+			media[meta.FsID] = info
+		}
+	}
+	return media, nil
+}
+
+// fileMetas fetches the metadata of the files with the given fs_ids; params selects extra
+// fields such as media info (needmedia) or download links (dlink)
+func (c *Client) fileMetas(ctx context.Context, ids []int64, params url.Values) (_ *FileMetasResponse, err error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	ctx, span := c.startSpan(ctx, "filemetas", attribute.Int("bdfs.files", len(ids)))
+	defer func() { endSpan(span, err) }()
+
+	fsids, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("method", "filemetas")
+	params.Set("access_token", c.getAccessToken())
+	params.Set("fsids", string(fsids))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(listAllURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("filemetas request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response FileMetasResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	setAPIResult(span, response.Errno, response.RequestID)
+
+	if response.Errno != 0 {
+		return nil, &errno.Error{Code: response.Errno}
+	}
+	return &response, nil
+}
+
+// parseResolution parses a resolution of the form "width:1920,height:1080"
+func parseResolution(resolution string) (width, height int) {
+	for _, field := range strings.Split(resolution, ",") {
+		key, value, _ := strings.Cut(field, ":")
+		n, _ := strconv.Atoi(strings.TrimSpace(value))
+		switch strings.TrimSpace(key) {
+		case "width":
+			width = n
+		case "height":
+			height = n
+		}
+	}
+	return width, height
+}
@@ -0,0 +1,94 @@
+package pan
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors updated by a Client.
+// A nil *Metrics is valid and records nothing.
+type Metrics struct {
+	apiCalls         *prometheus.CounterVec
+	transferBytes    *prometheus.CounterVec
+	transferDuration *prometheus.HistogramVec
+	retries          *prometheus.CounterVec
+}
+
+// NewMetrics creates the client collectors and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		apiCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bdfs",
+			Name:      "api_calls_total",
+			Help:      "Baidu Pan API calls by API method and returned errno.",
+		}, []string{"method", "errno"}),
+		transferBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bdfs",
+			Name:      "transfer_bytes_total",
+			Help:      "Bytes transferred to or from Baidu Pan.",
+		}, []string{"direction"}),
+		transferDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "bdfs",
+			Name:      "transfer_duration_seconds",
+			Help:      "Duration of completed file transfers.",
+			Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, []string{"direction"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bdfs",
+			Name:      "retries_total",
+			Help:      "Retried Baidu Pan API calls by reason.",
+		}, []string{"reason"}),
+	}
+
+	reg.MustRegister(m.apiCalls, m.transferBytes, m.transferDuration, m.retries)
+	return m
+}
+
+// WithMetrics records API calls, transfer volume, transfer durations and retries in m
+func WithMetrics(m *Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+// observeAPICall counts a completed API call; errno is empty when the response carried none
+func (m *Metrics) observeAPICall(method, errno string) {
+	if m == nil {
+		return
+	}
+	m.apiCalls.WithLabelValues(method, errno).Inc()
+}
+
+// observeTransfer records bytes moved in the given direction ("upload" or "download")
+func (m *Metrics) observeTransfer(direction string, bytes int64) {
+	if m == nil || bytes <= 0 {
+		return
+	}
+	m.transferBytes.WithLabelValues(direction).Add(float64(bytes))
+}
+
+// observeTransferDuration records how long a complete file transfer took
+func (m *Metrics) observeTransferDuration(direction string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.transferDuration.WithLabelValues(direction).Observe(time.Since(start).Seconds())
+}
+
+// observeRetry counts a retried API call
+func (m *Metrics) observeRetry(reason string) {
+	if m == nil {
+		return
+	}
+	m.retries.WithLabelValues(reason).Inc()
+}
+
+// errnoLabel formats an errno for use as a metric label
+func errnoLabel(errno *int64) string {
+	if errno == nil {
+		return ""
+	}
+	return strconv.FormatInt(*errno, 10)
+}
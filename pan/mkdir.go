@@ -7,12 +7,14 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // CreateDir creates a directory in Baidu Pan
 func (c *Client) CreateDir(remotePath string) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	// Ensure remote path is valid
@@ -23,20 +25,20 @@ func (c *Client) CreateDir(remotePath string) error {
 	// Prepare parameters for the create API
 	params := url.Values{}
 	params.Add("method", "create")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("path", remotePath)
 	params.Add("isdir", "1")       // 1 for directory, 0 for file
 	params.Add("block_list", "[]") // Empty block list for directories
 
 	// Create the POST request
-	req, err := http.NewRequest("POST", uploadCreateFileUrl, strings.NewReader(params.Encode()))
+	req, err := http.NewRequest("POST", c.endpoint(uploadCreateFileUrl), strings.NewReader(params.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create directory creation request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Execute the request
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("directory creation request failed: %w", err)
 	}
@@ -61,10 +63,11 @@ func (c *Client) CreateDir(remotePath string) error {
 
 	// Check if the API returned an error code
 	if response.Errno != 0 {
-		return fmt.Errorf("directory creation API returned error code %d. Response: %s", response.Errno, string(body))
+		return &errno.Error{API: "directory creation", Code: response.Errno}
 	}
 
 	// Success
-	PrintSuccess(fmt.Sprintf("Directory '%s' created successfully in Baidu Pan.", remotePath))
+	c.indexUpdate(response.indexEntry())
+	c.logger.Info(fmt.Sprintf("Directory '%s' created successfully in Baidu Pan.", remotePath))
 	return nil
 }
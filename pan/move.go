@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // MoveResponse represents the response from the move API
@@ -50,14 +52,23 @@ func (c *Client) MoveFile(sourcePath, destDir string) error {
 
 // MoveFiles moves multiple files based on the provided MoveRequest structs
 func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	if len(moveRequests) == 0 {
 		return fmt.Errorf("no files specified for move operation")
 	}
 
+	if c.DryRun() {
+		ops := make([]dryRunOperation, 0, len(moveRequests))
+		for _, req := range moveRequests {
+			ops = append(ops, dryRunOperation{path: req.Path, detail: fmt.Sprintf("%s -> %s/%s", req.Path, req.Dest, req.NewName)})
+		}
+		c.reportDryRun("filemanager move", ops)
+		return nil
+	}
+
 	// We'll attempt the move operation directly since the API handles both files and directories
 	// Path validation is handled by the API itself
 
@@ -70,7 +81,7 @@ func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
 	// Prepare query parameters
 	params := url.Values{}
 	params.Add("method", "filemanager")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("opera", "move")
 
 	// Additional parameters that might be required based on API documentation
@@ -78,7 +89,7 @@ func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
 	params.Add("channel", "chunlei")
 	params.Add("web", "1")
 	params.Add("app_id", "250528")
-	params.Add("bdstoken", c.accessToken) // Using access token as bdstoken (common practice)
+	params.Add("bdstoken", c.getAccessToken()) // Using access token as bdstoken (common practice)
 
 	// Create form data for POST body
 	formData := url.Values{}
@@ -87,7 +98,7 @@ func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
 	formData.Add("ondup", "newcopy")
 
 	// Create the request with form-encoded body
-	apiURL := fmt.Sprintf("https://pan.baidu.com/api/filemanager?%s", params.Encode())
+	apiURL := c.endpoint(fileManagerURL) + "?" + params.Encode()
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create move request: %w", err)
@@ -97,7 +108,7 @@ func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Execute the request
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("move request failed: %w", err)
 	}
@@ -120,66 +131,37 @@ func (c *Client) MoveFiles(moveRequests []MoveRequest) error {
 		return fmt.Errorf("failed to unmarshal move response: %w", err)
 	}
 
-	if moveResponse.Errno != 0 {
-		return fmt.Errorf("move API returned error code %d: %s", moveResponse.Errno, GetMoveErrorMessage(moveResponse.Errno))
+	if batchFailed(moveResponse.Errno, len(moveResponse.Info)) {
+		return &errno.Error{API: "move", Code: moveResponse.Errno}
 	}
 
 	// Check if any individual files failed to move
-	var failedMoves []string
+	var failedMoves []FileOpFailure
+	failed := make(map[int]bool)
 	for i, moveInfo := range moveResponse.Info {
-		if moveInfo.Errno != 0 {
+		if moveInfo.Errno != 0 && i < len(moveRequests) {
 			req := moveRequests[i] // Get the corresponding request
-			failedMoves = append(failedMoves, fmt.Sprintf("%s -> %s/%s (error code: %d)", req.Path, req.Dest, req.NewName, moveInfo.Errno))
+			failedMoves = append(failedMoves, FileOpFailure{Index: i, Path: req.Path, Dest: req.Dest + "/" + req.NewName, Err: &errno.Error{API: "move", Code: moveInfo.Errno}})
+			failed[i] = true
+		}
+	}
+
+	for i, req := range moveRequests {
+		if !failed[i] {
+			c.indexRename(req.Path, req.Dest+"/"+req.NewName)
 		}
 	}
 
 	if len(failedMoves) > 0 {
-		return fmt.Errorf("failed to move some files: %s", strings.Join(failedMoves, "; "))
+		return &BatchError{Op: "move", Failures: failedMoves}
 	}
 
 	return nil
 }
 
-// GetMoveErrorMessage returns a human-readable error message for common errno values
-func GetMoveErrorMessage(errno int) string {
-	switch errno {
-	case 0:
-		return "Success"
-	case 2:
-		return "Parameters error"
-	case 3:
-		return "User permission error"
-	case 4:
-		return "Request source error"
-	case 12:
-		return "Operation not allowed or path error"
-	case -9:
-		return "File does not exist"
-	case 111:
-		return "Another asynchronous task is currently executing"
-	case -7:
-		return "Invalid file name"
-	case 108:
-		return "Path error, path does not exist"
-	case 110:
-		return "Target path already exists"
-	case 112:
-		return "Same file already exists in the same directory"
-	case 113:
-		return "File or directory name contains forbidden words"
-	case 114:
-		return "Path too long"
-	case 115:
-		return "Target directory does not exist"
-	case 116:
-		return "Insufficient disk space"
-	case 117:
-		return "File too large"
-	case 31001:
-		return "User has been banned"
-	case 31026:
-		return "File contains illegal content"
-	default:
-		return fmt.Sprintf("Unknown error code: %d", errno)
-	}
+// GetMoveErrorMessage returns a human-readable error message for common errno values.
+//
+// Deprecated: use errno.Message.
+func GetMoveErrorMessage(code int) string {
+	return errno.Message(code)
 }
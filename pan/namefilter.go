@@ -0,0 +1,57 @@
+package pan
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// NameFilter selects files and directories by their names, with globs as path.Match and
+// regular expressions. A name passes when it matches any of them; an empty filter passes
+// every name.
+type NameFilter struct {
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// NewNameFilter returns a filter for the given globs and regular expressions, checking
+// that each is valid
+func NewNameFilter(globs, regexps []string) (*NameFilter, error) {
+	f := &NameFilter{globs: globs}
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid match pattern %q: %w", glob, err)
+		}
+	}
+	for _, expr := range regexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		f.regexps = append(f.regexps, re)
+	}
+	return f, nil
+}
+
+// Empty reports whether the filter has no patterns
+func (f *NameFilter) Empty() bool {
+	return len(f.globs) == 0 && len(f.regexps) == 0
+}
+
+// Match reports whether name passes the filter
+func (f *NameFilter) Match(name string) bool {
+	if f.Empty() {
+		return true
+	}
+	for _, glob := range f.globs {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	for _, re := range f.regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package pan
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Option configures optional behaviour of a Client
+type Option func(*Client)
+
+// WithUserAgent overrides the User-Agent header sent with every API request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTransport sends every request through rt instead of the client's own transport,
+// for proxies, custom TLS settings or a mock server such as pantest's
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.client.Transport = rt
+		c.downloadClient.Transport = rt
+	}
+}
+
+// WithDebug logs every HTTP request and response to w, with credentials redacted
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debugWriter = w
+	}
+}
+
+// WithLogger sets the structured logger used for progress and status messages.
+// By default the client discards all log output.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithProgressOutput writes transfer progress lines to w. By default no progress is shown.
+func WithProgressOutput(w io.Writer) Option {
+	return func(c *Client) {
+		c.progressOutput = w
+	}
+}
@@ -0,0 +1,238 @@
+package pan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOrganizeWorkers is the number of photo headers OrganizePhotos reads concurrently by default
+const DefaultOrganizeWorkers = 4
+
+// organizeBatchSize is the number of files moved per move request
+const organizeBatchSize = 100
+
+// OrganizeOptions controls OrganizePhotos; the zero value uses the defaults
+type OrganizeOptions struct {
+	DestDir  string // Root of the date directories; empty organizes in place beneath the source directory
+	Layout   string // Date directories beneath DestDir, from YYYY, MM and DD; empty uses DefaultPhotoLayout
+	Fallback bool   // Date photos without an EXIF capture date by their modification time instead of leaving them
+	Workers  int    // Photo headers read concurrently; 0 uses DefaultOrganizeWorkers
+
+	// Progress, if set, is called for every photo with its outcome: "moved" (to target),
+	// "undated" (no capture date, left in place) or "failed"
+	Progress func(remotePath, target, outcome string, err error)
+}
+
+// OrganizeResult counts the outcomes of OrganizePhotos
+type OrganizeResult struct {
+	Moved   int
+	InPlace int // Already in the right date directory
+	Undated int // No EXIF capture date and no fallback, left where they were
+	Failed  int
+}
+
+// organizeMove is a photo to move into the date directory for its capture date
+type organizeMove struct {
+	file   FileInfo
+	target string // Date directory
+}
+
+// OrganizePhotos moves the photos beneath root into date directories under opts.DestDir,
+// e.g. /Photos/2024/05/IMG_0001.JPG, by their EXIF capture dates. Only the first 128KB of
+// each photo, which holds its EXIF header, is downloaded, as a Range request. Photos
+// already in a directory matching the layout are skipped without being read, so running
+// it again only reads new arrivals. A same-named file in the target directory is kept,
+// and the moved photo is renamed by the server. Photos that cannot be read or moved are
+// counted as failed and the rest are still organized; only listing root fails the whole
+// run. In dry-run mode the moves are reported instead of performed.
+func (c *Client) OrganizePhotos(ctx context.Context, root string, opts OrganizeOptions) (OrganizeResult, error) {
+	var result OrganizeResult
+	root = normalizeRemoteDir(root)
+	destDir := root
+	if opts.DestDir != "" {
+		destDir = normalizeRemoteDir(opts.DestDir)
+	}
+	layout := photoLayout(opts.Layout)
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultOrganizeWorkers
+	}
+	report := func(remotePath, target, outcome string, err error) {
+		if opts.Progress != nil {
+			opts.Progress(remotePath, target, outcome, err)
+		}
+	}
+
+	dirs := map[string]bool{root: true} // Directories known to exist
+	var photos []FileInfo
+	for file, err := range c.ListAll(ctx, root) {
+		if err != nil {
+			return result, fmt.Errorf("failed to list %s: %w", root, err)
+		}
+		if file.IsDir == 1 {
+			dirs[file.Path] = true
+			continue
+		}
+		if file.Type() != TypeImage && LocalMediaType(file.ServerFilename) != TypeImage {
+			continue
+		}
+		if inDateDir(destDir, path.Dir(file.Path), layout) {
+			result.InPlace++
+			continue
+		}
+		photos = append(photos, file)
+	}
+
+	// Read the capture dates concurrently, keeping the listing order
+	dates := make([]time.Time, len(photos))
+	errs := make([]error, len(photos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(photos)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				dates[i], errs[i] = c.captureDate(ctx, &photos[i], opts.Fallback)
+			}
+		}()
+	}
+	for i := range photos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	byDir := make(map[string][]organizeMove)
+	for i, file := range photos {
+		switch {
+		case errors.Is(errs[i], ErrNoExifDate):
+			result.Undated++
+			report(file.Path, "", "undated", nil)
+			continue
+		case errs[i] != nil:
+			result.Failed++
+			report(file.Path, "", "failed", errs[i])
+			continue
+		}
+		target := path.Join(destDir, dates[i].Format(layout))
+		if path.Dir(file.Path) == target {
+			result.InPlace++
+			continue
+		}
+		byDir[target] = append(byDir[target], organizeMove{file: file, target: target})
+	}
+
+	targets := make([]string, 0, len(byDir))
+	var moves []organizeMove
+	for target := range byDir {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if err := c.ensureOrganizeDir(target, dirs); err != nil {
+			for _, move := range byDir[target] {
+				result.Failed++
+				report(move.file.Path, target, "failed", err)
+			}
+			continue
+		}
+		moves = append(moves, byDir[target]...)
+	}
+
+	for start := 0; start < len(moves); start += organizeBatchSize {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		batch := moves[start:min(start+organizeBatchSize, len(moves))]
+		requests := make([]MoveRequest, len(batch))
+		for i, move := range batch {
+			requests[i] = MoveRequest{Path: move.file.Path, Dest: move.target, NewName: move.file.ServerFilename}
+		}
+		err := c.MoveFiles(requests)
+		for _, move := range batch {
+			target := path.Join(move.target, move.file.ServerFilename)
+			if err != nil {
+				result.Failed++
+				report(move.file.Path, target, "failed", err)
+				continue
+			}
+			result.Moved++
+			report(move.file.Path, target, "moved", nil)
+		}
+	}
+	return result, nil
+}
+
+// captureDate returns the EXIF capture date of a remote photo, read from its header with
+// a Range request. With fallback, a photo without one is dated by its modification time.
+func (c *Client) captureDate(ctx context.Context, file *FileInfo, fallback bool) (time.Time, error) {
+	err := ErrNoExifDate
+	if file.Size > 0 {
+		var date time.Time
+		if date, err = c.remoteExifDate(ctx, file.Path, min(file.Size, exifHeaderSize)); err == nil {
+			return date, nil
+		}
+	}
+	if fallback && errors.Is(err, ErrNoExifDate) {
+		return file.ModTime(), nil
+	}
+	return time.Time{}, err
+}
+
+// remoteExifDate reads the first length bytes of the remote file and parses its EXIF date
+func (c *Client) remoteExifDate(ctx context.Context, remotePath string, length int64) (time.Time, error) {
+	body, err := c.openRange(ctx, remotePath, 0, length)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer body.Close()
+	header, err := io.ReadAll(body)
+	c.observeTransfer("download", int64(len(header)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read header of %s: %w", remotePath, err)
+	}
+	date, err := ExifDate(header)
+	if err != nil && !errors.Is(err, ErrNoExifDate) {
+		// Not an image the parser understands, e.g. PNG or HEIC: nothing to date it by
+		return time.Time{}, ErrNoExifDate
+	}
+	return date, err
+}
+
+// ensureOrganizeDir creates the date directory dir unless it is known to exist
+func (c *Client) ensureOrganizeDir(dir string, known map[string]bool) error {
+	if known[dir] {
+		return nil
+	}
+	if _, err := c.ListFiles(dir); err == nil {
+		known[dir] = true
+		return nil
+	} else if !isNotFoundError(err) {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	if c.DryRun() {
+		fmt.Fprintf(c.dryRunOutput, "[dry-run] mkdir: %s\n", dir)
+	} else if err := c.CreateDir(dir); err != nil {
+		return err
+	}
+	known[dir] = true
+	return nil
+}
+
+// inDateDir reports whether dir is a date directory beneath destDir that matches layout
+func inDateDir(destDir, dir, layout string) bool {
+	rel, ok := strings.CutPrefix(dir, strings.TrimSuffix(destDir, "/")+"/")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(layout, rel)
+	return err == nil
+}
@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -19,7 +24,6 @@ const (
 	deviceCodeURL       = "https://openapi.baidu.com/oauth/2.0/device/code"
 	accessTokenURL      = "https://openapi.baidu.com/oauth/2.0/token"
 	listFilesURL        = "https://pan.baidu.com/rest/2.0/xpan/file"
-	downloadFileURL     = "https://pan.baidu.com/rest/2.0/xpan/file"
 	uploadPrecreateURL  = "https://pan.baidu.com/rest/2.0/xpan/file?method=precreate"
 	uploadSuperfileURL  = "https://d.pcs.baidu.com/rest/2.0/pcs/superfile2"
 	uploadCreateFileUrl = "https://pan.baidu.com/rest/2.0/xpan/file?method=create"
@@ -121,8 +125,11 @@ type TokenFile struct {
 	CreatedAt    time.Time `json:"created_at"` // Time when token was obtained
 }
 
-// Client represents a Baidu Pan client
+// Client represents a Baidu Pan client.
+// A Client is safe for concurrent use by multiple goroutines once constructed.
 type Client struct {
+	mu             sync.RWMutex // Guards the token fields below
+	refreshMu      sync.Mutex   // Serializes token refreshes
 	client         *http.Client
 	downloadClient *http.Client // Separate client with longer timeout for downloads
 	clientID       string
@@ -133,21 +140,81 @@ type Client struct {
 	uid            string
 	tokenFile      string
 	tokenCreatedAt time.Time // Time when the current tokens were obtained
+	userAgent      string    // User-Agent sent with every API request
+	endpoints      Endpoints // Base URLs replacing Baidu's hosts, see WithEndpoints
+	debugWriter    io.Writer // Destination for request/response debug logs, nil when disabled
+	logger         *slog.Logger
+	progressOutput io.Writer // Destination for transfer progress, nil when disabled
+	tracer         trace.Tracer
+	metrics        *Metrics     // Prometheus collectors, nil when metrics are disabled
+	rateLimiter    *rateLimiter // Per-endpoint request throttle, nil when unlimited
+	rawDump        *rawDumper   // Destination of raw API responses, nil when disabled
+	curl           *curlPrinter // Destination of the curl commands of requests, nil when disabled
+	index          *Index       // Local metadata index kept up to date by mutations, nil when disabled
+	dryRunOutput   io.Writer    // Destination for skipped operations in dry-run mode, nil when disabled
+	readOnly       bool         // Refuse requests that change files or shares, see WithReadOnly
+	tokensInjected bool         // Tokens came from WithTokens and are never read from or saved to tokenFile
+	staticToken    bool         // Never refresh the access token or write tokenFile, see WithStaticToken
+
+	requestHooks  []func(*http.Request)         // Called before each API request is sent
+	responseHooks []func(*http.Response, error) // Called after each API response or failed request
+
+	hashCache *HashCache       // Local cache of file and slice MD5s, nil when disabled
+	history   *History         // Log of completed transfers, nil when disabled
+	stats     *statsCollector  // Counters reported by Stats
+	transfers *transferTracker // Transfers in progress and queued operations reported by Status
+
+	userMu   sync.Mutex // Guards userInfo
+	userInfo *UserInfo  // Cached uinfo response, nil until first needed
+
+	ctx    context.Context // Context of operations that take none, nil for context.Background()
+	resume *ResumeStore    // Saved state of interrupted transfers, nil when disabled
+
+	dlinkMu sync.Mutex            // Guards dlinks
+	dlinks  map[string]dlinkEntry // Download links by remote path, nil until first needed
+
+	throttleMaxRetries int           // Retries for responses with a rate-limit errno
+	throttleBaseDelay  time.Duration // Initial delay before retrying a throttled request
+
+	sliceMaxRetries int           // Retries of a failed slice upload
+	sliceBaseDelay  time.Duration // Initial delay before retrying a failed slice upload
+
+	apiSlots chan struct{} // Holds a token per API request in flight, nil when unlimited
+
+	failover hostFailover // Hosts in use in place of Baidu's after repeated failures
 }
 
 // NewClient creates a new Baidu Pan client
-func NewClient(clientID, clientSecret, tokenPath string) *Client {
+func NewClient(clientID, clientSecret, tokenPath string, opts ...Option) *Client {
 	// Ensure the directory exists
 	tokenDir := filepath.Dir(tokenPath)
 	os.MkdirAll(tokenDir, 0755)
 
-	return &Client{
-		client:         &http.Client{Timeout: 30 * time.Second},
-		downloadClient: &http.Client{Timeout: 300 * time.Second}, // 5 minutes timeout for downloads
+	transport := NewTransport(TransportOptions{})
+	c := &Client{
+		client:         &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		downloadClient: &http.Client{Timeout: 300 * time.Second, Transport: transport}, // 5 minutes timeout for downloads
 		clientID:       clientID,
 		clientSecret:   clientSecret,
 		tokenFile:      tokenPath,
+		userAgent:      defaultUserAgent,
+		logger:         slog.New(slog.DiscardHandler),
+		tracer:         defaultTracer(),
+		stats:          newStatsCollector(),
+		transfers:      newTransferTracker(),
+
+		throttleMaxRetries: defaultThrottleRetries,
+		throttleBaseDelay:  defaultThrottleBaseDelay,
+
+		sliceMaxRetries: defaultSliceRetries,
+		sliceBaseDelay:  DefaultSliceRetryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 // GetDeviceCode initiates the device code flow
@@ -157,14 +224,14 @@ func (c *Client) GetDeviceCode() (*DeviceCodeResponse, error) {
 	params.Add("response_type", "device_code")
 	params.Add("scope", "basic,netdisk")
 
-	req, err := http.NewRequest("POST", deviceCodeURL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequest("POST", c.endpoint(deviceCodeURL), strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -223,10 +290,7 @@ func (c *Client) PollForToken(deviceCode string) (*TokenResponse, error) {
 
 		// Check if we got an access token (authorization completed)
 		if tokenResp.AccessToken != "" {
-			c.accessToken = tokenResp.AccessToken
-			c.refreshToken = tokenResp.RefreshToken
-			c.expiresIn = tokenResp.ExpiresIn
-			c.uid = tokenResp.UID
+			c.setTokens(tokenResp)
 			return tokenResp, nil
 		}
 
@@ -248,14 +312,14 @@ func (c *Client) GetDeviceCodeForPoll(deviceCode string) (*DeviceCodeResponse, e
 
 // requestToken makes the token request
 func (c *Client) requestToken(params url.Values) (*TokenResponse, error) {
-	req, err := http.NewRequest("POST", accessTokenURL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequest("POST", c.endpoint(accessTokenURL), strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -281,6 +345,8 @@ func (c *Client) requestToken(params url.Values) (*TokenResponse, error) {
 			} else if errorResp.Error == "slow_down" {
 				// The polling interval is too fast, return empty token to continue with increased interval
 				return &TokenResponse{}, nil
+			} else if entry, ok := errno.LookupOAuth(errorResp.Error); ok {
+				return nil, fmt.Errorf("token request failed: %s (%s)", entry.Message, errorResp.Error)
 			}
 		}
 
@@ -298,19 +364,26 @@ func (c *Client) requestToken(params url.Values) (*TokenResponse, error) {
 
 // Authorize tries to load existing tokens first, and if not available or valid, performs device code authorization
 func (c *Client) Authorize(ctx context.Context) error {
+	if c.staticToken {
+		return c.authorizeStatic()
+	}
+	if c.tokensInjected {
+		return c.authorizeInjected()
+	}
+
 	// Try to load existing tokens first
 	if c.HasValidToken() {
 		err := c.LoadTokens()
 		if err == nil {
 			// Check if token is expired or will expire soon (within 2 days)
 			if c.IsTokenExpired() {
-				PrintSuccess("Access token is expired or will expire soon, attempting to refresh...")
+				c.logger.Info("Access token is expired or will expire soon, attempting to refresh...")
 
 				// Try to refresh the token
 				refreshErr := c.RefreshToken()
 				if refreshErr != nil {
-					PrintError(fmt.Sprintf("Token refresh failed: %v", refreshErr))
-					PrintSuccess("Removing expired token file and starting new authorization...")
+					c.logger.Error("Token refresh failed", "error", refreshErr)
+					c.logger.Info("Removing expired token file and starting new authorization...")
 
 					// If refresh fails, remove the token file and start new authorization
 					os.Remove(c.tokenFile)
@@ -318,15 +391,15 @@ func (c *Client) Authorize(ctx context.Context) error {
 					// Now perform device code authorization
 					return c.performDeviceCodeAuth(ctx)
 				} else {
-					PrintSuccess("Token refreshed successfully!")
+					c.logger.Info("Token refreshed successfully!")
 					return nil
 				}
 			} else {
-				PrintSuccess("Using existing tokens from .bdfs_certs")
+				c.logger.Info("Using existing tokens from .bdfs_certs")
 				return nil
 			}
 		} else {
-			PrintError(fmt.Sprintf("Could not load existing tokens, will re-authorize: %v", err))
+			c.logger.Error("Could not load existing tokens, will re-authorize", "error", err)
 		}
 	}
 
@@ -341,9 +414,10 @@ func (c *Client) performDeviceCodeAuth(ctx context.Context) error {
 		return fmt.Errorf("failed to get device code: %w", err)
 	}
 
-	fmt.Printf("Please visit: %s\n", deviceResp.VerificationURL)
-	fmt.Printf("Enter the code: %s\n", deviceResp.UserCode)
-	fmt.Printf("The code will expire in %d seconds.\n", deviceResp.ExpiresIn)
+	// The user has to act on this, so it goes to stderr even when logging is quiet or off
+	fmt.Fprintf(os.Stderr, "Please visit: %s\n", deviceResp.VerificationURL)
+	fmt.Fprintf(os.Stderr, "Enter the code: %s\n", deviceResp.UserCode)
+	fmt.Fprintf(os.Stderr, "The code will expire in %d seconds.\n", deviceResp.ExpiresIn)
 
 	// Start polling for token in a goroutine with context cancellation
 	tokenChan := make(chan *TokenResponse, 1)
@@ -362,10 +436,7 @@ func (c *Client) performDeviceCodeAuth(ctx context.Context) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	case token := <-tokenChan:
-		c.accessToken = token.AccessToken
-		c.refreshToken = token.RefreshToken
-		c.expiresIn = token.ExpiresIn
-		c.uid = token.UID
+		c.setTokens(token)
 
 		// Save tokens to file
 		err = c.SaveTokens()
@@ -373,18 +444,27 @@ func (c *Client) performDeviceCodeAuth(ctx context.Context) error {
 			return fmt.Errorf("failed to save tokens: %w", err)
 		}
 
-		PrintSuccess("Authorization successful! Tokens saved to .bdfs_certs")
+		c.logger.Info("Authorization successful! Tokens saved to .bdfs_certs")
 		return nil
 	case err := <-errChan:
 		return fmt.Errorf("failed to get token: %w", err)
 	}
 }
 
-// SaveTokens saves the access token to a file
+// SaveTokens saves the access token to a file.
+// The file is written to a temporary path and renamed into place so readers never see a partial file.
+// Tokens given with WithTokens are only kept in memory, and nothing is written in static-token mode.
 func (c *Client) SaveTokens() error {
+	c.mu.Lock()
 	if c.accessToken == "" {
+		c.mu.Unlock()
 		return fmt.Errorf("no access token to save")
 	}
+	if c.tokensInjected || c.staticToken {
+		c.tokenCreatedAt = time.Now()
+		c.mu.Unlock()
+		return nil
+	}
 
 	tokenFile := &TokenFile{
 		AccessToken:  c.accessToken,
@@ -396,17 +476,69 @@ func (c *Client) SaveTokens() error {
 
 	// Update the client's token creation time as well
 	c.tokenCreatedAt = tokenFile.CreatedAt
+	c.mu.Unlock()
 
 	data, err := json.MarshalIndent(tokenFile, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(c.tokenFile, data, 0600)
+	return writeFileAtomic(c.tokenFile, data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// setTokens stores the tokens from a token response on the client
+func (c *Client) setTokens(token *TokenResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.accessToken = token.AccessToken
+	c.refreshToken = token.RefreshToken
+	c.expiresIn = token.ExpiresIn
+	c.uid = token.UID
 }
 
-// LoadTokens loads the access token from a file
+// getAccessToken returns the current access token
+func (c *Client) getAccessToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.accessToken
+}
+
+// LoadTokens loads the access token from a file; tokens given with WithTokens are kept
 func (c *Client) LoadTokens() error {
+	if c.tokensInjected {
+		return nil
+	}
 	data, err := os.ReadFile(c.tokenFile)
 	if err != nil {
 		return fmt.Errorf("unable to read token file: %w", err)
@@ -418,17 +550,22 @@ func (c *Client) LoadTokens() error {
 		return err
 	}
 
+	c.mu.Lock()
 	c.accessToken = tokenFile.AccessToken
 	c.refreshToken = tokenFile.RefreshToken
 	c.expiresIn = tokenFile.ExpiresIn
 	c.uid = tokenFile.UID
 	c.tokenCreatedAt = tokenFile.CreatedAt
+	c.mu.Unlock()
 
 	return nil
 }
 
-// HasValidToken checks if there's a valid token in the file
+// HasValidToken checks if there's a valid token in the file, or tokens given with WithTokens
 func (c *Client) HasValidToken() bool {
+	if c.tokensInjected {
+		return true
+	}
 	_, err := os.Stat(c.tokenFile)
 	return err == nil
 }
@@ -436,6 +573,9 @@ func (c *Client) HasValidToken() bool {
 
 // IsTokenExpired checks if the token is expired or will expire soon (within 2 days)
 func (c *Client) IsTokenExpired() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if c.tokenCreatedAt.IsZero() || c.expiresIn == 0 {
 		// If we don't have creation time or expiration info, assume it's expired
 		return true
@@ -451,24 +591,34 @@ func (c *Client) IsTokenExpired() bool {
 
 // RefreshToken attempts to refresh the access token using the refresh token
 func (c *Client) RefreshToken() error {
-	if c.refreshToken == "" {
+	if c.staticToken {
+		return errStaticToken
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	refreshToken := c.refreshToken
+	c.mu.RUnlock()
+
+	if refreshToken == "" {
 		return fmt.Errorf("no refresh token available")
 	}
 
 	params := url.Values{}
 	params.Add("grant_type", "refresh_token")
-	params.Add("refresh_token", c.refreshToken)
+	params.Add("refresh_token", refreshToken)
 	params.Add("client_id", c.clientID)
 	params.Add("client_secret", c.clientSecret)
 
-	req, err := http.NewRequest("POST", accessTokenURL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequest("POST", c.endpoint(accessTokenURL), strings.NewReader(params.Encode()))
 	if err != nil {
 		return err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -494,10 +644,7 @@ func (c *Client) RefreshToken() error {
 	}
 
 	// Update client with new tokens
-	c.accessToken = tokenResp.AccessToken
-	c.refreshToken = tokenResp.RefreshToken
-	c.expiresIn = tokenResp.ExpiresIn
-	c.uid = tokenResp.UID
+	c.setTokens(&tokenResp)
 
 	// Save the refreshed tokens
 	return c.SaveTokens()
@@ -571,5 +718,7 @@ func GetSourceFileName(path string) string {
 
 // HasRefreshToken returns whether the client has a refresh token available
 func (c *Client) HasRefreshToken() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.refreshToken != ""
 }
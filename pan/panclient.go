@@ -0,0 +1,100 @@
+package pan
+
+import (
+	"context"
+	"iter"
+)
+
+// PanClient is the set of operations the CLI performs on Baidu Pan. *Client implements
+// it; embedders and tests can substitute a fake, or a client of pantest's mock server.
+type PanClient interface {
+	// Tokens
+	LoadTokens() error
+	HasValidToken() bool
+	HasRefreshToken() bool
+	RefreshToken() error
+	TokensInjected() bool
+
+	// Listing and metadata
+	ListFiles(dirPath string) ([]FileInfo, error)
+	ListIter(ctx context.Context, dirPath string, opts ListOpts) iter.Seq2[FileInfo, error]
+	ListAll(ctx context.Context, dirPath string) iter.Seq2[FileInfo, error]
+	ListTree(ctx context.Context, dirPath string, maxDepth int) ([]FileInfo, error)
+	GetFileInfoByPath(filePath string) (*FileInfo, error)
+	GetFileInfoByFsID(fsID int64) (*FileInfo, error)
+	FileInfosByFsID(ctx context.Context, ids []int64) ([]FileInfo, error)
+	PathsByFsID(ctx context.Context, ids []int64) ([]string, error)
+	GetDetailedFileInfo(filePath string) (*FileInfo, error)
+	GetAndDisplayFileInfo(filePath string) (*FileInfo, error)
+	FileInfosByPath(ctx context.Context, filePaths []string) ([]FileInfo, error)
+	GetMediaInfo(ctx context.Context, files []FileInfo) (map[int64]MediaInfo, error)
+	GetDocPreview(filePath string) (*DocPreview, error)
+	GetDiskInfo() (*DiskInfoResponse, error)
+	RecycleRetention() (int, error)
+
+	// Uploads and downloads
+	UploadFile(localFilePath, remoteFilePath string) error
+	UploadFileWithOptions(localFilePath, remoteFilePath string, opts UploadOptions) error
+	UploadDirArchive(ctx context.Context, localDir, remotePath string, format ArchiveFormat, opts UploadOptions) error
+	UploadFiles(ctx context.Context, jobs []UploadJob, opts UploadFilesOptions) (UploadFilesResult, error)
+	DownloadFileToPath(filePath, localPath string) error
+	DownloadFileToPathWithOptions(filePath, localPath string, opts DownloadOptions) error
+	DownloadFileSplit(remoteFilePath, localPath string, opts DownloadOptions) error
+	DownloadFileByFsID(fsID int64, localPath string, opts DownloadOptions) error
+	DownloadDirJobs(ctx context.Context, remoteDir, localDir string) ([]DownloadJob, error)
+	DownloadFiles(ctx context.Context, jobs []DownloadJob, opts DownloadFilesOptions) (DownloadFilesResult, error)
+	CopyToAccount(ctx context.Context, srcPath string, dst *Client, dstPath string, opts UploadOptions) error
+
+	// File management
+	CreateDir(remotePath string) error
+	RemoveFile(filePath string) error
+	RemoveFiles(filePaths []string) error
+	RemoveFilesByFsID(ids []int64) error
+	MoveFile(sourcePath, destDir string) error
+	MoveFiles(moveRequests []MoveRequest) error
+	MoveFilesByFsID(ids []int64, destDir string) error
+	MoveFilesInto(sources []string, destDir string) error
+	RenameFile(sourcePath, newName string) error
+	RenameFiles(renameRequests []RenameRequest) error
+	CopyFile(sourcePath, destPath string) error
+	CopyFiles(copyRequests []CopyRequest) error
+	CopyFilesInto(sources []string, destDir string) error
+	CopyFileWithOptions(sourcePath, destPath string, opts CopyOptions) error
+	CopyFilesWithOptions(copyRequests []CopyRequest, opts CopyOptions) error
+	CopyFilesIntoWithOptions(sources []string, destDir string, opts CopyOptions) error
+	StartCopyTask(copyRequests []CopyRequest) (int64, error)
+	StartMoveTask(moveRequests []MoveRequest) (int64, error)
+	StartRemoveTask(filePaths []string) (int64, error)
+	QueryTask(ctx context.Context, id int64) (*Task, error)
+	WaitTask(ctx context.Context, id int64) (*Task, error)
+	PruneEmptyDirs(ctx context.Context, root string) ([]string, error)
+
+	// Sharing
+	CreateShare(paths []string, opts ShareOptions) (*Share, error)
+	CreateShareByFsID(ids []int64, opts ShareOptions) (*Share, error)
+	ListReceivedShares(ctx context.Context) ([]ReceivedShare, error)
+
+	// Synchronization, manifests and reports
+	SyncUp(localDir, remoteDir string, opts SyncOptions) (*SyncResult, error)
+	SyncDown(remoteDir, localDir string, opts SyncOptions) (*SyncResult, error)
+	ExportManifest(ctx context.Context, root string) (*Manifest, error)
+	DiffWithRemote(ctx context.Context, oldManifest *Manifest, root string) (*ManifestDiff, error)
+	BuildStorageReport(ctx context.Context, root string) (*StorageReport, error)
+	SpeedTest(ctx context.Context, opts SpeedTestOptions) ([]SpeedTestResult, error)
+
+	// Index, queue and photos
+	RebuildIndex(idx *Index, root string) (int, error)
+	PruneIndex(idx *Index, root string) (int, error)
+	UpdateIndex(idx *Index) (*IndexUpdate, error)
+	Changes(cursor string) (*ChangeSet, error)
+	QueueDownloadItems(ctx context.Context, remotePath, localPath string) ([]QueueItem, error)
+	RunQueue(ctx context.Context, q *Queue, opts QueueRunOptions) (done, failed int, err error)
+	BackupPhotos(ctx context.Context, localDir string, idx *PhotoIndex, opts PhotoBackupOptions) (PhotoBackupResult, error)
+	OrganizePhotos(ctx context.Context, root string, opts OrganizeOptions) (OrganizeResult, error)
+
+	// Progress
+	Stats() Stats
+	Status() Status
+}
+
+var _ PanClient = (*Client)(nil)
@@ -0,0 +1,881 @@
+// Package pantest provides a mock Baidu Pan API server, so code using the pan client can
+// be tested without real credentials or network access. The server keeps an in-memory
+// file tree and implements the OAuth device flow, list, listall and meta, the file manager
+// (delete, move, copy and rename, including asynchronous tasks), uploads (precreate, locateupload, superfile2 and
+// create, which also creates directories), download links from filemetas and the
+// downloads they point to, with Range support, quota and uinfo endpoints.
+//
+//	srv := pantest.NewServer()
+//	defer srv.Close()
+//	srv.WriteFile("/apps/bdfs/a.txt", []byte("hello"))
+//	client := srv.NewClient(t)
+//	files, err := client.ListFiles("/apps/bdfs")
+package pantest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan"
+)
+
+// Credentials accepted by a new Server and written by NewClient
+const (
+	DefaultAccessToken  = "pantest-access-token"
+	DefaultRefreshToken = "pantest-refresh-token"
+)
+
+// DefaultQuota is the total space reported by the quota endpoint
+const DefaultQuota = 2 << 40
+
+// node is a file or directory of the mock file tree
+type node struct {
+	isDir   bool
+	content []byte
+	md5     string
+	fsID    int64
+	ctime   int64
+	mtime   int64
+}
+
+// upload is an upload between precreate and create
+type upload struct {
+	path   string
+	slices map[int][]byte
+}
+
+// Server is a mock Baidu Pan API server. Its exported fields may be changed before the
+// first request.
+type Server struct {
+	*httptest.Server
+
+	AccessToken  string // Token every API request must carry; others get errno -6
+	RefreshToken string // Token the refresh grant accepts
+	Quota        int64  // Total space reported by the quota endpoint
+	VIPType      int    // VIP level reported by uinfo: 0 normal, 1 VIP, 2 SVIP
+
+	// PendingPolls is how many device token polls answer authorization_pending before
+	// the device is authorized
+	PendingPolls int
+
+	mu            sync.Mutex
+	files         map[string]*node
+	uploads       map[string]*upload
+	failures      map[string][]int
+	entryFailures map[string][]int
+	tasks         map[int64]*task
+	calls         map[string]int
+	nextID        int64
+	requestID     int64
+}
+
+// task is an asynchronous file manager task, carried out when submitted but reported as
+// running on its first query
+type task struct {
+	queried bool
+	list    []map[string]any
+}
+
+// NewServer starts a mock server holding an empty root directory. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		AccessToken:   DefaultAccessToken,
+		RefreshToken:  DefaultRefreshToken,
+		Quota:         DefaultQuota,
+		files:         map[string]*node{"/": {isDir: true, fsID: 1}},
+		uploads:       make(map[string]*upload),
+		failures:      make(map[string][]int),
+		entryFailures: make(map[string][]int),
+		tasks:         make(map[int64]*task),
+		calls:         make(map[string]int),
+		nextID:        1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Transport returns a RoundTripper that sends every request, whatever its host, to the
+// server; use it with pan.WithTransport
+func (s *Server) Transport() http.RoundTripper {
+	return &redirectTransport{target: s.Listener.Addr().String()}
+}
+
+// Endpoints returns the base URLs of the server for every Baidu host; use them with
+// pan.WithEndpoints instead of Transport to also test the endpoint configuration
+func (s *Server) Endpoints() pan.Endpoints {
+	return pan.Endpoints{OpenAPI: s.URL, Pan: s.URL, PCS: s.URL, DPCS: s.URL}
+}
+
+// redirectTransport rewrites requests to Baidu's hosts to the mock server
+type redirectTransport struct {
+	target string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = t.target
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// NewClient returns a pan client authorized against the server, with its token file in
+// a temporary directory of tb. opts are applied after the server's transport.
+func (s *Server) NewClient(tb testing.TB, opts ...pan.Option) *pan.Client {
+	tb.Helper()
+	tokenPath := filepath.Join(tb.TempDir(), "token.json")
+	data, err := json.Marshal(pan.TokenFile{
+		AccessToken:  s.AccessToken,
+		RefreshToken: s.RefreshToken,
+		ExpiresIn:    2592000,
+		UID:          "1",
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.WriteFile(tokenPath, data, 0600); err != nil {
+		tb.Fatal(err)
+	}
+
+	client := pan.NewClient("pantest-client-id", "pantest-client-secret", tokenPath,
+		append([]pan.Option{pan.WithTransport(s.Transport())}, opts...)...)
+	if err := client.LoadTokens(); err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
+
+// WriteFile stores a file with content at the absolute path p, creating its parent
+// directories and replacing an existing file
+func (s *Server) WriteFile(p string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mkdirAll(path.Dir(path.Clean(p)))
+	s.putFile(path.Clean(p), content)
+}
+
+// Mkdir creates the directory p and its parents
+func (s *Server) Mkdir(p string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mkdirAll(path.Clean(p))
+}
+
+// ReadFile returns the content of the file at p, reporting whether there is one
+func (s *Server) ReadFile(p string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.files[path.Clean(p)]
+	if !ok || n.isDir {
+		return nil, false
+	}
+	return bytes.Clone(n.content), true
+}
+
+// Exists reports whether a file or directory exists at p
+func (s *Server) Exists(p string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.files[path.Clean(p)]
+	return ok
+}
+
+// Fail makes the next request to method answer with errno code instead of being
+// executed; calling it several times queues several failures. method is the opera of
+// file manager requests ("delete", "move", "copy", "rename"), otherwise the method
+// query parameter ("list", "precreate", "upload", "create", "locateupload", ...), or
+// for the other endpoints the last path element ("quota", "token", "code"); downloads
+// are "download".
+func (s *Server) Fail(method string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], code)
+}
+
+// FailEntry makes the next file manager opera ("delete", "move", "copy" or "rename") on
+// the entry at path answer with errno code in the info of its batch, while the other
+// entries are executed; calling it several times queues several failures
+func (s *Server) FailEntry(opera, path string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := opera + "\x00" + path
+	s.entryFailures[key] = append(s.entryFailures[key], code)
+}
+
+// Calls returns how many requests to method the server received, named as for Fail
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// methodName returns the name Fail and Calls use for the operation of r
+func methodName(r *http.Request) string {
+	if strings.HasPrefix(r.URL.Path, "/file/") {
+		return "download"
+	}
+	query := r.URL.Query()
+	if opera := query.Get("opera"); opera != "" {
+		return opera
+	}
+	if method := query.Get("method"); method != "" {
+		return method
+	}
+	return path.Base(r.URL.Path)
+}
+
+// serve dispatches a request to the handler of its endpoint
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	method := methodName(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+	s.requestID++
+	if codes := s.failures[method]; len(codes) > 0 {
+		s.failures[method] = codes[1:]
+		if method == "upload" || method == "download" {
+			// PCS reports slice upload and download failures with an HTTP error status
+			s.replyStatus(w, http.StatusInternalServerError, map[string]any{"error_code": codes[0], "error_msg": "injected failure"})
+			return
+		}
+		s.reply(w, map[string]any{"errno": codes[0]})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/oauth/2.0/device/code":
+		s.deviceCode(w)
+		return
+	case "/oauth/2.0/token":
+		s.token(w, r)
+		return
+	}
+
+	if r.Form.Get("access_token") != s.AccessToken {
+		s.reply(w, map[string]any{"errno": -6})
+		return
+	}
+	switch {
+	case r.URL.Path == "/rest/2.0/xpan/file" && method == "list":
+		s.list(w, r)
+	case r.URL.Path == "/rest/2.0/xpan/multimedia" && method == "listall":
+		s.listAll(w, r)
+	case r.URL.Path == "/rest/2.0/xpan/file" && method == "meta":
+		s.meta(w, r)
+	case r.URL.Path == "/rest/2.0/xpan/multimedia" && method == "filemetas":
+		s.fileMetas(w, r)
+	case r.URL.Path == "/rest/2.0/xpan/file" && method == "precreate":
+		s.precreate(w, r)
+	case r.URL.Path == "/rest/2.0/xpan/file" && method == "create":
+		s.create(w, r)
+	case r.URL.Path == "/api/filemanager":
+		s.fileManager(w, r, method)
+	case r.URL.Path == "/rest/2.0/pcs/file" && method == "locateupload":
+		s.reply(w, map[string]any{
+			"error_code": 0,
+			"host":       "d.pcs.baidu.com",
+			"servers":    []map[string]string{{"server": "https://d.pcs.baidu.com"}},
+		})
+	case r.URL.Path == "/rest/2.0/pcs/superfile2":
+		s.uploadSlice(w, r)
+	case r.URL.Path == "/api/quota":
+		s.quota(w)
+	case r.URL.Path == "/rest/2.0/xpan/nas" && method == "uinfo":
+		// Baidu sends this request_id as a string
+		s.reply(w, map[string]any{"errno": 0, "baidu_name": "pantest", "netdisk_name": "pantest",
+			"vip_type": s.VIPType, "uk": 1, "request_id": strconv.FormatInt(s.requestID, 10)})
+	case r.URL.Path == "/share/taskquery":
+		s.taskQuery(w, r)
+	case method == "download":
+		s.download(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// reply writes v as a JSON response, adding a request_id to maps
+func (s *Server) reply(w http.ResponseWriter, v any) {
+	if m, ok := v.(map[string]any); ok {
+		if _, ok := m["request_id"]; !ok {
+			m["request_id"] = s.requestID
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// replyStatus writes v as a JSON response with the given HTTP status
+func (s *Server) replyStatus(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// deviceCode starts the device flow
+func (s *Server) deviceCode(w http.ResponseWriter) {
+	s.reply(w, pan.DeviceCodeResponse{
+		DeviceCode:      "pantest-device-code",
+		UserCode:        "PANTEST",
+		VerificationURL: "https://openapi.baidu.com/device",
+		ExpiresIn:       300,
+		Interval:        1,
+	})
+}
+
+// token answers device token polls and refresh grants
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	switch r.Form.Get("grant_type") {
+	case "device_token":
+		if s.PendingPolls > 0 {
+			s.PendingPolls--
+			s.replyStatus(w, http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+			return
+		}
+	case "refresh_token":
+		if r.Form.Get("refresh_token") != s.RefreshToken {
+			s.replyStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+	default:
+		s.replyStatus(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	s.reply(w, pan.TokenResponse{
+		AccessToken:  s.AccessToken,
+		RefreshToken: s.RefreshToken,
+		ExpiresIn:    2592000,
+		Scope:        "basic netdisk",
+		UID:          "1",
+	})
+}
+
+// list lists a directory, paged with start and limit
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	dir := path.Clean(r.Form.Get("dir"))
+	if n, ok := s.files[dir]; !ok || !n.isDir {
+		s.reply(w, map[string]any{"errno": -9})
+		return
+	}
+
+	var entries []pan.FileInfo
+	for p, n := range s.files {
+		if p == "/" || path.Dir(p) != dir {
+			continue
+		}
+		if r.Form.Get("folder") == "1" && !n.isDir {
+			continue
+		}
+		if name := r.Form.Get("filename"); name != "" && path.Base(p) != name {
+			continue
+		}
+		entries = append(entries, fileInfo(p, n))
+	}
+	sortEntries(entries, r.Form.Get("order"), r.Form.Get("desc") == "1")
+
+	start, _ := strconv.Atoi(r.Form.Get("start"))
+	limit, err := strconv.Atoi(r.Form.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 1000
+	}
+	start = min(max(start, 0), len(entries))
+	entries = entries[start:min(start+limit, len(entries))]
+	s.reply(w, map[string]any{"errno": 0, "list": nonNil(entries)})
+}
+
+// listAll lists everything beneath path, in pages, as the recursive listall API does
+func (s *Server) listAll(w http.ResponseWriter, r *http.Request) {
+	dir := path.Clean(r.Form.Get("path"))
+	if n, ok := s.files[dir]; !ok || !n.isDir {
+		s.reply(w, map[string]any{"errno": -9})
+		return
+	}
+
+	var entries []pan.FileInfo
+	for p, n := range s.files {
+		if p != dir && strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/") {
+			entries = append(entries, fileInfo(p, n))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	start, _ := strconv.Atoi(r.Form.Get("start"))
+	limit, err := strconv.Atoi(r.Form.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 1000
+	}
+	start = min(max(start, 0), len(entries))
+	end := min(start+limit, len(entries))
+	hasMore := 0
+	if end < len(entries) {
+		hasMore = 1
+	}
+	s.reply(w, map[string]any{"errno": 0, "list": nonNil(entries[start:end]), "has_more": hasMore, "cursor": end})
+}
+
+// sortEntries orders entries as the list API does: directories first, then by order
+func sortEntries(entries []pan.FileInfo, order string, desc bool) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir > b.IsDir
+		}
+		less := a.ServerFilename < b.ServerFilename
+		switch order {
+		case "time":
+			less = a.ServerMtime < b.ServerMtime
+		case "size":
+			less = a.Size < b.Size
+		}
+		if desc {
+			return !less
+		}
+		return less
+	})
+}
+
+// meta returns the entry of one path
+func (s *Server) meta(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean(r.Form.Get("path"))
+	n, ok := s.files[p]
+	if !ok {
+		s.reply(w, map[string]any{"errno": -9})
+		return
+	}
+	s.reply(w, map[string]any{"errno": 0, "list": []pan.FileInfo{fileInfo(p, n)}})
+}
+
+// fileMetas returns the entries with the fs_ids of fsids; unknown ids are left out
+func (s *Server) fileMetas(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	if err := json.Unmarshal([]byte(r.Form.Get("fsids")), &ids); err != nil {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+
+	byID := make(map[int64]string, len(s.files))
+	for p, n := range s.files {
+		byID[n.fsID] = p
+	}
+	list := []map[string]any{}
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			continue
+		}
+		info := fileInfo(p, s.files[p])
+		entry := map[string]any{
+			"fs_id":        info.FsID,
+			"path":         p,
+			"filename":     info.ServerFilename,
+			"isdir":        info.IsDir,
+			"size":         info.Size,
+			"md5":          info.MD5,
+			"category":     info.Category,
+			"server_ctime": info.ServerCtime,
+			"server_mtime": info.ServerMtime,
+		}
+		if r.Form.Get("dlink") == "1" && info.IsDir == 0 {
+			entry["dlink"] = fmt.Sprintf("%s/file/%s?fid=%d", s.URL, info.MD5, info.FsID)
+		}
+		list = append(list, entry)
+	}
+	s.reply(w, map[string]any{"errno": 0, "list": list})
+}
+
+// download serves the content of the file with the fs_id fid of a download link,
+// honouring Range requests
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	fsID, _ := strconv.ParseInt(r.Form.Get("fid"), 10, 64)
+	for p, n := range s.files {
+		if n.fsID == fsID && !n.isDir {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, path.Base(p), time.Unix(n.mtime, 0), bytes.NewReader(n.content))
+			return
+		}
+	}
+	s.replyStatus(w, http.StatusNotFound, map[string]any{"error_code": 31066, "error_msg": "file does not exist"})
+}
+
+// precreate registers an upload and asks for every slice
+func (s *Server) precreate(w http.ResponseWriter, r *http.Request) {
+	var blockList []string
+	if err := json.Unmarshal([]byte(r.Form.Get("block_list")), &blockList); err != nil {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+	p := path.Clean(r.Form.Get("path"))
+	if !path.IsAbs(p) {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+
+	s.nextID++
+	uploadID := fmt.Sprintf("pantest-upload-%d", s.nextID)
+	s.uploads[uploadID] = &upload{path: p, slices: make(map[int][]byte)}
+	needed := make([]int, len(blockList))
+	for i := range needed {
+		needed[i] = i
+	}
+	s.reply(w, map[string]any{"errno": 0, "uploadid": uploadID, "return_type": 1, "block_list": needed})
+}
+
+// uploadSlice stores one slice of an upload
+func (s *Server) uploadSlice(w http.ResponseWriter, r *http.Request) {
+	up, ok := s.uploads[r.Form.Get("uploadid")]
+	if !ok {
+		s.replyStatus(w, http.StatusBadRequest, map[string]any{"error_code": 31299, "error_msg": "invalid uploadid"})
+		return
+	}
+	seq, err := strconv.Atoi(r.Form.Get("partseq"))
+	if err != nil || seq < 0 {
+		s.replyStatus(w, http.StatusBadRequest, map[string]any{"error_code": 31023, "error_msg": "invalid partseq"})
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		s.replyStatus(w, http.StatusBadRequest, map[string]any{"error_code": 31023, "error_msg": "missing file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		s.replyStatus(w, http.StatusBadRequest, map[string]any{"error_code": 31023, "error_msg": err.Error()})
+		return
+	}
+	up.slices[seq] = data
+	s.reply(w, map[string]any{"md5": md5Hex(data), "partseq": strconv.Itoa(seq)})
+}
+
+// create creates a directory, or the file of an upload from its slices
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean(r.Form.Get("path"))
+	if !path.IsAbs(p) {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+
+	if r.Form.Get("isdir") == "1" {
+		if _, exists := s.files[p]; exists {
+			s.reply(w, map[string]any{"errno": -8})
+			return
+		}
+		s.mkdirAll(p)
+		s.replyEntry(w, p)
+		return
+	}
+
+	up, ok := s.uploads[r.Form.Get("uploadid")]
+	if !ok || up.path != p {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+	var blockList []string
+	if err := json.Unmarshal([]byte(r.Form.Get("block_list")), &blockList); err != nil {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+	var content []byte
+	for i, md5sum := range blockList {
+		slice, ok := up.slices[i]
+		if !ok || md5Hex(slice) != md5sum {
+			s.reply(w, map[string]any{"errno": 31363})
+			return
+		}
+		content = append(content, slice...)
+	}
+	if size, err := strconv.ParseInt(r.Form.Get("size"), 10, 64); err != nil || size != int64(len(content)) {
+		s.reply(w, map[string]any{"errno": 31190})
+		return
+	}
+
+	if existing, exists := s.files[p]; exists {
+		switch r.Form.Get("rtype") {
+		case "3": // Overwrite
+			if existing.isDir {
+				s.reply(w, map[string]any{"errno": -8})
+				return
+			}
+		case "1", "2": // Rename the new file
+			p = s.freeName(p, time.Now().Format("_20060102_150405"))
+		default:
+			s.reply(w, map[string]any{"errno": -8})
+			return
+		}
+	}
+	delete(s.uploads, r.Form.Get("uploadid"))
+	s.mkdirAll(path.Dir(p))
+	s.putFile(p, content)
+	s.replyEntry(w, p)
+}
+
+// replyEntry answers a create with the entry at p
+func (s *Server) replyEntry(w http.ResponseWriter, p string) {
+	n := s.files[p]
+	info := fileInfo(p, n)
+	s.reply(w, map[string]any{
+		"errno":           0,
+		"fs_id":           info.FsID,
+		"path":            p,
+		"server_filename": info.ServerFilename,
+		"size":            info.Size,
+		"md5":             info.MD5,
+		"isdir":           info.IsDir,
+		"ctime":           n.ctime,
+		"mtime":           n.mtime,
+	})
+}
+
+// fileOp is an entry of a file manager filelist
+type fileOp struct {
+	Path    string `json:"path"`
+	Dest    string `json:"dest"`
+	NewName string `json:"newname"`
+}
+
+// fileManager deletes, moves, copies or renames the entries of filelist
+func (s *Server) fileManager(w http.ResponseWriter, r *http.Request, opera string) {
+	raw := r.Form.Get("filelist")
+	var ops []fileOp
+	if opera == "delete" {
+		var paths []string
+		if err := json.Unmarshal([]byte(raw), &paths); err != nil {
+			s.reply(w, map[string]any{"errno": 2})
+			return
+		}
+		for _, p := range paths {
+			ops = append(ops, fileOp{Path: p})
+		}
+	} else if err := json.Unmarshal([]byte(raw), &ops); err != nil {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+
+	failed := false
+	info := make([]map[string]any, 0, len(ops))
+	for _, op := range ops {
+		var code int
+		if key := opera + "\x00" + op.Path; len(s.entryFailures[key]) > 0 {
+			code = s.entryFailures[key][0]
+			s.entryFailures[key] = s.entryFailures[key][1:]
+		} else {
+			code = s.applyFileOp(opera, op, r.Form.Get("ondup"))
+		}
+		failed = failed || code != 0
+		info = append(info, map[string]any{"path": op.Path, "errno": code})
+	}
+	if r.Form.Get("async") == "2" {
+		id := s.nextID
+		s.nextID++
+		list := make([]map[string]any, len(ops))
+		for i, op := range ops {
+			list[i] = map[string]any{"from": op.Path, "to": path.Join(op.Dest, op.NewName), "errno": info[i]["errno"]}
+		}
+		s.tasks[id] = &task{list: list}
+		s.reply(w, map[string]any{"errno": 0, "info": []any{}, "taskid": id})
+		return
+	}
+	result := map[string]any{"errno": 0, "info": info, "taskid": 0}
+	if failed {
+		result["errno"] = 12 // Some entries failed
+	}
+	s.reply(w, result)
+}
+
+// taskQuery reports the state of an asynchronous file manager task
+func (s *Server) taskQuery(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.Form.Get("taskid"), 10, 64)
+	t, ok := s.tasks[id]
+	if !ok {
+		s.reply(w, map[string]any{"errno": 2})
+		return
+	}
+	if !t.queried {
+		t.queried = true
+		s.reply(w, map[string]any{"errno": 0, "task_errno": 0, "status": "running"})
+		return
+	}
+	s.reply(w, map[string]any{"errno": 0, "task_errno": 0, "status": "success", "list": t.list})
+}
+
+// applyFileOp executes one file manager operation and returns its errno
+func (s *Server) applyFileOp(opera string, op fileOp, ondup string) int {
+	src := path.Clean(op.Path)
+	if _, ok := s.files[src]; !ok || src == "/" {
+		return -9
+	}
+	if opera == "delete" {
+		s.removeTree(src)
+		return 0
+	}
+
+	var dest string
+	switch opera {
+	case "rename":
+		if op.NewName == "" || strings.Contains(op.NewName, "/") {
+			return -7
+		}
+		dest = path.Join(path.Dir(src), op.NewName)
+	case "move", "copy":
+		dir := path.Clean(op.Dest)
+		if n, ok := s.files[dir]; !ok || !n.isDir {
+			s.mkdirAll(dir) // The file manager creates missing destinations
+		}
+		name := op.NewName
+		if name == "" {
+			name = path.Base(src)
+		}
+		dest = path.Join(dir, name)
+	default:
+		return 2
+	}
+	if dest == src || strings.HasPrefix(dest, src+"/") {
+		return 2
+	}
+
+	if _, exists := s.files[dest]; exists {
+		switch ondup {
+		case "overwrite":
+			s.removeTree(dest)
+		case "newcopy":
+			dest = s.freeName(dest, "")
+		default:
+			return -8
+		}
+	}
+	if opera == "copy" {
+		s.copyTree(src, dest)
+	} else {
+		s.moveTree(src, dest)
+	}
+	return 0
+}
+
+// quota reports the space used by the files
+func (s *Server) quota(w http.ResponseWriter) {
+	var used int64
+	for _, n := range s.files {
+		used += int64(len(n.content))
+	}
+	s.reply(w, map[string]any{"errno": 0, "total": s.Quota, "used": used, "free": max(s.Quota-used, 0), "expire": false})
+}
+
+// mkdirAll creates the directory p and its missing parents; s.mu must be held
+func (s *Server) mkdirAll(p string) {
+	if _, ok := s.files[p]; ok || p == "/" || p == "." {
+		return
+	}
+	s.mkdirAll(path.Dir(p))
+	s.nextID++
+	now := time.Now().Unix()
+	s.files[p] = &node{isDir: true, fsID: s.nextID, ctime: now, mtime: now}
+}
+
+// putFile stores a file with content at p; s.mu must be held
+func (s *Server) putFile(p string, content []byte) {
+	s.nextID++
+	now := time.Now().Unix()
+	s.files[p] = &node{content: bytes.Clone(content), md5: md5Hex(content), fsID: s.nextID, ctime: now, mtime: now}
+}
+
+// removeTree deletes p and everything beneath it; s.mu must be held
+func (s *Server) removeTree(p string) {
+	for q := range s.files {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			delete(s.files, q)
+		}
+	}
+}
+
+// copyTree copies p and everything beneath it to dest with new fs_ids; s.mu must be held
+func (s *Server) copyTree(p, dest string) {
+	for q, n := range s.files {
+		if q != p && !strings.HasPrefix(q, p+"/") {
+			continue
+		}
+		copied := *n
+		s.nextID++
+		copied.fsID = s.nextID
+		s.files[dest+strings.TrimPrefix(q, p)] = &copied
+	}
+}
+
+// moveTree moves p and everything beneath it to dest, keeping their fs_ids as Baidu Pan
+// does; s.mu must be held
+func (s *Server) moveTree(p, dest string) {
+	for q, n := range s.files {
+		if q == p || strings.HasPrefix(q, p+"/") {
+			delete(s.files, q)
+			s.files[dest+strings.TrimPrefix(q, p)] = n
+		}
+	}
+}
+
+// freeName returns p with suffix inserted before its extension, followed by a number
+// when needed, so that no entry exists there
+func (s *Server) freeName(p, suffix string) string {
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	if _, exists := s.files[base+suffix+ext]; suffix != "" && !exists {
+		return base + suffix + ext
+	}
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s%s(%d)%s", base, suffix, i, ext)
+		if _, exists := s.files[candidate]; !exists {
+			return candidate
+		}
+	}
+}
+
+// fileInfo returns the list API entry of n at p
+func fileInfo(p string, n *node) pan.FileInfo {
+	info := pan.FileInfo{
+		FsID:           n.fsID,
+		Path:           p,
+		ServerFilename: path.Base(p),
+		Size:           int64(len(n.content)),
+		MD5:            n.md5,
+		ServerCtime:    n.ctime,
+		ServerMtime:    n.mtime,
+		LocalCTime:     n.ctime,
+		LocalMtime:     n.mtime,
+		Category:       6, // Other
+	}
+	if n.isDir {
+		info.IsDir = 1
+	}
+	return info
+}
+
+// md5Hex returns the hex MD5 of data
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// nonNil returns entries, or an empty slice so it encodes as [] rather than null
+func nonNil(entries []pan.FileInfo) []pan.FileInfo {
+	if entries == nil {
+		return []pan.FileInfo{}
+	}
+	return entries
+}
@@ -0,0 +1,118 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDirName is the name of the directory go-bdfs keeps its files in within each of the
+// user's base directories
+const appDirName = "bdfs"
+
+// DataDirs are the directories go-bdfs keeps its files in unless the configuration says
+// otherwise
+type DataDirs struct {
+	Config string // config.toml and token.json
+	State  string // Queue, history, photo index, resume records, snapshots and the daemon log
+	Cache  string // Metadata index and hash cache, which can be rebuilt
+}
+
+// DefaultDataDirs returns the directories of the platform: $XDG_CONFIG_HOME/bdfs (or
+// ~/.config/bdfs), $XDG_STATE_HOME/bdfs (or ~/.local/state/bdfs) and $XDG_CACHE_HOME/bdfs
+// (or ~/.cache/bdfs) on Linux and other Unix systems, ~/Library/Application Support/bdfs
+// and ~/Library/Caches/bdfs on macOS, and %AppData%\bdfs, %LocalAppData%\bdfs and
+// %LocalAppData%\bdfs\cache on Windows
+func DefaultDataDirs() (DataDirs, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return DataDirs{}, fmt.Errorf("failed to get the configuration directory: %w", err)
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return DataDirs{}, fmt.Errorf("failed to get the cache directory: %w", err)
+	}
+	stateDir, err := userStateDir()
+	if err != nil {
+		return DataDirs{}, fmt.Errorf("failed to get the state directory: %w", err)
+	}
+
+	dirs := DataDirs{
+		Config: filepath.Join(configDir, appDirName),
+		State:  filepath.Join(stateDir, appDirName),
+		Cache:  filepath.Join(cacheDir, appDirName),
+	}
+	if runtime.GOOS == "windows" {
+		// %LocalAppData% holds both the state and the cache, which Windows has no place for
+		dirs.Cache = filepath.Join(dirs.State, "cache")
+	}
+	return dirs, nil
+}
+
+// userStateDir returns the base directory of files an application keeps between runs
+// that are neither configuration nor cache, as os.UserConfigDir does for configuration
+func userStateDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+		return dir, nil
+	case "darwin", "ios":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $XDG_STATE_HOME is relative")
+		}
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "state"), nil
+}
+
+// LegacyDataDir returns ~/.local/app/bdfs, where earlier versions kept every file
+func LegacyDataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".local", "app", appDirName), nil
+}
+
+// MigrateLegacyFile moves the file or directory name out of LegacyDataDir to newPath and
+// reports whether it did. Nothing is moved when newPath already exists or the legacy
+// directory has no such file.
+func MigrateLegacyFile(name, newPath string) (bool, error) {
+	legacyDir, err := LegacyDataDir()
+	if err != nil {
+		return false, nil
+	}
+	oldPath := filepath.Join(legacyDir, name)
+	if oldPath == newPath {
+		return false, nil
+	}
+	if _, err := os.Lstat(newPath); !errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if _, err := os.Lstat(oldPath); err != nil {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0700); err != nil {
+		return false, fmt.Errorf("failed to create directory for %s: %w", newPath, err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return false, fmt.Errorf("failed to move %s to %s: %w", oldPath, newPath, err)
+	}
+	// The legacy directory goes once it is empty; it is kept while anything is left
+	os.Remove(legacyDir)
+	return true, nil
+}
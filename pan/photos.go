@@ -0,0 +1,269 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// photoIndexBucket is the bbolt bucket holding one record per backed-up file, keyed by content MD5
+var photoIndexBucket = []byte("media")
+
+// DefaultPhotoDir is the remote directory photo backups go to by default
+const DefaultPhotoDir = "/Photos"
+
+// DefaultPhotoLayout is the default date-based layout of photo backups: year/month
+const DefaultPhotoLayout = "YYYY/MM"
+
+// mediaExtensions maps the extensions of local image and video files to their type
+var mediaExtensions = map[string]FileType{
+	".jpg": TypeImage, ".jpeg": TypeImage, ".png": TypeImage, ".gif": TypeImage,
+	".heic": TypeImage, ".heif": TypeImage, ".webp": TypeImage, ".bmp": TypeImage,
+	".tif": TypeImage, ".tiff": TypeImage, ".dng": TypeImage, ".cr2": TypeImage,
+	".cr3": TypeImage, ".nef": TypeImage, ".arw": TypeImage, ".orf": TypeImage,
+	".rw2": TypeImage, ".raf": TypeImage,
+	".mp4": TypeVideo, ".mov": TypeVideo, ".m4v": TypeVideo, ".avi": TypeVideo,
+	".mkv": TypeVideo, ".3gp": TypeVideo, ".mts": TypeVideo, ".m2ts": TypeVideo,
+	".wmv": TypeVideo, ".webm": TypeVideo,
+}
+
+// LocalMediaType returns TypeImage or TypeVideo for a local file name with a photo or
+// video extension, or "" for anything else
+func LocalMediaType(name string) FileType {
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// PhotoRecord is a file backed up by BackupPhotos
+type PhotoRecord struct {
+	MD5        string    `json:"md5"` // MD5 of the content
+	RemotePath string    `json:"remote_path"`
+	LocalPath  string    `json:"local_path"` // Where the file was first backed up from
+	Size       int64     `json:"size"`
+	Uploaded   time.Time `json:"uploaded"`
+}
+
+// PhotoIndex records the content MD5 of every backed-up photo and video, backed by bbolt,
+// so files are uploaded once however often they are copied, renamed or moved locally
+type PhotoIndex struct {
+	db *bolt.DB
+}
+
+// OpenPhotoIndex opens (creating it and its directory if needed) the photo backup index at dbPath
+func OpenPhotoIndex(dbPath string) (*PhotoIndex, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create photo index directory: %w", err)
+	}
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open photo index %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(photoIndexBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize photo index: %w", err)
+	}
+
+	return &PhotoIndex{db: db}, nil
+}
+
+// Close closes the photo index database
+func (pi *PhotoIndex) Close() error {
+	return pi.db.Close()
+}
+
+// Lookup returns the record of the backed-up file with the given content MD5, or nil
+func (pi *PhotoIndex) Lookup(md5 string) (*PhotoRecord, error) {
+	var record *PhotoRecord
+	err := pi.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(photoIndexBucket).Get([]byte(md5))
+		if data == nil {
+			return nil
+		}
+		record = &PhotoRecord{}
+		return json.Unmarshal(data, record)
+	})
+	return record, err
+}
+
+// Put stores record, replacing any record with the same MD5
+func (pi *PhotoIndex) Put(record PhotoRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return pi.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(photoIndexBucket).Put([]byte(record.MD5), data)
+	})
+}
+
+// Count returns the number of backed-up files
+func (pi *PhotoIndex) Count() (int, error) {
+	count := 0
+	err := pi.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(photoIndexBucket).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// PhotoBackupOptions controls BackupPhotos; the zero value uses the defaults
+type PhotoBackupOptions struct {
+	RemoteDir string            // Remote root of the backup; empty uses DefaultPhotoDir
+	Layout    string            // Date directories beneath RemoteDir, from YYYY, MM and DD; empty uses DefaultPhotoLayout
+	Upload    UploadOptions     // Options for each upload
+	Links     LinkPolicy        // Symbolic links beneath the local directory; empty follows them
+	Special   SpecialFilePolicy // Sockets, pipes, devices and sparse files; empty skips them
+
+	// Progress, if set, is called for every media file with its outcome: "uploaded",
+	// "duplicate" (remotePath is the earlier copy) or "failed"
+	Progress func(localPath, remotePath, outcome string, err error)
+}
+
+// PhotoBackupResult counts the outcomes of BackupPhotos
+type PhotoBackupResult struct {
+	Uploaded   int
+	Duplicates int // Skipped because the same content was backed up before
+	Failed     int
+	Bytes      int64 // Bytes uploaded
+}
+
+// photoLayout converts a layout of YYYY, MM and DD into a time format
+func photoLayout(layout string) string {
+	if layout == "" {
+		layout = DefaultPhotoLayout
+	}
+	return strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(layout)
+}
+
+// BackupPhotos uploads the photos and videos beneath localDir into date directories under
+// opts.RemoteDir, e.g. /Photos/2024/05/IMG_0001.JPG, dated by their EXIF capture date or
+// else their modification time. Files whose content MD5 is already in idx are skipped,
+// wherever they were backed up from; a different file with the same name in the same
+// month is stored with its MD5 prefix appended to the name rather than overwriting it.
+// A failed upload is counted and the backup continues; only walking localDir or using
+// idx can fail the whole backup.
+func (c *Client) BackupPhotos(ctx context.Context, localDir string, idx *PhotoIndex, opts PhotoBackupOptions) (PhotoBackupResult, error) {
+	var result PhotoBackupResult
+	remoteDir := opts.RemoteDir
+	if remoteDir == "" {
+		remoteDir = DefaultPhotoDir
+	}
+	layout := photoLayout(opts.Layout)
+	report := func(localPath, remotePath, outcome string, err error) {
+		if opts.Progress != nil {
+			opts.Progress(localPath, remotePath, outcome, err)
+		}
+	}
+
+	// Names already present in each remote date directory, listed on first use
+	existing := make(map[string]map[string]bool)
+	remoteNames := func(dir string) (map[string]bool, error) {
+		if names, ok := existing[dir]; ok {
+			return names, nil
+		}
+		names := make(map[string]bool)
+		files, err := c.ListFiles(dir)
+		if err != nil && !isNotFoundError(err) {
+			return nil, err
+		}
+		for _, file := range files {
+			names[file.ServerFilename] = true
+		}
+		existing[dir] = names
+		return names, nil
+	}
+
+	err := walkLocal(localDir, c.localWalk(opts.Links, opts.Special), func(localPath, _ string, info fs.FileInfo) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || LocalMediaType(info.Name()) == "" {
+			return nil
+		}
+
+		md5, err := c.localMD5(localPath, info)
+		if err != nil {
+			result.Failed++
+			report(localPath, "", "failed", err)
+			return nil
+		}
+		record, err := idx.Lookup(md5)
+		if err != nil {
+			return fmt.Errorf("failed to read photo index: %w", err)
+		}
+		if record != nil {
+			result.Duplicates++
+			report(localPath, record.RemotePath, "duplicate", nil)
+			return nil
+		}
+
+		dir := path.Join(remoteDir, mediaTime(localPath, info).Format(layout))
+		names, err := remoteNames(dir)
+		if err != nil {
+			result.Failed++
+			report(localPath, "", "failed", fmt.Errorf("failed to list %s: %w", dir, err))
+			return nil
+		}
+		name := filepath.Base(localPath)
+		if names[name] {
+			ext := filepath.Ext(name)
+			name = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), md5[:8], ext)
+		}
+		remotePath := path.Join(dir, name)
+
+		if err := c.UploadFileWithOptions(localPath, remotePath, opts.Upload); err != nil {
+			result.Failed++
+			report(localPath, remotePath, "failed", err)
+			return nil
+		}
+		names[name] = true
+		result.Uploaded++
+		result.Bytes += info.Size()
+		report(localPath, remotePath, "uploaded", nil)
+		if c.DryRun() {
+			return nil
+		}
+		record = &PhotoRecord{MD5: md5, RemotePath: remotePath, LocalPath: localPath, Size: info.Size(), Uploaded: time.Now()}
+		if err := idx.Put(*record); err != nil {
+			return fmt.Errorf("failed to update photo index: %w", err)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// localMD5 returns the content MD5 of a local file, from the hash cache when it holds the
+// file unchanged
+func (c *Client) localMD5(localPath string, info fs.FileInfo) (string, error) {
+	if c.hashCache != nil {
+		if entry, err := c.hashCache.Lookup(localPath, info.Size(), info.ModTime()); err == nil && entry != nil && entry.MD5 != "" {
+			c.observeHashCache(true)
+			return entry.MD5, nil
+		}
+		c.observeHashCache(false)
+	}
+	return CalculateMD5(localPath)
+}
+
+// mediaTime returns the time a photo or video was taken, for dating its backup: a photo's
+// EXIF capture date, or else the file's modification time
+func mediaTime(localPath string, info os.FileInfo) time.Time {
+	if LocalMediaType(localPath) == TypeImage {
+		if date, err := localExifDate(localPath); err == nil {
+			return date
+		}
+	}
+	return info.ModTime()
+}
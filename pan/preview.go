@@ -0,0 +1,70 @@
+package pan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// DocPreview is a link for viewing a document in a browser, returned by the docpreview API
+type DocPreview struct {
+	Errno     int    `json:"errno"`
+	URL       string `json:"preview_url"`
+	ExpiresIn int    `json:"expires_in"` // Seconds the link stays valid, 0 if not reported
+	RequestID int64  `json:"request_id"`
+}
+
+// GetDocPreview returns a browser link to an online preview of the office document, PDF
+// or text file at filePath, so it can be read without downloading it
+func (c *Client) GetDocPreview(filePath string) (*DocPreview, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	params := url.Values{}
+	params.Add("method", "docpreview")
+	params.Add("access_token", c.getAccessToken())
+	params.Add("path", filePath)
+
+	req, err := http.NewRequest("GET", c.endpoint(listFilesURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doc preview request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response DocPreview
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Errno != 0 {
+		apiErr := &errno.Error{API: "doc preview", Code: response.Errno}
+		if _, ok := errno.LookupAPI(apiErr.API, apiErr.Code); !ok {
+			apiErr.Message = "the file may not be a document that can be previewed"
+		}
+		return nil, apiErr
+	}
+	if response.URL == "" {
+		return nil, fmt.Errorf("doc preview API returned no link for %s", filePath)
+	}
+
+	return &response, nil
+}
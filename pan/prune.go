@@ -0,0 +1,56 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+)
+
+// pruneBatchSize is the number of directories removed per delete request
+const pruneBatchSize = 100
+
+// PruneEmptyDirs removes every directory beneath root whose subtree contains no files,
+// and returns the directories removed. Only the topmost empty directory of each empty
+// subtree is deleted, which removes its empty subdirectories with it. root itself is
+// never removed. In dry-run mode the deletions are reported instead of performed.
+func (c *Client) PruneEmptyDirs(ctx context.Context, root string) ([]string, error) {
+	root = normalizeRemoteDir(root)
+
+	dirs := make(map[string]bool) // Directory path -> whether its subtree holds a file
+	err := c.WalkParallel(ctx, root, WalkOptions{}, func(file FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if file.IsDir == 1 {
+			if _, seen := dirs[file.Path]; !seen {
+				dirs[file.Path] = false
+			}
+			return nil
+		}
+		for dir := path.Dir(file.Path); dir != root && dir != "/" && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	// An empty directory whose parent is root or holds files is the top of an empty subtree
+	var empty []string
+	for dir, hasFiles := range dirs {
+		if parent := path.Dir(dir); !hasFiles && (parent == root || dirs[parent]) {
+			empty = append(empty, dir)
+		}
+	}
+	sort.Strings(empty)
+
+	for start := 0; start < len(empty); start += pruneBatchSize {
+		batch := empty[start:min(start+pruneBatchSize, len(empty))]
+		if err := c.RemoveFiles(batch); err != nil {
+			return empty[:start], fmt.Errorf("failed to remove empty directories: %w", err)
+		}
+	}
+	return empty, nil
+}
@@ -0,0 +1,398 @@
+package pan
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// queueBucket is the bbolt bucket holding one entry per queued transfer, keyed by ID
+var queueBucket = []byte("transfers")
+
+// ErrQueueItemNotFound is returned when a queue item ID does not exist
+var ErrQueueItemNotFound = errors.New("queue item not found")
+
+// QueueOp is the kind of transfer a queue item performs
+type QueueOp string
+
+const (
+	QueueUpload   QueueOp = "upload"   // Upload a local file to Baidu Pan
+	QueueDownload QueueOp = "download" // Download a remote file to the local disk
+)
+
+// QueueState is the progress of a queue item
+type QueueState string
+
+const (
+	QueuePending QueueState = "pending" // Waiting to run
+	QueueRunning QueueState = "running" // Being transferred (or interrupted while it was)
+	QueueDone    QueueState = "done"    // Transferred successfully
+	QueueFailed  QueueState = "failed"  // Failed on its last attempt
+)
+
+// QueueItem is one transfer in a Queue
+type QueueItem struct {
+	ID          uint64     `json:"id"`
+	Op          QueueOp    `json:"op"`
+	Source      string     `json:"source"`      // Local path for uploads, remote path for downloads
+	Destination string     `json:"destination"` // Remote path for uploads, local path for downloads
+	Size        int64      `json:"size"`
+	State       QueueState `json:"state"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"` // Error of the last failed attempt
+	Added       time.Time  `json:"added"`
+	Updated     time.Time  `json:"updated"`
+}
+
+// Queue is a persistent list of pending transfers, backed by bbolt, so that large batch
+// transfers can be worked through across crashes and reboots
+type Queue struct {
+	db *bolt.DB
+}
+
+// OpenQueue opens (creating it and its directory if needed) the transfer queue database at dbPath
+func OpenQueue(dbPath string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transfer queue directory: %w", err)
+	}
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transfer queue %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(queueBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize transfer queue: %w", err)
+	}
+
+	return &Queue{db: db}, nil
+}
+
+// Close closes the transfer queue database
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
+
+// queueKey returns the key of the item with the given ID; big-endian keys keep items in
+// the order they were added
+func queueKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, id)
+}
+
+// Add appends items to the queue as pending transfers and returns them with their IDs
+func (q *Queue) Add(items ...QueueItem) ([]QueueItem, error) {
+	now := time.Now()
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+		for i := range items {
+			id, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			items[i].ID = id
+			items[i].State = QueuePending
+			items[i].Added = now
+			items[i].Updated = now
+			if err := putQueueItem(bucket, items[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add to transfer queue: %w", err)
+	}
+	return items, nil
+}
+
+// List returns every item in the queue in the order they were added
+func (q *Queue) List() ([]QueueItem, error) {
+	var items []QueueItem
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(queueBucket).ForEach(func(_, data []byte) error {
+			var item QueueItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	})
+	return items, err
+}
+
+// Remove deletes the item with the given ID
+func (q *Queue) Remove(id uint64) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+		if bucket.Get(queueKey(id)) == nil {
+			return fmt.Errorf("%w: %d", ErrQueueItemNotFound, id)
+		}
+		return bucket.Delete(queueKey(id))
+	})
+}
+
+// RemoveState deletes every item in one of the given states and returns how many were removed
+func (q *Queue) RemoveState(states ...QueueState) (int, error) {
+	removed := 0
+	err := q.update(func(item *QueueItem) (bool, bool) {
+		for _, state := range states {
+			if item.State == state {
+				removed++
+				return false, true
+			}
+		}
+		return false, false
+	})
+	return removed, err
+}
+
+// Requeue returns items in one of the given states to pending and returns how many changed
+func (q *Queue) Requeue(states ...QueueState) (int, error) {
+	requeued := 0
+	err := q.update(func(item *QueueItem) (bool, bool) {
+		for _, state := range states {
+			if item.State == state {
+				item.State = QueuePending
+				requeued++
+				return true, false
+			}
+		}
+		return false, false
+	})
+	return requeued, err
+}
+
+// update calls fn for every item; fn reports whether to save its changes to the item or
+// to delete it
+func (q *Queue) update(fn func(item *QueueItem) (changed, remove bool)) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+
+		// The bucket must not be modified while it is being iterated
+		var changed []QueueItem
+		var removed []uint64
+		err := bucket.ForEach(func(_, data []byte) error {
+			var item QueueItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			change, remove := fn(&item)
+			switch {
+			case remove:
+				removed = append(removed, item.ID)
+			case change:
+				item.Updated = time.Now()
+				changed = append(changed, item)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range removed {
+			if err := bucket.Delete(queueKey(id)); err != nil {
+				return err
+			}
+		}
+		for _, item := range changed {
+			if err := putQueueItem(bucket, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// claimNext marks the oldest pending item as running and returns it, or nil when no
+// item is pending
+func (q *Queue) claimNext() (*QueueItem, error) {
+	var next *QueueItem
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+		cursor := bucket.Cursor()
+		for key, data := cursor.First(); key != nil; key, data = cursor.Next() {
+			var item QueueItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				return err
+			}
+			if item.State == QueuePending {
+				next = &item
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		next.State = QueueRunning
+		next.Attempts++
+		next.Updated = time.Now()
+		return putQueueItem(bucket, *next)
+	})
+	return next, err
+}
+
+// save stores item, replacing the stored item with the same ID
+func (q *Queue) save(item QueueItem) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return putQueueItem(tx.Bucket(queueBucket), item)
+	})
+}
+
+// putQueueItem stores item in bucket
+func putQueueItem(bucket *bolt.Bucket, item QueueItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(queueKey(item.ID), data)
+}
+
+// QueueUploadItems returns queue items uploading the local file or directory localPath
+// to remotePath, one per file; a directory's files keep their relative paths and its
+// symbolic links and special files are handled according to walk. Local paths are made
+// absolute, so the queue can be run from any directory.
+func QueueUploadItems(localPath, remotePath string, walk LocalWalkOptions) ([]QueueItem, error) {
+	localPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []QueueItem{{Op: QueueUpload, Source: localPath, Destination: remotePath, Size: info.Size()}}, nil
+	}
+
+	var items []QueueItem
+	err = walkLocal(localPath, walk, func(p, rel string, fileInfo fs.FileInfo) error {
+		if !fileInfo.Mode().IsRegular() {
+			return nil
+		}
+		items = append(items, QueueItem{
+			Op:          QueueUpload,
+			Source:      p,
+			Destination: path.Join(remotePath, rel),
+			Size:        fileInfo.Size(),
+		})
+		return nil
+	})
+	return items, err
+}
+
+// QueueDownloadItems returns queue items downloading the remote file or directory
+// remotePath to localPath, one per file; a directory's files keep their relative paths.
+// Local paths are made absolute, so the queue can be run from any directory.
+func (c *Client) QueueDownloadItems(ctx context.Context, remotePath, localPath string) ([]QueueItem, error) {
+	localPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.GetFileInfoByPath(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir == 0 {
+		return []QueueItem{{Op: QueueDownload, Source: remotePath, Destination: localPath, Size: info.Size}}, nil
+	}
+
+	var items []QueueItem
+	root := path.Clean(remotePath)
+	for file, err := range c.ListAll(ctx, root) {
+		if err != nil {
+			return nil, err
+		}
+		if file.IsDir != 0 {
+			continue
+		}
+		rel := manifestRelPath(root, file.Path)
+		items = append(items, QueueItem{
+			Op:          QueueDownload,
+			Source:      file.Path,
+			Destination: filepath.Join(localPath, filepath.FromSlash(rel)),
+			Size:        file.Size,
+		})
+	}
+	return items, nil
+}
+
+// QueueRunOptions controls how RunQueue performs transfers
+type QueueRunOptions struct {
+	Upload   UploadOptions
+	Download DownloadOptions
+
+	// Progress, if set, is called before each item is transferred and after it finishes
+	// with its outcome (err is nil on success)
+	Started  func(item QueueItem)
+	Finished func(item QueueItem, err error)
+}
+
+// RunQueue works through the pending items of q in order until none remain or ctx is
+// cancelled, and returns how many items succeeded and failed. Items left running by an
+// earlier run that crashed or was killed are requeued first, so an interrupted batch
+// resumes where it stopped. Cancellation takes effect between items.
+func (c *Client) RunQueue(ctx context.Context, q *Queue, opts QueueRunOptions) (done, failed int, err error) {
+	if _, err := q.Requeue(QueueRunning); err != nil {
+		return 0, 0, fmt.Errorf("failed to resume interrupted transfers: %w", err)
+	}
+
+	for ctx.Err() == nil {
+		item, err := q.claimNext()
+		if err != nil {
+			return done, failed, fmt.Errorf("failed to read transfer queue: %w", err)
+		}
+		if item == nil {
+			return done, failed, nil
+		}
+		if opts.Started != nil {
+			opts.Started(*item)
+		}
+
+		var transferErr error
+		switch item.Op {
+		case QueueUpload:
+			transferErr = c.UploadFileWithOptions(item.Source, item.Destination, opts.Upload)
+		case QueueDownload:
+			if err := os.MkdirAll(filepath.Dir(item.Destination), 0755); err != nil {
+				transferErr = err
+			} else {
+				transferErr = c.DownloadFileToPathWithOptions(item.Source, item.Destination, opts.Download)
+			}
+		default:
+			transferErr = fmt.Errorf("unknown queue operation %q", item.Op)
+		}
+
+		item.Updated = time.Now()
+		if transferErr == nil {
+			item.State = QueueDone
+			item.Error = ""
+			done++
+		} else {
+			item.State = QueueFailed
+			item.Error = transferErr.Error()
+			failed++
+		}
+		if err := q.save(*item); err != nil {
+			return done, failed, fmt.Errorf("failed to update transfer queue: %w", err)
+		}
+		if opts.Finished != nil {
+			opts.Finished(*item, transferErr)
+		}
+	}
+	return done, failed, ctx.Err()
+}
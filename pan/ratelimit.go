@@ -0,0 +1,69 @@
+package pan
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter throttles requests per API endpoint so Baidu's request limits (errno 31034) are not hit
+type rateLimiter struct {
+	mu       sync.Mutex
+	qps      rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+}
+
+// newRateLimiter creates a limiter allowing qps requests per second with the given burst for each endpoint
+func newRateLimiter(qps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		qps:      rate.Limit(qps),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// wait blocks until a request to endpoint is allowed or ctx is done
+func (rl *rateLimiter) wait(ctx context.Context, endpoint string) error {
+	if rl == nil {
+		return nil
+	}
+
+	rl.mu.Lock()
+	limiter, ok := rl.limiters[endpoint]
+	if !ok {
+		limiter = rate.NewLimiter(rl.qps, rl.burst)
+		rl.limiters[endpoint] = limiter
+	}
+	rl.mu.Unlock()
+
+	return limiter.Wait(ctx)
+}
+
+// WithRateLimit limits requests to qps per second for each API endpoint, allowing bursts of up to burst requests.
+// A qps of zero or less disables rate limiting.
+func WithRateLimit(qps float64, burst int) Option {
+	return func(c *Client) {
+		if qps <= 0 {
+			c.rateLimiter = nil
+			return
+		}
+		c.rateLimiter = newRateLimiter(qps, burst)
+	}
+}
+
+// WithThrottleRetry configures how responses with a rate-limit errno (31034, 9019, ...) are retried:
+// up to maxRetries times, starting at baseDelay and doubling the delay each attempt.
+// errno -6, usually a rejected access token, is retried only once. A maxRetries of zero
+// disables retrying.
+func WithThrottleRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.throttleMaxRetries = maxRetries
+		c.throttleBaseDelay = baseDelay
+	}
+}
@@ -0,0 +1,70 @@
+package pan
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// sensitiveJSONFields matches the string values of response fields holding credentials,
+// such as the access_token and refresh_token of a token response
+var sensitiveJSONFields = regexp.MustCompile(`("(?:access_token|refresh_token|session_key|session_secret|client_secret|bdstoken)"\s*:\s*)"[^"]*"`)
+
+// rawDumpRecord is one response written by WithRawDump, as a line of JSON
+type rawDumpRecord struct {
+	Time   time.Time       `json:"time"`
+	Method string          `json:"method"`
+	URL    string          `json:"url"` // Credentials redacted
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"` // The response as sent, or a string if it is not JSON
+}
+
+// rawDumper serializes the records written by concurrent requests
+type rawDumper struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithRawDump writes every JSON or text API response to w exactly as Baidu sent it, one
+// JSON object per line with the time, method, URL, HTTP status and body. Credentials
+// are redacted from the URL and from token fields of the body. Binary bodies, such as
+// downloads, are not written.
+func WithRawDump(w io.Writer) Option {
+	return func(c *Client) {
+		c.rawDump = &rawDumper{w: w}
+	}
+}
+
+// dumpRawResponse writes resp with its buffered body to the raw dump, if enabled
+func (c *Client) dumpRawResponse(resp *http.Response, body []byte) {
+	if c.rawDump == nil || body == nil {
+		return
+	}
+
+	redacted := RedactJSON(body)
+	if !json.Valid(redacted) {
+		redacted, _ = json.Marshal(string(redacted))
+	}
+	line, err := json.Marshal(rawDumpRecord{
+		Time:   time.Now(),
+		Method: resp.Request.Method,
+		URL:    RedactURL(resp.Request.URL.String()),
+		Status: resp.StatusCode,
+		Body:   redacted,
+	})
+	if err != nil {
+		return
+	}
+
+	c.rawDump.mu.Lock()
+	defer c.rawDump.mu.Unlock()
+	c.rawDump.w.Write(append(line, '\n'))
+}
+
+// RedactJSON replaces the values of credential fields in a JSON body with "REDACTED"
+func RedactJSON(body []byte) []byte {
+	return sensitiveJSONFields.ReplaceAll(body, []byte(`$1"REDACTED"`))
+}
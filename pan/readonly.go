@@ -0,0 +1,47 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrReadOnly is returned for requests that would change files or shares in Baidu Pan
+// when the client is in read-only mode
+var ErrReadOnly = errors.New("read-only mode: changes to Baidu Pan are not allowed")
+
+// mutatingMethods are the API methods, as named by apiMethod, that change files or shares
+var mutatingMethods = map[string]bool{
+	"filemanager": true, // Delete, move, copy and rename
+	"precreate":   true, // Uploads and directories
+	"upload":      true,
+	"create":      true,
+	"set":         true, // New shares
+}
+
+// WithReadOnly refuses every request that would change files or shares in Baidu Pan,
+// such as uploads, deletes, moves, renames, copies, new directories and shares, with an
+// error wrapping ErrReadOnly. Listing, searching and downloading work as usual, which
+// suits credentials shared for retrieval only.
+func WithReadOnly() Option {
+	return func(c *Client) {
+		c.readOnly = true
+	}
+}
+
+// ReadOnly reports whether the client is in read-only mode
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
+
+// checkWritable returns an error wrapping ErrReadOnly for a request that would change
+// files or shares in read-only mode
+func (c *Client) checkWritable(req *http.Request) error {
+	if !c.readOnly {
+		return nil
+	}
+	if method := apiMethod(req); mutatingMethods[method] {
+		return fmt.Errorf("%s request refused: %w", method, ErrReadOnly)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package pan
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OutputFormat is how ls and report print their rows
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = "text" // Human-readable lines and tables
+	OutputCSV  OutputFormat = "csv"  // Comma-separated values with a header row, as RFC 4180
+	OutputTSV  OutputFormat = "tsv"  // Tab-separated values with a header row, quoted as CSV
+)
+
+// ParseOutputFormat parses "text", "csv" or "tsv"; empty means OutputText
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch format := OutputFormat(s); format {
+	case "":
+		return OutputText, nil
+	case OutputText, OutputCSV, OutputTSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid output format %q (expected text, csv or tsv)", s)
+}
+
+// NewRecordWriter returns a writer of the records of format, OutputCSV or OutputTSV, to
+// w. Fields containing the separator, a quote or a line break are quoted, with quotes
+// doubled, so names with any characters survive the round trip. Call Flush when done.
+func NewRecordWriter(w io.Writer, format OutputFormat) *csv.Writer {
+	writer := csv.NewWriter(w)
+	if format == OutputTSV {
+		writer.Comma = '\t'
+	}
+	return writer
+}
+
+// WriteStorageReportRecords writes the groups of report as records with the header
+// section, name, files, bytes and share (a percentage of the report's bytes), section
+// being "extension", "folder" or "age"
+func WriteStorageReportRecords(w *csv.Writer, report *StorageReport) error {
+	if err := w.Write([]string{"section", "name", "files", "bytes", "share"}); err != nil {
+		return err
+	}
+	sections := []struct {
+		name   string
+		groups []ReportGroup
+	}{
+		{"extension", report.ByExtension},
+		{"folder", report.ByFolder},
+		{"age", report.ByAge},
+	}
+	for _, section := range sections {
+		for _, group := range section.groups {
+			share := 0.0
+			if report.Bytes > 0 {
+				share = float64(group.Bytes) / float64(report.Bytes) * 100
+			}
+			record := []string{section.name, group.Name, strconv.Itoa(group.Files),
+				strconv.FormatInt(group.Bytes, 10), strconv.FormatFloat(share, 'f', 1, 64)}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
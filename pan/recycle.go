@@ -0,0 +1,40 @@
+package pan
+
+import "time"
+
+// Days deleted files stay in the recycle bin, by VIP level
+const (
+	RecycleDaysNormal = 10
+	RecycleDaysVIP    = 15
+	RecycleDaysSVIP   = 30
+)
+
+// RecycleDays returns how many days files deleted by an account of the given VIP level
+// can be restored from the recycle bin
+func RecycleDays(vipType int) int {
+	switch vipType {
+	case VIPTypeSVIP:
+		return RecycleDaysSVIP
+	case VIPTypeVIP:
+		return RecycleDaysVIP
+	}
+	return RecycleDaysNormal
+}
+
+// RecycleRetention returns how many days the account's deleted files can be restored
+// from the recycle bin. Deleting through the file manager API always moves entries to
+// the recycle bin; the open API can neither restore them nor empty it, which is done in
+// the Baidu Pan website or app.
+func (c *Client) RecycleRetention() (int, error) {
+	info, err := c.GetUserInfo()
+	if err != nil {
+		return RecycleDaysNormal, err
+	}
+	return RecycleDays(info.VIPType), nil
+}
+
+// RecycleDeadline returns when an entry deleted at deleted is purged from a recycle bin
+// that keeps entries for days
+func RecycleDeadline(deleted time.Time, days int) time.Time {
+	return deleted.AddDate(0, 0, days)
+}
@@ -0,0 +1,283 @@
+package pan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReleaseFeed is the GitHub API URL of the project's latest release
+const DefaultReleaseFeed = "https://api.github.com/repos/baowuhe/go-bdfs/releases/latest"
+
+// Names of the release assets listing the SHA-256 checksums of the binaries and holding
+// their Ed25519 signature
+const (
+	releaseChecksumsAsset = "checksums.txt"
+	releaseSignatureAsset = "checksums.txt.sig"
+)
+
+// ErrNoReleaseAsset is returned when a release has no binary for this platform
+var ErrNoReleaseAsset = errors.New("release has no binary for this platform")
+
+// Release is a published version of go-bdfs
+type Release struct {
+	Version   string         `json:"tag_name"`
+	URL       string         `json:"html_url"` // Release notes page
+	Published time.Time      `json:"published_at"`
+	Assets    []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// asset returns the release's asset with the given name, or nil
+func (r *Release) asset(name string) *ReleaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// ReleaseAssetName returns the name of the release binary for the current platform,
+// e.g. "go-bdfs_linux_amd64" or "go-bdfs_windows_amd64.exe"
+func ReleaseAssetName() string {
+	name := fmt.Sprintf("go-bdfs_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// LatestRelease fetches the latest release from feedURL, a GitHub "latest release" API
+// URL or a mirror serving the same JSON; empty uses DefaultReleaseFeed
+func LatestRelease(ctx context.Context, feedURL string) (*Release, error) {
+	if feedURL == "" {
+		feedURL = DefaultReleaseFeed
+	}
+	body, err := fetchRelease(ctx, feedURL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release feed: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release feed: %w", err)
+	}
+	if release.Version == "" {
+		return nil, fmt.Errorf("release feed has no version")
+	}
+	return &release, nil
+}
+
+// CompareVersions compares two versions such as "v0.1.2" or "1.2.0-rc1" by their
+// numeric components and returns -1, 0 or +1. A pre-release sorts before the release.
+func CompareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// SelfUpdateOptions controls SelfUpdate
+type SelfUpdateOptions struct {
+	// PublicKey, if set, must have signed the release's checksums.txt; the signature is
+	// read from checksums.txt.sig, raw or base64-encoded
+	PublicKey ed25519.PublicKey
+}
+
+// SelfUpdate downloads the release's binary for this platform, verifies it against the
+// release's SHA-256 checksums (and their signature, with opts.PublicKey) and replaces the
+// executable at exePath with it. The old binary is only replaced once the new one has
+// been verified; on Windows, where a running executable cannot be overwritten, it is
+// renamed to exePath + ".old" first.
+func SelfUpdate(ctx context.Context, release *Release, exePath string, opts SelfUpdateOptions) error {
+	binary := release.asset(ReleaseAssetName())
+	if binary == nil {
+		return fmt.Errorf("%w (%s) in %s", ErrNoReleaseAsset, ReleaseAssetName(), release.Version)
+	}
+	checksumsAsset := release.asset(releaseChecksumsAsset)
+	if checksumsAsset == nil {
+		return fmt.Errorf("release %s has no %s to verify the download with", release.Version, releaseChecksumsAsset)
+	}
+
+	checksums, err := fetchRelease(ctx, checksumsAsset.URL, 1<<20)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", releaseChecksumsAsset, err)
+	}
+	if opts.PublicKey != nil {
+		if err := verifyChecksumsSignature(ctx, release, checksums, opts.PublicKey); err != nil {
+			return err
+		}
+	}
+	want, err := releaseChecksum(checksums, binary.Name)
+	if err != nil {
+		return err
+	}
+
+	// Write next to the executable so the final rename stays on one file system
+	tmp, err := os.CreateTemp(filepath.Dir(exePath), ".go-bdfs-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := downloadReleaseAsset(ctx, binary, tmp); err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, tmp); err != nil {
+		return fmt.Errorf("failed to hash download: %w", err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", binary.Name, got, want)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return replaceExecutable(tmp.Name(), exePath)
+}
+
+// verifyChecksumsSignature checks the release's signature of checksums with publicKey
+func verifyChecksumsSignature(ctx context.Context, release *Release, checksums []byte, publicKey ed25519.PublicKey) error {
+	sigAsset := release.asset(releaseSignatureAsset)
+	if sigAsset == nil {
+		return fmt.Errorf("release %s is not signed (no %s)", release.Version, releaseSignatureAsset)
+	}
+	sig, err := fetchRelease(ctx, sigAsset.URL, 4096)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", releaseSignatureAsset, err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		if sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil {
+			return fmt.Errorf("invalid signature in %s: %w", releaseSignatureAsset, err)
+		}
+	}
+	if !ed25519.Verify(publicKey, checksums, sig) {
+		return fmt.Errorf("signature of %s does not match the release key", releaseChecksumsAsset)
+	}
+	return nil
+}
+
+// releaseChecksum returns the SHA-256 listed for name in checksums, in sha256sum format
+func releaseChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", releaseChecksumsAsset, name)
+}
+
+// fetchRelease returns the body of a GET request to url, reading at most limit bytes
+func fetchRelease(ctx context.Context, url string, limit int64) ([]byte, error) {
+	resp, err := getReleaseURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// downloadReleaseAsset writes asset to w
+func downloadReleaseAsset(ctx context.Context, asset *ReleaseAsset, w io.Writer) error {
+	resp, err := getReleaseURL(ctx, asset.URL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	return nil
+}
+
+// getReleaseURL sends a GET request to url and fails on any status but 200
+func getReleaseURL(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "go-bdfs") // GitHub rejects API requests without one
+	resp, err := (&http.Client{Timeout: 10 * time.Minute}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// replaceExecutable moves newPath over exePath
+func replaceExecutable(newPath, exePath string) error {
+	if runtime.GOOS == "windows" {
+		oldPath := exePath + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(exePath, oldPath); err != nil {
+			return fmt.Errorf("failed to move the current binary aside: %w", err)
+		}
+		if err := os.Rename(newPath, exePath); err != nil {
+			os.Rename(oldPath, exePath)
+			return fmt.Errorf("failed to install the new binary: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(newPath, exePath); err != nil {
+		return fmt.Errorf("failed to install the new binary: %w", err)
+	}
+	return nil
+}
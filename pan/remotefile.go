@@ -0,0 +1,195 @@
+package pan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+// RemoteFile is an open file on Baidu Pan. Reads are served by HTTP Range requests,
+// so only the bytes actually read are transferred.
+type RemoteFile interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	Size() int64     // Size of the file in bytes
+	Info() *FileInfo // Metadata of the file when it was opened
+}
+
+// remoteFile implements RemoteFile. Sequential reads share one streaming response,
+// which is reopened at the new offset after a Seek; ReadAt issues its own request.
+type remoteFile struct {
+	client *Client
+	info   *FileInfo
+
+	mu     sync.Mutex // Guards offset and body
+	offset int64
+	body   io.ReadCloser // Response streaming from offset, nil until the next Read
+	closed bool
+}
+
+// Open opens the remote file at path for reading
+func (c *Client) Open(path string) (RemoteFile, error) {
+	info, err := c.GetFileInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir == 1 {
+		return nil, fmt.Errorf("cannot open directory: %s", path)
+	}
+	return &remoteFile{client: c, info: info}, nil
+}
+
+// Size returns the size of the file in bytes
+func (f *remoteFile) Size() int64 {
+	return f.info.Size
+}
+
+// Info returns the metadata of the file when it was opened
+func (f *remoteFile) Info() *FileInfo {
+	return f.info
+}
+
+// Read reads up to len(p) bytes from the current offset
+func (f *remoteFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.offset >= f.info.Size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		body, err := f.client.openRange(context.Background(), f.info.Path, f.offset, f.info.Size-f.offset)
+		if err != nil {
+			return 0, err
+		}
+		f.body = body
+	}
+
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	f.client.observeTransfer("download", int64(n))
+	if err == io.EOF && f.offset < f.info.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes starting at off with a dedicated Range request.
+// It does not affect the offset used by Read and is safe for concurrent use.
+func (f *remoteFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= f.info.Size {
+		return 0, io.EOF
+	}
+
+	length := int64(len(p))
+	if remaining := f.info.Size - off; length > remaining {
+		length = remaining
+	}
+	body, err := f.client.openRange(context.Background(), f.info.Path, off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:length])
+	f.client.observeTransfer("download", int64(n))
+	if err != nil {
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close releases the streaming response, if any
+func (f *remoteFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+// openRange requests length bytes of filePath starting at offset
+func (c *Client) openRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, error) {
+	req, err := c.newDownloadRequest(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := c.doDownload(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from Baidu Pan: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		return resp.Body, nil
+	case resp.StatusCode == http.StatusOK && offset == 0:
+		// The whole file was returned; serve the requested prefix of it
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(resp.Body, length), resp.Body}, nil
+	case resp.StatusCode == http.StatusOK:
+		// The body is the whole file, which may be gigabytes; don't read any of it
+		resp.Body.Close()
+		return nil, errors.New("download server ignored the Range request")
+	default:
+		defer resp.Body.Close()
+		c.forgetDownloadLink(filePath) // It may have expired
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("range request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+}
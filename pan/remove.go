@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // DeleteResponse represents the response from the delete API
@@ -29,14 +31,23 @@ func (c *Client) RemoveFile(filePath string) error {
 
 // RemoveFiles removes multiple files or directories from Baidu Pan
 func (c *Client) RemoveFiles(filePaths []string) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	if len(filePaths) == 0 {
 		return fmt.Errorf("no files specified for deletion")
 	}
 
+	if c.DryRun() {
+		ops := make([]dryRunOperation, 0, len(filePaths))
+		for _, path := range filePaths {
+			ops = append(ops, dryRunOperation{path: path, detail: path})
+		}
+		c.reportDryRun("filemanager delete", ops)
+		return nil
+	}
+
 	// Convert file paths to JSON format for the POST body
 	fileListJSON := "["
 	for i, path := range filePaths {
@@ -50,7 +61,7 @@ func (c *Client) RemoveFiles(filePaths []string) error {
 	// Prepare query parameters - using the correct endpoint according to documentation
 	params := url.Values{}
 	params.Add("method", "filemanager")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("opera", "delete")
 
 	// Additional parameters that might be required based on API documentation
@@ -58,14 +69,14 @@ func (c *Client) RemoveFiles(filePaths []string) error {
 	params.Add("channel", "chunlei")
 	params.Add("web", "1")
 	params.Add("app_id", "250528")
-	params.Add("bdstoken", c.accessToken) // Using access token as bdstoken (common practice)
+	params.Add("bdstoken", c.getAccessToken()) // Using access token as bdstoken (common practice)
 
 	// Create form data for POST body
 	formData := url.Values{}
 	formData.Add("filelist", fileListJSON)
 
 	// Create the request with form-encoded body
-	req, err := http.NewRequest("POST", "https://pan.baidu.com/api/filemanager?"+params.Encode(), strings.NewReader(formData.Encode()))
+	req, err := http.NewRequest("POST", c.endpoint(fileManagerURL)+"?"+params.Encode(), strings.NewReader(formData.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create delete request: %w", err)
 	}
@@ -74,7 +85,7 @@ func (c *Client) RemoveFiles(filePaths []string) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Execute the request
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("delete request failed: %w", err)
 	}
@@ -98,14 +109,22 @@ func (c *Client) RemoveFiles(filePaths []string) error {
 	}
 
 	if deleteResponse.Errno != 0 {
-		return fmt.Errorf("delete API returned error code %d: %s", deleteResponse.Errno, GetErrorMessage(deleteResponse.Errno))
+		return &errno.Error{API: "delete", Code: deleteResponse.Errno}
 	}
 
 	// Check if any individual files failed to delete
 	var failedFiles []string
+	failed := make(map[string]bool)
 	for _, entry := range deleteResponse.List {
 		if entry.Errno != 0 {
 			failedFiles = append(failedFiles, fmt.Sprintf("%s (error code: %d)", entry.Path, entry.Errno))
+			failed[entry.Path] = true
+		}
+	}
+
+	for _, path := range filePaths {
+		if !failed[path] {
+			c.indexRemove(path)
 		}
 	}
 
@@ -116,38 +135,9 @@ func (c *Client) RemoveFiles(filePaths []string) error {
 	return nil
 }
 
-// GetErrorMessage returns a human-readable error message for common errno values
-func GetErrorMessage(errno int) string {
-	switch errno {
-	case 0:
-		return "Success"
-	case 2:
-		return "Parameters error"
-	case 3:
-		return "User permission error"
-	case 4:
-		return "Request source error"
-	case 108:
-		return "Path error, path does not exist"
-	case 110:
-		return "Target path already exists"
-	case 112:
-		return "Same file already exists in the same directory"
-	case 113:
-		return "File or directory name contains forbidden words"
-	case 114:
-		return "Path too long"
-	case 115:
-		return "Target directory does not exist"
-	case 116:
-		return "Insufficient disk space"
-	case 117:
-		return "File too large"
-	case 31001:
-		return "User has been banned"
-	case 31026:
-		return "File contains illegal content"
-	default:
-		return fmt.Sprintf("Unknown error code: %d", errno)
-	}
+// GetErrorMessage returns a human-readable error message for common errno values.
+//
+// Deprecated: use errno.Message.
+func GetErrorMessage(code int) string {
+	return errno.Message(code)
 }
@@ -6,7 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
 )
 
 // RenameResponse represents the response from the rename API
@@ -42,14 +45,23 @@ func (c *Client) RenameFile(sourcePath, newName string) error {
 
 // RenameFiles renames multiple files based on the provided RenameRequest structs
 func (c *Client) RenameFiles(renameRequests []RenameRequest) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	if len(renameRequests) == 0 {
 		return fmt.Errorf("no files specified for rename operation")
 	}
 
+	if c.DryRun() {
+		ops := make([]dryRunOperation, 0, len(renameRequests))
+		for _, req := range renameRequests {
+			ops = append(ops, dryRunOperation{path: req.Path, detail: fmt.Sprintf("%s -> %s", req.Path, req.NewName)})
+		}
+		c.reportDryRun("filemanager rename", ops)
+		return nil
+	}
+
 	// Convert rename requests to JSON format for the POST body
 	renameRequestsJSON, err := json.Marshal(renameRequests)
 	if err != nil {
@@ -59,14 +71,14 @@ func (c *Client) RenameFiles(renameRequests []RenameRequest) error {
 	// Prepare query parameters
 	params := url.Values{}
 	params.Add("method", "filemanager")
-	params.Add("access_token", c.accessToken)
+	params.Add("access_token", c.getAccessToken())
 	params.Add("opera", "rename")
 
 	// Additional parameters that might be required based on API documentation
 	params.Add("channel", "chunlei")
 	params.Add("web", "1")
 	params.Add("app_id", "250528")
-	params.Add("bdstoken", c.accessToken) // Using access token as bdstoken (common practice)
+	params.Add("bdstoken", c.getAccessToken()) // Using access token as bdstoken (common practice)
 
 	// Create form data for POST body
 	formData := url.Values{}
@@ -77,7 +89,7 @@ func (c *Client) RenameFiles(renameRequests []RenameRequest) error {
 	formData.Add("async", "0")
 
 	// Create the request with form-encoded body
-	apiURL := fmt.Sprintf("https://pan.baidu.com/api/filemanager?%s", params.Encode())
+	apiURL := c.endpoint(fileManagerURL) + "?" + params.Encode()
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return fmt.Errorf("failed to create rename request: %w", err)
@@ -87,7 +99,7 @@ func (c *Client) RenameFiles(renameRequests []RenameRequest) error {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Execute the request
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("rename request failed: %w", err)
 	}
@@ -110,66 +122,37 @@ func (c *Client) RenameFiles(renameRequests []RenameRequest) error {
 		return fmt.Errorf("failed to unmarshal rename response: %w", err)
 	}
 
-	if renameResponse.Errno != 0 {
-		return fmt.Errorf("rename API returned error code %d: %s", renameResponse.Errno, GetRenameErrorMessage(renameResponse.Errno))
+	if batchFailed(renameResponse.Errno, len(renameResponse.Info)) {
+		return &errno.Error{API: "rename", Code: renameResponse.Errno}
 	}
 
 	// Check if any individual files failed to rename
-	var failedRenames []string
+	var failedRenames []FileOpFailure
+	failed := make(map[int]bool)
 	for i, renameInfo := range renameResponse.Info {
-		if renameInfo.Errno != 0 {
+		if renameInfo.Errno != 0 && i < len(renameRequests) {
 			req := renameRequests[i] // Get the corresponding request
-			failedRenames = append(failedRenames, fmt.Sprintf("%s -> %s (error code: %d)", req.Path, req.NewName, renameInfo.Errno))
+			failedRenames = append(failedRenames, FileOpFailure{Index: i, Path: req.Path, Dest: req.NewName, Err: &errno.Error{API: "rename", Code: renameInfo.Errno}})
+			failed[i] = true
+		}
+	}
+
+	for i, req := range renameRequests {
+		if !failed[i] {
+			c.indexRename(req.Path, path.Join(path.Dir(req.Path), req.NewName))
 		}
 	}
 
 	if len(failedRenames) > 0 {
-		return fmt.Errorf("failed to rename some files: %s", strings.Join(failedRenames, "; "))
+		return &BatchError{Op: "rename", Failures: failedRenames}
 	}
 
 	return nil
 }
 
-// GetRenameErrorMessage returns a human-readable error message for common errno values
-func GetRenameErrorMessage(errno int) string {
-	switch errno {
-	case 0:
-		return "Success"
-	case 2:
-		return "Parameters error"
-	case 3:
-		return "User permission error"
-	case 4:
-		return "Request source error"
-	case 12:
-		return "Operation not allowed or path error"
-	case -9:
-		return "File does not exist"
-	case 111:
-		return "Another asynchronous task is currently executing"
-	case -7:
-		return "Invalid file name"
-	case 108:
-		return "Path error, path does not exist"
-	case 110:
-		return "Target path already exists"
-	case 112:
-		return "Same file already exists in the same directory"
-	case 113:
-		return "File or directory name contains forbidden words"
-	case 114:
-		return "Path too long"
-	case 115:
-		return "Target directory does not exist"
-	case 116:
-		return "Insufficient disk space"
-	case 117:
-		return "File too large"
-	case 31001:
-		return "User has been banned"
-	case 31026:
-		return "File contains illegal content"
-	default:
-		return fmt.Sprintf("Unknown error code: %d", errno)
-	}
+// GetRenameErrorMessage returns a human-readable error message for common errno values.
+//
+// Deprecated: use errno.Message.
+func GetRenameErrorMessage(code int) string {
+	return errno.Message(code)
 }
@@ -0,0 +1,178 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ReportGroup holds the number and total size of the files in one group of a storage report
+type ReportGroup struct {
+	Name  string `json:"name"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// StorageReport aggregates the files beneath a remote directory by extension, by
+// top-level folder and by age
+type StorageReport struct {
+	Root        string        `json:"root"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Files       int           `json:"files"`
+	Bytes       int64         `json:"bytes"`
+	ByExtension []ReportGroup `json:"by_extension"` // Largest first
+	ByFolder    []ReportGroup `json:"by_folder"`    // Largest first
+	ByAge       []ReportGroup `json:"by_age"`       // Newest bucket first
+}
+
+// reportAgeBucket is an age range files are grouped into, by modification time
+type reportAgeBucket struct {
+	name   string
+	maxAge time.Duration // Files younger than this fall into the bucket; 0 for no limit
+}
+
+// reportAgeBuckets are the age ranges of a storage report, from newest to oldest
+var reportAgeBuckets = []reportAgeBucket{
+	{"< 1 month", 30 * 24 * time.Hour},
+	{"1-6 months", 182 * 24 * time.Hour},
+	{"6-12 months", 365 * 24 * time.Hour},
+	{"1-3 years", 3 * 365 * 24 * time.Hour},
+	{"> 3 years", 0},
+}
+
+const (
+	reportNoExtension = "(none)" // Group of files without an extension
+	reportRootFiles   = "(root)" // Group of files directly in the report root
+)
+
+// BuildStorageReport walks the remote tree beneath root and aggregates file counts and
+// sizes by lower-cased extension, by top-level folder beneath root and by age of the
+// file's modification time
+func (c *Client) BuildStorageReport(ctx context.Context, root string) (*StorageReport, error) {
+	root = normalizeRemoteDir(root)
+	now := time.Now()
+	report := &StorageReport{Root: root, GeneratedAt: now}
+
+	byExt := make(map[string]*ReportGroup)
+	byFolder := make(map[string]*ReportGroup)
+	byAge := make([]ReportGroup, len(reportAgeBuckets))
+	for i, bucket := range reportAgeBuckets {
+		byAge[i].Name = bucket.name
+	}
+
+	err := c.WalkParallel(ctx, root, WalkOptions{}, func(file FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if file.IsDir == 1 {
+			return nil
+		}
+
+		report.Files++
+		report.Bytes += file.Size
+		addToGroup(byExt, reportExtension(file.ServerFilename), file.Size)
+		addToGroup(byFolder, reportFolder(root, file.Path), file.Size)
+
+		age := now.Sub(file.ModTime())
+		for i, bucket := range reportAgeBuckets {
+			if bucket.maxAge == 0 || age < bucket.maxAge {
+				byAge[i].Files++
+				byAge[i].Bytes += file.Size
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	report.ByExtension = sortedGroups(byExt)
+	report.ByFolder = sortedGroups(byFolder)
+	report.ByAge = byAge
+	return report, nil
+}
+
+// reportExtension returns the lower-cased extension of name without the dot
+func reportExtension(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	if ext == "" {
+		return reportNoExtension
+	}
+	return ext
+}
+
+// reportFolder returns the top-level folder beneath root that holds filePath
+func reportFolder(root, filePath string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(filePath, root), "/")
+	folder, _, nested := strings.Cut(rel, "/")
+	if !nested {
+		return reportRootFiles
+	}
+	return folder
+}
+
+// addToGroup counts a file of size bytes in the named group
+func addToGroup(groups map[string]*ReportGroup, name string, size int64) {
+	group, ok := groups[name]
+	if !ok {
+		group = &ReportGroup{Name: name}
+		groups[name] = group
+	}
+	group.Files++
+	group.Bytes += size
+}
+
+// sortedGroups returns the groups ordered by size, largest first, then by name
+func sortedGroups(groups map[string]*ReportGroup) []ReportGroup {
+	sorted := make([]ReportGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Bytes != sorted[j].Bytes {
+			return sorted[i].Bytes > sorted[j].Bytes
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// FormatStorageReport formats a storage report as aligned tables, listing at most top
+// groups per table (0 for all)
+func FormatStorageReport(report *StorageReport, top int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Storage report for %s: %d files, %s\n", report.Root, report.Files, FormatBytes(report.Bytes))
+
+	sections := []struct {
+		title  string
+		groups []ReportGroup
+	}{
+		{"By extension", report.ByExtension},
+		{"By folder", report.ByFolder},
+		{"By age", report.ByAge},
+	}
+	for _, section := range sections {
+		groups := section.groups
+		if top > 0 && len(groups) > top {
+			groups = groups[:top]
+		}
+
+		fmt.Fprintf(&b, "\n%s:\n", section.title)
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  Name\tFiles\tSize\tShare\t")
+		for _, group := range groups {
+			share := 0.0
+			if report.Bytes > 0 {
+				share = float64(group.Bytes) / float64(report.Bytes) * 100
+			}
+			fmt.Fprintf(tw, "  %s\t%d\t%s\t%.1f%%\t\n", group.Name, group.Files, FormatBytes(group.Bytes), share)
+		}
+		tw.Flush()
+	}
+	return b.String()
+}
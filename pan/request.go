@@ -0,0 +1,252 @@
+package pan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// defaultUserAgent is the User-Agent Baidu Pan expects from xpan and dlink clients
+const defaultUserAgent = "pan.baidu.com"
+
+// maxDebugBodySize limits how much of a response body is written to the debug log
+const maxDebugBodySize = 1024
+
+// Defaults for retrying throttled requests
+const (
+	defaultThrottleRetries   = 5
+	defaultThrottleBaseDelay = 2 * time.Second
+	maxThrottleDelay         = time.Minute
+)
+
+// sensitiveParams lists query parameters that must never appear in logs
+var sensitiveParams = []string{"access_token", "refresh_token", "client_secret", "bdstoken", "code"}
+
+// apiMeta holds the common fields Baidu Pan includes in JSON responses
+type apiMeta struct {
+	Errno     *int64      `json:"errno"`
+	RequestID json.Number `json:"request_id"`
+}
+
+// do sends an API request with the common headers applied, once a slot under the
+// limit of WithMaxRequests is free. JSON responses are buffered by send, so the slot is
+// held for the whole exchange.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	release, err := c.acquireAPISlot(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return c.send(c.client, req, true)
+}
+
+// doDownload sends a download request using the client with the longer timeout. The
+// body is file content, so it is neither buffered nor inspected for an errno.
+func (c *Client) doDownload(req *http.Request) (*http.Response, error) {
+	return c.send(c.downloadClient, req, false)
+}
+
+// tokenRejectedErrno is Baidu's errno for a rejected access token. It is also returned
+// now and then when requests come too fast, so it is retried once like a rate limit.
+const tokenRejectedErrno = -6
+
+// send applies the common headers to the request and executes it with the given HTTP client.
+// When api is set, the response is inspected for an errno, and responses carrying a
+// rate-limit errno are retried with increasing delay. Requests that would change files are
+// refused in read-only mode.
+func (c *Client) send(httpClient *http.Client, req *http.Request, api bool) (*http.Response, error) {
+	if err := c.checkWritable(req); err != nil {
+		return nil, err
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	c.printCurl(req)
+
+	delay := c.throttleBaseDelay
+	for attempt := 0; ; attempt++ {
+		resp, meta, err := c.sendOnce(httpClient, req, api)
+		if retry, ok := c.failOver(req, resp, err); ok {
+			req = retry
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.Errno == nil || attempt >= c.throttleMaxRetries {
+			return resp, nil
+		}
+		code := int(*meta.Errno)
+		if !errno.IsThrottled(code) && (code != tokenRejectedErrno || attempt > 0) {
+			return resp, nil
+		}
+
+		// Throttled: wait and replay the request with a fresh body
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			req.Body = body
+		}
+		resp.Body.Close()
+
+		c.logger.Warn(fmt.Sprintf("Throttled by Baidu Pan (errno %d), retrying in %s", *meta.Errno, delay))
+		c.observeRetry("throttled")
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxThrottleDelay {
+			delay = maxThrottleDelay
+		}
+	}
+}
+
+// sendOnce executes a single attempt of req and, when api is set, inspects the response
+// for errno and request_id
+func (c *Client) sendOnce(httpClient *http.Client, req *http.Request, api bool) (*http.Response, apiMeta, error) {
+	if err := c.rateLimiter.wait(req.Context(), apiMethod(req)); err != nil {
+		return nil, apiMeta{}, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	c.debugf("--> %s %s\n", req.Method, RedactURL(req.URL.String()))
+
+	c.runRequestHooks(req)
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		c.debugf("<-- %s %s failed: %v\n", req.Method, RedactURL(req.URL.String()), err)
+		c.observeAPICall(apiMethod(req), "error")
+		c.logger.Debug(fmt.Sprintf("%s %s failed", req.Method, apiMethod(req)), "error", err)
+		c.runResponseHooks(nil, err)
+		return nil, apiMeta{}, err
+	}
+
+	var body []byte
+	var meta apiMeta
+	if api {
+		if body, meta, err = inspectResponse(resp); err != nil {
+			c.debugf("<-- %s %s failed reading the body: %v\n", req.Method, RedactURL(req.URL.String()), err)
+			c.observeAPICall(apiMethod(req), "error")
+			c.runResponseHooks(nil, err)
+			return nil, apiMeta{}, err
+		}
+	}
+	c.runResponseHooks(resp, nil)
+	c.observeAPICall(apiMethod(req), errnoLabel(meta.Errno))
+	c.debugResponse(resp, body, meta)
+	c.dumpRawResponse(resp, body)
+	c.logger.Debug(fmt.Sprintf("%s %s", req.Method, apiMethod(req)), "status", resp.StatusCode,
+		"errno", errnoLabel(meta.Errno), "request_id", meta.RequestID, "duration", time.Since(start).Round(time.Millisecond))
+	return resp, meta, nil
+}
+
+// apiMethod returns a short name for the API operation a request targets
+func apiMethod(req *http.Request) string {
+	if method := req.URL.Query().Get("method"); method != "" {
+		return method
+	}
+	if strings.HasPrefix(req.URL.Path, "/file/") {
+		return "dlink" // Download links end in a per-file token
+	}
+	return path.Base(req.URL.Path)
+}
+
+// inspectResponse buffers a textual API response body and extracts errno and request_id
+// from it. The body is restored so callers can still read it; binary bodies are left
+// untouched. A body that cannot be read in full is closed and the error returned, rather
+// than handing the caller a truncated body.
+func inspectResponse(resp *http.Response) ([]byte, apiMeta, error) {
+	var meta apiMeta
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "text/") {
+		return nil, meta, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, meta, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	json.Unmarshal(body, &meta)
+	return body, meta, nil
+}
+
+// debugf writes a formatted line to the debug log if debugging is enabled
+func (c *Client) debugf(format string, args ...interface{}) {
+	if c.debugWriter == nil {
+		return
+	}
+	fmt.Fprintf(c.debugWriter, format, args...)
+}
+
+// debugResponse logs the status of a response together with errno, request_id and a
+// truncated body, with the values of credential fields such as access_token redacted
+func (c *Client) debugResponse(resp *http.Response, body []byte, meta apiMeta) {
+	if c.debugWriter == nil {
+		return
+	}
+
+	c.debugf("<-- %d %s %s\n", resp.StatusCode, resp.Request.Method, RedactURL(resp.Request.URL.String()))
+
+	if body == nil {
+		c.debugf("    content-type=%q content-length=%d\n", resp.Header.Get("Content-Type"), resp.ContentLength)
+		return
+	}
+
+	if meta.Errno != nil {
+		c.debugf("    errno=%d request_id=%s\n", *meta.Errno, meta.RequestID)
+	}
+
+	redacted := RedactJSON(body)
+	truncated := redacted
+	if len(truncated) > maxDebugBodySize {
+		truncated = truncated[:maxDebugBodySize]
+	}
+	c.debugf("    body: %s", truncated)
+	if len(redacted) > maxDebugBodySize {
+		c.debugf("... (%d bytes total)", len(redacted))
+	}
+	c.debugf("\n")
+}
+
+// RedactURL replaces the values of credential query parameters in rawURL with "REDACTED"
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	redacted := false
+	for _, key := range sensitiveParams {
+		if query.Has(key) {
+			query.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
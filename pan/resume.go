@@ -0,0 +1,351 @@
+package pan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the resume store, holding one entry per interrupted upload, download and
+// sync; the progress of a sync is kept apart from its plan, which is written only once
+var (
+	resumeUploadsBucket      = []byte("uploads")
+	resumeDownloadsBucket    = []byte("downloads")
+	resumeSyncsBucket        = []byte("syncs")
+	resumeSyncProgressBucket = []byte("sync_progress")
+)
+
+// resumeBuckets lists every bucket of the resume store
+var resumeBuckets = [][]byte{resumeUploadsBucket, resumeDownloadsBucket, resumeSyncsBucket, resumeSyncProgressBucket}
+
+// uploadResumeTTL is how long an interrupted upload is resumed with its upload ID;
+// after that it starts over, as Baidu Pan discards unfinished uploads
+const uploadResumeTTL = 24 * time.Hour
+
+// UploadState records how far an interrupted upload of a local file got
+type UploadState struct {
+	LocalPath  string    `json:"local_path"` // Absolute local path
+	RemotePath string    `json:"remote_path"`
+	Size       int64     `json:"size"`
+	Mtime      int64     `json:"mtime"` // Local modification time (Unix nanoseconds)
+	SliceSize  int64     `json:"slice_size"`
+	UploadID   string    `json:"uploadid"`
+	SliceMD5s  []string  `json:"slice_md5s"` // MD5s of the slices uploaded so far, in order
+	Updated    time.Time `json:"updated"`
+}
+
+// ByteRange is the half-open range of bytes [Start, End) of a file
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// DownloadState records which byte ranges of an interrupted download are on disk
+type DownloadState struct {
+	RemotePath string      `json:"remote_path"`
+	LocalPath  string      `json:"local_path"` // Absolute local path
+	Size       int64       `json:"size"`
+	MD5        string      `json:"md5"` // Remote MD5, to detect a changed remote file
+	Done       []ByteRange `json:"done"`
+	Updated    time.Time   `json:"updated"`
+}
+
+// Downloaded returns the number of bytes already downloaded
+func (s DownloadState) Downloaded() int64 {
+	var n int64
+	for _, r := range s.Done {
+		n += r.End - r.Start
+	}
+	return n
+}
+
+// ResumeStore persists the state of interrupted transfers, backed by bbolt, so running
+// the same upload or download again continues where it stopped
+type ResumeStore struct {
+	db *bolt.DB
+}
+
+// OpenResumeStore opens (creating if needed) the resume store database at dbPath
+func OpenResumeStore(dbPath string) (*ResumeStore, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open resume store %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range resumeBuckets {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize resume store: %w", err)
+	}
+
+	return &ResumeStore{db: db}, nil
+}
+
+// Close closes the resume store database
+func (rs *ResumeStore) Close() error {
+	return rs.db.Close()
+}
+
+// Pending returns the interrupted uploads and downloads, oldest first
+func (rs *ResumeStore) Pending() ([]UploadState, []DownloadState, error) {
+	var uploads []UploadState
+	var downloads []DownloadState
+	err := rs.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(resumeUploadsBucket).ForEach(func(_, data []byte) error {
+			var state UploadState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return err
+			}
+			uploads = append(uploads, state)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(resumeDownloadsBucket).ForEach(func(_, data []byte) error {
+			var state DownloadState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return err
+			}
+			downloads = append(downloads, state)
+			return nil
+		})
+	})
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].Updated.Before(uploads[j].Updated) })
+	sort.Slice(downloads, func(i, j int) bool { return downloads[i].Updated.Before(downloads[j].Updated) })
+	return uploads, downloads, err
+}
+
+// Clear removes all saved states, so every transfer starts over
+func (rs *ResumeStore) Clear() error {
+	return rs.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range resumeBuckets {
+			if err := tx.DeleteBucket(bucket); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// resumeKey returns the key of the transfer between localPath and remotePath
+func resumeKey(localPath, remotePath string) ([]byte, error) {
+	abs, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(abs + "\x00" + remotePath), nil
+}
+
+// get reads the state stored under key in bucket into v, reporting whether there was one
+func (rs *ResumeStore) get(bucket, key []byte, v any) (bool, error) {
+	found := false
+	err := rs.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get(key)
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, v)
+	})
+	return found, err
+}
+
+// put stores v under key in bucket
+func (rs *ResumeStore) put(bucket, key []byte, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return rs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, data)
+	})
+}
+
+// remove deletes the state stored under key in bucket
+func (rs *ResumeStore) remove(bucket, key []byte) error {
+	return rs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+// WithResumeStore saves the state of interrupted uploads and downloads of local files in
+// store, and resumes them when the same transfer is started again. Encrypted transfers
+// are not resumed.
+func WithResumeStore(store *ResumeStore) Option {
+	return func(c *Client) {
+		c.resume = store
+	}
+}
+
+// uploadResume returns the saved state of an upload of localPath to remotePath that can
+// be continued: same file size, modification time and slice size, and recent enough
+func (c *Client) uploadResume(localPath, remotePath string, size int64, mtime time.Time, sliceSize int64) *UploadState {
+	if c.resume == nil {
+		return nil
+	}
+	key, err := resumeKey(localPath, remotePath)
+	if err != nil {
+		return nil
+	}
+	var state UploadState
+	if found, err := c.resume.get(resumeUploadsBucket, key, &state); err != nil || !found {
+		return nil
+	}
+	if state.Size != size || state.Mtime != mtime.UnixNano() || state.SliceSize != sliceSize ||
+		state.UploadID == "" || time.Since(state.Updated) > uploadResumeTTL {
+		c.resume.remove(resumeUploadsBucket, key)
+		return nil
+	}
+	return &state
+}
+
+// saveUploadResume stores state, logging rather than failing the upload on error
+func (c *Client) saveUploadResume(state *UploadState) {
+	key, err := resumeKey(state.LocalPath, state.RemotePath)
+	if err == nil {
+		state.Updated = time.Now()
+		err = c.resume.put(resumeUploadsBucket, key, state)
+	}
+	if err != nil {
+		c.logger.Warn("Failed to save upload resume state", "path", state.LocalPath, "error", err)
+	}
+}
+
+// forgetUploadResume deletes the saved state of the upload of localPath to remotePath
+func (c *Client) forgetUploadResume(localPath, remotePath string) {
+	if c.resume == nil {
+		return
+	}
+	if key, err := resumeKey(localPath, remotePath); err == nil {
+		c.resume.remove(resumeUploadsBucket, key)
+	}
+}
+
+// downloadResume returns the byte ranges of remote file already downloaded to localPath
+// by an interrupted download, if the remote file is unchanged and the partial local
+// file is still there
+func (c *Client) downloadResume(localPath, remotePath string, file *FileInfo) []ByteRange {
+	if c.resume == nil {
+		return nil
+	}
+	key, err := resumeKey(localPath, remotePath)
+	if err != nil {
+		return nil
+	}
+	var state DownloadState
+	if found, err := c.resume.get(resumeDownloadsBucket, key, &state); err != nil || !found {
+		return nil
+	}
+	info, err := os.Stat(localPath)
+	if err != nil || state.Size != file.Size || state.MD5 != file.MD5 || info.Size() > file.Size || info.Size() < maxRangeEnd(state.Done) {
+		c.resume.remove(resumeDownloadsBucket, key)
+		return nil
+	}
+	return state.Done
+}
+
+// saveDownloadResume records that the ranges done of the remote file are in localPath
+func (c *Client) saveDownloadResume(localPath, remotePath string, file *FileInfo, done []ByteRange) {
+	abs, err := filepath.Abs(localPath)
+	if err == nil {
+		state := DownloadState{RemotePath: remotePath, LocalPath: abs, Size: file.Size, MD5: file.MD5, Done: done, Updated: time.Now()}
+		err = c.resume.put(resumeDownloadsBucket, []byte(abs+"\x00"+remotePath), state)
+	}
+	if err != nil {
+		c.logger.Warn("Failed to save download resume state", "path", localPath, "error", err)
+	}
+}
+
+// forgetDownloadResume deletes the saved state of the download of remotePath to localPath
+func (c *Client) forgetDownloadResume(localPath, remotePath string) {
+	if c.resume == nil {
+		return
+	}
+	if key, err := resumeKey(localPath, remotePath); err == nil {
+		c.resume.remove(resumeDownloadsBucket, key)
+	}
+}
+
+// maxRangeEnd returns the largest end of ranges, or 0
+func maxRangeEnd(ranges []ByteRange) int64 {
+	var end int64
+	for _, r := range ranges {
+		end = max(end, r.End)
+	}
+	return end
+}
+
+// mergeRanges returns ranges sorted, with overlapping and adjacent ranges joined and
+// empty ones dropped
+func mergeRanges(ranges []ByteRange) []ByteRange {
+	sorted := make([]ByteRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.End > r.Start {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	var merged []ByteRange
+	for _, r := range sorted {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// missingRanges returns the ranges of a file of size bytes not covered by done
+func missingRanges(done []ByteRange, size int64) []ByteRange {
+	var missing []ByteRange
+	var pos int64
+	for _, r := range mergeRanges(done) {
+		if r.Start > pos {
+			missing = append(missing, ByteRange{pos, min(r.Start, size)})
+		}
+		pos = max(pos, r.End)
+	}
+	if pos < size {
+		missing = append(missing, ByteRange{pos, size})
+	}
+	return missing
+}
+
+// splitRanges splits ranges into at most n ranges of at least minSize bytes each, by
+// halving the largest range while that keeps both halves large enough
+func splitRanges(ranges []ByteRange, n int, minSize int64) []ByteRange {
+	split := append([]ByteRange(nil), ranges...)
+	for len(split) > 0 && len(split) < n {
+		largest := 0
+		for i, r := range split {
+			if r.End-r.Start > split[largest].End-split[largest].Start {
+				largest = i
+			}
+		}
+		r := split[largest]
+		if r.End-r.Start < 2*minSize {
+			break
+		}
+		mid := r.Start + (r.End-r.Start)/2
+		split[largest] = ByteRange{r.Start, mid}
+		split = append(split, ByteRange{mid, r.End})
+	}
+	return split
+}
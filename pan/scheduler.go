@@ -0,0 +1,121 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultJobRetryDelay is how long RunScheduler waits before retrying a failed job by default
+const DefaultJobRetryDelay = time.Minute
+
+// ScheduledJob is a task RunScheduler runs whenever its schedule matches
+type ScheduledJob struct {
+	Name       string
+	Schedule   *Schedule
+	Run        func(ctx context.Context) error
+	Retries    int           // Extra attempts after a failed run
+	RetryDelay time.Duration // Wait between attempts; <= 0 uses DefaultJobRetryDelay
+
+	// Done, if set, is called once a run has finished, with the error of its last
+	// attempt and the time since its first attempt started. It is not called for runs
+	// interrupted by cancellation.
+	Done func(err error, attempts int, duration time.Duration)
+}
+
+// RunScheduler runs each job at the times its schedule matches until ctx is cancelled,
+// logging every run to logger. A job that is still running when its next time comes
+// is not started again; that run is skipped with a warning. A failed run is retried up
+// to job.Retries times. RunScheduler returns once ctx is cancelled and every running
+// job has returned.
+func RunScheduler(ctx context.Context, jobs []ScheduledJob, logger *slog.Logger) error {
+	if len(jobs) == 0 {
+		return fmt.Errorf("no scheduled jobs")
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduleJob(ctx, job, logger, &wg)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// scheduleJob starts job at each of its scheduled times until ctx is cancelled
+func scheduleJob(ctx context.Context, job ScheduledJob, logger *slog.Logger, wg *sync.WaitGroup) {
+	var running atomic.Bool
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Error("Schedule never matches, job disabled", "job", job.Name, "schedule", job.Schedule.String())
+			return
+		}
+		logger.Info("Job scheduled", "job", job.Name, "next", next.Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !running.CompareAndSwap(false, true) {
+			logger.Warn("Previous run still in progress, skipping", "job", job.Name)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer running.Store(false)
+			runJob(ctx, job, logger)
+		}()
+	}
+}
+
+// runJob runs job once, retrying failed attempts
+func runJob(ctx context.Context, job ScheduledJob, logger *slog.Logger) {
+	delay := job.RetryDelay
+	if delay <= 0 {
+		delay = DefaultJobRetryDelay
+	}
+
+	firstStart := time.Now()
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		logger.Info("Job started", "job", job.Name, "attempt", attempt+1)
+		err := job.Run(ctx)
+		if err == nil {
+			logger.Info("Job finished", "job", job.Name, "duration", time.Since(start).Round(time.Second))
+			if job.Done != nil {
+				job.Done(nil, attempt+1, time.Since(firstStart))
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			logger.Warn("Job interrupted", "job", job.Name, "error", err)
+			return
+		}
+		if attempt >= job.Retries {
+			logger.Error("Job failed", "job", job.Name, "attempts", attempt+1, "error", err)
+			if job.Done != nil {
+				job.Done(err, attempt+1, time.Since(firstStart))
+			}
+			return
+		}
+
+		logger.Warn("Job failed, retrying", "job", job.Name, "attempt", attempt+1, "retry_in", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
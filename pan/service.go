@@ -0,0 +1,63 @@
+package pan
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultServiceName is the name the daemon is registered under with the system service manager
+const DefaultServiceName = "go-bdfs"
+
+// ServiceConfig describes the background service that runs the go-bdfs daemon. It is
+// registered as a systemd user unit on Linux, a launchd agent on macOS and a Windows
+// service on Windows; each restarts the daemon automatically if it fails.
+type ServiceConfig struct {
+	Name        string            // Service name; empty uses DefaultServiceName
+	Description string            // Human-readable description shown by the service manager
+	Executable  string            // Absolute path of the program to run
+	Args        []string          // Arguments passed to the program
+	Env         map[string]string // Environment variables set for the program
+	LogFile     string            // File receiving the program's output
+}
+
+// name returns the configured service name or the default
+func (cfg ServiceConfig) name() string {
+	if cfg.Name == "" {
+		return DefaultServiceName
+	}
+	return cfg.Name
+}
+
+// sortedEnv returns the environment as sorted KEY=value pairs
+func (cfg ServiceConfig) sortedEnv() []string {
+	env := make([]string, 0, len(cfg.Env))
+	for key, value := range cfg.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// ServiceEnv returns the BDFS_* variables of the current environment, so a service sees
+// the same configuration as the command that installed it
+func ServiceEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(key, "BDFS_") {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// DefaultServiceLogFile returns the default log file of the daemon service, daemon.log
+// in the state directory of DefaultDataDirs
+func DefaultServiceLogFile() (string, error) {
+	dirs, err := DefaultDataDirs()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirs.State, "daemon.log"), nil
+}
@@ -0,0 +1,141 @@
+package pan
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// serviceLabel returns the launchd label of the named service
+func serviceLabel(name string) string {
+	return "com.github.baowuhe." + name
+}
+
+// servicePlistPath returns the path of the launchd agent definition for the named service
+func servicePlistPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "Library", "LaunchAgents", serviceLabel(name)+".plist"), nil
+}
+
+// launchdDomain returns the launchd domain of the current user's GUI session
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// InstallService writes a launchd agent for cfg and loads it. The agent starts at login
+// and is restarted by launchd, at most every 30 seconds, whenever it exits with an error.
+func InstallService(cfg ServiceConfig) error {
+	plistPath, err := servicePlistPath(cfg.name())
+	if err != nil {
+		return fmt.Errorf("failed to locate LaunchAgents directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(plistPath), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+
+	var plist bytes.Buffer
+	plist.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	plist.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	plist.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	plistKeyString(&plist, "Label", serviceLabel(cfg.name()))
+	plist.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range append([]string{cfg.Executable}, cfg.Args...) {
+		fmt.Fprintf(&plist, "    <string>%s</string>\n", xmlEscape(arg))
+	}
+	plist.WriteString("  </array>\n")
+	if len(cfg.Env) > 0 {
+		plist.WriteString("  <key>EnvironmentVariables</key>\n  <dict>\n")
+		for _, kv := range cfg.sortedEnv() {
+			key, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(&plist, "    <key>%s</key>\n    <string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+		}
+		plist.WriteString("  </dict>\n")
+	}
+	if cfg.LogFile != "" {
+		plistKeyString(&plist, "StandardOutPath", cfg.LogFile)
+		plistKeyString(&plist, "StandardErrorPath", cfg.LogFile)
+	}
+	plist.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	plist.WriteString("  <key>KeepAlive</key>\n  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	plist.WriteString("  <key>ThrottleInterval</key>\n  <integer>30</integer>\n")
+	plist.WriteString("</dict>\n</plist>\n")
+
+	// The agent may hold credentials from the environment
+	if err := writeFileAtomic(plistPath, plist.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write launchd agent: %w", err)
+	}
+
+	// Replace a previously loaded definition; bootout fails harmlessly when none is loaded
+	exec.Command("launchctl", "bootout", launchdDomain()+"/"+serviceLabel(cfg.name())).Run()
+	return launchctl("bootstrap", launchdDomain(), plistPath)
+}
+
+// UninstallService unloads the named launchd agent and removes its definition
+func UninstallService(name string) error {
+	plistPath, err := servicePlistPath(name)
+	if err != nil {
+		return fmt.Errorf("failed to locate LaunchAgents directory: %w", err)
+	}
+	if _, err := os.Stat(plistPath); err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+
+	if err := launchctl("bootout", launchdDomain()+"/"+serviceLabel(name)); err != nil {
+		return err
+	}
+	if err := os.Remove(plistPath); err != nil {
+		return fmt.Errorf("failed to remove launchd agent: %w", err)
+	}
+	return nil
+}
+
+// ServiceStatus returns launchd's description of the named agent
+func ServiceStatus(name string) (string, error) {
+	plistPath, err := servicePlistPath(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate LaunchAgents directory: %w", err)
+	}
+	if _, err := os.Stat(plistPath); err != nil {
+		return fmt.Sprintf("Service %s is not installed.", name), nil
+	}
+
+	out, err := exec.Command("launchctl", "print", launchdDomain()+"/"+serviceLabel(name)).CombinedOutput()
+	if err != nil {
+		return fmt.Sprintf("Agent file: %s\nNot loaded.", plistPath), nil
+	}
+	return fmt.Sprintf("Agent file: %s\n%s", plistPath, out), nil
+}
+
+// RunAsService reports false: on macOS the daemon runs as an ordinary process under launchd
+func RunAsService(name string, run func(ctx context.Context)) (bool, error) {
+	return false, nil
+}
+
+// launchctl runs a launchctl command
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// plistKeyString writes a key with a string value to a plist dictionary
+func plistKeyString(b *bytes.Buffer, key, value string) {
+	fmt.Fprintf(b, "  <key>%s</key>\n  <string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+}
+
+// xmlEscape escapes s for use as XML character data
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// serviceUnitPath returns the path of the systemd user unit for the named service
+func serviceUnitPath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user", name+".service"), nil
+}
+
+// InstallService writes a systemd user unit for cfg, enables it and starts it. The unit
+// restarts the program 30 seconds after it fails. User units stop when the user logs
+// out unless lingering is enabled with "loginctl enable-linger".
+func InstallService(cfg ServiceConfig) error {
+	unitPath, err := serviceUnitPath(cfg.name())
+	if err != nil {
+		return fmt.Errorf("failed to locate systemd user directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0755); err != nil {
+		return fmt.Errorf("failed to create systemd user directory: %w", err)
+	}
+
+	var unit strings.Builder
+	fmt.Fprintf(&unit, "[Unit]\nDescription=%s\nAfter=network-online.target\nWants=network-online.target\n\n", cfg.Description)
+	unit.WriteString("[Service]\nType=simple\n")
+	fmt.Fprintf(&unit, "ExecStart=%s\n", systemdCommandLine(append([]string{cfg.Executable}, cfg.Args...)))
+	for _, kv := range cfg.sortedEnv() {
+		fmt.Fprintf(&unit, "Environment=%s\n", systemdQuote(kv))
+	}
+	if cfg.LogFile != "" {
+		fmt.Fprintf(&unit, "StandardOutput=append:%s\nStandardError=append:%s\n", cfg.LogFile, cfg.LogFile)
+	}
+	unit.WriteString("Restart=on-failure\nRestartSec=30\n\n[Install]\nWantedBy=default.target\n")
+
+	// The unit may hold credentials from the environment
+	if err := writeFileAtomic(unitPath, []byte(unit.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write systemd unit: %w", err)
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", cfg.name()+".service")
+}
+
+// UninstallService stops and disables the named systemd user unit and removes it
+func UninstallService(name string) error {
+	unitPath, err := serviceUnitPath(name)
+	if err != nil {
+		return fmt.Errorf("failed to locate systemd user directory: %w", err)
+	}
+	if _, err := os.Stat(unitPath); err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+
+	if err := systemctl("disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(unitPath); err != nil {
+		return fmt.Errorf("failed to remove systemd unit: %w", err)
+	}
+	return systemctl("daemon-reload")
+}
+
+// ServiceStatus returns the systemd status of the named user unit
+func ServiceStatus(name string) (string, error) {
+	unitPath, err := serviceUnitPath(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate systemd user directory: %w", err)
+	}
+	if _, err := os.Stat(unitPath); err != nil {
+		return fmt.Sprintf("Service %s is not installed.", name), nil
+	}
+
+	// systemctl status exits non-zero for stopped units, which is still a valid status
+	out, _ := exec.Command("systemctl", "--user", "status", "--no-pager", name+".service").CombinedOutput()
+	return fmt.Sprintf("Unit file: %s\n%s", unitPath, out), nil
+}
+
+// RunAsService reports false: on Linux the daemon runs as an ordinary process under systemd
+func RunAsService(name string, run func(ctx context.Context)) (bool, error) {
+	return false, nil
+}
+
+// systemctl runs a systemctl command against the user's service manager
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdCommandLine quotes each argument of a command line for an ExecStart setting
+func systemdCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdQuote quotes s for a systemd unit file when it contains special characters
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;$%") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, `$`, `$$`)
+	s = strings.ReplaceAll(s, `%`, `%%`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,29 @@
+//go:build !linux && !darwin && !windows
+
+package pan
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// InstallService is not supported on this platform
+func InstallService(cfg ServiceConfig) error {
+	return fmt.Errorf("installing a service is not supported on %s", runtime.GOOS)
+}
+
+// UninstallService is not supported on this platform
+func UninstallService(name string) error {
+	return fmt.Errorf("removing a service is not supported on %s", runtime.GOOS)
+}
+
+// ServiceStatus is not supported on this platform
+func ServiceStatus(name string) (string, error) {
+	return "", fmt.Errorf("service status is not supported on %s", runtime.GOOS)
+}
+
+// RunAsService reports false: there is no service manager integration on this platform
+func RunAsService(name string, run func(ctx context.Context)) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,171 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// InstallService registers cfg as an automatically started Windows service and starts
+// it. The service manager restarts it a minute after it fails. Windows services do not
+// capture output, so the program must write cfg.LogFile itself (the daemon does with
+// --log-file). Installing requires an elevated prompt.
+func InstallService(cfg ServiceConfig) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(cfg.name(), cfg.Executable, mgr.Config{
+		DisplayName: cfg.name(),
+		Description: cfg.Description,
+		StartType:   mgr.StartAutomatic,
+	}, cfg.Args...)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	defer s.Close()
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: time.Minute}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, 24*60*60); err != nil {
+		return fmt.Errorf("failed to set service restart policy: %w", err)
+	}
+
+	if len(cfg.Env) > 0 {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+cfg.name(), registry.SET_VALUE)
+		if err != nil {
+			return fmt.Errorf("failed to open service registry key: %w", err)
+		}
+		defer key.Close()
+		if err := key.SetStringsValue("Environment", cfg.sortedEnv()); err != nil {
+			return fmt.Errorf("failed to set service environment: %w", err)
+		}
+	}
+
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	return nil
+}
+
+// UninstallService stops and removes the named Windows service
+func UninstallService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	return nil
+}
+
+// ServiceStatus returns the state of the named Windows service
+func ServiceStatus(name string) (string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Sprintf("Service %s is not installed.", name), nil
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return "", fmt.Errorf("failed to query service: %w", err)
+	}
+	config, err := s.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read service configuration: %w", err)
+	}
+	return fmt.Sprintf("Service: %s\nState: %s\nCommand: %s", name, serviceStateName(status.State), config.BinaryPathName), nil
+}
+
+// serviceStateName returns a readable name for a service state
+func serviceStateName(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "starting"
+	case svc.StopPending:
+		return "stopping"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "resuming"
+	case svc.PausePending:
+		return "pausing"
+	case svc.Paused:
+		return "paused"
+	}
+	return fmt.Sprintf("unknown (%d)", state)
+}
+
+// RunAsService runs run under the Windows service manager when the process was started
+// as a service, cancelling its context when the service is stopped, and reports whether
+// it did. Outside the service manager it returns false immediately.
+func RunAsService(name string, run func(ctx context.Context)) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, err
+	}
+	return true, svc.Run(name, &serviceHandler{run: run})
+}
+
+// serviceHandler adapts a run function to the Windows service control protocol
+type serviceHandler struct {
+	run func(ctx context.Context)
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.run(ctx)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
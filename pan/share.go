@@ -0,0 +1,344 @@
+package pan
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// Share endpoints: creating share links, and listing the shares other users sent to the
+// account's message box
+const (
+	shareSetURL      = "https://pan.baidu.com/share/set"
+	receivedShareURL = "https://pan.baidu.com/mbox/msg/sharelist"
+)
+
+// receivedSharePageSize is the number of received shares requested per page
+const receivedSharePageSize = 100
+
+// shareCodeAlphabet is the characters of generated extraction codes
+const shareCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789" // No l, o, 0 or 1, which are easily confused
+
+// SharePeriods are the validity periods Baidu Pan offers for share links, in days; 0 never expires
+var SharePeriods = []int{1, 7, 30, 365, 0}
+
+// ShareOptions controls CreateShare
+type ShareOptions struct {
+	Password string // 4-letter extraction code; empty generates one
+	Period   int    // Days the link is valid, one of SharePeriods; 0 never expires
+}
+
+// Share is a created share link
+type Share struct {
+	ShareID  int64     `json:"share_id"`
+	Link     string    `json:"link"`
+	Password string    `json:"password"`
+	Period   int       `json:"period_days"`      // 0 never expires
+	Expires  time.Time `json:"expires,omitzero"` // Zero when the link never expires
+	Paths    []string  `json:"paths"`
+}
+
+// URL returns the share link with its extraction code embedded, which Baidu Pan fills in
+// automatically when the link is opened
+func (s *Share) URL() string {
+	return s.Link + "?pwd=" + s.Password
+}
+
+// String returns the link and extraction code on one copy-paste friendly line
+func (s *Share) String() string {
+	expiry := "never expires"
+	if !s.Expires.IsZero() {
+		expiry = "expires " + s.Expires.Format(time.DateTime)
+	}
+	return fmt.Sprintf("%s (code: %s, %s)", s.URL(), s.Password, expiry)
+}
+
+// ShareSetResponse represents the response from the share set API
+type ShareSetResponse struct {
+	Errno     int    `json:"errno"`
+	ShareID   int64  `json:"shareid"`
+	Link      string `json:"link"`
+	ShortURL  string `json:"shorturl"`
+	CTime     int64  `json:"ctime"`
+	RequestID int64  `json:"request_id"`
+}
+
+// ParseSharePeriod parses a share validity period such as "1d", "7d", "30d", "365d" or
+// "forever" into days, 0 meaning forever
+func ParseSharePeriod(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "forever" || s == "never" || s == "permanent" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+	if err == nil && days > 0 && slices.Contains(SharePeriods, days) {
+		return days, nil
+	}
+	return 0, fmt.Errorf("invalid share period %q (expected 1d, 7d, 30d, 365d or forever)", s)
+}
+
+// GenerateSharePassword returns a random 4-character extraction code
+func GenerateSharePassword() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate extraction code: %w", err)
+	}
+	for i, b := range buf {
+		buf[i] = shareCodeAlphabet[int(b)%len(shareCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// ValidateSharePassword checks that password is a valid extraction code: 4 ASCII letters or digits
+func ValidateSharePassword(password string) error {
+	if len(password) != 4 {
+		return fmt.Errorf("extraction code must be 4 characters, got %q", password)
+	}
+	for _, r := range password {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return fmt.Errorf("extraction code may only contain letters and digits, got %q", password)
+		}
+	}
+	return nil
+}
+
+// CreateShare creates one password-protected share link for the files and directories at
+// paths. In dry-run mode the share is reported instead of created and nil is returned.
+func (c *Client) CreateShare(paths []string, opts ShareOptions) (*Share, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files specified for sharing")
+	}
+	if err := checkShareOptions(opts); err != nil {
+		return nil, err
+	}
+
+	// The share API takes fs_ids rather than paths
+	ids := make([]int64, 0, len(paths))
+	for _, p := range paths {
+		info, err := c.GetDetailedFileInfo(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info for %s: %w", p, err)
+		}
+		ids = append(ids, info.FsID)
+	}
+	return c.createShare(ids, paths, opts)
+}
+
+// CreateShareByFsID creates one password-protected share link for the files and
+// directories with the given fs_ids, which the share API takes natively. Their paths
+// are only looked up for the returned Share.
+func (c *Client) CreateShareByFsID(ids []int64, opts ShareOptions) (*Share, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no files specified for sharing")
+	}
+	if err := checkShareOptions(opts); err != nil {
+		return nil, err
+	}
+
+	paths, err := c.PathsByFsID(context.Background(), ids)
+	if err != nil {
+		return nil, err
+	}
+	return c.createShare(ids, paths, opts)
+}
+
+// checkShareOptions validates the extraction code, if set, and the period of opts
+func checkShareOptions(opts ShareOptions) error {
+	if opts.Password != "" {
+		if err := ValidateSharePassword(opts.Password); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(SharePeriods, opts.Period) {
+		return fmt.Errorf("invalid share period of %d days (expected 1, 7, 30, 365 or 0 for forever)", opts.Period)
+	}
+	return nil
+}
+
+// createShare shares the files with the given fs_ids, found at paths, in one link
+func (c *Client) createShare(ids []int64, paths []string, opts ShareOptions) (*Share, error) {
+	password := opts.Password
+	if password == "" {
+		var err error
+		if password, err = GenerateSharePassword(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.DryRun() {
+		ops := make([]dryRunOperation, 0, len(paths))
+		for _, p := range paths {
+			ops = append(ops, dryRunOperation{path: p, detail: fmt.Sprintf("%s (period: %d days)", p, opts.Period)})
+		}
+		c.reportDryRun("share set", ops)
+		return nil, nil
+	}
+
+	fidList, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fs_ids to JSON: %w", err)
+	}
+
+	params := url.Values{}
+	params.Add("access_token", c.getAccessToken())
+	params.Add("channel", "chunlei")
+	params.Add("web", "1")
+	params.Add("app_id", "250528")
+
+	formData := url.Values{}
+	formData.Add("fid_list", string(fidList))
+	formData.Add("schannel", "4") // Password-protected share
+	formData.Add("channel_list", "[]")
+	formData.Add("period", strconv.Itoa(opts.Period))
+	formData.Add("pwd", password)
+
+	req, err := http.NewRequest("POST", c.endpoint(shareSetURL)+"?"+params.Encode(), strings.NewReader(formData.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("share request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read share response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("share request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response ShareSetResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal share response: %w", err)
+	}
+
+	if response.Errno != 0 {
+		return nil, &errno.Error{API: "share", Code: response.Errno}
+	}
+
+	share := &Share{ShareID: response.ShareID, Link: response.Link, Password: password, Period: opts.Period, Paths: paths}
+	if share.Link == "" {
+		share.Link = response.ShortURL
+	}
+	if opts.Period > 0 {
+		created := time.Now()
+		if response.CTime > 0 {
+			created = time.Unix(response.CTime, 0)
+		}
+		share.Expires = created.AddDate(0, 0, opts.Period)
+	}
+	return share, nil
+}
+
+// ReceivedShare is a share another user sent to the account
+type ReceivedShare struct {
+	ShareID  int64               `json:"share_id"`
+	MsgID    string              `json:"msg_id"`
+	FromUK   int64               `json:"from_uk"`
+	FromName string              `json:"from_uname"`
+	Title    string              `json:"title"`
+	CTime    int64               `json:"ctime"` // When it was received (Unix seconds)
+	Files    []ReceivedShareFile `json:"file_list"`
+}
+
+// ReceivedShareFile is a file or directory in a received share; its FsID identifies it
+// to the share's owner, for saving or copying it into the account
+type ReceivedShareFile struct {
+	FsID           int64  `json:"fs_id"`
+	Path           string `json:"path"`
+	ServerFilename string `json:"server_filename"`
+	Size           int64  `json:"size"`
+	IsDir          int    `json:"isdir"`
+}
+
+// ReceivedShareListResponse represents the response from the received share list API
+type ReceivedShareListResponse struct {
+	Errno     int             `json:"errno"`
+	Records   []ReceivedShare `json:"records"`
+	HasMore   int             `json:"has_more"`
+	RequestID int64           `json:"request_id"`
+}
+
+// ListReceivedShares returns the shares other users have sent to the account, newest first
+func (c *Client) ListReceivedShares(ctx context.Context) ([]ReceivedShare, error) {
+	var shares []ReceivedShare
+	for start := 0; ; start += receivedSharePageSize {
+		page, err := c.receivedSharePage(ctx, start)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, page.Records...)
+		if page.HasMore == 0 || len(page.Records) == 0 {
+			return shares, nil
+		}
+	}
+}
+
+// receivedSharePage fetches the page of received shares starting at start
+func (c *Client) receivedSharePage(ctx context.Context, start int) (*ReceivedShareListResponse, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	params := url.Values{}
+	params.Add("access_token", c.getAccessToken())
+	params.Add("start", strconv.Itoa(start))
+	params.Add("limit", strconv.Itoa(receivedSharePageSize))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(receivedShareURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received share list request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response ReceivedShareListResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Errno != 0 {
+		return nil, &errno.Error{API: "share", Code: response.Errno}
+	}
+	return &response, nil
+}
+
+// GetShareErrorMessage returns a human-readable error message for common share errno values
+func GetShareErrorMessage(code int) string {
+	return errno.APIMessage("share", code)
+}
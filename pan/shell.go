@@ -0,0 +1,72 @@
+package pan
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ResolveRemotePath returns p, a remote path that may be relative to the directory cwd,
+// as a clean absolute path. "..", "." and repeated slashes are resolved; ".." never
+// leaves the root.
+func ResolveRemotePath(cwd, p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(cwd, p)
+	}
+	return path.Clean("/" + p)
+}
+
+// SplitShellWords splits line into words at unquoted spaces and tabs, as a POSIX shell
+// does without expansions: single quotes keep everything up to the next one, and double
+// quotes and backslashes keep the next character, so names with spaces can be given
+func SplitShellWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && (quote == 0 || (i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]))):
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("unfinished escape at the end of %q", line)
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
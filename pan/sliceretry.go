@@ -0,0 +1,113 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// defaultSliceRetries is how often a failed slice upload is retried by default
+const defaultSliceRetries = 3
+
+// DefaultSliceRetryDelay is the delay before the first retry of a failed slice upload by default
+const DefaultSliceRetryDelay = time.Second
+
+// blockMismatchErrnos are the create API's errnos for slices that are missing or do not
+// match the block list: 31363 (block missing in superfile) and 31190 (slices incomplete)
+var blockMismatchErrnos = map[int]bool{31363: true, 31190: true}
+
+// WithSliceRetry configures how a failed slice upload is retried before the whole upload
+// fails: up to maxRetries times, starting at baseDelay and doubling the delay each attempt.
+// Only the failed slice is sent again. A maxRetries of zero disables retrying.
+func WithSliceRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.sliceMaxRetries = maxRetries
+		c.sliceBaseDelay = baseDelay
+	}
+}
+
+// uploadSliceWithRetry uploads a slice like uploadSlice to the upload server in use,
+// retrying it with backoff when it fails. Each retry fails over to the next server.
+func (c *Client) uploadSliceWithRetry(ctx context.Context, hosts *uploadHosts, remoteFilePath, uploadID string, partSeq int, fileName string, data []byte) error {
+	delay := c.sliceBaseDelay
+	for attempt := 0; ; attempt++ {
+		superfileURL := hosts.url()
+		err := c.uploadSlice(ctx, superfileURL, remoteFilePath, uploadID, partSeq, fileName, data)
+		if err == nil || attempt >= c.sliceMaxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		c.logger.Warn(fmt.Sprintf("Slice %d upload failed, retrying in %s", partSeq, delay), "path", remoteFilePath, "attempt", attempt+1, "error", err)
+		c.observeRetry("slice")
+		if next, ok := hosts.failover(superfileURL); ok && next != superfileURL {
+			c.logger.Warn(fmt.Sprintf("Switching upload server to %s", next), "path", remoteFilePath)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxThrottleDelay)
+	}
+}
+
+// repairUpload completes an upload whose create call reported missing or mismatched
+// slices. target must carry the real slice MD5s. It registers the upload again, sends
+// only the slices precreate still asks for, read from ra, and creates the file.
+func (c *Client) repairUpload(ctx context.Context, ra io.ReaderAt, target *uploadTarget, sliceSize int64) (*CreateFileResponse, error) {
+	precreateResponse, err := c.precreate(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if precreateResponse.ReturnType == 2 {
+		// The server holds every slice now and completed the file itself; its metadata
+		// is looked up, as the index and history need the real fs_id and MD5
+		return c.completedUpload(target)
+	}
+	if precreateResponse.UploadID == "" {
+		return nil, fmt.Errorf("precreate API did not return uploadid")
+	}
+
+	c.logger.Warn(fmt.Sprintf("Re-uploading %d mismatched slices of '%s'", len(precreateResponse.BlockList), target.remotePath))
+	hosts := c.locateUploadHosts(ctx, target.remotePath, precreateResponse.UploadID)
+	fileName := path.Base(target.remotePath)
+	buf := make([]byte, sliceSize)
+	for _, seq := range precreateResponse.BlockList {
+		n, err := ra.ReadAt(buf, int64(seq)*sliceSize)
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file slice %d: %w", seq, err)
+		}
+		if err := c.uploadSliceWithRetry(ctx, hosts, target.remotePath, precreateResponse.UploadID, seq, fileName, buf[:n]); err != nil {
+			return nil, err
+		}
+		c.observeTransfer("upload", int64(n))
+	}
+	return c.createFile(ctx, target, precreateResponse.UploadID)
+}
+
+// completedUpload returns the metadata of the file the server completed for target
+// without a create call, in the form create would have returned it
+func (c *Client) completedUpload(target *uploadTarget) (*CreateFileResponse, error) {
+	info, err := c.GetDetailedFileInfo(target.remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up completed upload '%s': %w", target.remotePath, err)
+	}
+	if info.IsDir == 1 || info.Size != target.size {
+		return nil, fmt.Errorf("completed upload '%s' has %d bytes, expected %d", target.remotePath, info.Size, target.size)
+	}
+	return &CreateFileResponse{
+		FsID:           info.FsID,
+		Path:           info.Path,
+		CTime:          info.ServerCtime,
+		MTime:          info.ServerMtime,
+		MD5:            info.MD5,
+		Size:           info.Size,
+		Category:       info.Category,
+		ServerFilename: info.ServerFilename,
+		ParentPath:     path.Dir(info.Path),
+	}, nil
+}
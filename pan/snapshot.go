@@ -0,0 +1,142 @@
+package pan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotSuffix is the file extension of stored snapshots
+const snapshotSuffix = ".json"
+
+// snapshotNamePattern restricts snapshot names to characters that are safe in file names
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrSnapshotExists is returned when saving a snapshot under a name that is already taken
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// SnapshotStore keeps named manifests of the remote tree in a local directory, one
+// JSON file per snapshot
+type SnapshotStore struct {
+	dir string
+}
+
+// SnapshotInfo summarizes a stored snapshot
+type SnapshotInfo struct {
+	Name        string
+	Root        string
+	GeneratedAt time.Time
+	Files       int
+	Bytes       int64
+}
+
+// OpenSnapshotStore opens the snapshot store in dir, creating the directory if needed
+func OpenSnapshotStore(dir string) (*SnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory %s: %w", dir, err)
+	}
+	return &SnapshotStore{dir: dir}, nil
+}
+
+// path returns the file holding the snapshot called name
+func (s *SnapshotStore) path(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return filepath.Join(s.dir, name+snapshotSuffix), nil
+}
+
+// Save stores manifest as the snapshot called name. An existing snapshot with the same
+// name is only replaced when overwrite is set; otherwise ErrSnapshotExists is returned.
+func (s *SnapshotStore) Save(name string, manifest *Manifest, overwrite bool) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(file); err == nil && !overwrite {
+		return fmt.Errorf("%w: %s", ErrSnapshotExists, name)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, manifest); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(file, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to save snapshot %s: %w", name, err)
+	}
+	return nil
+}
+
+// Load returns the manifest stored as the snapshot called name
+func (s *SnapshotStore) Load(name string) (*Manifest, error) {
+	file, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("snapshot %s not found", name)
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	manifest, err := ReadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+	}
+	return manifest, nil
+}
+
+// List returns a summary of every stored snapshot, oldest first
+func (s *SnapshotStore) List() ([]SnapshotInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var snapshots []SnapshotInfo
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotSuffix)
+		if !ok || entry.IsDir() || !snapshotNamePattern.MatchString(name) {
+			continue
+		}
+		manifest, err := s.Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		info := SnapshotInfo{Name: name, Root: manifest.Root, GeneratedAt: manifest.GeneratedAt, Files: len(manifest.Files)}
+		for _, file := range manifest.Files {
+			info.Bytes += file.Size
+		}
+		snapshots = append(snapshots, info)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].GeneratedAt.Before(snapshots[j].GeneratedAt)
+	})
+	return snapshots, nil
+}
+
+// Delete removes the snapshot called name
+func (s *SnapshotStore) Delete(name string) error {
+	file, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("snapshot %s not found", name)
+		}
+		return err
+	}
+	return nil
+}
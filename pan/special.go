@@ -0,0 +1,64 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// SpecialFilePolicy says what syncs and recursive uploads do with local files that
+// cannot or should not be uploaded as they are: sockets, named pipes and devices, which
+// would hang or never end, and sparse files, which would upload their holes as zeros
+type SpecialFilePolicy string
+
+const (
+	SpecialSkip   SpecialFilePolicy = "skip"   // Leave them out, reporting each
+	SpecialUpload SpecialFilePolicy = "upload" // Upload sparse files in full; other special files are still skipped
+	SpecialError  SpecialFilePolicy = "error"  // Fail on the first one
+)
+
+// ErrSpecialFile is returned, wrapped with the path, for a special file found under SpecialError
+var ErrSpecialFile = errors.New("special file found")
+
+// sparseMinSize is the size below which a file is never considered sparse
+const sparseMinSize = 1 << 20
+
+// sparseFileKind is the kind specialFileKind reports for a sparse file
+const sparseFileKind = "sparse file"
+
+// ParseSpecialFilePolicy parses "skip", "upload" or "error"; empty means SpecialSkip
+func ParseSpecialFilePolicy(s string) (SpecialFilePolicy, error) {
+	switch policy := SpecialFilePolicy(s); policy {
+	case "":
+		return SpecialSkip, nil
+	case SpecialSkip, SpecialUpload, SpecialError:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid special file policy %q (expected skip, upload or error)", s)
+}
+
+// specialFileKind describes what makes the file or directory of info unfit for upload,
+// or returns "" for a directory or an ordinary regular file. A regular file of at least
+// sparseMinSize bytes with less than 1/64 of them allocated on disk is sparse; on
+// platforms that do not report allocation, no file is.
+func specialFileKind(info fs.FileInfo) string {
+	mode := info.Mode()
+	switch {
+	case mode.IsDir():
+		return ""
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	case !mode.IsRegular():
+		return "special file"
+	}
+	if size := info.Size(); size >= sparseMinSize {
+		if allocated, ok := allocatedSize(info); ok && allocated < size/64 {
+			return sparseFileKind
+		}
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package pan
+
+import "io/fs"
+
+// allocatedSize is not supported on this platform
+func allocatedSize(info fs.FileInfo) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd
+
+package pan
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// allocatedSize returns the bytes the file of info occupies on disk
+func allocatedSize(info fs.FileInfo) (int64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(stat.Blocks) * 512, true
+}
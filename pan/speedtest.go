@@ -0,0 +1,261 @@
+package pan
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSpeedTestSize is the amount of data each speed test run transfers by default
+const DefaultSpeedTestSize int64 = 64 * 1024 * 1024
+
+// DefaultSpeedTestConnections are the connection counts a speed test compares by default
+var DefaultSpeedTestConnections = []int{1, 4, 8}
+
+// SpeedTestOptions controls SpeedTest; the zero value uses the defaults
+type SpeedTestOptions struct {
+	Size        int64  // Bytes transferred by each run; 0 uses DefaultSpeedTestSize
+	Connections []int  // Connection counts to compare; empty uses DefaultSpeedTestConnections
+	Dir         string // Remote directory for the temporary files; empty uses the root
+
+	// Progress, if set, is called with the result of each run as it completes
+	Progress func(result SpeedTestResult)
+}
+
+// SpeedTestResult is the throughput of one speed test run
+type SpeedTestResult struct {
+	Direction   string        `json:"direction"` // "upload" or "download"
+	Connections int           `json:"connections"`
+	Bytes       int64         `json:"bytes"`
+	Duration    time.Duration `json:"-"`
+}
+
+// BytesPerSecond returns the run's throughput
+func (r SpeedTestResult) BytesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Duration.Seconds()
+}
+
+// RecommendedConnections returns the fewest connections whose throughput in direction
+// came within 10% of the fastest run, as more connections than that only add load, or 0
+// when results holds no run in that direction
+func RecommendedConnections(results []SpeedTestResult, direction string) int {
+	var fastest float64
+	for _, r := range results {
+		if r.Direction == direction {
+			fastest = max(fastest, r.BytesPerSecond())
+		}
+	}
+	best := 0
+	for _, r := range results {
+		if r.Direction == direction && r.BytesPerSecond() >= 0.9*fastest && (best == 0 || r.Connections < best) {
+			best = r.Connections
+		}
+	}
+	return best
+}
+
+// SpeedTest measures sustained upload and download throughput with each of the given
+// numbers of concurrent connections. Every upload run sends opts.Size bytes of random
+// data as 4MB slices of a temporary file; the download runs then read the first of those
+// files back in as many byte ranges as connections. The temporary files are removed
+// afterwards, even when a run fails.
+func (c *Client) SpeedTest(ctx context.Context, opts SpeedTestOptions) ([]SpeedTestResult, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultSpeedTestSize
+	}
+	connections := opts.Connections
+	if len(connections) == 0 {
+		connections = DefaultSpeedTestConnections
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "/"
+	}
+	if sliceCount(size, SliceSizeNormal) > MaxSliceCount {
+		return nil, fmt.Errorf("speed test size %s exceeds the %s limit", FormatBytes(size), FormatBytes(SliceSizeNormal*MaxSliceCount))
+	}
+	if c.DryRun() {
+		for _, n := range connections {
+			fmt.Fprintf(c.dryRunOutput, "[dry-run] speedtest: upload and download %s with %d connections in %s\n", FormatBytes(size), n, dir)
+		}
+		return nil, nil
+	}
+	if err := c.EnsureRemoteDirExists(dir); err != nil {
+		return nil, err
+	}
+
+	var results []SpeedTestResult
+	var created []string
+	defer func() {
+		if len(created) > 0 {
+			if err := c.RemoveFiles(created); err != nil {
+				c.logger.Warn("Failed to remove speed test files", "paths", created, "error", err)
+			}
+		}
+	}()
+
+	report := func(result SpeedTestResult) {
+		results = append(results, result)
+		if opts.Progress != nil {
+			opts.Progress(result)
+		}
+	}
+
+	for _, n := range connections {
+		remotePath, result, err := c.speedTestUpload(ctx, dir, size, n)
+		if remotePath != "" {
+			created = append(created, remotePath)
+		}
+		if err != nil {
+			return results, fmt.Errorf("upload with %d connections failed: %w", n, err)
+		}
+		report(result)
+	}
+	for _, n := range connections {
+		result, err := c.speedTestDownload(ctx, created[0], size, n)
+		if err != nil {
+			return results, fmt.Errorf("download with %d connections failed: %w", n, err)
+		}
+		report(result)
+	}
+	return results, nil
+}
+
+// speedTestUpload uploads size bytes of random data to a new temporary file in dir using
+// n concurrent slice uploads and returns the file's path, empty if it was not created
+func (c *Client) speedTestUpload(ctx context.Context, dir string, size int64, n int) (string, SpeedTestResult, error) {
+	result := SpeedTestResult{Direction: "upload", Connections: n, Bytes: size}
+
+	// Each slice starts with its own sequence number, so no two slices are identical
+	template := make([]byte, SliceSizeNormal)
+	if _, err := rand.Read(template); err != nil {
+		return "", result, fmt.Errorf("failed to generate test data: %w", err)
+	}
+	numSlices := int(sliceCount(size, SliceSizeNormal))
+	slice := func(buf []byte, seq int) []byte {
+		length := min(SliceSizeNormal, size-int64(seq)*SliceSizeNormal)
+		buf = buf[:length]
+		copy(buf, template)
+		binary.BigEndian.PutUint64(buf, uint64(seq))
+		return buf
+	}
+
+	target := &uploadTarget{
+		remotePath: path.Join(dir, fmt.Sprintf(".bdfs-speedtest-%s-%d", hex.EncodeToString(template[:4]), n)),
+		size:       size,
+	}
+	if err := target.setBlockList(provisionalBlockList(numSlices)); err != nil {
+		return "", result, err
+	}
+	precreateResponse, err := c.precreate(ctx, target)
+	if err != nil {
+		return "", result, err
+	}
+	if precreateResponse.UploadID == "" {
+		return "", result, fmt.Errorf("precreate API did not return uploadid")
+	}
+	fileName := path.Base(target.remotePath)
+	superfileURL := c.locateUploadHosts(ctx, target.remotePath, precreateResponse.UploadID).url()
+
+	sliceMD5s := make([]string, numSlices)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	start := time.Now()
+	for worker := 0; worker < n; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, SliceSizeNormal)
+			for {
+				seq := int(next.Add(1) - 1)
+				if seq >= numSlices || ctx.Err() != nil {
+					errs[worker] = ctx.Err()
+					return
+				}
+				data := slice(buf, seq)
+				sum := md5.Sum(data)
+				sliceMD5s[seq] = hex.EncodeToString(sum[:])
+				if err := c.uploadSlice(ctx, superfileURL, target.remotePath, precreateResponse.UploadID, seq, fileName, data); err != nil {
+					errs[worker] = err
+					next.Store(int64(numSlices)) // Stop the other workers
+					return
+				}
+				c.observeTransfer("upload", int64(len(data)))
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+	for _, err := range errs {
+		if err != nil {
+			return "", result, err
+		}
+	}
+
+	if err := target.setBlockList(sliceMD5s); err != nil {
+		return "", result, err
+	}
+	if _, err := c.createFile(ctx, target, precreateResponse.UploadID); err != nil {
+		return "", result, err
+	}
+	return target.remotePath, result, nil
+}
+
+// speedTestDownload reads the size-byte file at remotePath as n concurrent byte ranges
+func (c *Client) speedTestDownload(ctx context.Context, remotePath string, size int64, n int) (SpeedTestResult, error) {
+	result := SpeedTestResult{Direction: "download", Connections: n, Bytes: size}
+	rangeSize := (size + int64(n) - 1) / int64(n)
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	start := time.Now()
+	for worker := 0; worker < n; worker++ {
+		offset := int64(worker) * rangeSize
+		length := min(rangeSize, size-offset)
+		if length <= 0 {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := c.openRange(ctx, remotePath, offset, length)
+			if err != nil {
+				errs[worker] = err
+				return
+			}
+			defer body.Close()
+			read, err := io.Copy(io.Discard, body)
+			c.observeTransfer("download", read)
+			switch {
+			case err != nil:
+				errs[worker] = fmt.Errorf("failed to read range at %d: %w", offset, err)
+			case read != length:
+				errs[worker] = fmt.Errorf("range at %d ended after %d of %d bytes", offset, read, length)
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+	for _, err := range errs {
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,224 @@
+package pan
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SplitManifestSuffix is appended to the remote path of a split upload to name its manifest
+const SplitManifestSuffix = ".bdfs-split.json"
+
+// SplitManifest describes a file uploaded as several part files
+type SplitManifest struct {
+	Version  int         `json:"version"`
+	Name     string      `json:"name"`  // Original file name
+	Size     int64       `json:"size"`  // Size of the original file
+	MD5      string      `json:"md5"`   // MD5 of the original file
+	Mtime    int64       `json:"mtime"` // Modification time of the original file (Unix seconds)
+	PartSize int64       `json:"part_size"`
+	Parts    []SplitPart `json:"parts"`
+}
+
+// SplitPart describes one part file of a split upload
+type SplitPart struct {
+	Path string `json:"path"` // Remote path of the part
+	Size int64  `json:"size"` // Size of the part's original (unencrypted) content
+	MD5  string `json:"md5"`  // MD5 of the part's original content
+}
+
+// splitPartPath returns the remote path of part index (0-based) of remotePath
+func splitPartPath(remotePath string, index int) string {
+	return fmt.Sprintf("%s.part%03d", remotePath, index+1)
+}
+
+// UploadFileSplit uploads localFilePath as part files of at most partSize bytes named
+// remotePath.part001, remotePath.part002, ..., followed by a manifest at remotePath +
+// SplitManifestSuffix recording each part's size and MD5 and the overall MD5. partSize 0
+// uses the largest file the account can upload. opts applies to every part and the manifest.
+func (c *Client) UploadFileSplit(localFilePath, remoteFilePath string, partSize int64, opts UploadOptions) error {
+	localFile, err := os.Open(localFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file for uploading: %w", err)
+	}
+	defer localFile.Close()
+
+	fileInfo, err := localFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get local file info: %w", err)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("cannot upload directory, please specify a file: %s", localFilePath)
+	}
+
+	if partSize <= 0 {
+		maxSize, err := c.MaxUploadSize(opts.SliceSize)
+		if err != nil {
+			return err
+		}
+		partSize = maxSize
+		if opts.Cipher != nil {
+			// Leave room for the encryption overhead so each encrypted part still fits
+			partSize -= opts.Cipher.EncryptedSize(maxSize) - maxSize
+		}
+	}
+
+	manifest := SplitManifest{
+		Version:  1,
+		Name:     fileInfo.Name(),
+		Size:     fileInfo.Size(),
+		Mtime:    fileInfo.ModTime().Unix(),
+		PartSize: partSize,
+	}
+
+	fileHash := md5.New()
+	ctx := c.context()
+	for offset, index := int64(0), 0; offset < manifest.Size || index == 0; offset, index = offset+partSize, index+1 {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %d parts of %s uploaded", ErrInterrupted, index, localFilePath)
+		}
+		size := min(partSize, manifest.Size-offset)
+		part := SplitPart{Path: splitPartPath(remoteFilePath, index), Size: size}
+
+		partHash := md5.New()
+		content := io.TeeReader(io.NewSectionReader(localFile, offset, size), io.MultiWriter(fileHash, partHash))
+		if err := c.uploadReader(ctx, content, size, part.Path, opts, time.Time{}); err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", index+1, err)
+		}
+		part.MD5 = hex.EncodeToString(partHash.Sum(nil))
+		manifest.Parts = append(manifest.Parts, part)
+	}
+	manifest.MD5 = hex.EncodeToString(fileHash.Sum(nil))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode split manifest: %w", err)
+	}
+	if err := c.uploadReader(ctx, bytes.NewReader(data), int64(len(data)), remoteFilePath+SplitManifestSuffix, opts, time.Time{}); err != nil {
+		return fmt.Errorf("failed to upload split manifest: %w", err)
+	}
+
+	c.logger.Info(fmt.Sprintf("File '%s' uploaded as %d parts with manifest '%s'", localFilePath, len(manifest.Parts), remoteFilePath+SplitManifestSuffix))
+	return nil
+}
+
+// GetSplitManifest downloads and decodes the manifest of the split upload at remoteFilePath
+func (c *Client) GetSplitManifest(remoteFilePath string, opts DownloadOptions) (*SplitManifest, error) {
+	var data bytes.Buffer
+	if err := c.downloadDecrypted(context.Background(), remoteFilePath+SplitManifestSuffix, &data, opts); err != nil {
+		return nil, fmt.Errorf("failed to download split manifest: %w", err)
+	}
+
+	var manifest SplitManifest
+	if err := json.Unmarshal(data.Bytes(), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode split manifest: %w", err)
+	}
+	if manifest.Version != 1 {
+		return nil, fmt.Errorf("unsupported split manifest version %d", manifest.Version)
+	}
+	return &manifest, nil
+}
+
+// DownloadFileSplit downloads the parts of the split upload at remoteFilePath and
+// reassembles them at localPath, verifying every part and the overall MD5 against the
+// manifest. The file only appears at localPath once it has been fully verified.
+func (c *Client) DownloadFileSplit(remoteFilePath, localPath string, opts DownloadOptions) error {
+	manifest, err := c.GetSplitManifest(remoteFilePath, opts)
+	if err != nil {
+		return err
+	}
+
+	if !opts.IgnoreSpace {
+		if err := CheckFreeSpace(localPath, manifest.Size); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for local path: %w", err)
+	}
+	tmpPath := localPath + ".partial"
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer os.Remove(tmpPath) // No-op once renamed into place
+	defer outFile.Close()
+
+	fileHash := md5.New()
+	ctx := c.context()
+	for i, part := range manifest.Parts {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %d of %d parts of %s downloaded", ErrInterrupted, i, len(manifest.Parts), remoteFilePath)
+		}
+		c.logger.Info(fmt.Sprintf("Downloading part %d of %d (%s)", i+1, len(manifest.Parts), FormatBytes(part.Size)))
+
+		partHash := md5.New()
+		counter := &countingWriter{w: io.MultiWriter(outFile, fileHash, partHash)}
+		if err := c.downloadDecrypted(ctx, part.Path, counter, opts); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %d of %d parts of %s downloaded", ErrInterrupted, i, len(manifest.Parts), remoteFilePath)
+			}
+			return fmt.Errorf("failed to download part %d: %w", i+1, err)
+		}
+		if got := hex.EncodeToString(partHash.Sum(nil)); counter.n != part.Size || got != part.MD5 {
+			return fmt.Errorf("part %d does not match the manifest: got %d bytes with MD5 %s, expected %d bytes with MD5 %s",
+				i+1, counter.n, got, part.Size, part.MD5)
+		}
+	}
+
+	if got := hex.EncodeToString(fileHash.Sum(nil)); got != manifest.MD5 {
+		return fmt.Errorf("reassembled file MD5 mismatch: expected %s, got %s", manifest.MD5, got)
+	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("failed to close local file: %w", err)
+	}
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		return fmt.Errorf("failed to move reassembled file into place: %w", err)
+	}
+
+	if !opts.NoPreserveTimes && manifest.Mtime > 0 {
+		mtime := time.Unix(manifest.Mtime, 0)
+		if err := os.Chtimes(localPath, mtime, mtime); err != nil {
+			return fmt.Errorf("failed to set modification time on local file: %w", err)
+		}
+	}
+	return nil
+}
+
+// downloadDecrypted streams remotePath into w, decrypting it when opts.Cipher is set
+func (c *Client) downloadDecrypted(ctx context.Context, remotePath string, w io.Writer, opts DownloadOptions) error {
+	var downloadOpts []DownloadOption
+	if opts.NoVerify {
+		downloadOpts = append(downloadOpts, WithoutVerify())
+	}
+
+	if opts.Cipher == nil {
+		_, err := c.DownloadTo(ctx, remotePath, w, downloadOpts...)
+		return err
+	}
+
+	decrypter := opts.Cipher.DecryptWriter(w)
+	if _, err := c.DownloadTo(ctx, remotePath, decrypter, downloadOpts...); err != nil {
+		return err
+	}
+	return decrypter.Close()
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,186 @@
+package pan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APICallStats counts the calls to one API method that ended with one errno
+type APICallStats struct {
+	Method string `json:"method"`
+	Errno  string `json:"errno"` // Empty when the response carried none, "error" when the request failed
+	Calls  int64  `json:"calls"`
+}
+
+// Stats is a snapshot of a client's activity since it was created
+type Stats struct {
+	Started         time.Time        `json:"started"`
+	APICalls        []APICallStats   `json:"api_calls"` // Sorted by method, then errno
+	BytesUp         int64            `json:"bytes_up"`
+	BytesDown       int64            `json:"bytes_down"`
+	Retries         map[string]int64 `json:"retries"` // Retried API calls by reason
+	HashCacheHits   int64            `json:"hash_cache_hits"`
+	HashCacheMisses int64            `json:"hash_cache_misses"`
+}
+
+// TotalAPICalls returns the number of API calls made and how many ended with an error:
+// a failed request or a non-zero errno
+func (s Stats) TotalAPICalls() (calls, errors int64) {
+	for _, call := range s.APICalls {
+		calls += call.Calls
+		if call.Errno != "" && call.Errno != "0" {
+			errors += call.Calls
+		}
+	}
+	return calls, errors
+}
+
+// TotalRetries returns the number of retried API calls
+func (s Stats) TotalRetries() int64 {
+	var retries int64
+	for _, n := range s.Retries {
+		retries += n
+	}
+	return retries
+}
+
+// Line returns a one-line summary of s, for printing periodically during long transfers
+func (s Stats) Line() string {
+	calls, errors := s.TotalAPICalls()
+	elapsed := time.Since(s.Started)
+	line := fmt.Sprintf("%s elapsed | %d API calls (%d errors) | %d retries | up %s | down %s",
+		elapsed.Round(time.Second), calls, errors, s.TotalRetries(), FormatBytes(s.BytesUp), FormatBytes(s.BytesDown))
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		line += fmt.Sprintf(" | %s/s", FormatBytes(int64(float64(s.BytesUp+s.BytesDown)/seconds)))
+	}
+	if lookups := s.HashCacheHits + s.HashCacheMisses; lookups > 0 {
+		line += fmt.Sprintf(" | hash cache %d/%d hits", s.HashCacheHits, lookups)
+	}
+	return line
+}
+
+// FormatStats returns a multi-line report of s with API calls broken down by method and errno
+func FormatStats(s Stats) string {
+	var b strings.Builder
+	b.WriteString(s.Line())
+	b.WriteString("\n")
+	for _, call := range s.APICalls {
+		errno := call.Errno
+		if errno == "" {
+			errno = "-"
+		}
+		fmt.Fprintf(&b, "  %-12s errno %-6s %d\n", call.Method, errno, call.Calls)
+	}
+	reasons := make([]string, 0, len(s.Retries))
+	for reason := range s.Retries {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "  retries (%s): %d\n", reason, s.Retries[reason])
+	}
+	return b.String()
+}
+
+// apiCallKey identifies a counter of API calls
+type apiCallKey struct {
+	method, errno string
+}
+
+// statsCollector accumulates the counters reported by Client.Stats
+type statsCollector struct {
+	mu              sync.Mutex
+	started         time.Time
+	apiCalls        map[apiCallKey]int64
+	bytesUp         int64
+	bytesDown       int64
+	retries         map[string]int64
+	hashCacheHits   int64
+	hashCacheMisses int64
+}
+
+// newStatsCollector returns an empty collector started now
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		started:  time.Now(),
+		apiCalls: make(map[apiCallKey]int64),
+		retries:  make(map[string]int64),
+	}
+}
+
+// Stats returns the client's API calls, transfer volume, retries and hash cache use since
+// it was created. It is safe to call while transfers are running.
+func (c *Client) Stats() Stats {
+	s := c.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		Started:         s.started,
+		BytesUp:         s.bytesUp,
+		BytesDown:       s.bytesDown,
+		Retries:         make(map[string]int64, len(s.retries)),
+		HashCacheHits:   s.hashCacheHits,
+		HashCacheMisses: s.hashCacheMisses,
+	}
+	for key, calls := range s.apiCalls {
+		stats.APICalls = append(stats.APICalls, APICallStats{Method: key.method, Errno: key.errno, Calls: calls})
+	}
+	sort.Slice(stats.APICalls, func(i, j int) bool {
+		a, b := stats.APICalls[i], stats.APICalls[j]
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Errno < b.Errno
+	})
+	for reason, n := range s.retries {
+		stats.Retries[reason] = n
+	}
+	return stats
+}
+
+// observeAPICall counts a completed API call in the client's stats and metrics
+func (c *Client) observeAPICall(method, errno string) {
+	c.stats.mu.Lock()
+	c.stats.apiCalls[apiCallKey{method, errno}]++
+	c.stats.mu.Unlock()
+	c.metrics.observeAPICall(method, errno)
+}
+
+// observeTransfer counts bytes moved in the given direction ("upload" or "download")
+// in the client's stats and metrics
+func (c *Client) observeTransfer(direction string, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	c.stats.mu.Lock()
+	if direction == "upload" {
+		c.stats.bytesUp += bytes
+	} else {
+		c.stats.bytesDown += bytes
+	}
+	c.stats.mu.Unlock()
+	c.metrics.observeTransfer(direction, bytes)
+}
+
+// observeRetry counts a retried API call in the client's stats and metrics
+func (c *Client) observeRetry(reason string) {
+	c.stats.mu.Lock()
+	c.stats.retries[reason]++
+	c.stats.mu.Unlock()
+	c.metrics.observeRetry(reason)
+}
+
+// observeHashCache counts a hash cache lookup
+func (c *Client) observeHashCache(hit bool) {
+	c.stats.mu.Lock()
+	if hit {
+		c.stats.hashCacheHits++
+	} else {
+		c.stats.hashCacheMisses++
+	}
+	c.stats.mu.Unlock()
+}
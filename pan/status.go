@@ -0,0 +1,174 @@
+package pan
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransferStatus is the progress of a file being uploaded or downloaded
+type TransferStatus struct {
+	Direction string    `json:"direction"` // "upload" or "download"
+	Path      string    `json:"path"`      // Remote path
+	Size      int64     `json:"size"`      // 0 if unknown
+	Done      int64     `json:"done"`      // Bytes transferred so far
+	Started   time.Time `json:"started"`
+}
+
+// Speed returns the average speed of the transfer in bytes per second
+func (t TransferStatus) Speed() float64 {
+	if seconds := time.Since(t.Started).Seconds(); seconds > 0 {
+		return float64(t.Done) / seconds
+	}
+	return 0
+}
+
+// ETA returns the estimated time left at the average speed, or 0 when it is unknown
+func (t TransferStatus) ETA() time.Duration {
+	speed := t.Speed()
+	if t.Size <= 0 || speed <= 0 || t.Done >= t.Size {
+		return 0
+	}
+	return time.Duration(float64(t.Size-t.Done) / speed * float64(time.Second))
+}
+
+// Status is a snapshot of what a client is doing: its stats, the files being transferred
+// and the operations queued after them
+type Status struct {
+	Stats     Stats            `json:"stats"`
+	Transfers []TransferStatus `json:"transfers"` // Oldest first
+	Queue     []string         `json:"queue"`     // Waiting operations, next first
+}
+
+// FormatStatus returns a multi-line report of s, for dumping on request during long
+// transfers and syncs
+func FormatStatus(s Status) string {
+	var b strings.Builder
+	b.WriteString(s.Stats.Line())
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Transfers in progress: %d\n", len(s.Transfers))
+	for _, t := range s.Transfers {
+		fmt.Fprintf(&b, "  %-8s %s: %s", t.Direction, t.Path, FormatBytes(t.Done))
+		if t.Size > 0 {
+			fmt.Fprintf(&b, " / %s (%.2f%%)", FormatBytes(t.Size), float64(t.Done)/float64(t.Size)*100)
+		}
+		fmt.Fprintf(&b, " | %s/s", FormatBytes(int64(t.Speed())))
+		if eta := t.ETA(); eta > 0 {
+			fmt.Fprintf(&b, " | ETA %s", eta.Round(time.Second))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Queued: %d\n", len(s.Queue))
+	for _, item := range s.Queue {
+		fmt.Fprintf(&b, "  %s\n", item)
+	}
+	return b.String()
+}
+
+// activeTransfer is a file being transferred, registered with the client's tracker
+type activeTransfer struct {
+	id        int
+	direction string
+	path      string
+	size      int64
+	started   time.Time
+	done      atomic.Int64
+}
+
+// add counts n more bytes transferred; t may be nil
+func (t *activeTransfer) add(n int) {
+	if t != nil {
+		t.done.Add(int64(n))
+	}
+}
+
+// transferTracker holds the transfers in progress and the queued operations reported by
+// Client.Status
+type transferTracker struct {
+	mu     sync.Mutex
+	nextID int
+	active map[int]*activeTransfer
+	queue  []string
+}
+
+// newTransferTracker returns a tracker with nothing in progress
+func newTransferTracker() *transferTracker {
+	return &transferTracker{active: make(map[int]*activeTransfer)}
+}
+
+// Status returns the client's stats, the uploads and downloads in progress with their
+// speed and ETA, and the operations waiting in the queue of DownloadFiles, UploadFiles
+// or a sync. It is safe to call while transfers are running.
+func (c *Client) Status() Status {
+	status := Status{Stats: c.Stats()}
+	tr := c.transfers
+	tr.mu.Lock()
+	for _, t := range tr.active {
+		status.Transfers = append(status.Transfers, TransferStatus{
+			Direction: t.direction,
+			Path:      t.path,
+			Size:      t.size,
+			Done:      t.done.Load(),
+			Started:   t.started,
+		})
+	}
+	status.Queue = slices.Clone(tr.queue)
+	tr.mu.Unlock()
+	sort.Slice(status.Transfers, func(i, j int) bool { return status.Transfers[i].Started.Before(status.Transfers[j].Started) })
+	return status
+}
+
+// beginTransfer registers a transfer of size bytes of the remote path in the given
+// direction; call endTransfer when it is over
+func (c *Client) beginTransfer(direction, path string, size int64) *activeTransfer {
+	tr := c.transfers
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.nextID++
+	t := &activeTransfer{id: tr.nextID, direction: direction, path: path, size: size, started: time.Now()}
+	tr.active[t.id] = t
+	return t
+}
+
+// endTransfer removes t from the transfers in progress
+func (c *Client) endTransfer(t *activeTransfer) {
+	c.transfers.mu.Lock()
+	delete(c.transfers.active, t.id)
+	c.transfers.mu.Unlock()
+}
+
+// setQueue replaces the queued operations reported by Status
+func (c *Client) setQueue(items []string) {
+	c.transfers.mu.Lock()
+	c.transfers.queue = slices.Clone(items)
+	c.transfers.mu.Unlock()
+}
+
+// dequeue removes the first occurrence of item from the queued operations
+func (c *Client) dequeue(item string) {
+	c.transfers.mu.Lock()
+	defer c.transfers.mu.Unlock()
+	if i := slices.Index(c.transfers.queue, item); i >= 0 {
+		c.transfers.queue = slices.Delete(c.transfers.queue, i, i+1)
+	}
+}
+
+// transferKey is the context key of the activeTransfer a download writes its bytes to
+type transferKey struct{}
+
+// withTransfer returns ctx carrying t, so the writers of a download deep in the call
+// chain count their bytes in it
+func withTransfer(ctx context.Context, t *activeTransfer) context.Context {
+	return context.WithValue(ctx, transferKey{}, t)
+}
+
+// transferFrom returns the activeTransfer carried by ctx, or nil
+func transferFrom(ctx context.Context) *activeTransfer {
+	t, _ := ctx.Value(transferKey{}).(*activeTransfer)
+	return t
+}
@@ -0,0 +1,445 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// ErrSyncCancelled is returned by a sync whose deletions SyncOptions.ConfirmDelete refused
+var ErrSyncCancelled = errors.New("sync cancelled")
+
+// SyncOp identifies a single operation planned by a sync
+type SyncOp string
+
+const (
+	SyncUpload       SyncOp = "upload"        // Upload a local file to the remote tree
+	SyncDownload     SyncOp = "download"      // Download a remote file to the local tree
+	SyncDeleteRemote SyncOp = "delete-remote" // Remove a remote file or directory absent locally
+	SyncDeleteLocal  SyncOp = "delete-local"  // Remove a local file or directory absent remotely
+)
+
+// SyncAction is one planned sync operation
+type SyncAction struct {
+	Op         SyncOp
+	RelPath    string // Path relative to the sync roots, slash-separated
+	LocalPath  string
+	RemotePath string
+	Size       int64 // Bytes transferred (or freed, for deletions of files)
+	IsDir      bool
+}
+
+// SyncOptions controls how a sync is planned and executed
+type SyncOptions struct {
+	Delete           bool              // Mirror mode: delete destination entries missing from the source
+	DryRun           bool              // Plan only; do not execute any operation (implied when the client is in dry-run mode)
+	MaxDeletePercent float64           // Abort if deletions exceed this percentage of destination files (0 disables the check)
+	UseIndex         bool              // Read the remote tree from the local index instead of listing it
+	ListWorkers      int               // Remote directories listed concurrently; <= 0 uses DefaultWalkWorkers
+	Filter           *Filter           // Include/exclude rules; excluded paths are neither transferred nor deleted
+	Cipher           *Cipher           // Encrypt uploads and decrypt downloads; remote sizes are compared as plaintext
+	Restart          bool              // Discard the saved plan of an interrupted run and compare the trees again
+	Links            LinkPolicy        // Symbolic links in the local tree; empty follows them
+	Special          SpecialFilePolicy // Sockets, pipes, devices and sparse files in the local tree of SyncUp; empty skips them
+	Compare          CompareMode       // How files present on both sides are compared; empty uses CompareSizeMtime
+	Upload           UploadOptions
+	Download         DownloadOptions
+
+	// ConfirmDelete, if set, is called with the plan before a sync that deletes anything
+	// is executed; returning false cancels the whole sync with ErrSyncCancelled. It is not
+	// called in a dry run or when continuing an interrupted sync.
+	ConfirmDelete func(result *SyncResult) bool
+}
+
+// SyncResult summarizes a sync plan and its execution
+type SyncResult struct {
+	Actions []SyncAction
+	Bytes   int64 // Total bytes to transfer
+	Deleted int   // Number of delete actions
+	Resumed int   // Actions already done by the interrupted run this one continued; Actions holds the rest
+}
+
+// syncEntry describes a file or directory on either side of a sync, keyed by its relative path
+type syncEntry struct {
+	size  int64
+	mtime int64 // Modification time (Unix seconds)
+	isDir bool
+	md5   string // Content MD5 of a remote file, as listed
+}
+
+// SyncUp makes remoteDir match localDir by uploading new or changed files.
+// With opts.Delete, remote entries missing locally are removed.
+func (c *Client) SyncUp(localDir, remoteDir string, opts SyncOptions) (*SyncResult, error) {
+	remoteDir = normalizeRemoteDir(remoteDir)
+	dryRun := opts.DryRun || c.DryRun()
+
+	// Continue an interrupted run from its saved plan rather than comparing the trees again
+	var key []byte
+	if !dryRun && c.resume != nil {
+		key, _ = syncCheckpointKey("up", localDir, remoteDir)
+		if result := c.resumeSync(key, opts); result != nil {
+			return result, c.executeSync(result, opts, key)
+		}
+	}
+
+	local, err := localTree(localDir, c.localWalk(opts.Links, opts.Special))
+	if err != nil {
+		return nil, err
+	}
+	remote, err := c.remoteTree(remoteDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	local, remote = filterTree(local, opts.Filter), filterTree(remote, opts.Filter)
+
+	same := c.syncComparer(localDir, opts)
+	result := planSync(local, remote, opts.Delete, same, func(rel string, src syncEntry, delete bool) SyncAction {
+		action := SyncAction{
+			LocalPath:  filepath.Join(localDir, filepath.FromSlash(rel)),
+			RemotePath: path.Join(remoteDir, rel),
+			Size:       src.size,
+			IsDir:      src.isDir,
+			Op:         SyncUpload,
+		}
+		if delete {
+			action.Op = SyncDeleteRemote
+		}
+		return action
+	})
+
+	if err := checkDeleteThreshold(result, remote, opts.MaxDeletePercent); err != nil {
+		return result, err
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := confirmDeletes(result, opts); err != nil {
+		return result, err
+	}
+	return result, c.executeSync(result, opts, key)
+}
+
+// SyncDown makes localDir match remoteDir by downloading new or changed files.
+// With opts.Delete, local entries missing remotely are removed.
+func (c *Client) SyncDown(remoteDir, localDir string, opts SyncOptions) (*SyncResult, error) {
+	remoteDir = normalizeRemoteDir(remoteDir)
+	dryRun := opts.DryRun || c.DryRun()
+
+	var key []byte
+	if !dryRun && c.resume != nil {
+		key, _ = syncCheckpointKey("down", localDir, remoteDir)
+		if result := c.resumeSync(key, opts); result != nil {
+			return result, c.executeSync(result, opts, key)
+		}
+	}
+
+	remote, err := c.remoteTree(remoteDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Sparse local files are compared like any other; sockets, pipes and devices are left alone
+	local, err := localTree(localDir, LocalWalkOptions{Links: opts.Links, Special: SpecialUpload})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	remote, local = filterTree(remote, opts.Filter), filterTree(local, opts.Filter)
+
+	compare := c.syncComparer(localDir, opts)
+	same := func(rel string, src, dst syncEntry) bool { return compare(rel, dst, src) }
+	result := planSync(remote, local, opts.Delete, same, func(rel string, src syncEntry, delete bool) SyncAction {
+		action := SyncAction{
+			LocalPath:  filepath.Join(localDir, filepath.FromSlash(rel)),
+			RemotePath: path.Join(remoteDir, rel),
+			Size:       src.size,
+			IsDir:      src.isDir,
+			Op:         SyncDownload,
+		}
+		if delete {
+			action.Op = SyncDeleteLocal
+		}
+		return action
+	})
+
+	if err := checkDeleteThreshold(result, local, opts.MaxDeletePercent); err != nil {
+		return result, err
+	}
+	if dryRun {
+		return result, nil
+	}
+	if err := confirmDeletes(result, opts); err != nil {
+		return result, err
+	}
+	return result, c.executeSync(result, opts, key)
+}
+
+// confirmDeletes asks opts.ConfirmDelete, if set, whether the deletions planned in result
+// may go ahead
+func confirmDeletes(result *SyncResult, opts SyncOptions) error {
+	if opts.ConfirmDelete == nil || result.Deleted == 0 || opts.ConfirmDelete(result) {
+		return nil
+	}
+	return ErrSyncCancelled
+}
+
+// planSync compares source and destination trees and builds the list of actions.
+// Files missing at the destination, or not the same there according to same, are
+// transferred; with mirror set, destination entries missing from the source are deleted
+// (a deleted directory covers its subtree).
+func planSync(src, dst map[string]syncEntry, mirror bool, same func(rel string, src, dst syncEntry) bool,
+	makeAction func(rel string, entry syncEntry, delete bool) SyncAction) *SyncResult {
+	result := &SyncResult{}
+
+	for _, rel := range sortedKeys(src) {
+		entry := src[rel]
+		if entry.isDir {
+			continue
+		}
+		if existing, ok := dst[rel]; ok && !existing.isDir && same(rel, entry, existing) {
+			continue
+		}
+		action := makeAction(rel, entry, false)
+		action.RelPath = rel
+		result.Actions = append(result.Actions, action)
+		result.Bytes += action.Size
+	}
+
+	if mirror {
+		var deletedDirs []string
+		for _, rel := range sortedKeys(dst) {
+			if _, ok := src[rel]; ok {
+				continue
+			}
+			if isBeneathAnyOf(rel, deletedDirs) {
+				continue
+			}
+			entry := dst[rel]
+			action := makeAction(rel, entry, true)
+			action.RelPath = rel
+			result.Actions = append(result.Actions, action)
+			result.Deleted++
+			if entry.isDir {
+				deletedDirs = append(deletedDirs, rel)
+			}
+		}
+	}
+
+	return result
+}
+
+// checkDeleteThreshold aborts a mirror whose deletions exceed maxPercent of the destination files
+func checkDeleteThreshold(result *SyncResult, dst map[string]syncEntry, maxPercent float64) error {
+	if maxPercent <= 0 || result.Deleted == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, entry := range dst {
+		if !entry.isDir {
+			total++
+		}
+	}
+
+	deletedFiles := 0
+	for _, action := range result.Actions {
+		if action.Op != SyncDeleteRemote && action.Op != SyncDeleteLocal {
+			continue
+		}
+		if !action.IsDir {
+			deletedFiles++
+			continue
+		}
+		// A deleted directory removes every file beneath it
+		prefix := action.RelPath + "/"
+		for rel, entry := range dst {
+			if !entry.isDir && strings.HasPrefix(rel, prefix) {
+				deletedFiles++
+			}
+		}
+	}
+
+	if total > 0 && float64(deletedFiles)/float64(total)*100 > maxPercent {
+		return fmt.Errorf("refusing to delete %d of %d files (more than %.0f%%); rerun with a higher --max-delete to proceed", deletedFiles, total, maxPercent)
+	}
+	return nil
+}
+
+// executeSync performs the planned actions in order. When the client's context is
+// cancelled it stops before the next action, returning an error wrapping ErrInterrupted.
+// With a resume store, the plan is saved under key and each completed action recorded,
+// so running the sync again continues with what is left.
+func (c *Client) executeSync(result *SyncResult, opts SyncOptions, key []byte) error {
+	if opts.Cipher != nil {
+		opts.Upload.Cipher = opts.Cipher
+		opts.Download.Cipher = opts.Cipher
+	}
+
+	ctx := c.context()
+	queued := make([]string, len(result.Actions))
+	for i, action := range result.Actions {
+		queued[i] = syncQueueItem(action)
+	}
+	c.setQueue(queued)
+	defer c.setQueue(nil)
+
+	if key != nil && result.Resumed == 0 && len(result.Actions) > 0 {
+		c.saveSyncCheckpoint(key, result, opts)
+	}
+
+	var remoteDeletes []string
+	for i, action := range result.Actions {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %d of %d operations done", ErrInterrupted, i, len(result.Actions))
+		}
+		c.dequeue(queued[i])
+		switch action.Op {
+		case SyncUpload:
+			if err := c.UploadFileWithOptions(action.LocalPath, action.RemotePath, opts.Upload); err != nil {
+				return fmt.Errorf("failed to upload %s: %w", action.LocalPath, err)
+			}
+		case SyncDownload:
+			if err := c.DownloadFileToPathWithOptions(action.RemotePath, action.LocalPath, opts.Download); err != nil {
+				return fmt.Errorf("failed to download %s: %w", action.RemotePath, err)
+			}
+		case SyncDeleteRemote:
+			// Removed in one batch at the end, so not recorded as done yet
+			remoteDeletes = append(remoteDeletes, action.RemotePath)
+			continue
+		case SyncDeleteLocal:
+			if err := os.RemoveAll(action.LocalPath); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", action.LocalPath, err)
+			}
+		}
+		if key != nil {
+			c.saveSyncProgress(key, result.Resumed+i+1)
+		}
+	}
+
+	if len(remoteDeletes) > 0 {
+		if err := c.RemoveFiles(remoteDeletes); err != nil {
+			return err
+		}
+	}
+	if key != nil {
+		c.forgetSyncCheckpoint(key)
+	}
+	return nil
+}
+
+// syncQueueItem describes action in the queue reported by Status
+func syncQueueItem(action SyncAction) string {
+	if action.Size > 0 && !action.IsDir {
+		return fmt.Sprintf("%s %s (%s)", action.Op, action.RelPath, FormatBytes(action.Size))
+	}
+	return fmt.Sprintf("%s %s", action.Op, action.RelPath)
+}
+
+// localTree lists every file and directory beneath root keyed by slash-separated relative
+// path, handling symbolic links and special files according to walk
+func localTree(root string, walk LocalWalkOptions) (map[string]syncEntry, error) {
+	tree := make(map[string]syncEntry)
+	err := walkLocal(root, walk, func(_, rel string, info fs.FileInfo) error {
+		tree[rel] = syncEntry{size: info.Size(), mtime: info.ModTime().Unix(), isDir: info.IsDir()}
+		return nil
+	})
+	if err != nil {
+		return tree, err
+	}
+	return tree, nil
+}
+
+// remoteTree lists every file and directory beneath root keyed by relative path,
+// reading from the local index when opts.UseIndex is set and an index is configured
+func (c *Client) remoteTree(root string, opts SyncOptions) (map[string]syncEntry, error) {
+	tree := make(map[string]syncEntry)
+	add := func(p string, size, mtime int64, isDir bool, md5 string) {
+		// Compare encrypted remote files by their plaintext size
+		if opts.Cipher != nil && !isDir {
+			if plainSize, err := opts.Cipher.DecryptedSize(size); err == nil {
+				size = plainSize
+			} else {
+				size = -1 // Not encrypted with this layout; never equal to a local size
+			}
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if rel != "" {
+			tree[rel] = syncEntry{size: size, mtime: mtime, isDir: isDir, md5: md5}
+		}
+	}
+
+	if opts.UseIndex && c.index != nil {
+		// An index kept up to date incrementally only needs the changes since its last update
+		if cursor, err := c.index.Cursor(); err == nil && cursor != "" {
+			if _, err := c.UpdateIndex(c.index); err != nil {
+				c.logger.Warn("Failed to update local index", "error", err)
+			}
+		}
+		err := c.index.Walk(root, func(entry IndexEntry) error {
+			mtime := entry.Mtime
+			if entry.LocalMtime > 0 {
+				mtime = entry.LocalMtime
+			}
+			add(entry.Path, entry.Size, mtime, entry.IsDir, entry.MD5)
+			return nil
+		})
+		return tree, err
+	}
+
+	var listed []FileInfo
+	walkOpts := WalkOptions{Workers: opts.ListWorkers}
+	walkErr := c.WalkParallel(c.context(), root, walkOpts, func(file FileInfo, err error) error {
+		if err != nil {
+			// A missing destination directory simply means nothing exists remotely yet, but
+			// any subdirectory that fails to list would leave the tree incomplete
+			if file.Path == normalizeRemoteDir(root) && isNotFoundError(err) {
+				return fs.SkipAll
+			}
+			return fmt.Errorf("failed to list %s: %w", file.Path, err)
+		}
+		add(file.Path, file.Size, file.ModTime().Unix(), file.IsDir == 1, file.MD5)
+		listed = append(listed, file)
+		return nil
+	})
+
+	// Refresh the index with what was just listed
+	if c.index != nil && len(listed) > 0 {
+		if err := c.index.PutFileInfo(listed...); err != nil {
+			c.logger.Warn("Failed to update local index", "error", err)
+		}
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	return tree, nil
+}
+
+// isNotFoundError reports whether err came from listing a directory that does not exist (errno -9)
+func isNotFoundError(err error) bool {
+	return errno.Is(err, -9)
+}
+
+// isBeneathAnyOf reports whether rel lies beneath any of the relative directories in dirs
+func isBeneathAnyOf(rel string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of tree in lexical order
+func sortedKeys(tree map[string]syncEntry) []string {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
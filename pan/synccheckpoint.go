@@ -0,0 +1,109 @@
+package pan
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// syncResumeTTL is how long after its last progress an interrupted sync is continued
+// from its saved plan; after that the trees are listed and compared again
+const syncResumeTTL = 24 * time.Hour
+
+// syncCheckpoint is the plan of a sync, saved before it starts so an interrupted run
+// can be continued without listing and comparing the trees again
+type syncCheckpoint struct {
+	Options string       `json:"options"` // The options that shaped the plan, see syncFingerprint
+	Actions []SyncAction `json:"actions"`
+	Created time.Time    `json:"created"`
+}
+
+// syncProgress records how many actions of a saved plan are done
+type syncProgress struct {
+	Done    int       `json:"done"`
+	Updated time.Time `json:"updated"`
+}
+
+// syncCheckpointKey returns the key of the sync in direction ("up" or "down") between
+// localDir and remoteDir
+func syncCheckpointKey(direction, localDir, remoteDir string) ([]byte, error) {
+	abs, err := filepath.Abs(localDir)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(direction + "\x00" + abs + "\x00" + remoteDir), nil
+}
+
+// syncFingerprint describes the options that change what a sync plans
+func syncFingerprint(opts SyncOptions) string {
+	return fmt.Sprintf("delete=%t crypt=%t links=%s special=%s compare=%s\n%s",
+		opts.Delete, opts.Cipher != nil, opts.Links, opts.Special, opts.Compare, opts.Filter.fingerprint())
+}
+
+// resumeSync returns what is left of the saved plan of the sync under key, with
+// Resumed set to the number of actions already done, or nil when there is none to
+// continue: no resume store, opts.Restart, other options or a plan too old
+func (c *Client) resumeSync(key []byte, opts SyncOptions) *SyncResult {
+	if c.resume == nil || key == nil {
+		return nil
+	}
+	if opts.Restart {
+		c.forgetSyncCheckpoint(key)
+		return nil
+	}
+
+	var checkpoint syncCheckpoint
+	if found, err := c.resume.get(resumeSyncsBucket, key, &checkpoint); err != nil || !found {
+		return nil
+	}
+	var progress syncProgress
+	if _, err := c.resume.get(resumeSyncProgressBucket, key, &progress); err != nil {
+		progress = syncProgress{}
+	}
+	updated := checkpoint.Created
+	if progress.Updated.After(updated) {
+		updated = progress.Updated
+	}
+	if checkpoint.Options != syncFingerprint(opts) || time.Since(updated) > syncResumeTTL ||
+		progress.Done < 0 || progress.Done > len(checkpoint.Actions) {
+		c.forgetSyncCheckpoint(key)
+		return nil
+	}
+
+	result := &SyncResult{Actions: checkpoint.Actions[progress.Done:], Resumed: progress.Done}
+	for _, action := range result.Actions {
+		switch action.Op {
+		case SyncUpload, SyncDownload:
+			result.Bytes += action.Size
+		default:
+			result.Deleted++
+		}
+	}
+	return result
+}
+
+// saveSyncCheckpoint stores the plan of the sync under key, logging rather than failing
+// the sync on error
+func (c *Client) saveSyncCheckpoint(key []byte, result *SyncResult, opts SyncOptions) {
+	checkpoint := syncCheckpoint{Options: syncFingerprint(opts), Actions: result.Actions, Created: time.Now()}
+	err := c.resume.put(resumeSyncsBucket, key, checkpoint)
+	if err == nil {
+		err = c.resume.put(resumeSyncProgressBucket, key, syncProgress{Updated: checkpoint.Created})
+	}
+	if err != nil {
+		c.logger.Warn("Failed to save sync checkpoint", "error", err)
+	}
+}
+
+// saveSyncProgress records that the first done actions of the saved plan under key are done
+func (c *Client) saveSyncProgress(key []byte, done int) {
+	if err := c.resume.put(resumeSyncProgressBucket, key, syncProgress{Done: done, Updated: time.Now()}); err != nil {
+		c.logger.Warn("Failed to save sync progress", "error", err)
+	}
+}
+
+// forgetSyncCheckpoint deletes the saved plan and progress of the sync under key
+func (c *Client) forgetSyncCheckpoint(key []byte) {
+	c.resume.remove(resumeSyncsBucket, key)
+	c.resume.remove(resumeSyncProgressBucket, key)
+}
@@ -0,0 +1,299 @@
+package pan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+// taskQueryURL is the endpoint reporting the state of an asynchronous file manager task
+const taskQueryURL = "https://pan.baidu.com/share/taskquery"
+
+// Delays between queries of a running task, doubling from the first to the last
+const (
+	taskPollMinInterval = 250 * time.Millisecond
+	taskPollMaxInterval = 2 * time.Second
+)
+
+// Task states reported by QueryTask
+const (
+	TaskPending = "pending"
+	TaskRunning = "running"
+	TaskSuccess = "success"
+	TaskFailed  = "failed"
+)
+
+// TaskEntry is an entry of a finished file manager task
+type TaskEntry struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Errno int    `json:"errno"` // 0 when the entry was carried out
+}
+
+// Err returns nil for an entry carried out, otherwise its errno
+func (e TaskEntry) Err() error {
+	if e.Errno == 0 {
+		return nil
+	}
+	return &errno.Error{API: "task", Code: e.Errno}
+}
+
+// Task is the state of an asynchronous file manager task
+type Task struct {
+	ID        int64       `json:"-"`
+	Status    string      `json:"status"`     // TaskPending, TaskRunning, TaskSuccess or TaskFailed
+	TaskErrno int         `json:"task_errno"` // Why the task failed
+	Progress  int         `json:"progress"`   // Percentage done while running, if reported
+	List      []TaskEntry `json:"list"`       // The entries of a finished task
+}
+
+// Finished reports whether the task succeeded or failed
+func (t *Task) Finished() bool {
+	return t.Status == TaskSuccess || t.Status == TaskFailed
+}
+
+// Err returns nil for a task that succeeded with every entry, otherwise the errno of the
+// task or of its first failed entry
+func (t *Task) Err() error {
+	if t.Status == TaskFailed {
+		code := t.TaskErrno
+		if code == 0 {
+			code = -1
+		}
+		return &errno.Error{API: "task", Code: code}
+	}
+	for _, entry := range t.List {
+		if err := entry.Err(); err != nil {
+			return fmt.Errorf("%s: %w", entry.From, err)
+		}
+	}
+	return nil
+}
+
+// entryErrnos returns the errno of the entry of the finished task copying, moving or
+// deleting each of paths, 0 for those carried out. A failed task lists the entries it
+// carried out before failing, if any; the others get its errno.
+func (t *Task) entryErrnos(paths []string) []int {
+	outcome := make(map[string]int, len(t.List))
+	for _, entry := range t.List {
+		outcome[entry.From] = entry.Errno
+	}
+	codes := make([]int, len(paths))
+	for i, p := range paths {
+		code, listed := outcome[p]
+		if t.Status == TaskFailed && !listed {
+			if code = t.TaskErrno; code == 0 {
+				code = -1
+			}
+		}
+		codes[i] = code
+	}
+	return codes
+}
+
+// taskQueryResponse is the response of the task query API
+type taskQueryResponse struct {
+	Task
+	Errno     int   `json:"errno"`
+	RequestID int64 `json:"request_id"`
+}
+
+// fileTaskInfo is the outcome of an entry the file manager carried out at once
+type fileTaskInfo struct {
+	Path  string `json:"path"`
+	Errno int    `json:"errno"`
+}
+
+// fileTaskResponse is the response of the file manager to an asynchronous request
+type fileTaskResponse struct {
+	Errno     int            `json:"errno"`
+	Info      []fileTaskInfo `json:"info"`
+	TaskID    int64          `json:"taskid"` // 0 when the server carried the request out at once
+	RequestID int64          `json:"request_id"`
+}
+
+// StartCopyTask submits the copies as an asynchronous file manager task and returns its
+// id at once, for QueryTask or WaitTask; see StartRemoveTask
+func (c *Client) StartCopyTask(copyRequests []CopyRequest) (int64, error) {
+	if c.DryRun() {
+		return 0, c.CopyFiles(copyRequests)
+	}
+	paths := make([]string, len(copyRequests))
+	for i, req := range copyRequests {
+		paths[i] = req.Path
+	}
+	return c.startTask("copy", copyRequests, paths)
+}
+
+// StartMoveTask submits the moves as an asynchronous file manager task and returns its
+// id at once, for QueryTask or WaitTask; see StartRemoveTask
+func (c *Client) StartMoveTask(moveRequests []MoveRequest) (int64, error) {
+	if c.DryRun() {
+		return 0, c.MoveFiles(moveRequests)
+	}
+	paths := make([]string, len(moveRequests))
+	for i, req := range moveRequests {
+		paths[i] = req.Path
+	}
+	return c.startTask("move", moveRequests, paths)
+}
+
+// StartRemoveTask submits the deletion of filePaths as an asynchronous file manager task
+// and returns its id at once, for QueryTask or WaitTask. The id is 0 when the server
+// carried the request out at once, or in dry-run mode; entries that failed then make
+// the error a *BatchError.
+func (c *Client) StartRemoveTask(filePaths []string) (int64, error) {
+	if c.DryRun() {
+		return 0, c.RemoveFiles(filePaths)
+	}
+	return c.startTask("delete", filePaths, filePaths)
+}
+
+// startTask submits filelist, whose entries have the source paths paths, as a task
+func (c *Client) startTask(opera string, filelist any, paths []string) (int64, error) {
+	if len(paths) == 0 {
+		return 0, fmt.Errorf("no files specified for %s operation", opera)
+	}
+	response, err := c.submitFileTask(c.context(), opera, filelist)
+	if err != nil {
+		return 0, err
+	}
+	if response.TaskID != 0 {
+		return response.TaskID, nil
+	}
+
+	var failures []FileOpFailure
+	for i, info := range response.Info {
+		if info.Errno != 0 && i < len(paths) {
+			failures = append(failures, FileOpFailure{Index: i, Path: paths[i], Err: &errno.Error{API: opera, Code: info.Errno}})
+		}
+	}
+	if len(failures) > 0 {
+		return 0, &BatchError{Op: opera, Failures: failures}
+	}
+	return 0, nil
+}
+
+// submitFileTask sends filelist to the file manager operation opera ("copy", "move" or
+// "delete") as an asynchronous task. An error means the whole request failed.
+func (c *Client) submitFileTask(ctx context.Context, opera string, filelist any) (*fileTaskResponse, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+	list, err := json.Marshal(filelist)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s requests to JSON: %w", opera, err)
+	}
+
+	params := url.Values{}
+	params.Add("method", "filemanager")
+	params.Add("access_token", c.getAccessToken())
+	params.Add("opera", opera)
+	params.Add("async", "2") // Always run as a task, however small the batch
+	params.Add("channel", "chunlei")
+	params.Add("web", "1")
+	params.Add("app_id", "250528")
+	params.Add("bdstoken", c.getAccessToken())
+
+	formData := url.Values{}
+	formData.Add("filelist", string(list))
+	if opera != "delete" {
+		formData.Add("ondup", "newcopy")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(fileManagerURL)+"?"+params.Encode(), strings.NewReader(formData.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", opera, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var response fileTaskResponse
+	if err := c.doJSON(req, opera, &response); err != nil {
+		return nil, err
+	}
+	if batchFailed(response.Errno, len(response.Info)) {
+		return nil, &errno.Error{API: opera, Code: response.Errno}
+	}
+	return &response, nil
+}
+
+// QueryTask returns the current state of the asynchronous file manager task id
+func (c *Client) QueryTask(ctx context.Context, id int64) (*Task, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	params := url.Values{}
+	params.Add("access_token", c.getAccessToken())
+	params.Add("taskid", strconv.FormatInt(id, 10))
+	params.Add("channel", "chunlei")
+	params.Add("web", "1")
+	params.Add("app_id", "250528")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint(taskQueryURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response taskQueryResponse
+	if err := c.doJSON(req, "taskquery", &response); err != nil {
+		return nil, fmt.Errorf("failed to query task %d: %w", id, err)
+	}
+	if response.Errno != 0 {
+		return nil, &errno.Error{API: "taskquery", Code: response.Errno}
+	}
+	task := response.Task
+	task.ID = id
+	return &task, nil
+}
+
+// WaitTask polls the asynchronous file manager task id until it succeeds or fails and
+// returns its final state; check it with Task.Err. When ctx is cancelled first the error
+// wraps ErrInterrupted, and the task keeps running on the server.
+func (c *Client) WaitTask(ctx context.Context, id int64) (*Task, error) {
+	delay := taskPollMinInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: task %d keeps running on the server", ErrInterrupted, id)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, taskPollMaxInterval)
+
+		task, err := c.QueryTask(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if task.Finished() {
+			return task, nil
+		}
+	}
+}
+
+// doJSON sends req and decodes its JSON response into v, naming api in errors
+func (c *Client) doJSON(req *http.Request, api string, v any) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", api, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", api, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s request failed with status %d: %s", api, resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s response: %w", api, err)
+	}
+	return nil
+}
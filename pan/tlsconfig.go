@@ -0,0 +1,62 @@
+package pan
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSFiles names the files and settings LoadTLSConfig builds a TLS configuration from.
+// Empty fields keep Go's defaults.
+type TLSFiles struct {
+	CAFile         string // PEM bundle of extra root CAs, e.g. of a TLS-intercepting proxy
+	ClientCertFile string // PEM client certificate, for proxies requiring one
+	ClientKeyFile  string // PEM private key of ClientCertFile
+	MinVersion     string // Lowest TLS version accepted: "1.2" or "1.3"
+}
+
+// LoadTLSConfig returns the TLS configuration of files for TransportOptions.TLSConfig,
+// or nil when files sets nothing. The CAs of CAFile are trusted in addition to the
+// system's.
+func LoadTLSConfig(files TLSFiles) (*tls.Config, error) {
+	if files == (TLSFiles{}) {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	switch files.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		config.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported TLS version %q (expected 1.2 or 1.3)", files.MinVersion)
+	}
+
+	if files.CAFile != "" {
+		pem, err := os.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", files.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if files.ClientCertFile != "" || files.ClientKeyFile != "" {
+		if files.ClientCertFile == "" || files.ClientKeyFile == "" {
+			return nil, fmt.Errorf("a client certificate needs both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(files.ClientCertFile, files.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
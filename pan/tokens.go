@@ -0,0 +1,105 @@
+package pan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTokens makes the client use accessToken instead of the token file, which is then
+// neither read nor written, so CI pipelines and containers can run without the device
+// code flow using secrets injected at runtime. When accessToken is empty, refreshToken
+// is exchanged for one by Authorize; tokens refreshed later are kept in memory only.
+func WithTokens(accessToken, refreshToken string) Option {
+	return func(c *Client) {
+		c.accessToken = accessToken
+		c.refreshToken = refreshToken
+		c.tokensInjected = true
+	}
+}
+
+// TokensInjected reports whether the client's tokens were given with WithTokens rather
+// than loaded from the token file
+func (c *Client) TokensInjected() bool {
+	return c.tokensInjected
+}
+
+// authorizeInjected makes sure the client has an access token given with WithTokens,
+// refreshing one from the injected refresh token if needed. It never falls back to the
+// device code flow, which nobody could complete in an unattended run.
+func (c *Client) authorizeInjected() error {
+	c.mu.RLock()
+	accessToken, refreshToken := c.accessToken, c.refreshToken
+	c.mu.RUnlock()
+
+	switch {
+	case accessToken != "":
+		c.logger.Info("Using the injected access token")
+		return nil
+	case refreshToken == "":
+		return ErrNotAuthorized
+	}
+	c.logger.Info("Exchanging the injected refresh token for an access token...")
+	if err := c.RefreshToken(); err != nil {
+		return fmt.Errorf("%w: refreshing the injected token failed: %w", ErrNotAuthorized, err)
+	}
+	return nil
+}
+
+// ErrTokenExpired is returned by Authorize in static-token mode once the access token has
+// expired, as it is never refreshed
+var ErrTokenExpired = errors.New("the access token has expired")
+
+// errStaticToken is returned by RefreshToken in static-token mode
+var errStaticToken = errors.New("static-token mode: the access token is never refreshed")
+
+// WithStaticToken uses the access token as it is, from the token file or WithTokens, and
+// never refreshes it or writes the token file, so one long-lived token, e.g. a read-only
+// one, can be distributed to many machines. Authorize never starts the device code flow,
+// and fails with an error wrapping ErrTokenExpired once the token has expired.
+func WithStaticToken() Option {
+	return func(c *Client) {
+		c.staticToken = true
+	}
+}
+
+// StaticToken reports whether the client is in static-token mode
+func (c *Client) StaticToken() bool {
+	return c.staticToken
+}
+
+// authorizeStatic makes sure the client has an access token that has not expired, without
+// refreshing it
+func (c *Client) authorizeStatic() error {
+	if err := c.LoadTokens(); err != nil {
+		return fmt.Errorf("%w: static-token mode needs a token: %w", ErrNotAuthorized, err)
+	}
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
+	}
+
+	expires, known := c.tokenExpiry()
+	switch {
+	case !known:
+		c.logger.Info("Using the static access token")
+	case expires.Before(time.Now()):
+		return fmt.Errorf("%w at %s; static-token mode does not refresh it, so replace the token",
+			ErrTokenExpired, expires.Format(time.DateTime))
+	case expires.Before(time.Now().Add(48 * time.Hour)):
+		c.logger.Warn(fmt.Sprintf("The static access token expires at %s; replace it before then", expires.Format(time.DateTime)))
+	default:
+		c.logger.Info(fmt.Sprintf("Using the static access token, which expires at %s", expires.Format(time.DateTime)))
+	}
+	return nil
+}
+
+// tokenExpiry returns when the access token expires, and false when that is unknown, as
+// for tokens given with WithTokens
+func (c *Client) tokenExpiry() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.tokenCreatedAt.IsZero() || c.expiresIn == 0 {
+		return time.Time{}, false
+	}
+	return c.tokenCreatedAt.Add(time.Duration(c.expiresIn) * time.Second), true
+}
@@ -0,0 +1,80 @@
+package pan_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+func TestRefreshTokenConcurrent(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	s.WriteFile("/dir/a", []byte("a"))
+
+	// The client starts with an expired token, which the refresh replaces
+	tokenPath := filepath.Join(t.TempDir(), "token.json")
+	data, err := json.Marshal(pan.TokenFile{
+		AccessToken:  "expired-access-token",
+		RefreshToken: s.RefreshToken,
+		ExpiresIn:    2592000,
+		CreatedAt:    time.Now().Add(-31 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tokenPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := pan.NewClient("pantest-client-id", "pantest-client-secret", tokenPath,
+		pan.WithTransport(s.Transport()), pan.WithThrottleRetry(0, 0))
+	if err := c.LoadTokens(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsTokenExpired() {
+		t.Fatal("token loaded from the file is not expired")
+	}
+
+	// Requests racing with the refreshes either see the old token or the new one
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := c.RefreshToken(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.ListFiles("/dir")
+			c.IsTokenExpired()
+		}()
+	}
+	wg.Wait()
+
+	if c.IsTokenExpired() {
+		t.Error("token is still expired after refreshing")
+	}
+	files, err := c.ListFiles("/dir")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("listing with the refreshed token returned %d files, %v", len(files), err)
+	}
+
+	var saved pan.TokenFile
+	data, err = os.ReadFile(tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("token file is not valid after concurrent refreshes: %v", err)
+	}
+	if saved.AccessToken != s.AccessToken || saved.RefreshToken != s.RefreshToken {
+		t.Errorf("token file holds %q and %q, want %q and %q", saved.AccessToken, saved.RefreshToken, s.AccessToken, s.RefreshToken)
+	}
+}
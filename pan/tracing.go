@@ -0,0 +1,49 @@
+package pan
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName identifies spans created by this package
+const tracerName = "github.com/baowuhe/go-bdfs/pan"
+
+// WithTracerProvider enables OpenTelemetry spans around API operations using tp
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// defaultTracer returns a tracer that records nothing
+func defaultTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// startSpan starts a client span for an API operation
+func (c *Client) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, "bdfs."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// setAPIResult records the errno and request_id returned by Baidu Pan on the span
+func setAPIResult(span trace.Span, errno int, requestID int64) {
+	span.SetAttributes(attribute.Int("bdfs.errno", errno))
+	if requestID != 0 {
+		span.SetAttributes(attribute.Int64("bdfs.request_id", requestID))
+	}
+}
+
+// endSpan marks the span as failed when err is non-nil and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
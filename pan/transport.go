@@ -0,0 +1,157 @@
+package pan
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is how many idle connections to each host a client keeps
+// for reuse unless tuned. Go's default of two would make parallel slice uploads and
+// API workers open a new connection for almost every request.
+const DefaultMaxIdleConnsPerHost = 16
+
+// Defaults of the dialer of http.DefaultTransport
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// IPFamily selects the IP version connections are made over
+type IPFamily int
+
+const (
+	IPAny IPFamily = iota // Whichever the resolver returns, IPv6 and IPv4 raced
+	IPv4
+	IPv6
+)
+
+// ParseIPFamily parses "4", "ipv4", "6", "ipv6", or "any" and "" for either
+func ParseIPFamily(s string) (IPFamily, error) {
+	switch strings.ToLower(s) {
+	case "", "any":
+		return IPAny, nil
+	case "4", "ipv4":
+		return IPv4, nil
+	case "6", "ipv6":
+		return IPv6, nil
+	}
+	return IPAny, fmt.Errorf("invalid IP family %q (expected 4, 6 or any)", s)
+}
+
+// TransportOptions tunes the HTTP connections of a client, for high-latency links or
+// many parallel transfers. Zero fields keep the defaults of http.DefaultTransport,
+// except MaxIdleConnsPerHost.
+type TransportOptions struct {
+	MaxIdleConnsPerHost   int           // Idle connections kept per host (default: DefaultMaxIdleConnsPerHost)
+	IdleConnTimeout       time.Duration // How long an idle connection is kept for reuse
+	KeepAlive             time.Duration // Interval of TCP keep-alive probes; negative disables them
+	DisableKeepAlives     bool          // Use a new connection for every request
+	TLSHandshakeTimeout   time.Duration // Longest TLS handshake
+	ResponseHeaderTimeout time.Duration // Longest wait for response headers once a request is sent
+
+	IPFamily  IPFamily            // Connect only over IPv4 or IPv6
+	Hosts     map[string][]string // IPs to connect to for a host name instead of resolving it, tried in order
+	DNSServer string              // host:port of the DNS server resolving host names, instead of the system's
+
+	TLSConfig *tls.Config // Trusted CAs, client certificate and TLS version, e.g. from LoadTLSConfig
+}
+
+// ValidateHosts checks that every pinned address of hosts is an IP address
+func ValidateHosts(hosts map[string][]string) error {
+	for host, ips := range hosts {
+		if len(ips) == 0 {
+			return fmt.Errorf("no IP addresses for host %s", host)
+		}
+		for _, ip := range ips {
+			if net.ParseIP(ip) == nil {
+				return fmt.Errorf("invalid IP address %q for host %s", ip, host)
+			}
+		}
+	}
+	return nil
+}
+
+// NewTransport returns a copy of http.DefaultTransport with opts applied
+func NewTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.KeepAlive != 0 || opts.IPFamily != IPAny || len(opts.Hosts) > 0 || opts.DNSServer != "" {
+		transport.DialContext = opts.dialContext()
+	}
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	if opts.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	}
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig.Clone()
+	}
+	return transport
+}
+
+// dialContext returns a dial function connecting over opts.IPFamily, to the pinned
+// addresses of opts.Hosts and resolving other names with opts.DNSServer
+func (opts TransportOptions) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}
+	if opts.KeepAlive != 0 {
+		dialer.KeepAlive = opts.KeepAlive
+	}
+	if opts.DNSServer != "" {
+		server := opts.DNSServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		switch opts.IPFamily {
+		case IPv4:
+			network = "tcp4"
+		case IPv6:
+			network = "tcp6"
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		ips := opts.Hosts[host]
+		if err != nil || len(ips) == 0 {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		// TLS still verifies the certificate against the host name of the request
+		for _, ip := range ips {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
+
+// WithTransportOptions sends every request through a transport tuned with opts
+func WithTransportOptions(opts TransportOptions) Option {
+	return WithTransport(NewTransport(opts))
+}
@@ -0,0 +1,219 @@
+package pan
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUploadTransfers is the number of files UploadFiles uploads concurrently by default
+const DefaultUploadTransfers = 4
+
+// UploadJob is one file for UploadFiles to upload
+type UploadJob struct {
+	Local  string
+	Remote string
+	Size   int64 // Used for the overall progress; 0 if unknown
+}
+
+// UploadFilesOptions controls UploadFiles; the zero value uses the defaults
+type UploadFilesOptions struct {
+	Transfers int           // Files uploaded concurrently; 0 uses DefaultUploadTransfers
+	Upload    UploadOptions // Options for each file
+
+	// Finished, if set, is called after each file with its outcome (err is nil on
+	// success). Calls are never concurrent.
+	Finished func(job UploadJob, err error)
+}
+
+// UploadFilesResult counts the outcomes of UploadFiles
+type UploadFilesResult struct {
+	Uploaded int
+	Skipped  int // Left alone because of IfExistsSkip or SkipIdentical
+	Failed   int
+	Bytes    int64 // Size of the files uploaded
+}
+
+// UploadDirJobs returns a job for every regular file beneath the local directory localDir,
+// uploading it to the same relative path beneath remoteDir. Symbolic links and special
+// files are handled according to walk.
+func UploadDirJobs(localDir, remoteDir string, walk LocalWalkOptions) ([]UploadJob, error) {
+	var jobs []UploadJob
+	err := walkLocal(localDir, walk, func(p, rel string, info fs.FileInfo) error {
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		jobs = append(jobs, UploadJob{Local: p, Remote: path.Join(remoteDir, rel), Size: info.Size()})
+		return nil
+	})
+	return jobs, err
+}
+
+// UploadPathJobs returns the jobs uploading each of localPaths, such as the matches of a
+// glob or the lines of a list of files, into remoteDir under its base name; a directory's
+// files keep their paths relative to it, as with UploadDirJobs
+func UploadPathJobs(localPaths []string, remoteDir string, walk LocalWalkOptions) ([]UploadJob, error) {
+	var jobs []UploadJob
+	for _, localPath := range localPaths {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return nil, err
+		}
+		remotePath := path.Join(remoteDir, filepath.Base(localPath))
+		if !info.IsDir() {
+			jobs = append(jobs, UploadJob{Local: localPath, Remote: remotePath, Size: info.Size()})
+			continue
+		}
+		dirJobs, err := UploadDirJobs(localPath, remotePath, walk)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, dirJobs...)
+	}
+	return jobs, nil
+}
+
+// ReadPathList reads a list of local paths, one per line, as given to 'ul --files-from'.
+// Surrounding spaces, blank lines and lines starting with # are ignored.
+func ReadPathList(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+// UploadFiles uploads jobs with up to opts.Transfers files in flight. A line is printed
+// for each file as it finishes, below a progress line showing the files finished, the
+// bytes uploaded and the overall speed. A failed file is counted and the others are
+// still uploaded. Cancelling ctx stops starting new files and returns an error wrapping
+// ErrInterrupted; the files in flight stop as well when ctx is the client's context (see
+// WithContext).
+func (c *Client) UploadFiles(ctx context.Context, jobs []UploadJob, opts UploadFilesOptions) (UploadFilesResult, error) {
+	var result UploadFilesResult
+	transfers := opts.Transfers
+	if transfers <= 0 {
+		transfers = DefaultUploadTransfers
+	}
+	fileOpts := opts.Upload
+	fileOpts.NoProgress = true
+
+	var total int64
+	for _, job := range jobs {
+		total += job.Size
+	}
+
+	// mu guards result and serializes opts.Finished and the output
+	var mu sync.Mutex
+	start := time.Now()
+	startBytes := c.Stats().BytesUp
+	lineWidth := 0
+	progress := func() {
+		bytes := c.Stats().BytesUp - startBytes
+		line := fmt.Sprintf("%d / %d files | %s", result.Uploaded+result.Skipped+result.Failed, len(jobs), FormatBytes(bytes))
+		if total > 0 {
+			line += fmt.Sprintf(" / %s (%.2f%%)", FormatBytes(total), float64(bytes)/float64(total)*100)
+		}
+		if seconds := time.Since(start).Seconds(); seconds > 0 {
+			line += fmt.Sprintf(" | %s/s", FormatBytes(int64(float64(bytes)/seconds)))
+		}
+		c.printProgress("\r%-*s", lineWidth, line)
+		lineWidth = len(line)
+	}
+	// fileLine replaces the progress line with a line about a finished file
+	fileLine := func(format string, args ...any) {
+		line := fmt.Sprintf(format, args...)
+		c.printProgress("\r%-*s\n", lineWidth, line)
+		lineWidth = 0
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(downloadProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				mu.Lock()
+				progress()
+				mu.Unlock()
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+	queued := make([]string, len(jobs))
+	for i, job := range jobs {
+		queued[i] = "upload " + job.Local
+	}
+	c.setQueue(queued)
+	defer c.setQueue(nil)
+
+	queue := make(chan UploadJob)
+	var wg sync.WaitGroup
+	for range min(transfers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				c.dequeue("upload " + job.Local)
+				err := c.UploadFileWithOptions(job.Local, job.Remote, fileOpts)
+				mu.Lock()
+				switch {
+				case errors.Is(err, ErrInterrupted):
+					// Neither uploaded nor failed: it resumes on the next run
+				case errors.Is(err, ErrUploadSkipped):
+					result.Skipped++
+					fileLine("Skipped %s: %v", job.Local, err)
+				case err != nil:
+					result.Failed++
+					fileLine("Failed %s: %v", job.Local, err)
+				default:
+					result.Uploaded++
+					result.Bytes += job.Size
+					fileLine("Uploaded %s -> %s (%s)", job.Local, job.Remote, FormatBytes(job.Size))
+				}
+				if opts.Finished != nil {
+					opts.Finished(job, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	close(stopProgress)
+	<-progressDone
+	if len(jobs) > 0 {
+		progress()
+		c.printProgress("\n")
+	}
+	if ctx.Err() != nil {
+		return result, fmt.Errorf("%w: %d of %d files uploaded", ErrInterrupted, result.Uploaded, len(jobs))
+	}
+	return result, nil
+}
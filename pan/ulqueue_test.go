@@ -0,0 +1,89 @@
+package pan_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+func TestUploadFiles(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	c := s.NewClient(t)
+
+	dir := t.TempDir()
+	for i := range 12 {
+		sub := filepath.Join(dir, fmt.Sprintf("d%d", i%3))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := bytes.Repeat([]byte{byte('a' + i)}, 1000*(i+1))
+		if err := os.WriteFile(filepath.Join(sub, fmt.Sprintf("f%d", i)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs, err := pan.UploadDirJobs(dir, "/up", pan.LocalWalkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := 0
+	result, err := c.UploadFiles(context.Background(), jobs, pan.UploadFilesOptions{
+		Transfers: 4,
+		Finished: func(job pan.UploadJob, err error) {
+			finished++ // Never called concurrently
+			if err != nil {
+				t.Errorf("uploading %s: %v", job.Local, err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != len(jobs) || result.Failed != 0 || finished != len(jobs) {
+		t.Errorf("uploaded %d and failed %d of %d files, Finished called %d times", result.Uploaded, result.Failed, len(jobs), finished)
+	}
+
+	for _, job := range jobs {
+		want, err := os.ReadFile(job.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := s.ReadFile(job.Remote); !ok || !bytes.Equal(got, want) {
+			t.Errorf("%s on the server holds %d bytes, want the %d of %s", job.Remote, len(got), len(want), job.Local)
+		}
+	}
+}
+
+func TestUploadFilesFailure(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	c := s.NewClient(t)
+
+	dir := t.TempDir()
+	for i := range 6 {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d", i)), []byte{byte(i)}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs, err := pan.UploadDirJobs(dir, "/up", pan.LocalWalkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One of the concurrent uploads fails for good; the others go on
+	s.Fail("create", 31061)
+	result, err := c.UploadFiles(context.Background(), jobs, pan.UploadFilesOptions{Transfers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Uploaded != len(jobs)-1 || result.Failed != 1 {
+		t.Errorf("uploaded %d and failed %d of %d files, want one failure", result.Uploaded, result.Failed, len(jobs))
+	}
+}
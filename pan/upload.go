@@ -2,21 +2,102 @@ package pan
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// UploadOptions controls optional upload behaviour; the zero value uses the defaults
+type UploadOptions struct {
+	NoPreserveTimes bool     // Don't send the local modification time, letting the server stamp the upload time
+	SliceSize       int64    // Upload slice size in bytes; 0 picks the largest size the account's VIP level allows
+	Cipher          *Cipher  // Encrypt the content before it leaves the machine, nil to upload it as-is
+	Split           bool     // Upload files over the account's size limit as parts plus a manifest (see UploadFileSplit)
+	IfExists        IfExists // What to do when the remote path is taken; empty overwrites
+	NoRapid         bool     // Always transfer the content, even when the server already holds an identical copy
+	SkipIdentical   bool     // Upload nothing when the remote file has the local file's size and MD5 (ErrUploadSkipped)
+	NoProgress      bool     // Don't print the per-file progress line, e.g. when a caller reports overall progress
+}
+
+// uploadTarget describes the remote file being created by an upload
+type uploadTarget struct {
+	remotePath string
+	size       int64
+	blockList  string // JSON array of slice MD5s
+	localCtime int64  // Local creation time sent to the server (Unix seconds), 0 to omit
+	localMtime int64  // Local modification time sent to the server (Unix seconds), 0 to omit
+	ifExists   IfExists
+	noRapid    bool // Never send the real slice MD5s to precreate, so it cannot match existing content
+	noProgress bool // Don't print the progress line of the slices
+
+	// resume, if set, is saved after every slice so an interrupted upload can continue.
+	// When it carries an upload ID, that upload is continued without precreate, and the
+	// slices whose MD5s match its SliceMD5s are not sent again.
+	resume *UploadState
+}
+
+// addTo adds the parameters shared by precreate and create to params
+func (t *uploadTarget) addTo(params url.Values) {
+	params.Add("path", t.remotePath)
+	params.Add("size", fmt.Sprintf("%d", t.size))
+	params.Add("isdir", "0") // 0 for file
+	params.Add("block_list", t.blockList)
+	params.Add("rtype", t.ifExists.rtype())
+	if t.localCtime > 0 {
+		params.Add("local_ctime", fmt.Sprintf("%d", t.localCtime))
+	}
+	if t.localMtime > 0 {
+		params.Add("local_mtime", fmt.Sprintf("%d", t.localMtime))
+	}
+}
+
+// setBlockList encodes md5s as the JSON block list sent to precreate and create
+func (t *uploadTarget) setBlockList(md5s []string) error {
+	encoded, err := json.Marshal(md5s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slice MD5s to JSON: %w", err)
+	}
+	t.blockList = string(encoded)
+	return nil
+}
+
+// provisionalBlockList returns a placeholder block list with one entry per slice, for
+// precreating an upload whose slice MD5s are only known once the slices have been read
+func provisionalBlockList(numSlices int) []string {
+	const placeholderMD5 = "d41d8cd98f00b204e9800998ecf8427e" // MD5 of empty content
+	blockList := make([]string, numSlices)
+	for i := range blockList {
+		blockList[i] = placeholderMD5
+	}
+	return blockList
+}
+
 // UploadFile uploads a local file to Baidu Pan
 func (c *Client) UploadFile(localFilePath, remoteFilePath string) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("no access token, please authorize first")
+	return c.UploadFileWithOptions(localFilePath, remoteFilePath, UploadOptions{})
+}
+
+// UploadFileWithOptions uploads a local file to Baidu Pan with the given options.
+// By default the local modification time is preserved on the uploaded file.
+func (c *Client) UploadFileWithOptions(localFilePath, remoteFilePath string, opts UploadOptions) (err error) {
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
 	}
 
 	// 1. Get local file information
@@ -31,199 +112,545 @@ func (c *Client) UploadFile(localFilePath, remoteFilePath string) error {
 	fileSize := fileInfo.Size()
 	fileName := fileInfo.Name()
 
+	// Encrypted content is larger than the file and differs on every upload
+	uploadSize := fileSize
+	if opts.Cipher != nil {
+		uploadSize = opts.Cipher.EncryptedSize(fileSize)
+	}
+
+	if opts.Split {
+		maxSize, err := c.MaxUploadSize(opts.SliceSize)
+		if err != nil {
+			return err
+		}
+		if uploadSize > maxSize {
+			return c.UploadFileSplit(localFilePath, remoteFilePath, 0, opts)
+		}
+	}
+
+	// Check the size limits and pick the slice size (4MB for normal accounts, larger for
+	// VIP/SVIP) before anything is hashed
+	sliceSize, err := c.uploadSliceSize(uploadSize, opts.SliceSize)
+	if err != nil {
+		return err
+	}
+
 	// Ensure remote path is valid
 	if err := c.EnsureRemoteDirExists(filepath.Dir(remoteFilePath)); err != nil {
 		return err
 	}
 
-	// Calculate slice MD5s (Baidu typically uses 4MB slices)
-	const sliceSize = 4 * 1024 * 1024 // 4MB
-	sliceMD5s, err := CalculateSliceMD5(localFilePath, sliceSize)
-	if err != nil {
-		return fmt.Errorf("failed to calculate slice MD5s: %w", err)
+	if err := c.checkRemote(remoteFilePath, opts, localFilePath, fileInfo); err != nil {
+		return err
 	}
 
-	// Convert slice MD5s to JSON string for precreate API
-	sliceMD5sJSON, err := json.Marshal(sliceMD5s)
-	if err != nil {
-		return fmt.Errorf("failed to marshal slice MD5s to JSON: %w", err)
+	if c.DryRun() {
+		fmt.Fprintf(c.dryRunOutput, "[dry-run] upload: %s -> %s (%s)\n", localFilePath, remoteFilePath, FormatBytes(fileSize))
+		return nil
 	}
 
-	PrintSuccess(fmt.Sprintf("Uploading %s (%s) to %s", fileName, byteCountToHumanReadable(fileSize), remoteFilePath))
+	ctx, span := c.startSpan(c.context(), "upload",
+		attribute.String("bdfs.path", remoteFilePath),
+		attribute.Int64("bdfs.size", fileSize))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
+	defer func() { c.recordTransfer("upload", localFilePath, remoteFilePath, fileSize, start, err) }()
+	span.SetAttributes(attribute.Int64("bdfs.slice_size", sliceSize))
+
+	// The slices are hashed before precreate, so it can detect an identical remote file
+	// and name the slices the server does not already hold; only those are uploaded.
+	// Slice MD5s cached from an earlier run of an unchanged file are used as-is. Neither
+	// applies to encrypted uploads, whose ciphertext is only known while uploading, or
+	// with NoRapid, which must not let precreate match; precreate then gets a provisional
+	// block list and create the MD5s computed while uploading.
+	var blockList []string
+	if opts.Cipher == nil && !opts.NoRapid {
+		blockList = c.cachedSliceMD5s(localFilePath, fileSize, fileInfo.ModTime(), sliceSize)
+		if blockList == nil {
+			blockList, err = CalculateSliceMD5(localFilePath, sliceSize)
+			if err != nil {
+				return fmt.Errorf("failed to calculate slice MD5s: %w", err)
+			}
+		}
+	}
 
-	// 2. Call Precreate API
-	precreateParams := url.Values{}
-	precreateParams.Add("access_token", c.accessToken)
-	precreateParams.Add("path", remoteFilePath)
-	precreateParams.Add("size", fmt.Sprintf("%d", fileSize))
-	precreateParams.Add("isdir", "0")    // 0 for file
-	precreateParams.Add("autoinit", "1") // Let Baidu initiate the upload
-	precreateParams.Add("rtype", "1")    // Overwrite existing file
-	precreateParams.Add("block_list", string(sliceMD5sJSON))
+	c.logger.Info(fmt.Sprintf("Uploading %s (%s) to %s", fileName, byteCountToHumanReadable(fileSize), remoteFilePath))
+
+	target := &uploadTarget{
+		remotePath: remoteFilePath,
+		size:       uploadSize,
+		ifExists:   opts.IfExists,
+		noRapid:    opts.NoRapid,
+		noProgress: opts.NoProgress,
+	}
+	if !opts.NoPreserveTimes {
+		// Go has no portable creation time, so the modification time stands in for both
+		target.localCtime = fileInfo.ModTime().Unix()
+		target.localMtime = fileInfo.ModTime().Unix()
+	}
+	resumed := false
+	if c.resume != nil && opts.Cipher == nil {
+		target.resume = c.uploadResume(localFilePath, remoteFilePath, fileSize, fileInfo.ModTime(), sliceSize)
+		resumed = target.resume != nil
+		if target.resume == nil {
+			absPath, _ := filepath.Abs(localFilePath)
+			target.resume = &UploadState{LocalPath: absPath, RemotePath: remoteFilePath, Size: fileSize,
+				Mtime: fileInfo.ModTime().UnixNano(), SliceSize: sliceSize}
+		}
+	}
 
-	precreateReq, err := http.NewRequest("POST", uploadPrecreateURL, strings.NewReader(precreateParams.Encode()))
+	localFile, err := os.Open(localFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to create precreate request: %w", err)
+		return fmt.Errorf("failed to open local file for uploading: %w", err)
+	}
+	defer localFile.Close()
+
+	var content io.Reader = localFile
+	if opts.Cipher != nil {
+		if content, err = opts.Cipher.EncryptReader(localFile); err != nil {
+			return err
+		}
 	}
-	precreateReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	precreateResp, err := c.client.Do(precreateReq)
+	hashes, err := c.uploadContent(ctx, content, target, sliceSize, blockList)
+	if err != nil && resumed && !errors.Is(err, ErrInterrupted) {
+		// The saved upload may have expired on the server: start over
+		c.logger.Warn("Resuming the upload failed, starting over", "path", localFilePath, "error", err)
+		if _, seekErr := localFile.Seek(0, io.SeekStart); seekErr == nil {
+			target.resume = &UploadState{LocalPath: target.resume.LocalPath, RemotePath: remoteFilePath,
+				Size: fileSize, Mtime: fileInfo.ModTime().UnixNano(), SliceSize: sliceSize}
+			hashes, err = c.uploadContent(ctx, content, target, sliceSize, blockList)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("precreate request failed: %w", err)
+		return err
+	}
+	c.forgetUploadResume(localFilePath, remoteFilePath)
+	if hashes != nil && opts.Cipher == nil {
+		c.storeHashes(HashEntry{Path: localFilePath, Size: fileSize, Mtime: fileInfo.ModTime().UnixNano(),
+			MD5: hashes.md5, SliceSize: sliceSize, SliceMD5s: hashes.sliceMD5s})
 	}
-	defer precreateResp.Body.Close()
 
-	precreateBody, err := io.ReadAll(precreateResp.Body)
+	c.metrics.observeTransferDuration("upload", start)
+	return nil
+}
+
+// UploadReader uploads size bytes read from r to remotePath on Baidu Pan. The content is
+// read once, sequentially, and hashed as it is uploaded, so r may be a generated or
+// network stream. It is an error for r to yield fewer than size bytes.
+func (c *Client) UploadReader(ctx context.Context, r io.Reader, size int64, remotePath string) error {
+	return c.uploadReader(ctx, r, size, remotePath, UploadOptions{}, time.Time{})
+}
+
+// uploadReader uploads size bytes read from r to remotePath, honouring opts.SliceSize
+// and opts.Cipher. mtime is sent as the file's modification time unless it is zero or
+// opts.NoPreserveTimes is set.
+func (c *Client) uploadReader(ctx context.Context, r io.Reader, size int64, remotePath string, opts UploadOptions, mtime time.Time) (err error) {
+	if c.getAccessToken() == "" {
+		return ErrNotAuthorized
+	}
+	if size < 0 {
+		return fmt.Errorf("invalid upload size %d", size)
+	}
+
+	uploadSize := size
+	if opts.Cipher != nil {
+		uploadSize = opts.Cipher.EncryptedSize(size)
+	}
+	sliceSize, err := c.uploadSliceSize(uploadSize, opts.SliceSize)
 	if err != nil {
-		return fmt.Errorf("failed to read precreate response body: %w", err)
+		return err
 	}
 
-	if precreateResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("precreate API failed with status %d: %s", precreateResp.StatusCode, string(precreateBody))
+	if err := c.EnsureRemoteDirExists(path.Dir(remotePath)); err != nil {
+		return err
 	}
 
-	var precreateResponse PrecreateResponse
-	err = json.Unmarshal(precreateBody, &precreateResponse)
+	if err := c.checkRemote(remotePath, opts, "", nil); err != nil {
+		return err
+	}
+
+	if c.DryRun() {
+		fmt.Fprintf(c.dryRunOutput, "[dry-run] upload: <stream> -> %s (%s)\n", remotePath, FormatBytes(size))
+		return nil
+	}
+
+	ctx, span := c.startSpan(ctx, "upload",
+		attribute.String("bdfs.path", remotePath),
+		attribute.Int64("bdfs.size", size))
+	defer func() { endSpan(span, err) }()
+	start := time.Now()
+	defer func() { c.recordTransfer("upload", "", remotePath, size, start, err) }()
+
+	if opts.Cipher != nil {
+		if r, err = opts.Cipher.EncryptReader(r); err != nil {
+			return err
+		}
+	}
+	span.SetAttributes(attribute.Int64("bdfs.slice_size", sliceSize))
+
+	c.logger.Info(fmt.Sprintf("Uploading stream (%s) to %s", byteCountToHumanReadable(size), remotePath))
+
+	target := &uploadTarget{
+		remotePath: remotePath,
+		size:       uploadSize,
+		ifExists:   opts.IfExists,
+		noRapid:    opts.NoRapid,
+		noProgress: opts.NoProgress,
+	}
+	if !opts.NoPreserveTimes && mtime.Unix() > 0 {
+		target.localCtime = mtime.Unix()
+		target.localMtime = mtime.Unix()
+	}
+	if _, err := c.uploadContent(ctx, r, target, sliceSize, nil); err != nil {
+		return err
+	}
+
+	c.metrics.observeTransferDuration("upload", start)
+	return nil
+}
+
+// UploadReaderSpooled uploads everything read from r to remotePath when the size is not
+// known in advance. The content is spooled to a temporary file, which is removed afterwards.
+func (c *Client) UploadReaderSpooled(ctx context.Context, r io.Reader, remotePath string) error {
+	spool, err := os.CreateTemp("", "bdfs-upload-*")
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal precreate response: %w", err)
+		return fmt.Errorf("failed to create spool file: %w", err)
 	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
 
-	if precreateResponse.Errno != 0 {
-		return fmt.Errorf("precreate API returned error code %d: %s", precreateResponse.Errno, string(precreateBody))
+	size, err := io.Copy(spool, r)
+	if err != nil {
+		return fmt.Errorf("failed to spool upload content: %w", err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spool file: %w", err)
+	}
+
+	return c.UploadReader(ctx, spool, size, remotePath)
+}
+
+// uploadHashes holds the MD5s computed while uploading content
+type uploadHashes struct {
+	md5       string   // MD5 of the whole content
+	sliceMD5s []string // MD5 of each slice
+}
+
+// uploadContent precreates target, uploads its content read sequentially from r in
+// sliceSize slices and creates the remote file. blockList holds the slice MD5s when they
+// are known up front, and then only the slices precreate asks for are sent; when nil, or
+// for a noRapid target, precreate gets a provisional list and every slice is sent. create
+// gets the MD5s computed while reading. It returns those MD5s, or nil when precreate found
+// an identical remote file and nothing was uploaded; such a match is only trusted for real
+// MD5s, and fails the upload for a provisional list.
+func (c *Client) uploadContent(ctx context.Context, r io.Reader, target *uploadTarget, sliceSize int64, blockList []string) (*uploadHashes, error) {
+	numSlices := int(sliceCount(target.size, sliceSize))
+	realMD5s := blockList != nil && !target.noRapid
+	if realMD5s {
+		numSlices = len(blockList)
+	} else {
+		blockList = provisionalBlockList(numSlices)
+	}
+	if err := target.setBlockList(blockList); err != nil {
+		return nil, err
+	}
+	fileName := path.Base(target.remotePath)
+
+	// 2. Call Precreate API, unless continuing an interrupted upload
+	var resumedMD5s []string
+	var precreateResponse *PrecreateResponse
+	if target.resume != nil && target.resume.UploadID != "" {
+		resumedMD5s = target.resume.SliceMD5s
+		precreateResponse = &PrecreateResponse{UploadID: target.resume.UploadID}
+		c.logger.Info(fmt.Sprintf("Resuming upload of '%s': %d of %d slices already uploaded", target.remotePath, len(resumedMD5s), numSlices))
+	} else {
+		var err error
+		if precreateResponse, err = c.precreate(ctx, target); err != nil {
+			return nil, err
+		}
 	}
 
 	// 3. Handle Precreate Response
+	if precreateResponse.ReturnType == 2 && !realMD5s {
+		// A match against placeholder MD5s says nothing about the content, so the upload
+		// cannot be treated as done
+		return nil, fmt.Errorf("precreate of '%s' reported an identical remote file for a provisional block list; nothing was uploaded", target.remotePath)
+	}
 	if precreateResponse.ReturnType == 2 {
-		PrintSuccess(fmt.Sprintf("File '%s' already exists on Baidu Pan and matches the local file. Skipping upload.", remoteFilePath))
-		return nil
+		c.logger.Info(fmt.Sprintf("File '%s' already exists on Baidu Pan and matches the local file. Skipping upload.", target.remotePath))
+		return nil, nil
 	}
 
 	if precreateResponse.UploadID == "" {
-		return fmt.Errorf("precreate API did not return uploadid")
+		return nil, fmt.Errorf("precreate API did not return uploadid")
+	}
+	if target.resume != nil {
+		target.resume.UploadID = precreateResponse.UploadID
 	}
 
-	// 4. Upload Slices
-	localFile, err := os.Open(localFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to open local file for uploading: %w", err)
+	// With the real slice MD5s, precreate lists the slices the server still needs; the
+	// others it already holds, e.g. from another file with the same content
+	var present map[int]bool
+	if realMD5s && resumedMD5s == nil && precreateResponse.BlockList != nil {
+		present = make(map[int]bool, numSlices)
+		for i := range numSlices {
+			present[i] = true
+		}
+		for _, i := range precreateResponse.BlockList {
+			delete(present, i)
+		}
+		if len(present) > 0 {
+			c.logger.Info(fmt.Sprintf("%d of %d slices of '%s' are already on the server", len(present), numSlices, target.remotePath))
+		}
 	}
-	defer localFile.Close()
 
-	PrintSuccess("Starting slice upload...")
+	transfer := c.beginTransfer("upload", target.remotePath, target.size)
+	defer c.endTransfer(transfer)
+
+	// 4. Upload Slices to the nearest upload server
+	hosts := c.locateUploadHosts(ctx, target.remotePath, precreateResponse.UploadID)
+	c.logger.Info("Starting slice upload...")
 	uploadedBytes := int64(0)
 
 	// Create a buffer for reading file slices
 	sliceBuffer := make([]byte, sliceSize)
-
-	for i := 0; i < len(sliceMD5s); i++ {
-		// Calculate the starting offset for the current slice
-		offset := int64(i) * sliceSize
-		_, err := localFile.Seek(offset, io.SeekStart)
-		if err != nil {
-			return fmt.Errorf("failed to seek to slice position: %w", err)
+	hashes := &uploadHashes{sliceMD5s: make([]string, 0, numSlices)}
+	fileHash := md5.New()
+
+	for i := 0; i < numSlices; i++ {
+		if ctx.Err() != nil {
+			if !target.noProgress {
+				c.printProgress("\n")
+			}
+			return nil, fmt.Errorf("%w: %d of %d slices of '%s' uploaded", ErrInterrupted, i, numSlices, target.remotePath)
 		}
 
-		// Read the current slice into the buffer
-		n, err := io.ReadFull(localFile, sliceBuffer)
+		// Read the next slice into the buffer; slices are read sequentially
+		n, err := io.ReadFull(r, sliceBuffer)
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
-			return fmt.Errorf("failed to read file slice %d: %w", i, err)
+			return nil, fmt.Errorf("failed to read file slice %d: %w", i, err)
 		}
-		currentSlice := sliceBuffer[:n]
 
-		// Create multipart form data for slice upload
-		var requestBody bytes.Buffer
-		multipartWriter := multipart.NewWriter(&requestBody)
+		sliceMD5 := md5.Sum(sliceBuffer[:n])
+		hashes.sliceMD5s = append(hashes.sliceMD5s, hex.EncodeToString(sliceMD5[:]))
+		fileHash.Write(sliceBuffer[:n])
 
-		// Add "file" field
-		fileWriter, err := multipartWriter.CreateFormFile("file", fileName)
-		if err != nil {
-			return fmt.Errorf("failed to create form file for slice: %w", err)
+		if (i < len(resumedMD5s) && resumedMD5s[i] == hashes.sliceMD5s[i]) || present[i] {
+			// Uploaded before the interruption with unchanged content, or already held by
+			// the server
+			uploadedBytes += int64(n)
+			transfer.add(n)
+			continue
 		}
-		_, err = fileWriter.Write(currentSlice)
-		if err != nil {
-			return fmt.Errorf("failed to write slice data to form file: %w", err)
-		}
-
-		// Close the multipart writer to finalize the form data
-		multipartWriter.Close()
 
-		sliceUploadURL := fmt.Sprintf("%s?access_token=%s&method=upload&type=tmpfile&path=%s&uploadid=%s&partseq=%d",
-			uploadSuperfileURL, c.accessToken, remoteFilePath, precreateResponse.UploadID, i)
-
-		sliceUploadReq, err := http.NewRequest("POST", sliceUploadURL, &requestBody)
-		if err != nil {
-			return fmt.Errorf("failed to create slice upload request: %w", err)
+		// An interruption lets the slice in flight finish, so its progress can be saved
+		if err := c.uploadSliceWithRetry(context.WithoutCancel(ctx), hosts, target.remotePath, precreateResponse.UploadID, i, fileName, sliceBuffer[:n]); err != nil {
+			return nil, err
 		}
-		sliceUploadReq.Header.Set("Content-Type", multipartWriter.FormDataContentType())
-
-		sliceUploadResp, err := c.client.Do(sliceUploadReq)
-		if err != nil {
-			return fmt.Errorf("slice upload request failed for part %d: %w", i, err)
+		if target.resume != nil {
+			target.resume.SliceMD5s = slices.Clone(hashes.sliceMD5s)
+			c.saveUploadResume(target.resume)
 		}
-		defer sliceUploadResp.Body.Close()
 
-		sliceUploadBody, err := io.ReadAll(sliceUploadResp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read slice upload response body for part %d: %w", i, err)
+		uploadedBytes += int64(n)
+		transfer.add(n)
+		c.observeTransfer("upload", int64(n))
+		if !target.noProgress {
+			c.printProgress("\r%d / %d (%.2f%%)",
+				uploadedBytes,
+				target.size,
+				float64(uploadedBytes)/float64(target.size)*100)
 		}
+	}
+	if !target.noProgress {
+		c.printProgress("\n")
+	}
+	if uploadedBytes != target.size {
+		return nil, fmt.Errorf("content ended after %d of %d bytes", uploadedBytes, target.size)
+	}
+	c.logger.Info("All slices uploaded.")
+	hashes.md5 = hex.EncodeToString(fileHash.Sum(nil))
 
-		if sliceUploadResp.StatusCode != http.StatusOK {
-			return fmt.Errorf("slice upload API failed for part %d with status %d: %s", i, sliceUploadResp.StatusCode, string(sliceUploadBody))
+	// 5. Call Create File API to finalize with the block list hashed during upload
+	if err := target.setBlockList(hashes.sliceMD5s); err != nil {
+		return nil, err
+	}
+	createFileResponse, err := c.createFile(ctx, target, precreateResponse.UploadID)
+	if err != nil && createFileResponse != nil && blockMismatchErrnos[createFileResponse.Errno] {
+		// Slices the server lost or received damaged can be sent again when the content
+		// can be re-read, e.g. from the local file
+		if ra, ok := r.(io.ReaderAt); ok {
+			createFileResponse, err = c.repairUpload(ctx, ra, target, sliceSize)
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		uploadedBytes += int64(n)
-		fmt.Printf("\r%d / %d (%.2f%%)",
-			uploadedBytes,
-			fileSize,
-			float64(uploadedBytes)/float64(fileSize)*100)
-		os.Stdout.Sync()
+	entry := createFileResponse.indexEntry()
+	entry.LocalMtime = target.localMtime
+	c.indexUpdate(entry)
+	c.logger.Info(fmt.Sprintf("File '%s' uploaded successfully to Baidu Pan as '%s'", fileName, createFileResponse.Path))
+
+	return hashes, nil
+}
+
+// precreate registers an upload with Baidu Pan and returns the upload ID and the slices still needed
+func (c *Client) precreate(ctx context.Context, target *uploadTarget) (_ *PrecreateResponse, err error) {
+	ctx, span := c.startSpan(ctx, "precreate", attribute.String("bdfs.path", target.remotePath))
+	defer func() { endSpan(span, err) }()
+
+	precreateParams := url.Values{}
+	precreateParams.Add("access_token", c.getAccessToken())
+	target.addTo(precreateParams)
+	precreateParams.Add("autoinit", "1") // Let Baidu initiate the upload
+
+	precreateReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(uploadPrecreateURL), strings.NewReader(precreateParams.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create precreate request: %w", err)
 	}
-	PrintSuccess("All slices uploaded.")
+	precreateReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	precreateResp, err := c.do(precreateReq)
+	if err != nil {
+		return nil, fmt.Errorf("precreate request failed: %w", err)
+	}
+	defer precreateResp.Body.Close()
+
+	precreateBody, err := io.ReadAll(precreateResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read precreate response body: %w", err)
+	}
+
+	if precreateResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("precreate API failed with status %d: %s", precreateResp.StatusCode, string(precreateBody))
+	}
+
+	var precreateResponse PrecreateResponse
+	err = json.Unmarshal(precreateBody, &precreateResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal precreate response: %w", err)
+	}
+	setAPIResult(span, precreateResponse.Errno, precreateResponse.RequestID)
+
+	if precreateResponse.Errno != 0 {
+		return nil, &errno.Error{API: "precreate", Code: precreateResponse.Errno}
+	}
+
+	return &precreateResponse, nil
+}
+
+// uploadSlice uploads a single slice of file data for the given upload ID to the slice
+// upload API at superfileURL
+func (c *Client) uploadSlice(ctx context.Context, superfileURL, remoteFilePath, uploadID string, partSeq int, fileName string, data []byte) (err error) {
+	ctx, span := c.startSpan(ctx, "upload_slice",
+		attribute.Int("bdfs.partseq", partSeq),
+		attribute.Int("bdfs.bytes", len(data)))
+	defer func() { endSpan(span, err) }()
+
+	// Create multipart form data for slice upload
+	var requestBody bytes.Buffer
+	multipartWriter := multipart.NewWriter(&requestBody)
+
+	// Add "file" field
+	fileWriter, err := multipartWriter.CreateFormFile("file", fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create form file for slice: %w", err)
+	}
+	_, err = fileWriter.Write(data)
+	if err != nil {
+		return fmt.Errorf("failed to write slice data to form file: %w", err)
+	}
+
+	// Close the multipart writer to finalize the form data
+	multipartWriter.Close()
+
+	sliceUploadURL := fmt.Sprintf("%s?access_token=%s&method=upload&type=tmpfile&path=%s&uploadid=%s&partseq=%d",
+		superfileURL, c.getAccessToken(), remoteFilePath, uploadID, partSeq)
+
+	sliceUploadReq, err := http.NewRequestWithContext(ctx, "POST", sliceUploadURL, &requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to create slice upload request: %w", err)
+	}
+	sliceUploadReq.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+
+	sliceUploadResp, err := c.doTransfer(sliceUploadReq)
+	if err != nil {
+		return fmt.Errorf("slice upload request failed for part %d: %w", partSeq, err)
+	}
+	defer sliceUploadResp.Body.Close()
+
+	sliceUploadBody, err := io.ReadAll(sliceUploadResp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read slice upload response body for part %d: %w", partSeq, err)
+	}
+
+	if sliceUploadResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slice upload API failed for part %d with status %d: %s", partSeq, sliceUploadResp.StatusCode, string(sliceUploadBody))
+	}
+
+	return nil
+}
+
+// createFile finalizes an upload by merging the uploaded slices into the remote file
+func (c *Client) createFile(ctx context.Context, target *uploadTarget, uploadID string) (_ *CreateFileResponse, err error) {
+	ctx, span := c.startSpan(ctx, "create",
+		attribute.String("bdfs.path", target.remotePath),
+		attribute.Int64("bdfs.size", target.size))
+	defer func() { endSpan(span, err) }()
 
-	// 5. Call Create File API to finalize
 	createFileParams := url.Values{}
-	createFileParams.Add("access_token", c.accessToken)
-	createFileParams.Add("path", remoteFilePath)
-	createFileParams.Add("size", fmt.Sprintf("%d", fileSize))
-	createFileParams.Add("isdir", "0")
-	createFileParams.Add("uploadid", precreateResponse.UploadID)
-	createFileParams.Add("block_list", string(sliceMD5sJSON)) // Need to send all block MD5s again
-	createFileParams.Add("rtype", "1")                        // Overwrite existing file
+	createFileParams.Add("access_token", c.getAccessToken())
+	target.addTo(createFileParams) // Need to send all block MD5s again
+	createFileParams.Add("uploadid", uploadID)
 
-	createFileReq, err := http.NewRequest("POST", uploadCreateFileUrl, strings.NewReader(createFileParams.Encode()))
+	createFileReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(uploadCreateFileUrl), strings.NewReader(createFileParams.Encode()))
 	if err != nil {
-		return fmt.Errorf("failed to create create file request: %w", err)
+		return nil, fmt.Errorf("failed to create create file request: %w", err)
 	}
 	createFileReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	createFileResp, err := c.client.Do(createFileReq)
+	createFileResp, err := c.do(createFileReq)
 	if err != nil {
-		return fmt.Errorf("create file request failed: %w", err)
+		return nil, fmt.Errorf("create file request failed: %w", err)
 	}
 	defer createFileResp.Body.Close()
 
 	createFileBody, err := io.ReadAll(createFileResp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read create file response body: %w", err)
+		return nil, fmt.Errorf("failed to read create file response body: %w", err)
 	}
 
 	if createFileResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("create file API failed with status %d: %s", createFileResp.StatusCode, string(createFileBody))
+		return nil, fmt.Errorf("create file API failed with status %d: %s", createFileResp.StatusCode, string(createFileBody))
 	}
 
 	var createFileResponse CreateFileResponse
 	err = json.Unmarshal(createFileBody, &createFileResponse)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal create file response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal create file response: %w", err)
 	}
+	setAPIResult(span, createFileResponse.Errno, 0)
 
 	if createFileResponse.Errno != 0 {
-		return fmt.Errorf("create file API returned error code %d: %s", createFileResponse.Errno, string(createFileBody))
+		// The response is returned too, so callers can act on the errno
+		return &createFileResponse, &errno.Error{API: "create file", Code: createFileResponse.Errno}
 	}
 
-	PrintSuccess(fmt.Sprintf("File '%s' uploaded successfully to Baidu Pan as '%s'", fileName, createFileResponse.Path))
+	return &createFileResponse, nil
+}
 
-	return nil
+// indexEntry converts the created file's metadata into an index entry
+func (r *CreateFileResponse) indexEntry() IndexEntry {
+	return IndexEntry{
+		Path:  r.Path,
+		FsID:  r.FsID,
+		Size:  r.Size,
+		Mtime: r.MTime,
+		MD5:   r.MD5,
+		IsDir: r.Isdir == 1,
+	}
 }
 
 // byteCountToHumanReadable converts bytes to a human-readable string
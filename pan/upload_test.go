@@ -0,0 +1,91 @@
+package pan_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+// writeLocal writes a local file of n bytes and returns its path and content
+func writeLocal(t *testing.T, name string, n int) (string, []byte) {
+	t.Helper()
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte(i * 7)
+	}
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p, content
+}
+
+func TestUploadFile(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	c := s.NewClient(t)
+	local, content := writeLocal(t, "report.bin", 5000)
+
+	err := c.UploadFileWithOptions(local, "/apps/bdfs/report.bin", pan.UploadOptions{SliceSize: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.ReadFile("/apps/bdfs/report.bin"); !ok || !bytes.Equal(got, content) {
+		t.Errorf("server holds %d bytes, want the %d uploaded", len(got), len(content))
+	}
+	if calls := s.Calls("upload"); calls != 5 {
+		t.Errorf("server received %d slices of a 5000-byte file in 1024-byte slices, want 5", calls)
+	}
+
+	info, err := c.GetDetailedFileInfo("/apps/bdfs/report.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("meta reports %d bytes, want %d", info.Size, len(content))
+	}
+}
+
+func TestUploadFileSliceRetry(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	c := s.NewClient(t, pan.WithSliceRetry(2, time.Millisecond))
+	local, content := writeLocal(t, "a.bin", 3000)
+
+	// Only the failed slice is sent again
+	s.Fail("upload", 31299)
+	if err := c.UploadFileWithOptions(local, "/a.bin", pan.UploadOptions{SliceSize: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.ReadFile("/a.bin"); !ok || !bytes.Equal(got, content) {
+		t.Errorf("server holds %d bytes, want the %d uploaded", len(got), len(content))
+	}
+	if calls := s.Calls("upload"); calls != 4 {
+		t.Errorf("server received %d slice requests for 3 slices and one failure, want 4", calls)
+	}
+}
+
+func TestUploadFileIfExists(t *testing.T) {
+	s := pantest.NewServer()
+	defer s.Close()
+	s.WriteFile("/a.bin", []byte("remote"))
+	c := s.NewClient(t)
+	local, _ := writeLocal(t, "a.bin", 100)
+
+	err := c.UploadFileWithOptions(local, "/a.bin", pan.UploadOptions{IfExists: pan.IfExistsFail})
+	if !errors.Is(err, pan.ErrRemoteExists) {
+		t.Errorf("upload over an existing file returned %v, want %v", err, pan.ErrRemoteExists)
+	}
+	if got, _ := s.ReadFile("/a.bin"); string(got) != "remote" {
+		t.Errorf("remote file was replaced by %d bytes", len(got))
+	}
+	if calls := s.Calls("precreate"); calls != 0 {
+		t.Errorf("upload sent %d precreate requests, want none", calls)
+	}
+}
@@ -0,0 +1,214 @@
+package pan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/baowuhe/go-bdfs/pan/errno"
+)
+
+const userInfoURL = "https://pan.baidu.com/rest/2.0/xpan/nas"
+
+// VIP levels reported by the uinfo API
+const (
+	VIPTypeNormal = 0
+	VIPTypeVIP    = 1
+	VIPTypeSVIP   = 2
+)
+
+// Upload slice sizes allowed for each VIP level
+const (
+	SliceSizeNormal int64 = 4 * 1024 * 1024  // 4MB
+	SliceSizeVIP    int64 = 16 * 1024 * 1024 // 16MB
+	SliceSizeSVIP   int64 = 32 * 1024 * 1024 // 32MB
+)
+
+// Largest single file each VIP level may upload
+const (
+	MaxFileSizeNormal int64 = 4 << 30  // 4GB
+	MaxFileSizeVIP    int64 = 10 << 30 // 10GB
+	MaxFileSizeSVIP   int64 = 20 << 30 // 20GB
+)
+
+// ErrFileTooLarge is returned, wrapped with the limit, for a file larger than the account
+// can upload in one piece
+var ErrFileTooLarge = errors.New("file too large")
+
+// UserInfo represents the response from the uinfo API
+type UserInfo struct {
+	Errno       int    `json:"errno"`
+	BaiduName   string `json:"baidu_name"`
+	NetdiskName string `json:"netdisk_name"`
+	AvatarURL   string `json:"avatar_url"`
+	VIPType     int    `json:"vip_type"` // 0 normal, 1 VIP, 2 SVIP
+	UK          int64  `json:"uk"`
+	RequestID   string `json:"request_id"`
+}
+
+// GetUserInfo gets the account's name and VIP level
+func (c *Client) GetUserInfo() (*UserInfo, error) {
+	if c.getAccessToken() == "" {
+		return nil, ErrNotAuthorized
+	}
+
+	params := url.Values{}
+	params.Add("method", "uinfo")
+	params.Add("access_token", c.getAccessToken())
+
+	req, err := http.NewRequest("GET", c.endpoint(userInfoURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get user info request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response UserInfo
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Errno != 0 {
+		return nil, &errno.Error{Code: response.Errno}
+	}
+
+	return &response, nil
+}
+
+// SliceSizeForVIPType returns the largest upload slice size allowed for a VIP level
+func SliceSizeForVIPType(vipType int) int64 {
+	switch vipType {
+	case VIPTypeSVIP:
+		return SliceSizeSVIP
+	case VIPTypeVIP:
+		return SliceSizeVIP
+	default:
+		return SliceSizeNormal
+	}
+}
+
+// MaxFileSizeForVIPType returns the largest single file a VIP level may upload
+func MaxFileSizeForVIPType(vipType int) int64 {
+	switch vipType {
+	case VIPTypeSVIP:
+		return MaxFileSizeSVIP
+	case VIPTypeVIP:
+		return MaxFileSizeVIP
+	default:
+		return MaxFileSizeNormal
+	}
+}
+
+// cachedUserInfo returns the account info, fetching it once per client
+func (c *Client) cachedUserInfo() (*UserInfo, error) {
+	c.userMu.Lock()
+	defer c.userMu.Unlock()
+
+	if c.userInfo != nil {
+		return c.userInfo, nil
+	}
+	info, err := c.GetUserInfo()
+	if err != nil {
+		return nil, err
+	}
+	c.userInfo = info
+	return info, nil
+}
+
+// MaxSliceCount is the largest number of slices Baidu Pan accepts for a single upload. It
+// limits the length of the block list, whatever the VIP level: a normal account reaches it
+// at its 4GB file limit with 4MB slices, while with the larger slices of VIP (16MB) and
+// SVIP (32MB) the file size limit is reached first, at 640 slices. It only restricts a
+// VIP account that chooses a smaller slice size.
+const MaxSliceCount = 1024
+
+// uploadSliceSize picks the slice size for a fileSize-byte upload: the explicit override
+// when set, otherwise the largest size the account's VIP level allows. If the file is
+// over the VIP level's file size limit or would need more than MaxSliceCount slices, or
+// the override is larger than the VIP level allows, an error describing the limit is
+// returned before any hashing starts. So is the error of reading the VIP level, rather
+// than a guess that could reject a valid upload.
+func (c *Client) uploadSliceSize(fileSize, override int64) (int64, error) {
+	vipType, err := c.vipType()
+	if err != nil {
+		return 0, err
+	}
+	if limit := MaxFileSizeForVIPType(vipType); fileSize > limit {
+		return 0, fmt.Errorf("%w: file size %s exceeds the %s limit for a single file on this account (%s)",
+			ErrFileTooLarge, FormatBytes(fileSize), FormatBytes(limit), vipName(vipType))
+	}
+
+	if override > 0 {
+		if limit := SliceSizeForVIPType(vipType); override > limit {
+			return 0, fmt.Errorf("slice size %s exceeds the %s limit for this account (%s)",
+				FormatBytes(override), FormatBytes(limit), vipName(vipType))
+		}
+		if slices := sliceCount(fileSize, override); slices > MaxSliceCount {
+			return 0, fmt.Errorf("file needs %d slices of %s, more than the limit of %d; use a slice size of at least %s",
+				slices, FormatBytes(override), MaxSliceCount, FormatBytes(minSliceSize(fileSize)))
+		}
+		return override, nil
+	}
+
+	maxSize := SliceSizeForVIPType(vipType)
+	if sliceCount(fileSize, maxSize) > MaxSliceCount {
+		return 0, fmt.Errorf("%w: file size %s exceeds the %s limit for this account (%d slices of %s)",
+			ErrFileTooLarge, FormatBytes(fileSize), FormatBytes(maxSize*MaxSliceCount), MaxSliceCount, FormatBytes(maxSize))
+	}
+	return maxSize, nil
+}
+
+// vipType returns the account's VIP level
+func (c *Client) vipType() (int, error) {
+	info, err := c.cachedUserInfo()
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine the account's VIP level: %w", err)
+	}
+	return info.VIPType, nil
+}
+
+// MaxUploadSize returns the largest file the account can upload in one piece: the file
+// size limit of its VIP level, or MaxSliceCount slices of sliceSize if that is less.
+// sliceSize 0 stands for the largest slice size the VIP level allows. It fails when the
+// VIP level cannot be read.
+func (c *Client) MaxUploadSize(sliceSize int64) (int64, error) {
+	vipType, err := c.vipType()
+	if err != nil {
+		return 0, err
+	}
+	if sliceSize <= 0 {
+		sliceSize = SliceSizeForVIPType(vipType)
+	}
+	return min(sliceSize*MaxSliceCount, MaxFileSizeForVIPType(vipType)), nil
+}
+
+// sliceCount returns how many slices of sliceSize a fileSize-byte file is split into
+func sliceCount(fileSize, sliceSize int64) int64 {
+	return (fileSize + sliceSize - 1) / sliceSize
+}
+
+// minSliceSize returns the smallest standard slice size that keeps fileSize within MaxSliceCount
+func minSliceSize(fileSize int64) int64 {
+	for _, size := range []int64{SliceSizeNormal, SliceSizeVIP, SliceSizeSVIP} {
+		if sliceCount(fileSize, size) <= MaxSliceCount {
+			return size
+		}
+	}
+	return (fileSize + MaxSliceCount - 1) / MaxSliceCount
+}
@@ -3,20 +3,40 @@ package pan
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 // PrintSuccess prints a success message with consistent formatting
 func PrintSuccess(message string) {
-	fmt.Printf("[✓] %s\n", message)
+	consoleLogger.Info(message)
 }
 
 // PrintError prints an error message with consistent formatting
 func PrintError(message string) {
-	fmt.Printf("[×] %s\n", message)
+	consoleLogger.Error(message)
 }
 
 // PrintErrorAndExit prints an error message and exits with code 1
 func PrintErrorAndExit(message string) {
 	PrintError(message)
-	os.Exit(1)
-}
\ No newline at end of file
+	os.Exit(ExitError)
+}
+
+// ExitWithError prints an error message and exits with the status ExitCode gives err
+func ExitWithError(message string, err error) {
+	PrintError(message)
+	os.Exit(ExitCode(err))
+}
+
+// Confirm prints prompt and reports whether the answer read from stdin is y or yes, in
+// any case. An empty answer, or none when stdin is closed, is a no.
+func Confirm(prompt string) bool {
+	fmt.Print(prompt)
+	var response string
+	fmt.Scanln(&response)
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package pan
+
+import (
+	"context"
+	"io/fs"
+	"path"
+)
+
+// DefaultWalkWorkers is the number of directories WalkParallel lists concurrently by default
+const DefaultWalkWorkers = 4
+
+// WalkOptions configures WalkParallel
+type WalkOptions struct {
+	Workers int  // Directories listed concurrently; <= 0 uses DefaultWalkWorkers
+	Ordered bool // Visit entries in the same order as WalkDir instead of as listings complete
+}
+
+// dirListing is the result of listing one directory, available once done is closed
+type dirListing struct {
+	files []FileInfo
+	err   error
+	done  chan struct{}
+}
+
+// WalkParallel walks the remote tree beneath root like WalkDir, but lists up to
+// opts.Workers sibling directories concurrently. fn is always called from a single
+// goroutine and follows the WalkDirFunc conventions, including fs.SkipDir and fs.SkipAll.
+// Without opts.Ordered, entries are visited as listings complete, so the order varies
+// between runs; with it, the order matches WalkDir while listings are still prefetched.
+func (c *Client) WalkParallel(ctx context.Context, root string, opts WalkOptions, fn WalkDirFunc) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWalkWorkers
+	}
+
+	// Cancelling on return stops listings that are no longer needed from being started
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &parallelWalker{client: c, ctx: ctx, sem: make(chan struct{}, workers), fn: fn}
+	var err error
+	if opts.Ordered {
+		err = w.walkOrdered(normalizeRemoteDir(root), w.list(normalizeRemoteDir(root)))
+	} else {
+		err = w.walkUnordered(normalizeRemoteDir(root))
+	}
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// parallelWalker holds the state shared by a single WalkParallel call
+type parallelWalker struct {
+	client *Client
+	ctx    context.Context
+	sem    chan struct{} // Bounds the number of concurrent listings
+	fn     WalkDirFunc
+}
+
+// list starts listing dir in the background and returns its pending result
+func (w *parallelWalker) list(dir string) *dirListing {
+	l := &dirListing{done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		select {
+		case w.sem <- struct{}{}:
+		case <-w.ctx.Done():
+			l.err = w.ctx.Err()
+			return
+		}
+		defer func() { <-w.sem }()
+
+		if l.err = w.ctx.Err(); l.err == nil {
+			l.files, l.err = w.client.listFiles(w.ctx, dir)
+		}
+	}()
+	return l
+}
+
+// visit calls fn for the entries of a completed listing of dir and returns the
+// subdirectories to descend into
+func (w *parallelWalker) visit(dir string, l *dirListing) ([]string, error) {
+	if l.err != nil {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, w.fn(FileInfo{Path: dir, ServerFilename: path.Base(dir), IsDir: 1}, l.err)
+	}
+
+	var subdirs []string
+	for _, file := range l.files {
+		if err := w.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := w.fn(file, nil); err != nil {
+			if err == fs.SkipDir && file.IsDir == 1 {
+				continue
+			}
+			if err == fs.SkipDir {
+				break // Skip the remaining entries of this directory
+			}
+			return nil, err
+		}
+		if file.IsDir == 1 {
+			subdirs = append(subdirs, file.Path)
+		}
+	}
+	return subdirs, nil
+}
+
+// walkOrdered visits dir depth-first in listing order, descending into each subdirectory
+// right after visiting it, as walkDir does. The subdirectories of each listing are
+// prefetched before its entries are visited, so listing overlaps with fn calls.
+func (w *parallelWalker) walkOrdered(dir string, l *dirListing) error {
+	<-l.done
+	if l.err != nil {
+		_, err := w.visit(dir, l)
+		return err
+	}
+
+	prefetched := make(map[string]*dirListing)
+	for _, file := range l.files {
+		if file.IsDir == 1 {
+			prefetched[file.Path] = w.list(file.Path)
+		}
+	}
+
+	for _, file := range l.files {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		if err := w.fn(file, nil); err != nil {
+			if err == fs.SkipDir && file.IsDir == 1 {
+				continue
+			}
+			return err // fs.SkipDir for a file skips the remaining entries of dir
+		}
+		if file.IsDir == 1 {
+			if err := w.walkOrdered(file.Path, prefetched[file.Path]); err != nil && err != fs.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkUnordered visits entries as listings complete, scheduling subdirectories as they are found
+func (w *parallelWalker) walkUnordered(root string) error {
+	type result struct {
+		dir     string
+		listing *dirListing
+	}
+	results := make(chan result)
+	pending := 0
+
+	schedule := func(dir string) {
+		pending++
+		l := w.list(dir)
+		go func() {
+			<-l.done
+			select {
+			case results <- result{dir: dir, listing: l}:
+			case <-w.ctx.Done():
+			}
+		}()
+	}
+
+	schedule(root)
+	for pending > 0 {
+		var r result
+		select {
+		case r = <-results:
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+		pending--
+
+		subdirs, err := w.visit(r.dir, r.listing)
+		if err != nil && err != fs.SkipDir {
+			return err
+		}
+		for _, subdir := range subdirs {
+			schedule(subdir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package pan_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/baowuhe/go-bdfs/pan"
+	"github.com/baowuhe/go-bdfs/pan/pantest"
+)
+
+// newTree returns a server holding a tree of directories and files beneath /tree
+func newTree(t *testing.T) *pantest.Server {
+	t.Helper()
+	s := pantest.NewServer()
+	t.Cleanup(s.Close)
+	for i := range 6 {
+		for j := range 4 {
+			for k := range 3 {
+				s.WriteFile(fmt.Sprintf("/tree/d%d/s%d/f%d", i, j, k), []byte(fmt.Sprintf("%d%d%d", i, j, k)))
+			}
+		}
+	}
+	return s
+}
+
+// walkPaths returns the paths walk passes to its WalkDirFunc, in the order visited
+func walkPaths(t *testing.T, walk func(pan.WalkDirFunc) error) []string {
+	t.Helper()
+	var paths []string
+	err := walk(func(file pan.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, file.Path) // Never called concurrently
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestWalkParallel(t *testing.T) {
+	s := newTree(t)
+	c := s.NewClient(t)
+	ctx := context.Background()
+
+	want := walkPaths(t, func(fn pan.WalkDirFunc) error { return c.WalkDir(ctx, "/tree", fn) })
+	if len(want) != 6+6*4+6*4*3 {
+		t.Fatalf("WalkDir visited %d entries, want %d", len(want), 6+6*4+6*4*3)
+	}
+
+	ordered := walkPaths(t, func(fn pan.WalkDirFunc) error {
+		return c.WalkParallel(ctx, "/tree", pan.WalkOptions{Workers: 8, Ordered: true}, fn)
+	})
+	if !slices.Equal(ordered, want) {
+		t.Errorf("ordered WalkParallel visited\n%v\nwant\n%v", ordered, want)
+	}
+
+	unordered := walkPaths(t, func(fn pan.WalkDirFunc) error {
+		return c.WalkParallel(ctx, "/tree", pan.WalkOptions{Workers: 8}, fn)
+	})
+	slices.Sort(unordered)
+	sorted := slices.Sorted(slices.Values(want))
+	if !slices.Equal(unordered, sorted) {
+		t.Errorf("WalkParallel visited\n%v\nwant\n%v", unordered, sorted)
+	}
+}
+
+func TestWalkParallelCancel(t *testing.T) {
+	s := newTree(t)
+	c := s.NewClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	visited := 0
+	err := c.WalkParallel(ctx, "/tree", pan.WalkOptions{Workers: 8}, func(file pan.FileInfo, err error) error {
+		if visited++; visited == 5 {
+			cancel()
+		}
+		return err
+	})
+	if err != context.Canceled {
+		t.Fatalf("WalkParallel returned %v, want %v", err, context.Canceled)
+	}
+}
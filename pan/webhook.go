@@ -0,0 +1,152 @@
+package pan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// WebhookFormat selects the payload a webhook receives
+type WebhookFormat string
+
+const (
+	WebhookGeneric  WebhookFormat = "generic"  // The JobReport as JSON
+	WebhookSlack    WebhookFormat = "slack"    // Slack incoming webhook text message
+	WebhookDingTalk WebhookFormat = "dingtalk" // DingTalk robot text message
+	WebhookFeishu   WebhookFormat = "feishu"   // Feishu (Lark) bot text message
+)
+
+// ParseWebhookFormat parses a webhook format name; empty means WebhookGeneric
+func ParseWebhookFormat(name string) (WebhookFormat, error) {
+	switch format := WebhookFormat(strings.ToLower(name)); format {
+	case "":
+		return WebhookGeneric, nil
+	case WebhookGeneric, WebhookSlack, WebhookDingTalk, WebhookFeishu:
+		return format, nil
+	case "lark":
+		return WebhookFeishu, nil
+	}
+	return "", fmt.Errorf("unknown webhook format %q (expected generic, slack, dingtalk or feishu)", name)
+}
+
+// JobReport describes the outcome of a job or transfer for a webhook notification
+type JobReport struct {
+	Job      string        `json:"job"`              // Job name, or the command for a direct run
+	Success  bool          `json:"success"`          // Whether the job completed without error
+	Bytes    int64         `json:"bytes"`            // Bytes transferred
+	Files    int           `json:"files"`            // Files transferred or operations performed
+	Duration time.Duration `json:"-"`                // Wall time of the job
+	Started  time.Time     `json:"started_at"`       // When the job started
+	Errors   []string      `json:"errors,omitempty"` // Error messages, when it failed
+	Host     string        `json:"host,omitempty"`   // Host the job ran on
+}
+
+// MarshalJSON adds the result as text and the duration in seconds
+func (r JobReport) MarshalJSON() ([]byte, error) {
+	type report JobReport
+	return json.Marshal(struct {
+		report
+		Result          string  `json:"result"`
+		DurationSeconds float64 `json:"duration_seconds"`
+	}{report(r), r.result(), r.Duration.Seconds()})
+}
+
+// UnmarshalJSON reads a report written by MarshalJSON
+func (r *JobReport) UnmarshalJSON(data []byte) error {
+	type report JobReport
+	var aux struct {
+		report
+		DurationSeconds float64 `json:"duration_seconds"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*r = JobReport(aux.report)
+	r.Duration = time.Duration(aux.DurationSeconds * float64(time.Second))
+	return nil
+}
+
+// result returns "success" or "failure"
+func (r JobReport) result() string {
+	if r.Success {
+		return "success"
+	}
+	return "failure"
+}
+
+// Text returns a short human-readable summary of the report, as chat webhooks show it
+func (r JobReport) Text() string {
+	var b strings.Builder
+	if r.Success {
+		fmt.Fprintf(&b, "go-bdfs job '%s' succeeded", r.Job)
+	} else {
+		fmt.Fprintf(&b, "go-bdfs job '%s' FAILED", r.Job)
+	}
+	if r.Host != "" {
+		fmt.Fprintf(&b, " on %s", r.Host)
+	}
+	fmt.Fprintf(&b, "\nTransferred: %s in %d files\nDuration: %s", FormatBytes(r.Bytes), r.Files, r.Duration.Round(time.Second))
+	for _, msg := range r.Errors {
+		fmt.Fprintf(&b, "\nError: %s", msg)
+	}
+	return b.String()
+}
+
+// Webhook posts job reports to a URL
+type Webhook struct {
+	URL        string
+	Format     WebhookFormat
+	HTTPClient *http.Client // nil uses a client with a 30-second timeout
+}
+
+// Notify posts report to the webhook in its format. The host name is filled in when
+// the report has none.
+func (w *Webhook) Notify(ctx context.Context, report JobReport) error {
+	if report.Host == "" {
+		report.Host, _ = os.Hostname()
+	}
+
+	var payload any
+	switch w.Format {
+	case WebhookSlack:
+		payload = map[string]any{"text": report.Text()}
+	case WebhookDingTalk:
+		payload = map[string]any{"msgtype": "text", "text": map[string]string{"content": report.Text()}}
+	case WebhookFeishu:
+		payload = map[string]any{"msg_type": "text", "content": map[string]string{"text": report.Text()}}
+	default:
+		payload = report
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
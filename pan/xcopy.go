@@ -0,0 +1,150 @@
+package pan
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Downloaded data is buffered in memory in chunks while it waits to be uploaded, so the
+// download keeps running during slice uploads. At most crossCopyBufferChunks chunks are
+// held, bounding memory use to about 16MB plus one upload slice.
+const (
+	crossCopyChunkSize    = 1 << 20
+	crossCopyBufferChunks = 16
+)
+
+// CopyToAccount copies the file or directory at srcPath in c's account to dstPath in
+// dst's account. Content is streamed from the download straight into the upload, with
+// a bounded in-memory buffer between them, so nothing is written to local disk.
+// Directories are copied recursively. The source modification time is preserved unless
+// opts.NoPreserveTimes is set; opts.SliceSize applies to the uploads. A file whose
+// streamed content does not match the source MD5 is removed from dst.
+func (c *Client) CopyToAccount(ctx context.Context, srcPath string, dst *Client, dstPath string, opts UploadOptions) error {
+	info, err := c.GetFileInfoByPath(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to get source file info: %w", err)
+	}
+	if info.IsDir == 0 {
+		return c.copyFileToAccount(ctx, info, dst, dstPath, opts)
+	}
+
+	root := normalizeRemoteDir(srcPath)
+	return c.WalkDir(ctx, root, func(file FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		target := path.Join(dstPath, strings.TrimPrefix(file.Path, root))
+		if file.IsDir == 1 {
+			return dst.EnsureRemoteDirExists(target)
+		}
+		return c.copyFileToAccount(ctx, &file, dst, target, opts)
+	})
+}
+
+// copyFileToAccount streams the single file described by info to dstPath in dst's account
+func (c *Client) copyFileToAccount(ctx context.Context, info *FileInfo, dst *Client, dstPath string, opts UploadOptions) error {
+	if c.DryRun() || dst.DryRun() {
+		out := dst.dryRunOutput
+		if out == nil {
+			out = c.dryRunOutput
+		}
+		fmt.Fprintf(out, "[dry-run] xcopy: %s -> %s (%s)\n", info.Path, dstPath, FormatBytes(info.Size))
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Stops the prefetching goroutine if the upload fails
+
+	resp, err := c.openDownload(ctx, info.Path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	hash := md5.New()
+	content := io.TeeReader(newPrefetchReader(ctx, resp.Body, crossCopyChunkSize, crossCopyBufferChunks), hash)
+	c.logger.Info(fmt.Sprintf("Copying %s (%s) to %s in the destination account", info.Path, FormatBytes(info.Size), dstPath))
+	if err := dst.uploadReader(ctx, content, info.Size, dstPath, opts, info.ModTime()); err != nil {
+		return err
+	}
+	c.observeTransfer("download", info.Size)
+
+	// Only verify when the source metadata carries a usable MD5
+	if got := hex.EncodeToString(hash.Sum(nil)); isHexMD5(info.MD5) && got != strings.ToLower(info.MD5) {
+		if err := dst.RemoveFile(dstPath); err != nil {
+			c.logger.Warn("Failed to remove corrupt copy", "path", dstPath, "error", err)
+		}
+		return fmt.Errorf("MD5 mismatch copying %s: expected %s, got %s", info.Path, info.MD5, got)
+	}
+	return nil
+}
+
+// prefetchChunk is a chunk of data read ahead by a prefetchReader, or the error that ended reading
+type prefetchChunk struct {
+	data []byte
+	err  error
+}
+
+// prefetchReader reads its source ahead in a background goroutine, holding up to a fixed
+// number of chunks, so a slow consumer does not stall the source between reads
+type prefetchReader struct {
+	chunks <-chan prefetchChunk
+	cur    []byte
+	err    error
+}
+
+// newPrefetchReader starts reading r ahead in chunkSize chunks, buffering at most depth of
+// them. Reading stops early when ctx is cancelled.
+func newPrefetchReader(ctx context.Context, r io.Reader, chunkSize, depth int) *prefetchReader {
+	chunks := make(chan prefetchChunk, depth)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, chunkSize)
+			n, err := io.ReadFull(r, buf)
+			if n > 0 {
+				select {
+				case chunks <- prefetchChunk{data: buf[:n]}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				select {
+				case chunks <- prefetchChunk{err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+	return &prefetchReader{chunks: chunks}
+}
+
+func (p *prefetchReader) Read(b []byte) (int, error) {
+	for len(p.cur) == 0 {
+		if p.err != nil {
+			return 0, p.err
+		}
+		chunk, ok := <-p.chunks
+		switch {
+		case !ok:
+			p.err = io.EOF
+		case chunk.err != nil:
+			p.err = chunk.err
+		default:
+			p.cur = chunk.data
+		}
+	}
+	n := copy(b, p.cur)
+	p.cur = p.cur[n:]
+	return n, nil
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals are the signals that make a running command print its status
+var statusSignals = []os.Signal{syscall.SIGUSR1}